@@ -1,51 +1,322 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+	// company timezone is loaded by name, embed the database for hosts without zoneinfo
+	_ "time/tzdata"
 
 	"github.com/geedotrar/erp-api/config"
 	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/lifecycle"
+	"github.com/geedotrar/erp-api/pkg/metrics"
+	"github.com/geedotrar/erp-api/pkg/notify"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/geedotrar/erp-api/pkg/statutory"
+	"github.com/geedotrar/erp-api/pkg/tlsreload"
 	"github.com/geedotrar/erp-api/repository"
 	"github.com/geedotrar/erp-api/routes"
 	"github.com/geedotrar/erp-api/service"
 	"github.com/gin-gonic/gin"
-
-	"github.com/joho/godotenv"
 )
 
+// version is set on release build with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
 func main() {
 	server()
 }
 
 func server() {
-	err := godotenv.Load("../../.env")
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+		log.Fatalf("failed to load config: %v", err)
 	}
-	g := gin.Default()
-	g.Use(gin.Recovery())
+	log.Printf("config:\n%s", cfg)
+	middleware.SetAuthConfig(cfg.Auth)
+
+	// metrics come first so request recovered from panic is counted as 500
+	g := gin.New()
+	g.Use(middleware.Metrics, gin.Logger(), gin.Recovery())
+
+	// every router document its routes, route without document fail the startup check below
+	spec := openapi.New("ERP API", "1.0.0")
+	spec.Format(models.Date{}, openapi.Schema{Type: "string", Format: "date"})
+
+	// api is versioned, next version get its own group and routers next to v1
+	v1 := g.Group(routes.API_V1)
+
+	// background workers share one context, it is cancelled on shutdown after the server stop
+	workers := lifecycle.New()
 
-	usersGroup := g.Group("/users")
-	gorm := config.NewGormPostgres()
+	gorm := config.NewGormPostgres(cfg.Database)
+	// statement latency, error and mutation count, pool gauges are exported as go_sql_*
+	if err := gorm.GetConnection().Use(metrics.GormPlugin{}); err != nil {
+		log.Fatalf("failed to register gorm metrics: %v", err)
+	}
+	if db, err := gorm.GetConnection().DB(); err == nil {
+		if err := metrics.RegisterDB(db, cfg.Database.Name); err != nil {
+			log.Fatalf("failed to register database metrics: %v", err)
+		}
+	}
 	userRepo := repository.NewUserQuery(gorm)
-	userSvc := service.NewUserService(userRepo)
+	companyRepo := repository.NewCompanyQuery(gorm)
+	positionRepo := repository.NewPositionQuery(gorm)
+	checklistRepo := repository.NewChecklistQuery(gorm)
+	headcountRepo := repository.NewHeadcountQuery(gorm)
+	// every service check admin access to company through the same scope
+	companyScope := service.NewCompanyScope(userRepo, companyRepo)
+
+	// probe path sit at the root, readiness fail once shutdown start
+	healthGroup := g.Group("")
+	healthRepo := repository.NewHealthQuery(gorm)
+	healthSvc := service.NewHealthService(healthRepo, workers, version)
+	healthHdl := handlers.NewHealthHandler(healthSvc)
+	healthRouter := routes.NewHealthRouter(healthGroup, healthHdl)
+	healthRouter.Mount()
+	spec.Add(healthGroup.BasePath(), healthRouter.Docs()...)
+
+	switch {
+	case !cfg.Metrics.Enabled:
+	case cfg.Metrics.Addr == "":
+		metricsRouter := routes.NewMetricsRouter(healthGroup)
+		metricsRouter.Mount()
+		spec.Add(healthGroup.BasePath(), metricsRouter.Docs()...)
+	default:
+		metricsServer, err := metrics.Listen(cfg.Metrics.Addr)
+		if err != nil {
+			log.Fatalf("failed to listen metrics: %v", err)
+		}
+		workers.Go("metrics", metricsServer.Run)
+	}
+
+	// change and its domain event are saved together, dispatcher deliver the event to subscribers
+	outboxRepo := repository.NewOutboxQuery(gorm)
+	eventBus := service.NewEventBus(outboxRepo)
+
+	webhookGroup := v1.Group("/webhooks")
+	webhookRepo := repository.NewWebhookQuery(gorm)
+	webhookSvc := service.NewWebhookService(webhookRepo, companyScope)
+	webhookHdl := handlers.NewWebhookHandler(webhookSvc)
+	webhookRouter := routes.NewWebhookRouter(webhookGroup, webhookHdl)
+	webhookRouter.Mount()
+	spec.Add(webhookGroup.BasePath(), webhookRouter.Docs()...)
+	workers.Go("webhooks", webhookSvc.RunWorker)
+	eventBus.Subscribe("webhooks", "*", webhookSvc.HandleEvent)
+
+	notificationGroup := v1.Group("/notifications")
+	notificationRepo := repository.NewNotificationQuery(gorm)
+	// channel without config stay disabled, preference cannot turn it on
+	var emailSender notify.Sender
+	if cfg.SMTP.Host != "" {
+		emailSender = notify.NewSMTPSender(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password.Value(), cfg.SMTP.From)
+	}
+	notificationSvc := service.NewNotificationService(notificationRepo, companyScope, emailSender, notify.NewChatSender(cfg.Chat.Adapter))
+	notificationHdl := handlers.NewNotificationHandler(notificationSvc)
+	notificationRouter := routes.NewNotificationRouter(notificationGroup, notificationHdl)
+	notificationRouter.Mount()
+	spec.Add(notificationGroup.BasePath(), notificationRouter.Docs()...)
+	eventBus.Subscribe("notifications", "*", notificationSvc.HandleEvent)
+
+	exportGroup := v1.Group("/exports")
+	exportRepo := repository.NewExportQuery(gorm)
+	exportSvc := service.NewExportService(exportRepo, companyScope, userRepo, companyRepo, positionRepo, cfg.Storage.ExportDir)
+	exportHdl := handlers.NewExportHandler(exportSvc)
+	exportRouter := routes.NewExportRouter(exportGroup, exportHdl)
+	exportRouter.Mount()
+	spec.Add(exportGroup.BasePath(), exportRouter.Docs()...)
+	workers.Go("exports", exportSvc.RunWorker)
+
+	usersGroup := v1.Group("/users")
+	userSvc := service.NewUserService(userRepo, companyScope, checklistRepo, headcountRepo, outboxRepo, cfg.Auth.JWTSecret)
 	userHdl := handlers.NewUserHandler(userSvc)
-	userRouter := routes.NewUserRouter(usersGroup, userHdl)
+	userRouter := routes.NewUserRouter(usersGroup, userHdl, exportHdl)
 	userRouter.Mount()
+	spec.Add(usersGroup.BasePath(), userRouter.Docs()...)
 
-	companyGroup := g.Group("/company")
-	companyRepo := repository.NewCompanyQuery(gorm)
-	companySvc := service.NewCompanyService(companyRepo)
+	companyGroup := v1.Group("/companies")
+	companySvc := service.NewCompanyService(companyRepo, companyScope, outboxRepo)
 	companyHdl := handlers.NewCompanyHandler(companySvc)
-	companyRouter := routes.NewCompanyRouter(companyGroup, companyHdl)
+	companyRouter := routes.NewCompanyRouter(companyGroup, companyHdl, exportHdl)
 	companyRouter.Mount()
+	spec.Add(companyGroup.BasePath(), companyRouter.Docs()...)
 
-	positionGroup := g.Group("/positions")
-	positionRepo := repository.NewPositionQuery(gorm)
-	positionSvc := service.NewPositionService(positionRepo)
+	positionGroup := v1.Group("/positions")
+	positionSvc := service.NewPositionService(positionRepo, companyScope, outboxRepo)
 	positionHdl := handlers.NewPositionHandler(positionSvc)
-	positionRouter := routes.NewPositionRouter(positionGroup, positionHdl)
+	positionRouter := routes.NewPositionRouter(positionGroup, positionHdl, exportHdl)
 	positionRouter.Mount()
+	spec.Add(positionGroup.BasePath(), positionRouter.Docs()...)
+
+	departmentGroup := v1.Group("/departments")
+	departmentRepo := repository.NewDepartmentQuery(gorm)
+	departmentSvc := service.NewDepartmentService(departmentRepo, companyScope)
+	departmentHdl := handlers.NewDepartmentHandler(departmentSvc)
+	departmentRouter := routes.NewDepartmentRouter(departmentGroup, departmentHdl)
+	departmentRouter.Mount()
+	spec.Add(departmentGroup.BasePath(), departmentRouter.Docs()...)
+
+	employeeGroup := v1.Group("/employees")
+	employeeRepo := repository.NewEmployeeQuery(gorm)
+	employeeSvc := service.NewEmployeeService(employeeRepo, companyScope)
+	employeeHdl := handlers.NewEmployeeHandler(employeeSvc)
+	employeeRouter := routes.NewEmployeeRouter(employeeGroup, employeeHdl)
+	employeeRouter.Mount()
+	spec.Add(employeeGroup.BasePath(), employeeRouter.Docs()...)
+
+	checklistGroup := v1.Group("/checklists")
+	checklistSvc := service.NewChecklistService(checklistRepo, companyScope, outboxRepo)
+	checklistHdl := handlers.NewChecklistHandler(checklistSvc)
+	checklistRouter := routes.NewChecklistRouter(checklistGroup, checklistHdl)
+	checklistRouter.Mount()
+	spec.Add(checklistGroup.BasePath(), checklistRouter.Docs()...)
 
-	g.Run(":8080")
+	headcountGroup := v1.Group("/headcount")
+	headcountSvc := service.NewHeadcountService(headcountRepo, companyScope)
+	headcountHdl := handlers.NewHeadcountHandler(headcountSvc)
+	headcountRouter := routes.NewHeadcountRouter(headcountGroup, headcountHdl)
+	headcountRouter.Mount()
+	spec.Add(headcountGroup.BasePath(), headcountRouter.Docs()...)
+
+	assignmentGroup := v1.Group("/assignments")
+	assignmentRepo := repository.NewAssignmentQuery(gorm)
+	assignmentSvc := service.NewAssignmentService(assignmentRepo, companyScope, headcountRepo, checklistRepo, outboxRepo)
+	assignmentHdl := handlers.NewAssignmentHandler(assignmentSvc)
+	assignmentRouter := routes.NewAssignmentRouter(assignmentGroup, assignmentHdl)
+	assignmentRouter.Mount()
+	spec.Add(assignmentGroup.BasePath(), assignmentRouter.Docs()...)
+	workers.Go("assignments", assignmentSvc.RunWorker)
+
+	attendanceGroup := v1.Group("/attendance")
+	attendanceRepo := repository.NewAttendanceQuery(gorm)
+	attendanceSvc := service.NewAttendanceService(attendanceRepo, companyScope)
+	attendanceHdl := handlers.NewAttendanceHandler(attendanceSvc)
+	attendanceRouter := routes.NewAttendanceRouter(attendanceGroup, attendanceHdl)
+	attendanceRouter.Mount()
+	spec.Add(attendanceGroup.BasePath(), attendanceRouter.Docs()...)
+
+	leaveGroup := v1.Group("/leaves")
+	leaveRepo := repository.NewLeaveQuery(gorm)
+	leaveSvc := service.NewLeaveService(leaveRepo, companyScope, outboxRepo)
+	leaveHdl := handlers.NewLeaveHandler(leaveSvc)
+	leaveRouter := routes.NewLeaveRouter(leaveGroup, leaveHdl)
+	leaveRouter.Mount()
+	spec.Add(leaveGroup.BasePath(), leaveRouter.Docs()...)
+
+	recruitmentGroup := v1.Group("/recruitment")
+	recruitmentRepo := repository.NewRecruitmentQuery(gorm)
+	recruitmentSvc := service.NewRecruitmentService(recruitmentRepo, companyScope, headcountRepo, userSvc, employeeSvc, outboxRepo, cfg.Storage.RecruitmentDir, cfg.Recruitment.CandidateRetentionDays)
+	recruitmentHdl := handlers.NewRecruitmentHandler(recruitmentSvc)
+	recruitmentRouter := routes.NewRecruitmentRouter(recruitmentGroup, recruitmentHdl)
+	recruitmentRouter.Mount()
+	spec.Add(recruitmentGroup.BasePath(), recruitmentRouter.Docs()...)
+	workers.Go("recruitment", recruitmentSvc.RunWorker)
+
+	payrollGroup := v1.Group("/payroll")
+	payrollRepo := repository.NewPayrollQuery(gorm)
+	statutoryRepo := repository.NewStatutoryQuery(gorm)
+	payrollSvc := service.NewPayrollService(payrollRepo, companyScope, statutoryRepo, statutory.Indonesia())
+	payrollHdl := handlers.NewPayrollHandler(payrollSvc)
+	payrollRouter := routes.NewPayrollRouter(payrollGroup, payrollHdl)
+	payrollRouter.Mount()
+	spec.Add(payrollGroup.BasePath(), payrollRouter.Docs()...)
+
+	// old unversioned path answer with the v1 route and deprecation header until sunset
+	legacyRouter := routes.NewLegacyRouter(g)
+	legacyRouter.Mount()
+	spec.AddAliases(legacyRouter.Aliases()...)
+
+	openapi.Mount(g, spec)
+	if err := spec.Check(g.Routes()); err != nil {
+		log.Fatalf("openapi document is out of date: %v", err)
+	}
+
+	// subscribers are registered above, start dispatching
+	workers.Go("events", eventBus.RunDispatcher)
+
+	srv := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           g,
+		ReadTimeout:       cfg.Server.ReadTimeout.Value(),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout.Value(),
+		WriteTimeout:      cfg.Server.WriteTimeout.Value(),
+		IdleTimeout:       cfg.Server.IdleTimeout.Value(),
+		MaxHeaderBytes:    cfg.Server.MaxHeaderBytes,
+	}
+	// open stream never become idle, close it so Shutdown does not wait for the timeout
+	srv.RegisterOnShutdown(notificationSvc.CloseStreams)
+	if cfg.Server.TLS() {
+		cert, err := tlsreload.Load(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		if err != nil {
+			log.Fatalf("failed to load tls certificate: %v", err)
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: cert.GetCertificate,
+		}
+		workers.Go("tls", cert.Watch)
+	}
+
+	serve(srv, cfg.Server, workers, gorm)
+}
+
+// serve run the server until SIGINT or SIGTERM, then fail readiness for the drain delay, stop
+// accepting connection, wait for in-flight request, stop the workers and close the database
+// pool within shutdown timeout
+func serve(srv *http.Server, cfg config.ServerConfig, workers *lifecycle.Manager, gorm config.GormPostgres) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s (tls %t)", cfg.Addr, cfg.TLS())
+		if cfg.TLS() {
+			// certificate come from TLSConfig.GetCertificate
+			serveErr <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		log.Fatalf("server stopped: %v", err)
+	case <-ctx.Done():
+	}
+	// second signal kill the process right away
+	stop()
+
+	workers.Drain()
+	if cfg.DrainDelay > 0 {
+		log.Printf("draining, readiness fail for %s before shutdown", cfg.DrainDelay)
+		time.Sleep(cfg.DrainDelay.Value())
+	}
+
+	log.Printf("shutting down, waiting up to %s", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Value())
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("server shutdown", err.Error())
+	}
+	if err := workers.Shutdown(shutdownCtx); err != nil {
+		log.Println("workers shutdown", err.Error())
+	}
+	if err := gorm.Close(); err != nil {
+		log.Println("database close", err.Error())
+	}
+	log.Println("shutdown complete")
 }
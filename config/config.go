@@ -0,0 +1,247 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/notify"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is every setting of the application, see Load for where value come from.
+// Each setting has yaml and toml key for config file and env var, flag name is the
+// env var in lower case with dash, DB_HOST become -db-host
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	Auth        AuthConfig        `yaml:"auth" toml:"auth"`
+	Storage     StorageConfig     `yaml:"storage" toml:"storage"`
+	Recruitment RecruitmentConfig `yaml:"recruitment" toml:"recruitment"`
+	SMTP        SMTPConfig        `yaml:"smtp" toml:"smtp"`
+	Chat        ChatConfig        `yaml:"chat" toml:"chat"`
+	Metrics     MetricsConfig     `yaml:"metrics" toml:"metrics"`
+}
+
+type ServerConfig struct {
+	Addr              string   `yaml:"addr" toml:"addr" env:"SERVER_ADDR"`
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	// long lived response like notification stream and export download lift the deadline themselves
+	WriteTimeout   Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout    Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	MaxHeaderBytes int      `yaml:"max_header_bytes" toml:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES"`
+	// readiness fail for this long after SIGTERM before the server stop accepting request,
+	// so load balancer has time to take the instance out. 0 stop right away
+	DrainDelay Duration `yaml:"drain_delay" toml:"drain_delay" env:"SERVER_DRAIN_DELAY"`
+	// time given to in-flight request and worker to finish after SIGTERM
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// serve https when both are set, certificate is reloaded when the file change or on SIGHUP
+	TLSCertFile string `yaml:"tls_cert_file" toml:"tls_cert_file" env:"SERVER_TLS_CERT_FILE"`
+	TLSKeyFile  string `yaml:"tls_key_file" toml:"tls_key_file" env:"SERVER_TLS_KEY_FILE"`
+}
+
+// TLS return true when server is configured to serve https
+func (s ServerConfig) TLS() bool {
+	return s.TLSCertFile != "" && s.TLSKeyFile != ""
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password Secret `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+}
+
+type AuthConfig struct {
+	JWTSecret Secret `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET"`
+	// static credential of CheckAuthBasic, basic auth reject every request when empty
+	BasicUsername string `yaml:"basic_username" toml:"basic_username" env:"BASIC_AUTH_USERNAME"`
+	BasicPassword Secret `yaml:"basic_password" toml:"basic_password" env:"BASIC_AUTH_PASSWORD"`
+}
+
+type StorageConfig struct {
+	ExportDir string `yaml:"export_dir" toml:"export_dir" env:"EXPORT_DIR"`
+	// cv is stored apart from export file, it contains personal data of non employee
+	RecruitmentDir string `yaml:"recruitment_dir" toml:"recruitment_dir" env:"RECRUITMENT_DIR"`
+}
+
+type RecruitmentConfig struct {
+	CandidateRetentionDays int `yaml:"candidate_retention_days" toml:"candidate_retention_days" env:"CANDIDATE_RETENTION_DAYS"`
+}
+
+// SMTPConfig of email notification, email channel is disabled when host is empty
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" toml:"username" env:"SMTP_USERNAME"`
+	Password Secret `yaml:"password" toml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" toml:"from" env:"SMTP_FROM"`
+}
+
+// ChatConfig of chat notification, chat channel is disabled when adapter is empty
+type ChatConfig struct {
+	Adapter string `yaml:"adapter" toml:"adapter" env:"CHAT_ADAPTER"`
+}
+
+// MetricsConfig of prometheus /metrics, it is served on the api port when addr is empty,
+// otherwise on its own admin port that is not exposed to client
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"METRICS_ENABLED"`
+	Addr    string `yaml:"addr" toml:"addr" env:"METRICS_ADDR"`
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadTimeout:       Duration(30 * time.Second),
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			WriteTimeout:      Duration(60 * time.Second),
+			IdleTimeout:       Duration(120 * time.Second),
+			MaxHeaderBytes:    1 << 20,
+			DrainDelay:        Duration(5 * time.Second),
+			ShutdownTimeout:   Duration(30 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Port:    5432,
+			SSLMode: "disable",
+		},
+		Storage: StorageConfig{
+			ExportDir:      filepath.Join(os.TempDir(), "erp-exports"),
+			RecruitmentDir: filepath.Join(os.TempDir(), "erp-recruitment"),
+		},
+		Recruitment: RecruitmentConfig{
+			CandidateRetentionDays: models.DEFAULT_CANDIDATE_RETENTION_DAYS,
+		},
+		SMTP: SMTPConfig{
+			Port: 587,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+	}
+}
+
+// jwt is signed with HS512, shorter secret is easy to brute force
+const minJWTSecretLength = 32
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Validate return every invalid setting at once, named by its config key and env var
+func (c Config) Validate() error {
+	errs := []error{}
+	invalid := func(key, env, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s (%s) %s", key, env, fmt.Sprintf(format, args...)))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		invalid("server.addr", "SERVER_ADDR", "must be host:port, got %q", c.Server.Addr)
+	}
+	timeouts := []struct {
+		key, env string
+		value    Duration
+	}{
+		{"server.read_timeout", "SERVER_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"server.read_header_timeout", "SERVER_READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout},
+		{"server.write_timeout", "SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"server.idle_timeout", "SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", "SERVER_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value <= 0 {
+			invalid(t.key, t.env, "must be greater than 0, got %s", t.value)
+		}
+	}
+	if c.Server.DrainDelay < 0 {
+		invalid("server.drain_delay", "SERVER_DRAIN_DELAY", "cannot be negative, got %s", c.Server.DrainDelay)
+	}
+	if c.Server.MaxHeaderBytes < 4096 {
+		invalid("server.max_header_bytes", "SERVER_MAX_HEADER_BYTES", "must be at least 4096, got %d", c.Server.MaxHeaderBytes)
+	}
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		invalid("server.tls_cert_file", "SERVER_TLS_CERT_FILE", "and server.tls_key_file (SERVER_TLS_KEY_FILE) must be set together")
+	}
+
+	if c.Database.Host == "" {
+		invalid("database.host", "DB_HOST", "is required")
+	}
+	if c.Database.Port <= 0 || c.Database.Port > 65535 {
+		invalid("database.port", "DB_PORT", "must be between 1 and 65535, got %d", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		invalid("database.user", "DB_USER", "is required")
+	}
+	if c.Database.Name == "" {
+		invalid("database.name", "DB_NAME", "is required")
+	}
+	if !contains(sslModes, c.Database.SSLMode) {
+		invalid("database.sslmode", "DB_SSLMODE", "must be one of %v, got %q", sslModes, c.Database.SSLMode)
+	}
+
+	if len(c.Auth.JWTSecret) < minJWTSecretLength {
+		invalid("auth.jwt_secret", "JWT_SECRET", "must be at least %d characters", minJWTSecretLength)
+	}
+	if (c.Auth.BasicUsername == "") != (c.Auth.BasicPassword == "") {
+		invalid("auth.basic_username", "BASIC_AUTH_USERNAME", "and auth.basic_password (BASIC_AUTH_PASSWORD) must be set together")
+	}
+
+	if c.Storage.ExportDir == "" {
+		invalid("storage.export_dir", "EXPORT_DIR", "is required")
+	}
+	if c.Storage.RecruitmentDir == "" {
+		invalid("storage.recruitment_dir", "RECRUITMENT_DIR", "is required")
+	}
+	if c.Recruitment.CandidateRetentionDays <= 0 {
+		invalid("recruitment.candidate_retention_days", "CANDIDATE_RETENTION_DAYS", "must be greater than 0, got %d", c.Recruitment.CandidateRetentionDays)
+	}
+
+	if c.SMTP.Host != "" {
+		if c.SMTP.Port <= 0 || c.SMTP.Port > 65535 {
+			invalid("smtp.port", "SMTP_PORT", "must be between 1 and 65535, got %d", c.SMTP.Port)
+		}
+		if c.SMTP.From == "" {
+			invalid("smtp.from", "SMTP_FROM", "is required when smtp.host is set")
+		}
+	}
+	if c.Chat.Adapter != "" && !contains(notify.ChatAdapters(), c.Chat.Adapter) {
+		invalid("chat.adapter", "CHAT_ADAPTER", "must be one of %v, got %q", notify.ChatAdapters(), c.Chat.Adapter)
+	}
+
+	if c.Metrics.Enabled && c.Metrics.Addr != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Addr); err != nil {
+			invalid("metrics.addr", "METRICS_ADDR", "must be host:port, got %q", c.Metrics.Addr)
+		} else if c.Metrics.Addr == c.Server.Addr {
+			invalid("metrics.addr", "METRICS_ADDR", "must differ from server.addr, leave it empty to serve metrics on the api port")
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// String print config as yaml with every secret redacted, safe to log
+func (c Config) String() string {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package config
+
+import "time"
+
+// Duration is time.Duration written like 30s or 5m in config file, toml has no
+// duration type so it is decoded from text. Value return the time.Duration
+type Duration time.Duration
+
+func (d Duration) Value() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const CONFIG_FILE_ENV = "CONFIG_FILE"
+
+// Load build config from these sources, later one override earlier one:
+//  1. Default
+//  2. yaml or toml file given by -config flag or CONFIG_FILE
+//  3. env var, .env of working directory is loaded into env when it exists
+//  4. flag
+//
+// the result is validated, error list every invalid setting
+func Load(args []string) (Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf(".env: %w", err)
+	}
+
+	cfg := Default()
+	settings := settingsOf(reflect.ValueOf(&cfg).Elem(), "")
+
+	// flag is applied last, only remember what is given here
+	flagValues := map[*setting]string{}
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(CONFIG_FILE_ENV), "path of yaml or toml config file ("+CONFIG_FILE_ENV+")")
+	for _, s := range settings {
+		s := s
+		flags.Func(s.flag, s.key+" ("+s.env+")", func(value string) error {
+			flagValues[s] = value
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	for _, s := range settings {
+		if value := os.Getenv(s.env); value != "" {
+			if err := s.set(value); err != nil {
+				return Config{}, err
+			}
+		}
+	}
+	for _, s := range settings {
+		if value, ok := flagValues[s]; ok {
+			if err := s.set(value); err != nil {
+				return Config{}, err
+			}
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// unknown key is rejected, so typo in config file does not silently fall back to default
+func loadFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(f)
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(f)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	default:
+		return fmt.Errorf("config file %s: extension must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// setting is one leaf field of Config that can be set from env var and flag
+type setting struct {
+	key   string
+	env   string
+	flag  string
+	value reflect.Value
+}
+
+func settingsOf(v reflect.Value, prefix string) []*setting {
+	settings := []*setting{}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key := prefix + field.Tag.Get("yaml")
+		if field.Type.Kind() == reflect.Struct {
+			settings = append(settings, settingsOf(v.Field(i), key+".")...)
+			continue
+		}
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		settings = append(settings, &setting{
+			key:   key,
+			env:   env,
+			flag:  strings.ToLower(strings.ReplaceAll(env, "_", "-")),
+			value: v.Field(i),
+		})
+	}
+	return settings
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+func (s *setting) set(value string) error {
+	invalid := func(kind string) error {
+		return fmt.Errorf("%s (%s) must be %s, got %q", s.key, s.env, kind, value)
+	}
+
+	switch {
+	case s.value.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return invalid("a duration like 30s or 5m")
+		}
+		s.value.SetInt(int64(d))
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(value)
+	case s.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return invalid("a number")
+		}
+		s.value.SetInt(int64(n))
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid("true or false")
+		}
+		s.value.SetBool(b)
+	default:
+		return fmt.Errorf("%s (%s) has unsupported type %s", s.key, s.env, s.value.Type())
+	}
+	return nil
+}
@@ -2,7 +2,6 @@ package config
 
 import (
 	"fmt"
-	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -10,26 +9,23 @@ import (
 
 type GormPostgres interface {
 	GetConnection() *gorm.DB
+	// Close the connection pool, called on shutdown after the last request is done
+	Close() error
 }
 
 type gormPostgresImpl struct {
 	master *gorm.DB
 }
 
-func NewGormPostgres() GormPostgres {
+func NewGormPostgres(cfg DatabaseConfig) GormPostgres {
 	return &gormPostgresImpl{
-		master: connect(),
+		master: connect(cfg),
 	}
 }
 
-func connect() *gorm.DB {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	connectionString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", host, port, user, password, dbname)
+func connect(cfg DatabaseConfig) *gorm.DB {
+	connectionString := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password.Value(), cfg.Name, cfg.SSLMode)
 	db, err := gorm.Open(postgres.Open(connectionString), &gorm.Config{})
 	if err != nil {
 		panic(err)
@@ -40,3 +36,11 @@ func connect() *gorm.DB {
 func (g *gormPostgresImpl) GetConnection() *gorm.DB {
 	return g.master
 }
+
+func (g *gormPostgresImpl) Close() error {
+	db, err := g.master.DB()
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
@@ -0,0 +1,18 @@
+CREATE TABLE export_jobs (
+    id SERIAL PRIMARY KEY,
+    entity VARCHAR(50) NOT NULL,
+    format VARCHAR(10) CHECK (format IN ('csv', 'xlsx', 'ndjson')) NOT NULL,
+    columns TEXT NOT NULL DEFAULT '',
+    filters TEXT NOT NULL DEFAULT '{}',
+    status VARCHAR(10) CHECK (status IN ('pending', 'running', 'done', 'failed')) NOT NULL DEFAULT 'pending',
+    file_path TEXT NOT NULL DEFAULT '',
+    row_count BIGINT NOT NULL DEFAULT 0,
+    error_reason TEXT NOT NULL DEFAULT '',
+    requested_by INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP,
+    FOREIGN KEY (requested_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_export_jobs_status ON export_jobs(status);
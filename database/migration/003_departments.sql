@@ -0,0 +1,19 @@
+CREATE TABLE departments (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    parent_id INT,
+    head_user_id INT,
+    department_name VARCHAR(255) NOT NULL,
+    department_code VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (parent_id) REFERENCES departments(id),
+    FOREIGN KEY (head_user_id) REFERENCES users(id)
+);
+
+-- department name only unique inside one company
+CREATE UNIQUE INDEX idx_departments_company_name ON departments(company_id, department_name) WHERE deleted_at IS NULL;
+
+ALTER TABLE users ADD COLUMN department_id INT REFERENCES departments(id);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN manager_id INT REFERENCES users(id);
+
+CREATE INDEX idx_users_manager_id ON users(manager_id);
@@ -0,0 +1,23 @@
+CREATE TABLE employees (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    user_id INT,
+    employee_number VARCHAR(50) NOT NULL,
+    first_name VARCHAR(255) NOT NULL,
+    last_name VARCHAR(255) NOT NULL,
+    date_of_birth DATE,
+    hire_date DATE NOT NULL,
+    employment_type VARCHAR(20) CHECK (employment_type IN ('permanent', 'contract', 'probation')) NOT NULL,
+    status VARCHAR(20) CHECK (status IN ('active', 'suspended', 'terminated')) NOT NULL DEFAULT 'active',
+    termination_date DATE,
+    termination_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+-- employee number unique per company, one employee per login account
+CREATE UNIQUE INDEX idx_employees_company_number ON employees(company_id, employee_number) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_employees_user_id ON employees(user_id) WHERE deleted_at IS NULL;
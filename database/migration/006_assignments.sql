@@ -0,0 +1,25 @@
+CREATE TABLE assignments (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    company_id INT NOT NULL,
+    department_id INT,
+    position_id INT NOT NULL,
+    valid_from DATE NOT NULL,
+    valid_to DATE,
+    change_type VARCHAR(20) CHECK (change_type IN ('hire', 'transfer', 'promotion')) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    applied BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (department_id) REFERENCES departments(id),
+    FOREIGN KEY (position_id) REFERENCES positions(id),
+    FOREIGN KEY (created_by) REFERENCES users(id),
+    CHECK (valid_to IS NULL OR valid_to > valid_from)
+);
+
+CREATE INDEX idx_assignments_user_valid ON assignments(user_id, valid_from);
+-- future dated assignment waiting to be applied to users table
+CREATE INDEX idx_assignments_pending ON assignments(valid_from) WHERE applied = FALSE;
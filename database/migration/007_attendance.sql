@@ -0,0 +1,67 @@
+CREATE TABLE attendance_settings (
+    company_id INT PRIMARY KEY,
+    work_start VARCHAR(5) NOT NULL DEFAULT '08:00',
+    work_end VARCHAR(5) NOT NULL DEFAULT '17:00',
+    late_grace_minutes INT NOT NULL DEFAULT 0,
+    early_leave_grace_minutes INT NOT NULL DEFAULT 0,
+    geofence_required BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
+
+CREATE TABLE attendance_geofences (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    radius_meters DOUBLE PRECISION NOT NULL CHECK (radius_meters > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
+
+CREATE TABLE attendances (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    company_id INT NOT NULL,
+    work_date DATE NOT NULL,
+    clock_in_at TIMESTAMP NOT NULL,
+    clock_out_at TIMESTAMP,
+    clock_in_source VARCHAR(10) NOT NULL DEFAULT 'web',
+    clock_out_source VARCHAR(10) NOT NULL DEFAULT '',
+    clock_in_lat DOUBLE PRECISION,
+    clock_in_lng DOUBLE PRECISION,
+    clock_out_lat DOUBLE PRECISION,
+    clock_out_lng DOUBLE PRECISION,
+    is_late BOOLEAN NOT NULL DEFAULT FALSE,
+    is_early_leave BOOLEAN NOT NULL DEFAULT FALSE,
+    worked_minutes INT NOT NULL DEFAULT 0,
+    corrected_by INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (corrected_by) REFERENCES users(id),
+    UNIQUE (user_id, work_date)
+);
+
+CREATE INDEX idx_attendances_company_date ON attendances(company_id, work_date);
+
+CREATE TABLE attendance_corrections (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    company_id INT NOT NULL,
+    work_date DATE NOT NULL,
+    clock_in_at TIMESTAMP NOT NULL,
+    clock_out_at TIMESTAMP,
+    reason TEXT NOT NULL,
+    status VARCHAR(10) CHECK (status IN ('pending', 'approved', 'rejected')) NOT NULL DEFAULT 'pending',
+    reviewed_by INT,
+    reviewed_at TIMESTAMP,
+    review_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (reviewed_by) REFERENCES users(id)
+);
@@ -0,0 +1,59 @@
+CREATE TABLE leave_types (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    is_paid BOOLEAN NOT NULL DEFAULT TRUE,
+    requires_balance BOOLEAN NOT NULL DEFAULT TRUE,
+    yearly_entitlement NUMERIC(5,1) NOT NULL DEFAULT 0,
+    accrual VARCHAR(10) CHECK (accrual IN ('yearly', 'monthly')) NOT NULL DEFAULT 'yearly',
+    carry_over_max NUMERIC(5,1) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
+
+CREATE UNIQUE INDEX idx_leave_types_company_code ON leave_types(company_id, code) WHERE deleted_at IS NULL;
+
+CREATE TABLE leave_balances (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    leave_type_id INT NOT NULL,
+    year INT NOT NULL,
+    entitled NUMERIC(5,1) NOT NULL DEFAULT 0,
+    carried_over NUMERIC(5,1) NOT NULL DEFAULT 0,
+    used NUMERIC(5,1) NOT NULL DEFAULT 0,
+    pending NUMERIC(5,1) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (leave_type_id) REFERENCES leave_types(id),
+    UNIQUE (user_id, leave_type_id, year),
+    CHECK (used >= 0 AND pending >= 0)
+);
+
+CREATE TABLE leave_requests (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    company_id INT NOT NULL,
+    leave_type_id INT NOT NULL,
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    days NUMERIC(5,1) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    status VARCHAR(10) CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')) NOT NULL DEFAULT 'pending',
+    reviewed_by INT,
+    reviewed_at TIMESTAMP,
+    review_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (leave_type_id) REFERENCES leave_types(id),
+    FOREIGN KEY (reviewed_by) REFERENCES users(id),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_leave_requests_user_dates ON leave_requests(user_id, start_date, end_date);
+CREATE INDEX idx_leave_requests_company_dates ON leave_requests(company_id, start_date, end_date);
@@ -0,0 +1,74 @@
+CREATE TABLE salary_components (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(10) CHECK (type IN ('allowance', 'deduction')) NOT NULL,
+    taxable BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
+
+CREATE UNIQUE INDEX idx_salary_components_company_code ON salary_components(company_id, code) WHERE deleted_at IS NULL;
+
+CREATE TABLE employee_salaries (
+    id SERIAL PRIMARY KEY,
+    employee_id INT NOT NULL UNIQUE,
+    base_salary NUMERIC(18,2) NOT NULL CHECK (base_salary >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (employee_id) REFERENCES employees(id)
+);
+
+CREATE TABLE employee_salary_components (
+    id SERIAL PRIMARY KEY,
+    employee_id INT NOT NULL,
+    component_id INT NOT NULL,
+    amount NUMERIC(18,2) NOT NULL CHECK (amount >= 0),
+    FOREIGN KEY (employee_id) REFERENCES employees(id),
+    FOREIGN KEY (component_id) REFERENCES salary_components(id),
+    UNIQUE (employee_id, component_id)
+);
+
+CREATE TABLE payroll_runs (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    period DATE NOT NULL,
+    status VARCHAR(10) CHECK (status IN ('draft', 'approved')) NOT NULL DEFAULT 'draft',
+    employee_count INT NOT NULL DEFAULT 0,
+    gross_total NUMERIC(18,2) NOT NULL DEFAULT 0,
+    deduction_total NUMERIC(18,2) NOT NULL DEFAULT 0,
+    net_total NUMERIC(18,2) NOT NULL DEFAULT 0,
+    created_by INT,
+    approved_by INT,
+    approved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (created_by) REFERENCES users(id),
+    FOREIGN KEY (approved_by) REFERENCES users(id),
+    -- one run per company per month, running again reuse the same run
+    UNIQUE (company_id, period)
+);
+
+CREATE TABLE payslips (
+    id SERIAL PRIMARY KEY,
+    payroll_run_id INT NOT NULL,
+    employee_id INT NOT NULL,
+    employee_number VARCHAR(50) NOT NULL,
+    employee_name VARCHAR(255) NOT NULL,
+    base_salary NUMERIC(18,2) NOT NULL,
+    allowance_total NUMERIC(18,2) NOT NULL DEFAULT 0,
+    deduction_total NUMERIC(18,2) NOT NULL DEFAULT 0,
+    gross NUMERIC(18,2) NOT NULL,
+    net NUMERIC(18,2) NOT NULL,
+    lines JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (payroll_run_id) REFERENCES payroll_runs(id) ON DELETE CASCADE,
+    FOREIGN KEY (employee_id) REFERENCES employees(id),
+    UNIQUE (payroll_run_id, employee_id)
+);
+
+CREATE INDEX idx_payslips_employee ON payslips(employee_id);
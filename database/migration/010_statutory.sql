@@ -0,0 +1,206 @@
+ALTER TABLE employees ADD COLUMN ptkp_status VARCHAR(4) NOT NULL DEFAULT 'TK/0';
+ALTER TABLE employees ADD COLUMN tax_number VARCHAR(30) NOT NULL DEFAULT '';
+
+-- statutory result of payslip, used for year end reconciliation and 1721-A1
+ALTER TABLE payslips ADD COLUMN taxable_income NUMERIC(18,2) NOT NULL DEFAULT 0;
+ALTER TABLE payslips ADD COLUMN pension_contribution NUMERIC(18,2) NOT NULL DEFAULT 0;
+ALTER TABLE payslips ADD COLUMN tax NUMERIC(18,2) NOT NULL DEFAULT 0;
+ALTER TABLE payslips ADD COLUMN final_period BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- every statutory rate is effective dated, the row with the latest valid_from
+-- on or before the payroll period is used. new regulation is a new row, not an update
+CREATE TABLE statutory_parameters (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL,
+    value NUMERIC(18,4) NOT NULL,
+    valid_from DATE NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    UNIQUE (code, valid_from)
+);
+
+-- rate in percent for income above income_from
+CREATE TABLE statutory_brackets (
+    id SERIAL PRIMARY KEY,
+    table_code VARCHAR(50) NOT NULL,
+    income_from NUMERIC(18,2) NOT NULL,
+    rate NUMERIC(7,4) NOT NULL,
+    valid_from DATE NOT NULL,
+    UNIQUE (table_code, income_from, valid_from)
+);
+
+CREATE TABLE pph21_ptkp (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(4) NOT NULL,
+    amount NUMERIC(18,2) NOT NULL,
+    ter_category CHAR(1) NOT NULL,
+    valid_from DATE NOT NULL,
+    UNIQUE (status, valid_from)
+);
+
+-- BPJS Kesehatan (Perpres 64/2020), BPJS Ketenagakerjaan (PP 44/2015, PP 45/2015),
+-- JP wage cap is updated every march, biaya jabatan (PMK 250/2008)
+INSERT INTO statutory_parameters (code, value, valid_from, description) VALUES
+    ('bpjs_kes_employee_rate', 1, '2020-01-01', 'BPJS Kesehatan paid by employee, percent'),
+    ('bpjs_kes_employer_rate', 4, '2020-01-01', 'BPJS Kesehatan paid by employer, percent'),
+    ('bpjs_kes_wage_cap', 12000000, '2020-01-01', 'BPJS Kesehatan maximum wage'),
+    ('bpjs_jht_employee_rate', 2, '2015-07-01', 'JHT paid by employee, percent'),
+    ('bpjs_jht_employer_rate', 3.7, '2015-07-01', 'JHT paid by employer, percent'),
+    ('bpjs_jp_employee_rate', 1, '2015-07-01', 'JP paid by employee, percent'),
+    ('bpjs_jp_employer_rate', 2, '2015-07-01', 'JP paid by employer, percent'),
+    ('bpjs_jp_wage_cap', 9559600, '2023-03-01', 'JP maximum wage'),
+    ('bpjs_jp_wage_cap', 10042300, '2024-03-01', 'JP maximum wage'),
+    ('bpjs_jp_wage_cap', 10547400, '2025-03-01', 'JP maximum wage'),
+    ('bpjs_jkk_employer_rate', 0.24, '2015-07-01', 'JKK very low risk group, percent'),
+    ('bpjs_jkm_employer_rate', 0.3, '2015-07-01', 'JKM paid by employer, percent'),
+    ('pph21_job_expense_rate', 5, '2009-01-01', 'biaya jabatan, percent of gross'),
+    ('pph21_job_expense_annual_cap', 6000000, '2009-01-01', 'biaya jabatan maximum per year');
+
+-- PTKP (PMK 101/2016), TER category (PP 58/2023). payroll is supported from 2024-01,
+-- the first TER period, so PTKP and TER rows start there (statutory.SupportedFrom)
+INSERT INTO pph21_ptkp (status, amount, ter_category, valid_from) VALUES
+    ('TK/0', 54000000, 'A', '2024-01-01'),
+    ('TK/1', 58500000, 'A', '2024-01-01'),
+    ('TK/2', 63000000, 'B', '2024-01-01'),
+    ('TK/3', 67500000, 'B', '2024-01-01'),
+    ('K/0', 58500000, 'A', '2024-01-01'),
+    ('K/1', 63000000, 'B', '2024-01-01'),
+    ('K/2', 67500000, 'B', '2024-01-01'),
+    ('K/3', 72000000, 'C', '2024-01-01');
+
+-- pasal 17 rate (UU HPP 7/2021)
+INSERT INTO statutory_brackets (table_code, income_from, rate, valid_from) VALUES
+    ('pph21_pasal17', 0, 5, '2022-01-01'),
+    ('pph21_pasal17', 60000000, 15, '2022-01-01'),
+    ('pph21_pasal17', 250000000, 25, '2022-01-01'),
+    ('pph21_pasal17', 500000000, 30, '2022-01-01'),
+    ('pph21_pasal17', 5000000000, 35, '2022-01-01');
+
+-- TER monthly rate (PP 58/2023)
+INSERT INTO statutory_brackets (table_code, income_from, rate, valid_from) VALUES
+    ('pph21_ter_a', 0, 0, '2024-01-01'),
+    ('pph21_ter_a', 5400000, 0.25, '2024-01-01'),
+    ('pph21_ter_a', 5650000, 0.5, '2024-01-01'),
+    ('pph21_ter_a', 5950000, 0.75, '2024-01-01'),
+    ('pph21_ter_a', 6300000, 1, '2024-01-01'),
+    ('pph21_ter_a', 6750000, 1.25, '2024-01-01'),
+    ('pph21_ter_a', 7500000, 1.5, '2024-01-01'),
+    ('pph21_ter_a', 8550000, 1.75, '2024-01-01'),
+    ('pph21_ter_a', 9650000, 2, '2024-01-01'),
+    ('pph21_ter_a', 10050000, 2.25, '2024-01-01'),
+    ('pph21_ter_a', 10350000, 2.5, '2024-01-01'),
+    ('pph21_ter_a', 10700000, 3, '2024-01-01'),
+    ('pph21_ter_a', 11050000, 3.5, '2024-01-01'),
+    ('pph21_ter_a', 11600000, 4, '2024-01-01'),
+    ('pph21_ter_a', 12500000, 5, '2024-01-01'),
+    ('pph21_ter_a', 13750000, 6, '2024-01-01'),
+    ('pph21_ter_a', 15100000, 7, '2024-01-01'),
+    ('pph21_ter_a', 16950000, 8, '2024-01-01'),
+    ('pph21_ter_a', 19750000, 9, '2024-01-01'),
+    ('pph21_ter_a', 24150000, 10, '2024-01-01'),
+    ('pph21_ter_a', 26450000, 11, '2024-01-01'),
+    ('pph21_ter_a', 28000000, 12, '2024-01-01'),
+    ('pph21_ter_a', 30050000, 13, '2024-01-01'),
+    ('pph21_ter_a', 32400000, 14, '2024-01-01'),
+    ('pph21_ter_a', 35400000, 15, '2024-01-01'),
+    ('pph21_ter_a', 39100000, 16, '2024-01-01'),
+    ('pph21_ter_a', 43850000, 17, '2024-01-01'),
+    ('pph21_ter_a', 47800000, 18, '2024-01-01'),
+    ('pph21_ter_a', 51400000, 19, '2024-01-01'),
+    ('pph21_ter_a', 56300000, 20, '2024-01-01'),
+    ('pph21_ter_a', 62200000, 21, '2024-01-01'),
+    ('pph21_ter_a', 68600000, 22, '2024-01-01'),
+    ('pph21_ter_a', 77500000, 23, '2024-01-01'),
+    ('pph21_ter_a', 89000000, 24, '2024-01-01'),
+    ('pph21_ter_a', 103000000, 25, '2024-01-01'),
+    ('pph21_ter_a', 125000000, 26, '2024-01-01'),
+    ('pph21_ter_a', 157000000, 27, '2024-01-01'),
+    ('pph21_ter_a', 206000000, 28, '2024-01-01'),
+    ('pph21_ter_a', 337000000, 29, '2024-01-01'),
+    ('pph21_ter_a', 454000000, 30, '2024-01-01'),
+    ('pph21_ter_a', 550000000, 31, '2024-01-01'),
+    ('pph21_ter_a', 695000000, 32, '2024-01-01'),
+    ('pph21_ter_a', 910000000, 33, '2024-01-01'),
+    ('pph21_ter_a', 1400000000, 34, '2024-01-01'),
+    ('pph21_ter_b', 0, 0, '2024-01-01'),
+    ('pph21_ter_b', 6200000, 0.25, '2024-01-01'),
+    ('pph21_ter_b', 6500000, 0.5, '2024-01-01'),
+    ('pph21_ter_b', 6850000, 0.75, '2024-01-01'),
+    ('pph21_ter_b', 7300000, 1, '2024-01-01'),
+    ('pph21_ter_b', 9200000, 1.5, '2024-01-01'),
+    ('pph21_ter_b', 10750000, 2, '2024-01-01'),
+    ('pph21_ter_b', 11250000, 2.5, '2024-01-01'),
+    ('pph21_ter_b', 11600000, 3, '2024-01-01'),
+    ('pph21_ter_b', 12600000, 4, '2024-01-01'),
+    ('pph21_ter_b', 13600000, 5, '2024-01-01'),
+    ('pph21_ter_b', 14950000, 6, '2024-01-01'),
+    ('pph21_ter_b', 16400000, 7, '2024-01-01'),
+    ('pph21_ter_b', 18450000, 8, '2024-01-01'),
+    ('pph21_ter_b', 21850000, 9, '2024-01-01'),
+    ('pph21_ter_b', 26000000, 10, '2024-01-01'),
+    ('pph21_ter_b', 27700000, 11, '2024-01-01'),
+    ('pph21_ter_b', 29350000, 12, '2024-01-01'),
+    ('pph21_ter_b', 31450000, 13, '2024-01-01'),
+    ('pph21_ter_b', 33950000, 14, '2024-01-01'),
+    ('pph21_ter_b', 37100000, 15, '2024-01-01'),
+    ('pph21_ter_b', 41100000, 16, '2024-01-01'),
+    ('pph21_ter_b', 45800000, 17, '2024-01-01'),
+    ('pph21_ter_b', 49500000, 18, '2024-01-01'),
+    ('pph21_ter_b', 53800000, 19, '2024-01-01'),
+    ('pph21_ter_b', 58500000, 20, '2024-01-01'),
+    ('pph21_ter_b', 64000000, 21, '2024-01-01'),
+    ('pph21_ter_b', 71000000, 22, '2024-01-01'),
+    ('pph21_ter_b', 80000000, 23, '2024-01-01'),
+    ('pph21_ter_b', 93000000, 24, '2024-01-01'),
+    ('pph21_ter_b', 109000000, 25, '2024-01-01'),
+    ('pph21_ter_b', 129000000, 26, '2024-01-01'),
+    ('pph21_ter_b', 163000000, 27, '2024-01-01'),
+    ('pph21_ter_b', 211000000, 28, '2024-01-01'),
+    ('pph21_ter_b', 374000000, 29, '2024-01-01'),
+    ('pph21_ter_b', 459000000, 30, '2024-01-01'),
+    ('pph21_ter_b', 555000000, 31, '2024-01-01'),
+    ('pph21_ter_b', 704000000, 32, '2024-01-01'),
+    ('pph21_ter_b', 957000000, 33, '2024-01-01'),
+    ('pph21_ter_b', 1405000000, 34, '2024-01-01'),
+    ('pph21_ter_c', 0, 0, '2024-01-01'),
+    ('pph21_ter_c', 6600000, 0.25, '2024-01-01'),
+    ('pph21_ter_c', 6950000, 0.5, '2024-01-01'),
+    ('pph21_ter_c', 7350000, 0.75, '2024-01-01'),
+    ('pph21_ter_c', 7800000, 1, '2024-01-01'),
+    ('pph21_ter_c', 8850000, 1.25, '2024-01-01'),
+    ('pph21_ter_c', 9800000, 1.5, '2024-01-01'),
+    ('pph21_ter_c', 10950000, 1.75, '2024-01-01'),
+    ('pph21_ter_c', 11200000, 2, '2024-01-01'),
+    ('pph21_ter_c', 12050000, 3, '2024-01-01'),
+    ('pph21_ter_c', 12950000, 4, '2024-01-01'),
+    ('pph21_ter_c', 14150000, 5, '2024-01-01'),
+    ('pph21_ter_c', 15550000, 6, '2024-01-01'),
+    ('pph21_ter_c', 17050000, 7, '2024-01-01'),
+    ('pph21_ter_c', 19500000, 8, '2024-01-01'),
+    ('pph21_ter_c', 22700000, 9, '2024-01-01'),
+    ('pph21_ter_c', 26600000, 10, '2024-01-01'),
+    ('pph21_ter_c', 28100000, 11, '2024-01-01'),
+    ('pph21_ter_c', 30100000, 12, '2024-01-01'),
+    ('pph21_ter_c', 32600000, 13, '2024-01-01'),
+    ('pph21_ter_c', 35400000, 14, '2024-01-01'),
+    ('pph21_ter_c', 38900000, 15, '2024-01-01'),
+    ('pph21_ter_c', 43000000, 16, '2024-01-01'),
+    ('pph21_ter_c', 47400000, 17, '2024-01-01'),
+    ('pph21_ter_c', 51200000, 18, '2024-01-01'),
+    ('pph21_ter_c', 55800000, 19, '2024-01-01'),
+    ('pph21_ter_c', 60400000, 20, '2024-01-01'),
+    ('pph21_ter_c', 66700000, 21, '2024-01-01'),
+    ('pph21_ter_c', 74500000, 22, '2024-01-01'),
+    ('pph21_ter_c', 83200000, 23, '2024-01-01'),
+    ('pph21_ter_c', 95600000, 24, '2024-01-01'),
+    ('pph21_ter_c', 110000000, 25, '2024-01-01'),
+    ('pph21_ter_c', 134000000, 26, '2024-01-01'),
+    ('pph21_ter_c', 169000000, 27, '2024-01-01'),
+    ('pph21_ter_c', 221000000, 28, '2024-01-01'),
+    ('pph21_ter_c', 390000000, 29, '2024-01-01'),
+    ('pph21_ter_c', 463000000, 30, '2024-01-01'),
+    ('pph21_ter_c', 561000000, 31, '2024-01-01'),
+    ('pph21_ter_c', 709000000, 32, '2024-01-01'),
+    ('pph21_ter_c', 965000000, 33, '2024-01-01'),
+    ('pph21_ter_c', 1419000000, 34, '2024-01-01');
+
+ALTER TABLE payroll_runs ADD COLUMN include_thr BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,20 @@
+ALTER TABLE positions ADD COLUMN job_grade VARCHAR(20) NOT NULL DEFAULT '';
+
+CREATE TABLE salary_bands (
+    id SERIAL PRIMARY KEY,
+    position_id INT NOT NULL,
+    currency CHAR(3) NOT NULL,
+    min_salary NUMERIC(18,2) NOT NULL CHECK (min_salary >= 0),
+    mid_salary NUMERIC(18,2) NOT NULL,
+    max_salary NUMERIC(18,2) NOT NULL,
+    enforcement VARCHAR(10) NOT NULL DEFAULT 'warn' CHECK (enforcement IN ('warn', 'reject')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (position_id, currency),
+    CHECK (min_salary <= mid_salary AND mid_salary <= max_salary),
+    FOREIGN KEY (position_id) REFERENCES positions(id)
+);
+
+ALTER TABLE employee_salaries
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR',
+    ADD COLUMN band_override_reason TEXT NOT NULL DEFAULT '';
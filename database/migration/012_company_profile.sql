@@ -0,0 +1,40 @@
+ALTER TABLE company
+    ADD COLUMN legal_name VARCHAR(255) NOT NULL DEFAULT '',
+    -- NPWP, 15 digit old format or 16 digit new format
+    ADD COLUMN tax_number VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN registration_number VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR',
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Jakarta',
+    ADD COLUMN locale VARCHAR(20) NOT NULL DEFAULT 'id-ID',
+    ADD COLUMN fiscal_year_start_month SMALLINT NOT NULL DEFAULT 1 CHECK (fiscal_year_start_month BETWEEN 1 AND 12);
+
+CREATE TABLE company_addresses (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('registered', 'billing', 'shipping', 'office')),
+    line1 VARCHAR(255) NOT NULL,
+    line2 VARCHAR(255) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL,
+    province VARCHAR(100) NOT NULL DEFAULT '',
+    postal_code VARCHAR(20) NOT NULL DEFAULT '',
+    country CHAR(2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
+
+CREATE INDEX idx_company_addresses_company ON company_addresses(company_id);
+
+CREATE TABLE company_contacts (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    title VARCHAR(100) NOT NULL DEFAULT '',
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(20) NOT NULL DEFAULT '',
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
+
+-- at most one primary contact per company
+CREATE UNIQUE INDEX idx_company_contacts_primary ON company_contacts(company_id) WHERE is_primary;
@@ -0,0 +1,7 @@
+-- parent company of subsidiary, cycle is prevented by the service
+ALTER TABLE company
+    ADD COLUMN parent_id INT,
+    ADD CONSTRAINT fk_company_parent FOREIGN KEY (parent_id) REFERENCES company(id),
+    ADD CONSTRAINT chk_company_parent_self CHECK (parent_id <> id);
+
+CREATE INDEX idx_company_parent ON company(parent_id);
@@ -0,0 +1,21 @@
+-- position belong to a company, null company is a global template.
+-- name and code are unique inside the company instead of globally
+ALTER TABLE positions
+    DROP CONSTRAINT positions_position_name_key,
+    DROP CONSTRAINT positions_position_code_key,
+    ADD COLUMN company_id INT,
+    ADD COLUMN template_id INT,
+    ADD CONSTRAINT fk_positions_company FOREIGN KEY (company_id) REFERENCES company(id),
+    ADD CONSTRAINT fk_positions_template FOREIGN KEY (template_id) REFERENCES positions(id);
+
+CREATE UNIQUE INDEX idx_positions_company_name ON positions(COALESCE(company_id, 0), LOWER(position_name)) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX idx_positions_company_code ON positions(COALESCE(company_id, 0), position_code) WHERE deleted_at IS NULL;
+
+-- company without rule use the default pattern of the application
+CREATE TABLE position_code_rules (
+    company_id INT PRIMARY KEY,
+    pattern VARCHAR(255) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
@@ -0,0 +1,22 @@
+ALTER TABLE company ADD COLUMN headcount_policy VARCHAR(10) NOT NULL DEFAULT 'flag' CHECK (headcount_policy IN ('flag', 'block'));
+
+-- assignment saved beyond headcount plan under flag policy
+ALTER TABLE assignments ADD COLUMN over_plan BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE headcount_plans (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    -- null department plan the position in every department of company
+    department_id INT,
+    position_id INT NOT NULL,
+    -- first day of the month
+    period DATE NOT NULL,
+    planned INT NOT NULL CHECK (planned >= 0),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (department_id) REFERENCES departments(id),
+    FOREIGN KEY (position_id) REFERENCES positions(id)
+);
+
+CREATE UNIQUE INDEX idx_headcount_plans_seat ON headcount_plans(company_id, COALESCE(department_id, 0), position_id, period);
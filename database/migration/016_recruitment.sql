@@ -0,0 +1,87 @@
+CREATE TABLE recruitment_stages (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    sort_order INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
+
+CREATE INDEX idx_recruitment_stages_company ON recruitment_stages(company_id, sort_order);
+
+CREATE TABLE requisitions (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    department_id INT,
+    position_id INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    openings INT NOT NULL CHECK (openings > 0),
+    hired INT NOT NULL DEFAULT 0,
+    employment_type VARCHAR(20) NOT NULL CHECK (employment_type IN ('permanent', 'contract', 'probation')),
+    status VARCHAR(20) NOT NULL CHECK (status IN ('open', 'on_hold', 'closed')),
+    created_by INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (department_id) REFERENCES departments(id),
+    FOREIGN KEY (position_id) REFERENCES positions(id),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_requisitions_company_status ON requisitions(company_id, status);
+
+-- candidate personal data is kept apart from users and employees,
+-- purged columns are emptied and purged_at is set
+CREATE TABLE candidates (
+    id SERIAL PRIMARY KEY,
+    requisition_id INT NOT NULL,
+    company_id INT NOT NULL,
+    stage_id INT NOT NULL,
+    first_name VARCHAR(255) NOT NULL,
+    last_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    phone_number VARCHAR(20) NOT NULL DEFAULT '',
+    source VARCHAR(100) NOT NULL DEFAULT '',
+    cv_path VARCHAR(500) NOT NULL DEFAULT '',
+    cv_file_name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('active', 'rejected', 'withdrawn', 'hired')),
+    reject_reason TEXT NOT NULL DEFAULT '',
+    user_id INT,
+    employee_id INT,
+    closed_at TIMESTAMP,
+    purged_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (requisition_id) REFERENCES requisitions(id),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (stage_id) REFERENCES recruitment_stages(id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (employee_id) REFERENCES employees(id)
+);
+
+CREATE UNIQUE INDEX idx_candidates_requisition_email ON candidates(requisition_id, LOWER(email)) WHERE purged_at IS NULL;
+-- closed candidate waiting for retention purge
+CREATE INDEX idx_candidates_retention ON candidates(closed_at) WHERE closed_at IS NOT NULL AND purged_at IS NULL;
+
+CREATE TABLE interviews (
+    id SERIAL PRIMARY KEY,
+    candidate_id INT NOT NULL,
+    interviewer_id INT NOT NULL,
+    scheduled_at TIMESTAMP NOT NULL,
+    duration_minutes INT NOT NULL DEFAULT 60,
+    location VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL CHECK (status IN ('scheduled', 'completed', 'cancelled')),
+    score SMALLINT CHECK (score BETWEEN 1 AND 5),
+    feedback TEXT NOT NULL DEFAULT '',
+    created_by INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (candidate_id) REFERENCES candidates(id) ON DELETE CASCADE,
+    FOREIGN KEY (interviewer_id) REFERENCES users(id),
+    FOREIGN KEY (created_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_interviews_candidate ON interviews(candidate_id);
+CREATE INDEX idx_interviews_interviewer ON interviews(interviewer_id, scheduled_at);
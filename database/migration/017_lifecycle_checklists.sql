@@ -0,0 +1,71 @@
+CREATE TABLE checklist_templates (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    -- null position apply the template to every position of company
+    position_id INT,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('onboarding', 'transfer', 'offboarding')),
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (position_id) REFERENCES positions(id)
+);
+
+CREATE INDEX idx_checklist_templates_company_type ON checklist_templates(company_id, type);
+
+CREATE TABLE checklist_template_tasks (
+    id SERIAL PRIMARY KEY,
+    template_id INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- owner of the task, for example IT, finance or facilities
+    team VARCHAR(100) NOT NULL DEFAULT '',
+    assignee_id INT,
+    due_days INT NOT NULL DEFAULT 0 CHECK (due_days BETWEEN 0 AND 365),
+    sort_order INT NOT NULL,
+    FOREIGN KEY (template_id) REFERENCES checklist_templates(id) ON DELETE CASCADE,
+    FOREIGN KEY (assignee_id) REFERENCES users(id)
+);
+
+CREATE TABLE checklists (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    company_id INT NOT NULL,
+    -- task is copied from template, removing the template keep started checklist
+    template_id INT,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('onboarding', 'transfer', 'offboarding')),
+    name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('open', 'completed')),
+    start_date DATE NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (company_id) REFERENCES company(id),
+    FOREIGN KEY (template_id) REFERENCES checklist_templates(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_checklists_user ON checklists(user_id, type, status);
+
+CREATE TABLE checklist_tasks (
+    id SERIAL PRIMARY KEY,
+    checklist_id INT NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    team VARCHAR(100) NOT NULL DEFAULT '',
+    assignee_id INT,
+    due_date DATE NOT NULL,
+    sort_order INT NOT NULL,
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    completed_at TIMESTAMP,
+    completed_by INT,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (checklist_id) REFERENCES checklists(id) ON DELETE CASCADE,
+    FOREIGN KEY (assignee_id) REFERENCES users(id),
+    FOREIGN KEY (completed_by) REFERENCES users(id)
+);
+
+CREATE INDEX idx_checklist_tasks_checklist ON checklist_tasks(checklist_id);
+CREATE INDEX idx_checklist_tasks_assignee_open ON checklist_tasks(assignee_id) WHERE completed = FALSE;
@@ -0,0 +1,18 @@
+-- domain event saved in the same transaction as the change, dispatcher deliver it
+-- to every in-process subscriber at least once
+CREATE TABLE outbox_events (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    company_id INT,
+    payload TEXT NOT NULL,
+    status VARCHAR(10) CHECK (status IN ('pending', 'dispatched', 'failed')) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    -- comma separated subscriber that already handled the event, skipped on retry
+    done_subscribers TEXT NOT NULL DEFAULT '',
+    last_error TEXT NOT NULL DEFAULT '',
+    occurred_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_events_due ON outbox_events(next_attempt_at, id) WHERE status = 'pending';
@@ -0,0 +1,40 @@
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    data TEXT NOT NULL DEFAULT '{}',
+    event_id INT,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (event_id) REFERENCES outbox_events(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, id DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+-- dispatcher may deliver the same event again
+CREATE UNIQUE INDEX idx_notifications_user_event ON notifications(user_id, event_id) WHERE event_id IS NOT NULL;
+
+CREATE TABLE notification_preferences (
+    user_id INT PRIMARY KEY,
+    in_app BOOLEAN NOT NULL DEFAULT TRUE,
+    email BOOLEAN NOT NULL DEFAULT FALSE,
+    chat BOOLEAN NOT NULL DEFAULT FALSE,
+    chat_address TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE notification_templates (
+    id SERIAL PRIMARY KEY,
+    company_id INT NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    locale VARCHAR(20) NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (company_id, type, locale),
+    FOREIGN KEY (company_id) REFERENCES company(id)
+);
@@ -0,0 +1,30 @@
+-- applied migration, readiness compare it with the migration files built into the binary.
+-- every migration from now on end with inserting its own version
+CREATE TABLE schema_migrations (
+    version VARCHAR(255) PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- migration is applied in order, everything before this one is already in place
+INSERT INTO schema_migrations (version) VALUES
+    ('001_migrate_table'),
+    ('002_export_jobs'),
+    ('003_departments'),
+    ('004_reporting_lines'),
+    ('005_employees'),
+    ('006_assignments'),
+    ('007_attendance'),
+    ('008_leaves'),
+    ('009_payroll'),
+    ('010_statutory'),
+    ('011_salary_bands'),
+    ('012_company_profile'),
+    ('013_company_groups'),
+    ('014_position_catalogs'),
+    ('015_headcount_plans'),
+    ('016_recruitment'),
+    ('017_lifecycle_checklists'),
+    ('018_webhooks'),
+    ('019_outbox_events'),
+    ('020_notifications'),
+    ('021_schema_migrations');
@@ -0,0 +1,6 @@
+-- the application read and write companies, the table was created as company.
+-- foreign key and index follow the table, so only the table and its sequence are renamed
+ALTER TABLE company RENAME TO companies;
+ALTER SEQUENCE company_id_seq RENAME TO companies_id_seq;
+
+INSERT INTO schema_migrations (version) VALUES ('022_companies_table');
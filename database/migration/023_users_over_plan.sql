@@ -0,0 +1,4 @@
+-- user created beyond headcount plan under flag policy, like assignments.over_plan
+ALTER TABLE users ADD COLUMN over_plan BOOLEAN NOT NULL DEFAULT FALSE;
+
+INSERT INTO schema_migrations (version) VALUES ('023_users_over_plan');
@@ -0,0 +1,27 @@
+// Package migration embed the sql files so the running binary know which
+// migration it expects, the files are still applied in order by hand
+package migration
+
+import (
+	"embed"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Versions return file name without extension of every migration in order
+func Versions() []string {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return []string{}
+	}
+	sort.Strings(names)
+	versions := make([]string, 0, len(names))
+	for _, name := range names {
+		versions = append(versions, strings.TrimSuffix(name, ".sql"))
+	}
+	return versions
+}
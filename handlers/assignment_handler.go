@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AssignmentHandler interface {
+	GetAssignments(ctx *gin.Context)
+	GetUserAssignments(ctx *gin.Context)
+
+	TransferUser(ctx *gin.Context)
+	PromoteUser(ctx *gin.Context)
+}
+
+type assignmentHandlerImpl struct {
+	svc service.AssignmentService
+}
+
+func NewAssignmentHandler(svc service.AssignmentService) AssignmentHandler {
+	return &assignmentHandlerImpl{svc: svc}
+}
+
+// error from invalid transfer or promotion
+var assignmentValidationErrors = []string{
+	"user not found",
+	"company not found",
+	"position not found",
+	"department not found",
+	"department must belong to the same company",
+	"position must belong to the same company",
+	"effective date must be after",
+	"hiring beyond headcount plan is not allowed",
+}
+
+func isAssignmentValidationError(err error) bool {
+	for _, msg := range assignmentValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAssignments return assignment of all users valid on as_of date
+func (a *assignmentHandlerImpl) GetAssignments(ctx *gin.Context) {
+	filter := models.AssignmentFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AssignmentsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	asOf, err := models.ParseAsOf(filter.AsOf)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AssignmentsResponse{
+			Status:  http.StatusBadRequest,
+			Message: err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	assignments, err := a.svc.GetAssignments(ctx, filter, asOf)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.AssignmentsResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get assignments",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(assignments) == 0 {
+		ctx.JSON(http.StatusNotFound, models.AssignmentsResponse{
+			Status:  http.StatusNotFound,
+			Message: "assignments not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AssignmentsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get assignments",
+		Data:    &assignments,
+		Error:   false,
+	})
+}
+
+// GetUserAssignments return full history of user,
+// or only the assignment valid on as_of date when as_of is sent
+func (a *assignmentHandlerImpl) GetUserAssignments(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AssignmentsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if ctx.Query("as_of") != "" {
+		asOf, err := models.ParseAsOf(ctx.Query("as_of"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, models.AssignmentResponse{
+				Status:  http.StatusBadRequest,
+				Message: err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+
+		assignment, err := a.svc.GetAssignmentAsOf(ctx, uint64(id), asOf)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, models.AssignmentResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "failed to get assignment",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if assignment.ID == 0 {
+			ctx.JSON(http.StatusNotFound, models.AssignmentResponse{
+				Status:  http.StatusNotFound,
+				Message: "assignment not found",
+				Data:    nil,
+				Error:   false,
+			})
+			return
+		}
+		ctx.JSON(http.StatusOK, models.AssignmentResponse{
+			Status:  http.StatusOK,
+			Message: "success to get assignment",
+			Data:    &assignment,
+			Error:   false,
+		})
+		return
+	}
+
+	assignments, err := a.svc.GetAssignmentsByUserID(ctx, uint64(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.AssignmentsResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get assignments",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(assignments) == 0 {
+		ctx.JSON(http.StatusNotFound, models.AssignmentsResponse{
+			Status:  http.StatusNotFound,
+			Message: "assignments not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AssignmentsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get assignments",
+		Data:    &assignments,
+		Error:   false,
+	})
+}
+
+func (a *assignmentHandlerImpl) TransferUser(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AssignmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	transfer := models.AssignmentTransferRequest{}
+	if err := ctx.ShouldBindJSON(&transfer); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AssignmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to transfer user: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	assignment, err := a.svc.TransferUser(ctx, uint64(id), transfer, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isAssignmentValidationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.AssignmentResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to transfer user: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "outside of your access") {
+			ctx.JSON(http.StatusForbidden, models.AssignmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to transfer user: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.AssignmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to transfer user: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.AssignmentResponse{
+		Status:  http.StatusCreated,
+		Message: "user transferred successfully",
+		Data:    &assignment,
+		Error:   false,
+	})
+}
+
+func (a *assignmentHandlerImpl) PromoteUser(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AssignmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	promotion := models.AssignmentPromotionRequest{}
+	if err := ctx.ShouldBindJSON(&promotion); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AssignmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to promote user: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	assignment, err := a.svc.PromoteUser(ctx, uint64(id), promotion, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isAssignmentValidationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.AssignmentResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to promote user: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "outside of your access") {
+			ctx.JSON(http.StatusForbidden, models.AssignmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to promote user: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.AssignmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to promote user: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.AssignmentResponse{
+		Status:  http.StatusCreated,
+		Message: "user promoted successfully",
+		Data:    &assignment,
+		Error:   false,
+	})
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type AttendanceHandler interface {
+	ClockIn(ctx *gin.Context)
+	ClockOut(ctx *gin.Context)
+	GetMyAttendances(ctx *gin.Context)
+	CreateCorrection(ctx *gin.Context)
+
+	GetCorrections(ctx *gin.Context)
+	ApproveCorrection(ctx *gin.Context)
+	RejectCorrection(ctx *gin.Context)
+	GetMonthlySummary(ctx *gin.Context)
+
+	GetAttendanceSetting(ctx *gin.Context)
+	SaveAttendanceSetting(ctx *gin.Context)
+	CreateGeofence(ctx *gin.Context)
+	DeleteGeofence(ctx *gin.Context)
+}
+
+type attendanceHandlerImpl struct {
+	svc service.AttendanceService
+}
+
+func NewAttendanceHandler(svc service.AttendanceService) AttendanceHandler {
+	return &attendanceHandlerImpl{svc: svc}
+}
+
+// error caused by the request, not by the server
+var attendanceValidationErrors = []string{
+	"user not found",
+	"correction not found",
+	"geofence not found",
+	"already clocked in",
+	"not clocked in yet",
+	"location is",
+	"latitude",
+	"source must be",
+	"invalid month",
+	"work date",
+	"work start",
+	"work end",
+	"grace minutes",
+	"radius must be",
+	"clock out must be after",
+	"company id cannot be empty",
+	"already reviewed",
+}
+
+// attendanceErrorStatus map error of attendance service to http status
+func attendanceErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range attendanceValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func (a *attendanceHandlerImpl) ClockIn(ctx *gin.Context) {
+	// body is optional when company does not require location
+	clock := models.ClockRequest{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&clock); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.AttendanceResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to clock in: unable to parse request body",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+	}
+
+	attendance, err := a.svc.ClockIn(ctx, middleware.GetClaimUserID(ctx), clock)
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceResponse{
+			Status:  status,
+			Message: "failed to clock in: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusCreated, models.AttendanceResponse{
+		Status:  http.StatusCreated,
+		Message: "success to clock in",
+		Data:    &attendance,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) ClockOut(ctx *gin.Context) {
+	// body is optional when company does not require location
+	clock := models.ClockRequest{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&clock); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.AttendanceResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to clock out: unable to parse request body",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+	}
+
+	attendance, err := a.svc.ClockOut(ctx, middleware.GetClaimUserID(ctx), clock)
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceResponse{
+			Status:  status,
+			Message: "failed to clock out: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AttendanceResponse{
+		Status:  http.StatusOK,
+		Message: "success to clock out",
+		Data:    &attendance,
+		Error:   false,
+	})
+}
+
+// GetMyAttendances return attendance of the login user in one month
+func (a *attendanceHandlerImpl) GetMyAttendances(ctx *gin.Context) {
+	attendances, err := a.svc.GetMyAttendances(ctx, middleware.GetClaimUserID(ctx), ctx.Query("month"))
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendancesResponse{
+			Status:  status,
+			Message: "failed to get attendances: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(attendances) == 0 {
+		ctx.JSON(http.StatusNotFound, models.AttendancesResponse{
+			Status:  http.StatusNotFound,
+			Message: "attendances not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AttendancesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get attendances",
+		Data:    &attendances,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) CreateCorrection(ctx *gin.Context) {
+	correctionRequest := models.AttendanceCorrectionRequest{}
+	if err := ctx.ShouldBindJSON(&correctionRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceCorrectionResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create correction: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	correction, err := a.svc.CreateCorrection(ctx, middleware.GetClaimUserID(ctx), correctionRequest)
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceCorrectionResponse{
+			Status:  status,
+			Message: "failed to create correction: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusCreated, models.AttendanceCorrectionResponse{
+		Status:  http.StatusCreated,
+		Message: "success to create correction",
+		Data:    &correction,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) GetCorrections(ctx *gin.Context) {
+	corrections, err := a.svc.GetCorrections(ctx, middleware.GetClaimUserID(ctx), ctx.Query("status"))
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceCorrectionsResponse{
+			Status:  status,
+			Message: "failed to get corrections: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(corrections) == 0 {
+		ctx.JSON(http.StatusNotFound, models.AttendanceCorrectionsResponse{
+			Status:  http.StatusNotFound,
+			Message: "corrections not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AttendanceCorrectionsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get corrections",
+		Data:    &corrections,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) ApproveCorrection(ctx *gin.Context) {
+	a.reviewCorrection(ctx, "approve", a.svc.ApproveCorrection)
+}
+
+func (a *attendanceHandlerImpl) RejectCorrection(ctx *gin.Context) {
+	a.reviewCorrection(ctx, "reject", a.svc.RejectCorrection)
+}
+
+// reviewCorrection handle approve and reject, the note is optional
+func (a *attendanceHandlerImpl) reviewCorrection(ctx *gin.Context, action string, review func(ctx context.Context, adminID uint64, id uint64, note string) (models.AttendanceCorrection, error)) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceCorrectionResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	reviewRequest := models.AttendanceReviewRequest{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&reviewRequest); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.AttendanceCorrectionResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to " + action + " correction: unable to parse request body",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+	}
+
+	correction, err := review(ctx, middleware.GetClaimUserID(ctx), uint64(id), reviewRequest.Note)
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceCorrectionResponse{
+			Status:  status,
+			Message: "failed to " + action + " correction: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AttendanceCorrectionResponse{
+		Status:  http.StatusOK,
+		Message: "success to " + action + " correction",
+		Data:    &correction,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) GetMonthlySummary(ctx *gin.Context) {
+	filter := models.AttendanceFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceSummariesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	summaries, err := a.svc.GetMonthlySummary(ctx, middleware.GetClaimUserID(ctx), filter)
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceSummariesResponse{
+			Status:  status,
+			Message: "failed to get attendance summary: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(summaries) == 0 {
+		ctx.JSON(http.StatusNotFound, models.AttendanceSummariesResponse{
+			Status:  http.StatusNotFound,
+			Message: "attendance summary not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AttendanceSummariesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get attendance summary",
+		Data:    &summaries,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) GetAttendanceSetting(ctx *gin.Context) {
+	companyID, err := strconv.Atoi(ctx.Param("company_id"))
+	if companyID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceSettingResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing company ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	setting, err := a.svc.GetAttendanceSetting(ctx, middleware.GetClaimUserID(ctx), uint64(companyID))
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceSettingResponse{
+			Status:  status,
+			Message: "failed to get attendance setting: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AttendanceSettingResponse{
+		Status:  http.StatusOK,
+		Message: "success to get attendance setting",
+		Data:    &setting,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) SaveAttendanceSetting(ctx *gin.Context) {
+	companyID, err := strconv.Atoi(ctx.Param("company_id"))
+	if companyID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceSettingResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing company ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	setting := models.AttendanceSetting{}
+	if err := ctx.ShouldBindJSON(&setting); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceSettingResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save attendance setting: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	setting.CompanyID = uint64(companyID)
+
+	savedSetting, err := a.svc.SaveAttendanceSetting(ctx, middleware.GetClaimUserID(ctx), setting)
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceSettingResponse{
+			Status:  status,
+			Message: "failed to save attendance setting: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AttendanceSettingResponse{
+		Status:  http.StatusOK,
+		Message: "success to save attendance setting",
+		Data:    &savedSetting,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) CreateGeofence(ctx *gin.Context) {
+	companyID, err := strconv.Atoi(ctx.Param("company_id"))
+	if companyID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceGeofenceResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing company ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	geofence := models.AttendanceGeofence{}
+	if err := ctx.ShouldBindJSON(&geofence); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceGeofenceResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create geofence: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	geofence.CompanyID = uint64(companyID)
+
+	createdGeofence, err := a.svc.CreateGeofence(ctx, middleware.GetClaimUserID(ctx), geofence)
+	if err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceGeofenceResponse{
+			Status:  status,
+			Message: "failed to create geofence: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusCreated, models.AttendanceGeofenceResponse{
+		Status:  http.StatusCreated,
+		Message: "success to create geofence",
+		Data:    &createdGeofence,
+		Error:   false,
+	})
+}
+
+func (a *attendanceHandlerImpl) DeleteGeofence(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.AttendanceGeofenceResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := a.svc.DeleteGeofence(ctx, middleware.GetClaimUserID(ctx), uint64(id)); err != nil {
+		status := attendanceErrorStatus(err)
+		ctx.JSON(status, models.AttendanceGeofenceResponse{
+			Status:  status,
+			Message: "failed to delete geofence: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.AttendanceGeofenceResponse{
+		Status:  http.StatusOK,
+		Message: "success to delete geofence",
+		Data:    nil,
+		Error:   false,
+	})
+}
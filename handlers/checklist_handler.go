@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ChecklistHandler interface {
+	GetTemplates(ctx *gin.Context)
+	GetTemplateByID(ctx *gin.Context)
+	CreateTemplate(ctx *gin.Context)
+	UpdateTemplate(ctx *gin.Context)
+	DeleteTemplate(ctx *gin.Context)
+
+	GetChecklistByID(ctx *gin.Context)
+	GetUserChecklists(ctx *gin.Context)
+
+	GetMyTasks(ctx *gin.Context)
+	CompleteTask(ctx *gin.Context)
+}
+
+type checklistHandlerImpl struct {
+	svc service.ChecklistService
+}
+
+func NewChecklistHandler(svc service.ChecklistService) ChecklistHandler {
+	return &checklistHandlerImpl{svc: svc}
+}
+
+// error from invalid template or task
+var checklistValidationErrors = []string{
+	"company id cannot be empty",
+	"checklist type must be",
+	"checklist template must have at least one task",
+	"task title cannot be empty",
+	"task due days must be",
+	"must belong to the same company",
+	"company of checklist template cannot be changed",
+	"checklist task is already completed",
+}
+
+func checklistErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range checklistValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func (c *checklistHandlerImpl) GetTemplates(ctx *gin.Context) {
+	filter := models.ChecklistTemplateFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistTemplatesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	templates, err := c.svc.GetTemplates(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := checklistErrorStatus(err)
+		ctx.JSON(status, models.ChecklistTemplatesResponse{
+			Status:  status,
+			Message: "failed to get checklist templates: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(templates) == 0 {
+		ctx.JSON(http.StatusNotFound, models.ChecklistTemplatesResponse{
+			Status:  http.StatusNotFound,
+			Message: "checklist templates not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.ChecklistTemplatesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get checklist templates",
+		Data:    &templates,
+		Error:   false,
+	})
+}
+
+func (c *checklistHandlerImpl) GetTemplateByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistTemplateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	template, err := c.svc.GetTemplateByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := checklistErrorStatus(err)
+		ctx.JSON(status, models.ChecklistTemplateResponse{
+			Status:  status,
+			Message: "failed to get checklist template: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ChecklistTemplateResponse{
+		Status:  http.StatusOK,
+		Message: "success to get checklist template",
+		Data:    &template,
+		Error:   false,
+	})
+}
+
+func (c *checklistHandlerImpl) CreateTemplate(ctx *gin.Context) {
+	request := models.ChecklistTemplateRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistTemplateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create checklist template: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	template, err := c.svc.CreateTemplate(ctx, request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := checklistErrorStatus(err)
+		ctx.JSON(status, models.ChecklistTemplateResponse{
+			Status:  status,
+			Message: "failed to create checklist template: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.ChecklistTemplateResponse{
+		Status:  http.StatusCreated,
+		Message: "checklist template created successfully",
+		Data:    &template,
+		Error:   false,
+	})
+}
+
+func (c *checklistHandlerImpl) UpdateTemplate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistTemplateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	request := models.ChecklistTemplateRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistTemplateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to update checklist template: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	template, err := c.svc.UpdateTemplate(ctx, uint64(id), request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := checklistErrorStatus(err)
+		ctx.JSON(status, models.ChecklistTemplateResponse{
+			Status:  status,
+			Message: "failed to update checklist template: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ChecklistTemplateResponse{
+		Status:  http.StatusOK,
+		Message: "success to update checklist template",
+		Data:    &template,
+		Error:   false,
+	})
+}
+
+func (c *checklistHandlerImpl) DeleteTemplate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistTemplateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := c.svc.DeleteTemplate(ctx, uint64(id), middleware.GetClaimUserID(ctx)); err != nil {
+		status := checklistErrorStatus(err)
+		ctx.JSON(status, models.ChecklistTemplateResponse{
+			Status:  status,
+			Message: "failed to delete checklist template: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ChecklistTemplateResponse{
+		Status:  http.StatusOK,
+		Message: "success to delete checklist template",
+		Data:    nil,
+		Error:   false,
+	})
+}
+
+func (c *checklistHandlerImpl) GetChecklistByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	checklist, err := c.svc.GetChecklistByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := checklistErrorStatus(err)
+		ctx.JSON(status, models.ChecklistResponse{
+			Status:  status,
+			Message: "failed to get checklist: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ChecklistResponse{
+		Status:  http.StatusOK,
+		Message: "success to get checklist",
+		Data:    &checklist,
+		Error:   false,
+	})
+}
+
+// GetUserChecklists return onboarding, transfer and offboarding checklist of user
+func (c *checklistHandlerImpl) GetUserChecklists(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	checklists, err := c.svc.GetUserChecklists(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := checklistErrorStatus(err)
+		ctx.JSON(status, models.ChecklistsResponse{
+			Status:  status,
+			Message: "failed to get checklists: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(checklists) == 0 {
+		ctx.JSON(http.StatusNotFound, models.ChecklistsResponse{
+			Status:  http.StatusNotFound,
+			Message: "checklists not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.ChecklistsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get checklists",
+		Data:    &checklists,
+		Error:   false,
+	})
+}
+
+// GetMyTasks return open task assigned to the logged in user
+func (c *checklistHandlerImpl) GetMyTasks(ctx *gin.Context) {
+	tasks, err := c.svc.GetMyTasks(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.ChecklistTasksResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get checklist tasks",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(tasks) == 0 {
+		ctx.JSON(http.StatusNotFound, models.ChecklistTasksResponse{
+			Status:  http.StatusNotFound,
+			Message: "checklist tasks not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.ChecklistTasksResponse{
+		Status:  http.StatusOK,
+		Message: "success to get checklist tasks",
+		Data:    &tasks,
+		Error:   false,
+	})
+}
+
+func (c *checklistHandlerImpl) CompleteTask(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ChecklistTaskResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// note is optional
+	request := models.ChecklistTaskCompleteRequest{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.ChecklistTaskResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to complete checklist task: unable to parse request body",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+	}
+
+	task, err := c.svc.CompleteTask(ctx, uint64(id), request, middleware.GetClaimUserID(ctx), middleware.IsClaimAdmin(ctx))
+	if err != nil {
+		status := checklistErrorStatus(err)
+		ctx.JSON(status, models.ChecklistTaskResponse{
+			Status:  status,
+			Message: "failed to complete checklist task: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.ChecklistTaskResponse{
+		Status:  http.StatusOK,
+		Message: "success to complete checklist task",
+		Data:    &task,
+		Error:   false,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/geedotrar/erp-api/middleware"
 	"github.com/geedotrar/erp-api/models"
 	"github.com/geedotrar/erp-api/service"
 	"github.com/gin-gonic/gin"
@@ -20,6 +21,12 @@ type CompanyHandler interface {
 
 	DeleteCompany(ctx *gin.Context)
 	RestoreCompany(ctx *gin.Context)
+
+	GetOrgChart(ctx *gin.Context)
+
+	SetParentCompany(ctx *gin.Context)
+	GetCompanySubtree(ctx *gin.Context)
+	GetCompanyRollup(ctx *gin.Context)
 }
 
 type companyHandlerImpl struct {
@@ -30,8 +37,44 @@ func NewCompanyHandler(svc service.CompanyService) CompanyHandler {
 	return &companyHandlerImpl{svc: svc}
 }
 
+// error from invalid company profile
+var companyValidationErrors = []string{
+	"tax number must be",
+	"currency must be",
+	"timezone must be",
+	"locale must be",
+	"fiscal year start month must be",
+	"headcount policy must be",
+	"address type must be",
+	"address line1 and city",
+	"address country must be",
+	"contact name is required",
+	"contact email is invalid",
+	"only have one primary contact",
+}
+
+func isCompanyValidationError(err error) bool {
+	for _, msg := range companyValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *companyHandlerImpl) GetCompany(ctx *gin.Context) {
-	company, err := u.svc.GetCompany(ctx)
+	filter := models.CompanyFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CompaniesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	company, err := u.svc.GetCompany(ctx, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.CompaniesResponse{
 			Status:  http.StatusInternalServerError,
@@ -52,6 +95,9 @@ func (u *companyHandlerImpl) GetCompany(ctx *gin.Context) {
 		})
 		return
 	}
+	for i := range company {
+		company[i] = company[i].Public()
+	}
 	ctx.JSON(http.StatusOK, models.CompaniesResponse{
 		Status:  http.StatusOK,
 		Message: "success to get company",
@@ -93,6 +139,7 @@ func (c *companyHandlerImpl) GetCompanyByID(ctx *gin.Context) {
 		return
 	}
 
+	company = company.Public()
 	ctx.JSON(http.StatusOK, models.CompanyResponse{
 		Status:  http.StatusOK,
 		Message: "success to get company",
@@ -158,6 +205,15 @@ func (c *companyHandlerImpl) CreateCompany(ctx *gin.Context) {
 			})
 			return
 		}
+		if isCompanyValidationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.CompanyResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to create company: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, models.CompanyResponse{
 			Status:  http.StatusInternalServerError,
 			Message: "failed to create company: internal server error",
@@ -246,8 +302,17 @@ func (c *companyHandlerImpl) UpdateCompany(ctx *gin.Context) {
 	}
 
 	// call service to edit company
-	updatedCompany, err := c.svc.UpdateCompany(ctx, uint64(id), companyEdit)
+	updatedCompany, err := c.svc.UpdateCompany(ctx, uint64(id), companyEdit, middleware.GetClaimUserID(ctx))
 	if err != nil {
+		if strings.Contains(err.Error(), "outside of your access") {
+			ctx.JSON(http.StatusForbidden, models.CompanyResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to update company: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		if strings.Contains(err.Error(), "company already exists") {
 			ctx.JSON(http.StatusConflict, models.CompanyResponse{
 				Status:  http.StatusConflict,
@@ -257,6 +322,15 @@ func (c *companyHandlerImpl) UpdateCompany(ctx *gin.Context) {
 			})
 			return
 		}
+		if isCompanyValidationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.CompanyResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to update company: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, models.CompanyResponse{
 			Status:  http.StatusInternalServerError,
 			Message: "failed to update company: internal server error",
@@ -290,10 +364,10 @@ func (c *companyHandlerImpl) DeleteCompany(ctx *gin.Context) {
 
 	company, err := c.svc.DeleteCompany(ctx, uint64(id))
 	if err != nil {
-		if strings.Contains(err.Error(), "company is still in use by user") {
+		if strings.Contains(err.Error(), "company is still in use by") {
 			ctx.JSON(http.StatusBadRequest, models.CompanyResponse{
 				Status:  http.StatusBadRequest,
-				Message: "failed delete company: company is still in use by user",
+				Message: "failed delete company: " + err.Error(),
 				Data:    nil,
 				Error:   true,
 			})
@@ -358,3 +432,183 @@ func (c *companyHandlerImpl) RestoreCompany(ctx *gin.Context) {
 		Error:   false,
 	})
 }
+
+func (c *companyHandlerImpl) GetOrgChart(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.OrgChartResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	company, err := c.svc.GetCompanyByID(ctx, uint64(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.OrgChartResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get org chart",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if company.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.OrgChartResponse{
+			Status:  http.StatusNotFound,
+			Message: "company not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+
+	chart, err := c.svc.GetOrgChart(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if strings.Contains(err.Error(), "outside of your access") {
+			ctx.JSON(http.StatusForbidden, models.OrgChartResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to get org chart: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.OrgChartResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get org chart",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.OrgChartResponse{
+		Status:  http.StatusOK,
+		Message: "success to get org chart",
+		Data:    &chart,
+		Error:   false,
+	})
+}
+
+// companyGroupErrorStatus map error of company hierarchy to http status
+func companyGroupErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	if strings.Contains(err.Error(), "parent company cannot be") || strings.Contains(err.Error(), "invalid month") {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// SetParentCompany move company under another company, parent_id null detach it from the group
+func (c *companyHandlerImpl) SetParentCompany(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CompanyResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	parentRequest := models.CompanyParentRequest{}
+	if err := ctx.ShouldBindJSON(&parentRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CompanyResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to set parent company: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	company, err := c.svc.SetParentCompany(ctx, uint64(id), parentRequest, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := companyGroupErrorStatus(err)
+		ctx.JSON(status, models.CompanyResponse{
+			Status:  status,
+			Message: "failed to set parent company: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.CompanyResponse{
+		Status:  http.StatusOK,
+		Message: "success to set parent company",
+		Data:    &company,
+		Error:   false,
+	})
+}
+
+func (c *companyHandlerImpl) GetCompanySubtree(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CompanyTreeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	tree, err := c.svc.GetCompanySubtree(ctx, middleware.GetClaimUserID(ctx), uint64(id))
+	if err != nil {
+		status := companyGroupErrorStatus(err)
+		ctx.JSON(status, models.CompanyTreeResponse{
+			Status:  status,
+			Message: "failed to get company subtree: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.CompanyTreeResponse{
+		Status:  http.StatusOK,
+		Message: "success to get company subtree",
+		Data:    &tree,
+		Error:   false,
+	})
+}
+
+// GetCompanyRollup sum headcount and payroll of the month across company and its subsidiaries
+func (c *companyHandlerImpl) GetCompanyRollup(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CompanyRollupResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	rollup, err := c.svc.GetCompanyRollup(ctx, middleware.GetClaimUserID(ctx), uint64(id), ctx.Query("period"))
+	if err != nil {
+		status := companyGroupErrorStatus(err)
+		ctx.JSON(status, models.CompanyRollupResponse{
+			Status:  status,
+			Message: "failed to get company rollup: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.CompanyRollupResponse{
+		Status:  http.StatusOK,
+		Message: "success to get company rollup",
+		Data:    &rollup,
+		Error:   false,
+	})
+}
@@ -0,0 +1,513 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type DepartmentHandler interface {
+	GetDepartment(ctx *gin.Context)
+	GetDepartmentByID(ctx *gin.Context)
+
+	CreateDepartment(ctx *gin.Context)
+	UpdateDepartment(ctx *gin.Context)
+
+	DeleteDepartment(ctx *gin.Context)
+	RestoreDepartment(ctx *gin.Context)
+}
+
+type departmentHandlerImpl struct {
+	svc service.DepartmentService
+}
+
+func NewDepartmentHandler(svc service.DepartmentService) DepartmentHandler {
+	return &departmentHandlerImpl{svc: svc}
+}
+
+// error from invalid company, parent or head of department
+var departmentRelationErrors = []string{
+	"company not found",
+	"parent department not found",
+	"parent department must belong to the same company",
+	"department cannot be nested under itself",
+	"department head not found",
+	"department head must belong to the same company",
+}
+
+func isDepartmentRelationError(err error) bool {
+	for _, msg := range departmentRelationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// error when company of department is outside admin access
+func isDepartmentForbidden(err error) bool {
+	return strings.Contains(err.Error(), "outside of your access")
+}
+
+func (d *departmentHandlerImpl) GetDepartment(ctx *gin.Context) {
+	filter := models.DepartmentFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter: company_id is required",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	departments, err := d.svc.GetDepartment(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentsResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to get department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentsResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get department",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// department not found
+	if len(departments) == 0 {
+		ctx.JSON(http.StatusNotFound, models.DepartmentsResponse{
+			Status:  http.StatusNotFound,
+			Message: "department not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.DepartmentsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get department",
+		Data:    &departments,
+		Error:   false,
+	})
+}
+
+func (d *departmentHandlerImpl) GetDepartmentByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	department, err := d.svc.GetDepartmentByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to get department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get department",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if department.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.DepartmentResponse{
+			Status:  http.StatusNotFound,
+			Message: "department not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.DepartmentResponse{
+		Status:  http.StatusOK,
+		Message: "success to get department",
+		Data:    &department,
+		Error:   false,
+	})
+}
+
+func (d *departmentHandlerImpl) CreateDepartment(ctx *gin.Context) {
+	departmentCreate := models.DepartmentRequest{}
+	// check req JSON OK
+	if err := ctx.ShouldBindJSON(&departmentCreate); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create department: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// validasi data ke request jika req salah atau data tidak diisi
+	validate := validator.New()
+	if err := validate.Struct(departmentCreate); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create department: invalid data",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// check is data already exists in soft deleted
+	softDeleted, err := d.svc.IsDepartmentSoftDeleted(ctx, departmentCreate.CompanyID, departmentCreate.DepartmentName, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to create department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to create department: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if softDeleted {
+		ctx.JSON(http.StatusConflict, models.DepartmentResponse{
+			Status:  http.StatusConflict,
+			Message: "failed to create department: department already exists in soft deleted",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	department, err := d.svc.CreateDepartment(ctx, departmentCreate, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to create department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "department already exists") {
+			ctx.JSON(http.StatusConflict, models.DepartmentResponse{
+				Status:  http.StatusConflict,
+				Message: "failed to create department: department already exists",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if isDepartmentRelationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to create department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to create department: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.DepartmentResponse{
+		Status:  http.StatusCreated,
+		Message: "department created successfully",
+		Data:    department.Data,
+		Error:   false,
+	})
+}
+
+func (d *departmentHandlerImpl) UpdateDepartment(ctx *gin.Context) {
+	// parameter
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// check id exists
+	department, err := d.svc.GetDepartmentByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to update department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to update department: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if department.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.DepartmentResponse{
+			Status:  http.StatusNotFound,
+			Message: "department not found",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// check req JSON OK
+	var departmentEdit models.DepartmentRequest
+	if err := ctx.ShouldBindJSON(&departmentEdit); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// validasi data ke request jika req salah atau data tidak diisi
+	validate := validator.New()
+	if err := validate.Struct(departmentEdit); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to update department: invalid data",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// check is data exists in soft deleted
+	softDeleted, err := d.svc.IsDepartmentSoftDeleted(ctx, departmentEdit.CompanyID, departmentEdit.DepartmentName, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to update department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to update department: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if softDeleted {
+		ctx.JSON(http.StatusConflict, models.DepartmentResponse{
+			Status:  http.StatusConflict,
+			Message: "failed to update department: department already exists in soft deleted",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// call service to edit department
+	updatedDepartment, err := d.svc.UpdateDepartment(ctx, uint64(id), departmentEdit, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to update department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "department already exists") {
+			ctx.JSON(http.StatusConflict, models.DepartmentResponse{
+				Status:  http.StatusConflict,
+				Message: "failed to update department: department already exists",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if isDepartmentRelationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to update department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to update department: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// Return updated department data
+	ctx.JSON(http.StatusOK, models.DepartmentResponse{
+		Status:  http.StatusOK,
+		Message: "updated department successfully",
+		Data:    updatedDepartment.Data,
+		Error:   false,
+	})
+}
+
+func (d *departmentHandlerImpl) DeleteDepartment(ctx *gin.Context) {
+	// get id department
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	department, err := d.svc.DeleteDepartment(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed delete department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "department is still in use") {
+			ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed delete department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed Delete Department: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if department.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.DepartmentResponse{
+			Status:  http.StatusNotFound,
+			Message: "department not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.DepartmentResponse{
+		Status:  http.StatusOK,
+		Message: "department deleted successfully",
+		Data:    &department,
+		Error:   false,
+	})
+}
+
+func (d *departmentHandlerImpl) RestoreDepartment(ctx *gin.Context) {
+	// Get department ID from URL parameter
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.DepartmentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// Restore department
+	err = d.svc.RestoreDepartment(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if strings.Contains(err.Error(), "department not found") {
+			ctx.JSON(http.StatusNotFound, models.DepartmentResponse{
+				Status:  http.StatusNotFound,
+				Message: "failed to restore department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if isDepartmentForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.DepartmentResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to restore department: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.DepartmentResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to restore department: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// Response success message
+	ctx.JSON(http.StatusOK, models.DepartmentResponse{
+		Status:  http.StatusOK,
+		Message: "department restored successfully",
+		Data:    nil,
+		Error:   false,
+	})
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type EmployeeHandler interface {
+	GetEmployees(ctx *gin.Context)
+	GetEmployeeByID(ctx *gin.Context)
+
+	CreateEmployee(ctx *gin.Context)
+	UpdateEmployee(ctx *gin.Context)
+
+	DeleteEmployee(ctx *gin.Context)
+
+	ConfirmEmployee(ctx *gin.Context)
+	SuspendEmployee(ctx *gin.Context)
+	ReactivateEmployee(ctx *gin.Context)
+	TerminateEmployee(ctx *gin.Context)
+}
+
+type employeeHandlerImpl struct {
+	svc service.EmployeeService
+}
+
+func NewEmployeeHandler(svc service.EmployeeService) EmployeeHandler {
+	return &employeeHandlerImpl{svc: svc}
+}
+
+// error from invalid company or linked user
+var employeeRelationErrors = []string{
+	"company not found",
+	"user not found",
+	"user must belong to the same company",
+}
+
+func isEmployeeRelationError(err error) bool {
+	for _, msg := range employeeRelationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// error when company of employee is outside admin access
+func isEmployeeForbidden(err error) bool {
+	return strings.Contains(err.Error(), "outside of your access")
+}
+
+func (e *employeeHandlerImpl) GetEmployees(ctx *gin.Context) {
+	filter := models.EmployeeFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter: company_id is required",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	employees, err := e.svc.GetEmployees(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isEmployeeForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.EmployeesResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to get employees: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.EmployeesResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get employees",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// employee not found
+	if len(employees) == 0 {
+		ctx.JSON(http.StatusNotFound, models.EmployeesResponse{
+			Status:  http.StatusNotFound,
+			Message: "employees not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.EmployeesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get employees",
+		Data:    &employees,
+		Error:   false,
+	})
+}
+
+func (e *employeeHandlerImpl) GetEmployeeByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	employee, err := e.svc.GetEmployeeByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isEmployeeForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.EmployeeResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to get employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.EmployeeResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get employee",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if employee.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.EmployeeResponse{
+			Status:  http.StatusNotFound,
+			Message: "employee not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.EmployeeResponse{
+		Status:  http.StatusOK,
+		Message: "success to get employee",
+		Data:    &employee,
+		Error:   false,
+	})
+}
+
+func (e *employeeHandlerImpl) CreateEmployee(ctx *gin.Context) {
+	employeeCreate := models.EmployeeCreateRequest{}
+	if err := ctx.ShouldBindJSON(&employeeCreate); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create employee: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := employeeCreate.ValidateCreate(); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, models.EmployeeResponse{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "failed to create employee: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	employee, err := e.svc.CreateEmployee(ctx, employeeCreate, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isEmployeeForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.EmployeeResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to create employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "already") {
+			ctx.JSON(http.StatusConflict, models.EmployeeResponse{
+				Status:  http.StatusConflict,
+				Message: "failed to create employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if isEmployeeRelationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to create employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.EmployeeResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to create employee: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.EmployeeResponse{
+		Status:  http.StatusCreated,
+		Message: "employee created successfully",
+		Data:    employee.Data,
+		Error:   false,
+	})
+}
+
+func (e *employeeHandlerImpl) UpdateEmployee(ctx *gin.Context) {
+	// parameter
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// check id exists
+	employee, err := e.svc.GetEmployeeByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil && isEmployeeForbidden(err) {
+		ctx.JSON(http.StatusForbidden, models.EmployeeResponse{
+			Status:  http.StatusForbidden,
+			Message: "failed to update employee: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if employee.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.EmployeeResponse{
+			Status:  http.StatusNotFound,
+			Message: "employee not found",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	// check req OK
+	var employeeEdit models.EmployeeUpdateRequest
+	if err := ctx.ShouldBindJSON(&employeeEdit); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := employeeEdit.ValidateUpdate(); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, models.EmployeeResponse{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "failed to update employee: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	updatedEmployee, err := e.svc.UpdateEmployee(ctx, uint64(id), employeeEdit, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isEmployeeForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.EmployeeResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to update employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "already") || strings.Contains(err.Error(), "terminated employee") {
+			ctx.JSON(http.StatusConflict, models.EmployeeResponse{
+				Status:  http.StatusConflict,
+				Message: "failed to update employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if isEmployeeRelationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to update employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.EmployeeResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to update employee: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.EmployeeResponse{
+		Status:  http.StatusOK,
+		Message: "updated employee successfully",
+		Data:    updatedEmployee.Data,
+		Error:   false,
+	})
+}
+
+func (e *employeeHandlerImpl) DeleteEmployee(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	employee, err := e.svc.DeleteEmployee(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isEmployeeForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.EmployeeResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed delete employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.EmployeeResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed delete employee: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if employee.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.EmployeeResponse{
+			Status:  http.StatusNotFound,
+			Message: "employee not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.EmployeeResponse{
+		Status:  http.StatusOK,
+		Message: "employee deleted successfully",
+		Data:    &employee,
+		Error:   false,
+	})
+}
+
+func (e *employeeHandlerImpl) ConfirmEmployee(ctx *gin.Context) {
+	e.lifecycle(ctx, "confirm", e.svc.ConfirmEmployee)
+}
+
+func (e *employeeHandlerImpl) SuspendEmployee(ctx *gin.Context) {
+	e.lifecycle(ctx, "suspend", e.svc.SuspendEmployee)
+}
+
+func (e *employeeHandlerImpl) ReactivateEmployee(ctx *gin.Context) {
+	e.lifecycle(ctx, "reactivate", e.svc.ReactivateEmployee)
+}
+
+func (e *employeeHandlerImpl) TerminateEmployee(ctx *gin.Context) {
+	terminate := models.EmployeeTerminateRequest{}
+	if err := ctx.ShouldBindJSON(&terminate); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to terminate employee: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if err := terminate.ValidateTerminate(); err != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, models.EmployeeResponse{
+			Status:  http.StatusUnprocessableEntity,
+			Message: "failed to terminate employee: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	e.lifecycle(ctx, "terminate", func(c context.Context, id uint64, adminID uint64) (models.Employee, error) {
+		return e.svc.TerminateEmployee(c, id, terminate, adminID)
+	})
+}
+
+// lifecycle run one status transition of employee from id parameter
+func (e *employeeHandlerImpl) lifecycle(ctx *gin.Context, action string, fn func(ctx context.Context, id uint64, adminID uint64) (models.Employee, error)) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	employee, err := fn(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		if isEmployeeForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.EmployeeResponse{
+				Status:  http.StatusForbidden,
+				Message: "failed to " + action + " employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid status transition") {
+			ctx.JSON(http.StatusConflict, models.EmployeeResponse{
+				Status:  http.StatusConflict,
+				Message: "failed to " + action + " employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "termination date") {
+			ctx.JSON(http.StatusUnprocessableEntity, models.EmployeeResponse{
+				Status:  http.StatusUnprocessableEntity,
+				Message: "failed to " + action + " employee: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, models.EmployeeResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to " + action + " employee: internal server error",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if employee.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.EmployeeResponse{
+			Status:  http.StatusNotFound,
+			Message: "employee not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.EmployeeResponse{
+		Status:  http.StatusOK,
+		Message: "success to " + action + " employee",
+		Data:    &employee,
+		Error:   false,
+	})
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/export"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ExportHandler interface {
+	ExportUsers(ctx *gin.Context)
+	ExportCompany(ctx *gin.Context)
+	ExportPositions(ctx *gin.Context)
+
+	GetExportJobByID(ctx *gin.Context)
+	DownloadExportJob(ctx *gin.Context)
+}
+
+type exportHandlerImpl struct {
+	svc service.ExportService
+}
+
+func NewExportHandler(svc service.ExportService) ExportHandler {
+	return &exportHandlerImpl{svc: svc}
+}
+
+func (e *exportHandlerImpl) ExportUsers(ctx *gin.Context) {
+	filter := models.UserFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ExportJobResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to export users: invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if !e.scopeFilter(ctx, models.EXPORT_ENTITY_USERS, &filter) {
+		return
+	}
+	e.export(ctx, models.EXPORT_ENTITY_USERS, filter)
+}
+
+func (e *exportHandlerImpl) ExportCompany(ctx *gin.Context) {
+	filter := models.CompanyFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ExportJobResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to export company: invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if !e.scopeFilter(ctx, models.EXPORT_ENTITY_COMPANY, &filter) {
+		return
+	}
+	e.export(ctx, models.EXPORT_ENTITY_COMPANY, filter)
+}
+
+func (e *exportHandlerImpl) ExportPositions(ctx *gin.Context) {
+	filter := models.PositionFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ExportJobResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to export positions: invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if !e.scopeFilter(ctx, models.EXPORT_ENTITY_POSITIONS, &filter) {
+		return
+	}
+	e.export(ctx, models.EXPORT_ENTITY_POSITIONS, filter)
+}
+
+// scopeFilter limit filter to company of admin, response is written when false
+func (e *exportHandlerImpl) scopeFilter(ctx *gin.Context, entity string, filter any) bool {
+	err := e.svc.ScopeFilter(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err == nil {
+		return true
+	}
+	status := http.StatusInternalServerError
+	message := "failed to export " + entity + ": internal server error"
+	switch {
+	case strings.Contains(err.Error(), "outside of your access"):
+		status = http.StatusForbidden
+		message = "failed to export " + entity + ": " + err.Error()
+	case strings.Contains(err.Error(), "company id cannot be empty"):
+		status = http.StatusBadRequest
+		message = "failed to export " + entity + ": company_id is required"
+	}
+	ctx.JSON(status, models.ExportJobResponse{
+		Status:  status,
+		Message: message,
+		Data:    nil,
+		Error:   true,
+	})
+	return false
+}
+
+// export stream the file directly, or create background job when
+// requested with async=true or when there is too many rows
+func (e *exportHandlerImpl) export(ctx *gin.Context, entity string, filter any) {
+	req := models.ExportRequest{}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ExportJobResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to export " + entity + ": invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	req, err := e.svc.ValidateExportRequest(entity, req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ExportJobResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to export " + entity + ": " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	async := req.Async
+	if !async {
+		async, err = e.svc.IsLargeExport(ctx, entity, filter)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, models.ExportJobResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "failed to export " + entity + ": internal server error",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+	}
+
+	if async {
+		job, err := e.svc.CreateExportJob(ctx, entity, filter, req, middleware.GetClaimUserID(ctx))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, models.ExportJobResponse{
+				Status:  http.StatusInternalServerError,
+				Message: "failed to create export job: internal server error",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		job.DownloadURL = fmt.Sprintf(models.EXPORT_DOWNLOAD_URL, job.ID)
+		ctx.JSON(http.StatusAccepted, models.ExportJobResponse{
+			Status:  http.StatusAccepted,
+			Message: "export job created, download will be available when finished",
+			Data:    &job,
+			Error:   false,
+		})
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-%s.%s", entity, time.Now().Format("20060102150405"), req.Format)
+	ctx.Header("Content-Type", export.ContentType(req.Format))
+	ctx.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	ctx.Status(http.StatusOK)
+
+	// status already sent, error can only be logged
+	if _, err := e.svc.Export(ctx, entity, filter, req, ctx.Writer); err != nil {
+		log.Println("export failed", entity, err.Error())
+	}
+}
+
+func (e *exportHandlerImpl) GetExportJobByID(ctx *gin.Context) {
+	job, ok := e.getOwnedJob(ctx)
+	if !ok {
+		return
+	}
+
+	if job.Status == models.EXPORT_STATUS_DONE {
+		job.DownloadURL = fmt.Sprintf(models.EXPORT_DOWNLOAD_URL, job.ID)
+	}
+	ctx.JSON(http.StatusOK, models.ExportJobResponse{
+		Status:  http.StatusOK,
+		Message: "success to get export job",
+		Data:    &job,
+		Error:   false,
+	})
+}
+
+func (e *exportHandlerImpl) DownloadExportJob(ctx *gin.Context) {
+	job, ok := e.getOwnedJob(ctx)
+	if !ok {
+		return
+	}
+
+	if job.Status != models.EXPORT_STATUS_DONE {
+		ctx.JSON(http.StatusConflict, models.ExportJobResponse{
+			Status:  http.StatusConflict,
+			Message: "export job is not finished",
+			Data:    &job,
+			Error:   true,
+		})
+		return
+	}
+
+	if _, err := os.Stat(job.FilePath); err != nil {
+		ctx.JSON(http.StatusGone, models.ExportJobResponse{
+			Status:  http.StatusGone,
+			Message: "export file is no longer available",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	fileName := fmt.Sprintf("%s-%d.%s", job.Entity, job.ID, job.Format)
+	ctx.Header("Content-Type", export.ContentType(job.Format))
+	ctx.FileAttachment(job.FilePath, fileName)
+}
+
+// getOwnedJob load job from id parameter, only requester can see the job
+func (e *exportHandlerImpl) getOwnedJob(ctx *gin.Context) (models.ExportJob, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.ExportJobResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return models.ExportJob{}, false
+	}
+
+	job, err := e.svc.GetExportJobByID(ctx, uint64(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.ExportJobResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get export job",
+			Data:    nil,
+			Error:   true,
+		})
+		return models.ExportJob{}, false
+	}
+
+	if job.ID == 0 || job.RequestedBy != middleware.GetClaimUserID(ctx) {
+		ctx.JSON(http.StatusNotFound, models.ExportJobResponse{
+			Status:  http.StatusNotFound,
+			Message: "export job not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return models.ExportJob{}, false
+	}
+	return job, true
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type HeadcountHandler interface {
+	GetHeadcountPlans(ctx *gin.Context)
+	SaveHeadcountPlan(ctx *gin.Context)
+	DeleteHeadcountPlan(ctx *gin.Context)
+
+	GetVacancies(ctx *gin.Context)
+}
+
+type headcountHandlerImpl struct {
+	svc service.HeadcountService
+}
+
+func NewHeadcountHandler(svc service.HeadcountService) HeadcountHandler {
+	return &headcountHandlerImpl{svc: svc}
+}
+
+// error from invalid plan or filter
+var headcountValidationErrors = []string{
+	"company id cannot be empty",
+	"invalid month",
+	"planned headcount cannot be negative",
+	"department must belong to the same company",
+	"position must belong to the same company",
+}
+
+func headcountErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range headcountValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// GetHeadcountPlans return plans of company in period with filled and open seats
+func (h *headcountHandlerImpl) GetHeadcountPlans(ctx *gin.Context) {
+	filter := models.HeadcountFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.HeadcountPlansResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	plans, err := h.svc.GetHeadcountPlans(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := headcountErrorStatus(err)
+		ctx.JSON(status, models.HeadcountPlansResponse{
+			Status:  status,
+			Message: "failed to get headcount plans: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(plans) == 0 {
+		ctx.JSON(http.StatusNotFound, models.HeadcountPlansResponse{
+			Status:  http.StatusNotFound,
+			Message: "headcount plans not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.HeadcountPlansResponse{
+		Status:  http.StatusOK,
+		Message: "success to get headcount plans",
+		Data:    &plans,
+		Error:   false,
+	})
+}
+
+func (h *headcountHandlerImpl) SaveHeadcountPlan(ctx *gin.Context) {
+	request := models.HeadcountPlanRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.HeadcountPlanResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save headcount plan: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	plan, err := h.svc.SaveHeadcountPlan(ctx, request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := headcountErrorStatus(err)
+		ctx.JSON(status, models.HeadcountPlanResponse{
+			Status:  status,
+			Message: "failed to save headcount plan: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.HeadcountPlanResponse{
+		Status:  http.StatusOK,
+		Message: "success to save headcount plan",
+		Data:    &plan,
+		Error:   false,
+	})
+}
+
+func (h *headcountHandlerImpl) DeleteHeadcountPlan(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.HeadcountPlanResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := h.svc.DeleteHeadcountPlan(ctx, uint64(id), middleware.GetClaimUserID(ctx)); err != nil {
+		status := headcountErrorStatus(err)
+		ctx.JSON(status, models.HeadcountPlanResponse{
+			Status:  status,
+			Message: "failed to delete headcount plan: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.HeadcountPlanResponse{
+		Status:  http.StatusOK,
+		Message: "success to delete headcount plan",
+		Data:    nil,
+		Error:   false,
+	})
+}
+
+// GetVacancies return plans of company in period which still have open seats
+func (h *headcountHandlerImpl) GetVacancies(ctx *gin.Context) {
+	filter := models.HeadcountFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.HeadcountPlansResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	vacancies, err := h.svc.GetVacancies(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := headcountErrorStatus(err)
+		ctx.JSON(status, models.HeadcountPlansResponse{
+			Status:  status,
+			Message: "failed to get vacancies: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(vacancies) == 0 {
+		ctx.JSON(http.StatusNotFound, models.HeadcountPlansResponse{
+			Status:  http.StatusNotFound,
+			Message: "vacancies not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.HeadcountPlansResponse{
+		Status:  http.StatusOK,
+		Message: "success to get vacancies",
+		Data:    &vacancies,
+		Error:   false,
+	})
+}
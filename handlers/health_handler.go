@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler interface {
+	Liveness(ctx *gin.Context)
+	Readiness(ctx *gin.Context)
+	Status(ctx *gin.Context)
+}
+
+type healthHandlerImpl struct {
+	svc service.HealthService
+}
+
+func NewHealthHandler(svc service.HealthService) HealthHandler {
+	return &healthHandlerImpl{svc: svc}
+}
+
+func (h *healthHandlerImpl) Liveness(ctx *gin.Context) {
+	health := h.svc.Liveness()
+	ctx.JSON(http.StatusOK, models.HealthResponse{
+		Status:  http.StatusOK,
+		Message: "alive",
+		Data:    &health,
+		Error:   false,
+	})
+}
+
+// Readiness answer 503 when one of the check fail, so probe take the instance out of rotation
+func (h *healthHandlerImpl) Readiness(ctx *gin.Context) {
+	health := h.svc.Readiness(ctx)
+	if health.Status != models.HEALTH_STATUS_OK {
+		ctx.JSON(http.StatusServiceUnavailable, models.HealthResponse{
+			Status:  http.StatusServiceUnavailable,
+			Message: "not ready",
+			Data:    &health,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.HealthResponse{
+		Status:  http.StatusOK,
+		Message: "ready",
+		Data:    &health,
+		Error:   false,
+	})
+}
+
+func (h *healthHandlerImpl) Status(ctx *gin.Context) {
+	status := h.svc.Status(ctx)
+	ctx.JSON(http.StatusOK, models.SystemStatusResponse{
+		Status:  http.StatusOK,
+		Message: "success to get status",
+		Data:    &status,
+		Error:   false,
+	})
+}
@@ -0,0 +1,416 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type LeaveHandler interface {
+	GetLeaveTypes(ctx *gin.Context)
+	CreateLeaveType(ctx *gin.Context)
+	UpdateLeaveType(ctx *gin.Context)
+	DeleteLeaveType(ctx *gin.Context)
+
+	GetLeaveBalances(ctx *gin.Context)
+
+	CreateLeaveRequest(ctx *gin.Context)
+	GetMyLeaveRequests(ctx *gin.Context)
+	GetPendingLeaveRequests(ctx *gin.Context)
+	ApproveLeaveRequest(ctx *gin.Context)
+	RejectLeaveRequest(ctx *gin.Context)
+	CancelLeaveRequest(ctx *gin.Context)
+
+	GetLeaveCalendar(ctx *gin.Context)
+}
+
+type leaveHandlerImpl struct {
+	svc service.LeaveService
+}
+
+func NewLeaveHandler(svc service.LeaveService) LeaveHandler {
+	return &leaveHandlerImpl{svc: svc}
+}
+
+// error caused by the request, not by the server
+var leaveValidationErrors = []string{
+	"accrual must be",
+	"cannot be negative",
+	"entitlement is required",
+	"already exists",
+	"date",
+	"span two years",
+	"working day",
+	"must belong to the company",
+	"overlaps with another request",
+	"insufficient leave balance",
+	"leave request is already",
+	"leave request is no longer",
+	"cannot review own",
+	"cannot be cancelled",
+}
+
+// leaveErrorStatus map error of leave service to http status
+func leaveErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") ||
+		strings.Contains(err.Error(), "only manager or company admin") ||
+		strings.Contains(err.Error(), "only requester") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range leaveValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// GetLeaveTypes return leave types of company_id, or of the company of login user
+func (l *leaveHandlerImpl) GetLeaveTypes(ctx *gin.Context) {
+	companyID, _ := strconv.Atoi(ctx.Query("company_id"))
+
+	leaveTypes, err := l.svc.GetLeaveTypes(ctx, middleware.GetClaimUserID(ctx), uint64(companyID))
+	if err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveTypesResponse{
+			Status:  status,
+			Message: "failed to get leave types: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(leaveTypes) == 0 {
+		ctx.JSON(http.StatusNotFound, models.LeaveTypesResponse{
+			Status:  http.StatusNotFound,
+			Message: "leave types not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.LeaveTypesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get leave types",
+		Data:    &leaveTypes,
+		Error:   false,
+	})
+}
+
+func (l *leaveHandlerImpl) CreateLeaveType(ctx *gin.Context) {
+	leaveType := models.LeaveType{}
+	if err := ctx.ShouldBindJSON(&leaveType); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.LeaveTypeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create leave type: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	createdLeaveType, err := l.svc.CreateLeaveType(ctx, middleware.GetClaimUserID(ctx), leaveType)
+	if err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveTypeResponse{
+			Status:  status,
+			Message: "failed to create leave type: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusCreated, models.LeaveTypeResponse{
+		Status:  http.StatusCreated,
+		Message: "success to create leave type",
+		Data:    &createdLeaveType,
+		Error:   false,
+	})
+}
+
+// UpdateLeaveType change everything except company and code
+func (l *leaveHandlerImpl) UpdateLeaveType(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.LeaveTypeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	leaveType := models.LeaveType{}
+	if err := ctx.ShouldBindJSON(&leaveType); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.LeaveTypeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to update leave type: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	leaveType.ID = uint64(id)
+
+	updatedLeaveType, err := l.svc.UpdateLeaveType(ctx, middleware.GetClaimUserID(ctx), leaveType)
+	if err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveTypeResponse{
+			Status:  status,
+			Message: "failed to update leave type: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.LeaveTypeResponse{
+		Status:  http.StatusOK,
+		Message: "success to update leave type",
+		Data:    &updatedLeaveType,
+		Error:   false,
+	})
+}
+
+func (l *leaveHandlerImpl) DeleteLeaveType(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.LeaveTypeResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := l.svc.DeleteLeaveType(ctx, middleware.GetClaimUserID(ctx), uint64(id)); err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveTypeResponse{
+			Status:  status,
+			Message: "failed to delete leave type: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.LeaveTypeResponse{
+		Status:  http.StatusOK,
+		Message: "success to delete leave type",
+		Data:    nil,
+		Error:   false,
+	})
+}
+
+// GetLeaveBalances return balance of login user, year default to this year
+func (l *leaveHandlerImpl) GetLeaveBalances(ctx *gin.Context) {
+	year := 0
+	if ctx.Query("year") != "" {
+		parsed, err := strconv.Atoi(ctx.Query("year"))
+		if err != nil || parsed < 1 {
+			ctx.JSON(http.StatusBadRequest, models.LeaveBalancesResponse{
+				Status:  http.StatusBadRequest,
+				Message: "invalid year parameter",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+		year = parsed
+	}
+
+	balances, err := l.svc.GetLeaveBalances(ctx, middleware.GetClaimUserID(ctx), year)
+	if err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveBalancesResponse{
+			Status:  status,
+			Message: "failed to get leave balances: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(balances) == 0 {
+		ctx.JSON(http.StatusNotFound, models.LeaveBalancesResponse{
+			Status:  http.StatusNotFound,
+			Message: "leave balances not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.LeaveBalancesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get leave balances",
+		Data:    &balances,
+		Error:   false,
+	})
+}
+
+func (l *leaveHandlerImpl) CreateLeaveRequest(ctx *gin.Context) {
+	leaveRequest := models.LeaveRequestCreate{}
+	if err := ctx.ShouldBindJSON(&leaveRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.LeaveRequestResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create leave request: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	createdRequest, err := l.svc.CreateLeaveRequest(ctx, middleware.GetClaimUserID(ctx), leaveRequest)
+	if err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveRequestResponse{
+			Status:  status,
+			Message: "failed to create leave request: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusCreated, models.LeaveRequestResponse{
+		Status:  http.StatusCreated,
+		Message: "success to create leave request",
+		Data:    &createdRequest,
+		Error:   false,
+	})
+}
+
+func (l *leaveHandlerImpl) GetMyLeaveRequests(ctx *gin.Context) {
+	l.listLeaveRequests(ctx, l.svc.GetMyLeaveRequests)
+}
+
+func (l *leaveHandlerImpl) GetPendingLeaveRequests(ctx *gin.Context) {
+	l.listLeaveRequests(ctx, l.svc.GetPendingLeaveRequests)
+}
+
+func (l *leaveHandlerImpl) listLeaveRequests(ctx *gin.Context, list func(ctx context.Context, userID uint64) ([]models.LeaveRequest, error)) {
+	requests, err := list(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveRequestsResponse{
+			Status:  status,
+			Message: "failed to get leave requests: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(requests) == 0 {
+		ctx.JSON(http.StatusNotFound, models.LeaveRequestsResponse{
+			Status:  http.StatusNotFound,
+			Message: "leave requests not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.LeaveRequestsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get leave requests",
+		Data:    &requests,
+		Error:   false,
+	})
+}
+
+func (l *leaveHandlerImpl) ApproveLeaveRequest(ctx *gin.Context) {
+	l.reviewLeaveRequest(ctx, "approve", l.svc.ApproveLeaveRequest)
+}
+
+func (l *leaveHandlerImpl) RejectLeaveRequest(ctx *gin.Context) {
+	l.reviewLeaveRequest(ctx, "reject", l.svc.RejectLeaveRequest)
+}
+
+func (l *leaveHandlerImpl) CancelLeaveRequest(ctx *gin.Context) {
+	l.reviewLeaveRequest(ctx, "cancel", func(ctx context.Context, userID uint64, id uint64, _ string) (models.LeaveRequest, error) {
+		return l.svc.CancelLeaveRequest(ctx, userID, id)
+	})
+}
+
+// reviewLeaveRequest handle approve, reject and cancel, the note is optional
+func (l *leaveHandlerImpl) reviewLeaveRequest(ctx *gin.Context, action string, review func(ctx context.Context, userID uint64, id uint64, note string) (models.LeaveRequest, error)) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.LeaveRequestResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	reviewRequest := models.LeaveReviewRequest{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&reviewRequest); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.LeaveRequestResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to " + action + " leave request: unable to parse request body",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+	}
+
+	request, err := review(ctx, middleware.GetClaimUserID(ctx), uint64(id), reviewRequest.Note)
+	if err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveRequestResponse{
+			Status:  status,
+			Message: "failed to " + action + " leave request: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.LeaveRequestResponse{
+		Status:  http.StatusOK,
+		Message: "success to " + action + " leave request",
+		Data:    &request,
+		Error:   false,
+	})
+}
+
+// GetLeaveCalendar return who is out in the company of login user
+func (l *leaveHandlerImpl) GetLeaveCalendar(ctx *gin.Context) {
+	filter := models.LeaveCalendarFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.LeaveCalendarResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter, from and to are required",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	entries, err := l.svc.GetLeaveCalendar(ctx, middleware.GetClaimUserID(ctx), filter)
+	if err != nil {
+		status := leaveErrorStatus(err)
+		ctx.JSON(status, models.LeaveCalendarResponse{
+			Status:  status,
+			Message: "failed to get leave calendar: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.LeaveCalendarResponse{
+		Status:  http.StatusOK,
+		Message: "success to get leave calendar",
+		Data:    &entries,
+		Error:   false,
+	})
+}
@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+// comment line keep idle stream open behind proxy
+const notificationStreamHeartbeat = 25 * time.Second
+
+type NotificationHandler interface {
+	GetNotifications(ctx *gin.Context)
+	GetUnreadCount(ctx *gin.Context)
+	MarkRead(ctx *gin.Context)
+	MarkNotificationRead(ctx *gin.Context)
+	MarkNotificationUnread(ctx *gin.Context)
+	Stream(ctx *gin.Context)
+
+	GetPreference(ctx *gin.Context)
+	SavePreference(ctx *gin.Context)
+
+	GetTemplates(ctx *gin.Context)
+	SaveTemplate(ctx *gin.Context)
+	DeleteTemplate(ctx *gin.Context)
+}
+
+type notificationHandlerImpl struct {
+	svc service.NotificationService
+}
+
+func NewNotificationHandler(svc service.NotificationService) NotificationHandler {
+	return &notificationHandlerImpl{svc: svc}
+}
+
+// error from invalid preference or template
+var notificationValidationErrors = []string{
+	"company id cannot be empty",
+	"chat address must be",
+	"channel is not configured",
+	"notification type is not supported",
+	"template locale cannot be empty",
+	"template title and body cannot be empty",
+	"invalid template",
+}
+
+func notificationErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range notificationValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// GetNotifications return inbox of logged in user, newest first
+func (n *notificationHandlerImpl) GetNotifications(ctx *gin.Context) {
+	filter := models.NotificationFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.NotificationsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	notifications, err := n.svc.GetNotifications(ctx, middleware.GetClaimUserID(ctx), filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.NotificationsResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get notifications",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(notifications) == 0 {
+		ctx.JSON(http.StatusNotFound, models.NotificationsResponse{
+			Status:  http.StatusNotFound,
+			Message: "notifications not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.NotificationsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get notifications",
+		Data:    &notifications,
+		Error:   false,
+	})
+}
+
+func (n *notificationHandlerImpl) GetUnreadCount(ctx *gin.Context) {
+	count, err := n.svc.GetUnreadCount(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.NotificationCountResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get unread notification count",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NotificationCountResponse{
+		Status:  http.StatusOK,
+		Message: "success to get unread notification count",
+		Data:    &count,
+		Error:   false,
+	})
+}
+
+// MarkRead mark the given notification read, every notification when ids is empty
+func (n *notificationHandlerImpl) MarkRead(ctx *gin.Context) {
+	request := models.NotificationReadRequest{}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, models.NotificationCountResponse{
+				Status:  http.StatusBadRequest,
+				Message: "failed to mark notifications read: unable to parse request body",
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
+	}
+
+	count, err := n.svc.MarkRead(ctx, middleware.GetClaimUserID(ctx), request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.NotificationCountResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to mark notifications read",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NotificationCountResponse{
+		Status:  http.StatusOK,
+		Message: "success to mark notifications read",
+		Data:    &count,
+		Error:   false,
+	})
+}
+
+func (n *notificationHandlerImpl) MarkNotificationRead(ctx *gin.Context) {
+	n.setRead(ctx, true)
+}
+
+func (n *notificationHandlerImpl) MarkNotificationUnread(ctx *gin.Context) {
+	n.setRead(ctx, false)
+}
+
+func (n *notificationHandlerImpl) setRead(ctx *gin.Context, read bool) {
+	action := "mark notification read"
+	if !read {
+		action = "mark notification unread"
+	}
+
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.NotificationResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	notification, err := n.svc.SetRead(ctx, middleware.GetClaimUserID(ctx), uint64(id), read)
+	if err != nil {
+		status := notificationErrorStatus(err)
+		ctx.JSON(status, models.NotificationResponse{
+			Status:  status,
+			Message: "failed to " + action + ": " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NotificationResponse{
+		Status:  http.StatusOK,
+		Message: "success to " + action,
+		Data:    &notification,
+		Error:   false,
+	})
+}
+
+// Stream send new notification of logged in user as server-sent events
+func (n *notificationHandlerImpl) Stream(ctx *gin.Context) {
+	stream, cancel := n.svc.Subscribe(middleware.GetClaimUserID(ctx))
+	defer cancel()
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(notificationStreamHeartbeat)
+	defer heartbeat.Stop()
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case notification, ok := <-stream:
+			// stream is closed on shutdown
+			if !ok {
+				return false
+			}
+			ctx.SSEvent("notification", notification)
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		}
+	})
+}
+
+func (n *notificationHandlerImpl) GetPreference(ctx *gin.Context) {
+	preference, err := n.svc.GetPreference(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.NotificationPreferenceResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get notification preference",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NotificationPreferenceResponse{
+		Status:  http.StatusOK,
+		Message: "success to get notification preference",
+		Data:    &preference,
+		Error:   false,
+	})
+}
+
+func (n *notificationHandlerImpl) SavePreference(ctx *gin.Context) {
+	request := models.NotificationPreference{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.NotificationPreferenceResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save notification preference: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	preference, err := n.svc.SavePreference(ctx, middleware.GetClaimUserID(ctx), request)
+	if err != nil {
+		status := notificationErrorStatus(err)
+		ctx.JSON(status, models.NotificationPreferenceResponse{
+			Status:  status,
+			Message: "failed to save notification preference: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NotificationPreferenceResponse{
+		Status:  http.StatusOK,
+		Message: "success to save notification preference",
+		Data:    &preference,
+		Error:   false,
+	})
+}
+
+func (n *notificationHandlerImpl) GetTemplates(ctx *gin.Context) {
+	filter := models.NotificationTemplateFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.NotificationTemplatesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	templates, err := n.svc.GetTemplates(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := notificationErrorStatus(err)
+		ctx.JSON(status, models.NotificationTemplatesResponse{
+			Status:  status,
+			Message: "failed to get notification templates: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(templates) == 0 {
+		ctx.JSON(http.StatusNotFound, models.NotificationTemplatesResponse{
+			Status:  http.StatusNotFound,
+			Message: "notification templates not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.NotificationTemplatesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get notification templates",
+		Data:    &templates,
+		Error:   false,
+	})
+}
+
+// SaveTemplate create or replace template of company for type and locale
+func (n *notificationHandlerImpl) SaveTemplate(ctx *gin.Context) {
+	request := models.NotificationTemplate{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.NotificationTemplateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save notification template: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	template, err := n.svc.SaveTemplate(ctx, request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := notificationErrorStatus(err)
+		ctx.JSON(status, models.NotificationTemplateResponse{
+			Status:  status,
+			Message: "failed to save notification template: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NotificationTemplateResponse{
+		Status:  http.StatusOK,
+		Message: "success to save notification template",
+		Data:    &template,
+		Error:   false,
+	})
+}
+
+func (n *notificationHandlerImpl) DeleteTemplate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.NotificationTemplateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := n.svc.DeleteTemplate(ctx, uint64(id), middleware.GetClaimUserID(ctx)); err != nil {
+		status := notificationErrorStatus(err)
+		ctx.JSON(status, models.NotificationTemplateResponse{
+			Status:  status,
+			Message: "failed to delete notification template: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NotificationTemplateResponse{
+		Status:  http.StatusOK,
+		Message: "success to delete notification template",
+		Data:    nil,
+		Error:   false,
+	})
+}
@@ -0,0 +1,583 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type PayrollHandler interface {
+	GetSalaryComponents(ctx *gin.Context)
+	CreateSalaryComponent(ctx *gin.Context)
+	UpdateSalaryComponent(ctx *gin.Context)
+	DeleteSalaryComponent(ctx *gin.Context)
+
+	GetEmployeeSalary(ctx *gin.Context)
+	SaveEmployeeSalary(ctx *gin.Context)
+
+	RunPayroll(ctx *gin.Context)
+	GetPayrollRuns(ctx *gin.Context)
+	GetPayrollRunByID(ctx *gin.Context)
+	ApprovePayrollRun(ctx *gin.Context)
+
+	GetMyPayslips(ctx *gin.Context)
+	DownloadPayslip(ctx *gin.Context)
+
+	GetCompaRatioReport(ctx *gin.Context)
+
+	GetTaxReconciliation(ctx *gin.Context)
+	GetForm1721A1(ctx *gin.Context)
+}
+
+type payrollHandlerImpl struct {
+	svc service.PayrollService
+}
+
+func NewPayrollHandler(svc service.PayrollService) PayrollHandler {
+	return &payrollHandlerImpl{svc: svc}
+}
+
+// error caused by the request, not by the server
+var payrollValidationErrors = []string{
+	"component type must be",
+	"already exists",
+	"cannot be negative",
+	"cannot be set twice",
+	"must belong to the company",
+	"invalid month",
+	"already approved",
+	"has no payslip",
+	"statutory",
+	"ptkp",
+	"currency must be",
+	"outside of salary band",
+}
+
+// payrollErrorStatus map error of payroll service to http status
+func payrollErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range payrollValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func (p *payrollHandlerImpl) GetSalaryComponents(ctx *gin.Context) {
+	components, err := p.svc.GetSalaryComponents(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.SalaryComponentsResponse{
+			Status:  status,
+			Message: "failed to get salary components: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(components) == 0 {
+		ctx.JSON(http.StatusNotFound, models.SalaryComponentsResponse{
+			Status:  http.StatusNotFound,
+			Message: "salary components not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.SalaryComponentsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get salary components",
+		Data:    &components,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) CreateSalaryComponent(ctx *gin.Context) {
+	component := models.SalaryComponent{}
+	if err := ctx.ShouldBindJSON(&component); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SalaryComponentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create salary component: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	createdComponent, err := p.svc.CreateSalaryComponent(ctx, middleware.GetClaimUserID(ctx), component)
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.SalaryComponentResponse{
+			Status:  status,
+			Message: "failed to create salary component: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusCreated, models.SalaryComponentResponse{
+		Status:  http.StatusCreated,
+		Message: "success to create salary component",
+		Data:    &createdComponent,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) UpdateSalaryComponent(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SalaryComponentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	component := models.SalaryComponent{}
+	if err := ctx.ShouldBindJSON(&component); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SalaryComponentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to update salary component: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	component.ID = uint64(id)
+
+	updatedComponent, err := p.svc.UpdateSalaryComponent(ctx, middleware.GetClaimUserID(ctx), component)
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.SalaryComponentResponse{
+			Status:  status,
+			Message: "failed to update salary component: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.SalaryComponentResponse{
+		Status:  http.StatusOK,
+		Message: "success to update salary component",
+		Data:    &updatedComponent,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) DeleteSalaryComponent(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SalaryComponentResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := p.svc.DeleteSalaryComponent(ctx, middleware.GetClaimUserID(ctx), uint64(id)); err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.SalaryComponentResponse{
+			Status:  status,
+			Message: "failed to delete salary component: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.SalaryComponentResponse{
+		Status:  http.StatusOK,
+		Message: "success to delete salary component",
+		Data:    nil,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) GetEmployeeSalary(ctx *gin.Context) {
+	employeeID, err := strconv.Atoi(ctx.Param("employee_id"))
+	if employeeID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeSalaryResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing employee ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	salary, err := p.svc.GetEmployeeSalary(ctx, middleware.GetClaimUserID(ctx), uint64(employeeID))
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.EmployeeSalaryResponse{
+			Status:  status,
+			Message: "failed to get salary: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.EmployeeSalaryResponse{
+		Status:  http.StatusOK,
+		Message: "success to get salary",
+		Data:    &salary,
+		Error:   false,
+	})
+}
+
+// SaveEmployeeSalary replace base salary and all components of employee
+func (p *payrollHandlerImpl) SaveEmployeeSalary(ctx *gin.Context) {
+	employeeID, err := strconv.Atoi(ctx.Param("employee_id"))
+	if employeeID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeSalaryResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing employee ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	salaryRequest := models.EmployeeSalaryRequest{}
+	if err := ctx.ShouldBindJSON(&salaryRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.EmployeeSalaryResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save salary: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	salary, err := p.svc.SaveEmployeeSalary(ctx, middleware.GetClaimUserID(ctx), uint64(employeeID), salaryRequest)
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.EmployeeSalaryResponse{
+			Status:  status,
+			Message: "failed to save salary: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.EmployeeSalaryResponse{
+		Status:  http.StatusOK,
+		Message: "success to save salary",
+		Data:    &salary,
+		Error:   false,
+	})
+}
+
+// RunPayroll create the run of the period, or run the draft one again
+func (p *payrollHandlerImpl) RunPayroll(ctx *gin.Context) {
+	runRequest := models.PayrollRunRequest{}
+	if err := ctx.ShouldBindJSON(&runRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PayrollRunResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to run payroll: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	run, err := p.svc.RunPayroll(ctx, middleware.GetClaimUserID(ctx), runRequest)
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.PayrollRunResponse{
+			Status:  status,
+			Message: "failed to run payroll: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.PayrollRunResponse{
+		Status:  http.StatusOK,
+		Message: "success to run payroll",
+		Data:    &run,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) GetPayrollRuns(ctx *gin.Context) {
+	runs, err := p.svc.GetPayrollRuns(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.PayrollRunsResponse{
+			Status:  status,
+			Message: "failed to get payroll runs: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(runs) == 0 {
+		ctx.JSON(http.StatusNotFound, models.PayrollRunsResponse{
+			Status:  http.StatusNotFound,
+			Message: "payroll runs not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.PayrollRunsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get payroll runs",
+		Data:    &runs,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) GetPayrollRunByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PayrollRunResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	run, err := p.svc.GetPayrollRunByID(ctx, middleware.GetClaimUserID(ctx), uint64(id))
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.PayrollRunResponse{
+			Status:  status,
+			Message: "failed to get payroll run: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.PayrollRunResponse{
+		Status:  http.StatusOK,
+		Message: "success to get payroll run",
+		Data:    &run,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) ApprovePayrollRun(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PayrollRunResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	run, err := p.svc.ApprovePayrollRun(ctx, middleware.GetClaimUserID(ctx), uint64(id))
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.PayrollRunResponse{
+			Status:  status,
+			Message: "failed to approve payroll run: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.PayrollRunResponse{
+		Status:  http.StatusOK,
+		Message: "success to approve payroll run",
+		Data:    &run,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) GetMyPayslips(ctx *gin.Context) {
+	payslips, err := p.svc.GetMyPayslips(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.PayslipsResponse{
+			Status:  status,
+			Message: "failed to get payslips: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(payslips) == 0 {
+		ctx.JSON(http.StatusNotFound, models.PayslipsResponse{
+			Status:  http.StatusNotFound,
+			Message: "payslips not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.PayslipsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get payslips",
+		Data:    &payslips,
+		Error:   false,
+	})
+}
+
+// DownloadPayslip render the payslip to memory first, so error can still be sent as json
+func (p *payrollHandlerImpl) DownloadPayslip(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PayslipsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	buf := bytes.Buffer{}
+	if err := p.svc.WritePayslipPDF(ctx, middleware.GetClaimUserID(ctx), uint64(id), &buf); err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.PayslipsResponse{
+			Status:  status,
+			Message: "failed to download payslip: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="payslip-`+strconv.Itoa(id)+`.pdf"`)
+	ctx.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func (p *payrollHandlerImpl) GetCompaRatioReport(ctx *gin.Context) {
+	reports, err := p.svc.GetCompaRatioReport(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.CompaRatioReportResponse{
+			Status:  status,
+			Message: "failed to get compa-ratio report: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(reports) == 0 {
+		ctx.JSON(http.StatusNotFound, models.CompaRatioReportResponse{
+			Status:  http.StatusNotFound,
+			Message: "compa-ratio report not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.CompaRatioReportResponse{
+		Status:  http.StatusOK,
+		Message: "success to get compa-ratio report",
+		Data:    &reports,
+		Error:   false,
+	})
+}
+
+// taxYear read year query parameter, default to the current year
+func taxYear(ctx *gin.Context) (int, bool) {
+	year, err := strconv.Atoi(ctx.DefaultQuery("year", strconv.Itoa(time.Now().Year())))
+	if err != nil || year < 2000 {
+		return 0, false
+	}
+	return year, true
+}
+
+func (p *payrollHandlerImpl) GetTaxReconciliation(ctx *gin.Context) {
+	year, ok := taxYear(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, models.TaxReconciliationsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid year parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	reconciliations, err := p.svc.GetTaxReconciliation(ctx, middleware.GetClaimUserID(ctx), year)
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.TaxReconciliationsResponse{
+			Status:  status,
+			Message: "failed to get tax reconciliation: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(reconciliations) == 0 {
+		ctx.JSON(http.StatusNotFound, models.TaxReconciliationsResponse{
+			Status:  http.StatusNotFound,
+			Message: "tax reconciliation not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.TaxReconciliationsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get tax reconciliation",
+		Data:    &reconciliations,
+		Error:   false,
+	})
+}
+
+func (p *payrollHandlerImpl) GetForm1721A1(ctx *gin.Context) {
+	employeeID, err := strconv.Atoi(ctx.Param("employee_id"))
+	if employeeID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.Form1721A1Response{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing employee ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	year, ok := taxYear(ctx)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, models.Form1721A1Response{
+			Status:  http.StatusBadRequest,
+			Message: "invalid year parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	form, err := p.svc.GetForm1721A1(ctx, middleware.GetClaimUserID(ctx), uint64(employeeID), year)
+	if err != nil {
+		status := payrollErrorStatus(err)
+		ctx.JSON(status, models.Form1721A1Response{
+			Status:  status,
+			Message: "failed to get form 1721-A1: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.Form1721A1Response{
+		Status:  http.StatusOK,
+		Message: "success to get form 1721-A1",
+		Data:    &form,
+		Error:   false,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/geedotrar/erp-api/middleware"
 	"github.com/geedotrar/erp-api/models"
 	"github.com/geedotrar/erp-api/service"
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,14 @@ type PositionHandler interface {
 	UpdatePosition(ctx *gin.Context)
 
 	DeletePosition(ctx *gin.Context)
+
+	GetSalaryBands(ctx *gin.Context)
+	SaveSalaryBand(ctx *gin.Context)
+	DeleteSalaryBand(ctx *gin.Context)
+
+	AdoptPositionTemplate(ctx *gin.Context)
+	GetPositionCodeRule(ctx *gin.Context)
+	SavePositionCodeRule(ctx *gin.Context)
 }
 
 type positionHandlerImpl struct {
@@ -30,7 +39,18 @@ func NewPositionHandler(svc service.PositionService) PositionHandler {
 }
 
 func (p *positionHandlerImpl) GetPosition(ctx *gin.Context) {
-	position, err := p.svc.GetPosition(ctx)
+	filter := models.PositionFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PositionsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	position, err := p.svc.GetPosition(ctx, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.PositionsResponse{
 			Status:  http.StatusInternalServerError,
@@ -124,7 +144,7 @@ func (p *positionHandlerImpl) CreatePosition(ctx *gin.Context) {
 		return
 	}
 
-	softDeleted, err := p.svc.IsPositionSoftDeleted(ctx, positionCreate.PositionName, positionCreate.PositionCode)
+	softDeleted, err := p.svc.IsPositionSoftDeleted(ctx, positionCreate.CompanyID, positionCreate.PositionName, positionCreate.PositionCode)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.PositionResponse{
 			Status:  http.StatusInternalServerError,
@@ -155,6 +175,15 @@ func (p *positionHandlerImpl) CreatePosition(ctx *gin.Context) {
 			})
 			return
 		}
+		if status := positionErrorStatus(err); status != http.StatusInternalServerError {
+			ctx.JSON(status, models.PositionResponse{
+				Status:  status,
+				Message: "failed to create position: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, models.PositionResponse{
 			Status:  http.StatusInternalServerError,
 			Message: "failed to create position: internal server error",
@@ -222,7 +251,7 @@ func (p *positionHandlerImpl) UpdatePosition(ctx *gin.Context) {
 	}
 
 	// check is data exists in soft deleted
-	softDeleted, err := p.svc.IsPositionSoftDeleted(ctx, positionEdit.PositionName, positionEdit.PositionCode)
+	softDeleted, err := p.svc.IsPositionSoftDeleted(ctx, position.CompanyID, positionEdit.PositionName, positionEdit.PositionCode)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.PositionResponse{
 			Status:  http.StatusInternalServerError,
@@ -254,6 +283,15 @@ func (p *positionHandlerImpl) UpdatePosition(ctx *gin.Context) {
 			})
 			return
 		}
+		if status := positionErrorStatus(err); status != http.StatusInternalServerError {
+			ctx.JSON(status, models.PositionResponse{
+				Status:  status,
+				Message: "failed to update position: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, models.PositionResponse{
 			Status:  http.StatusInternalServerError,
 			Message: "failed to update position: internal server error",
@@ -322,3 +360,267 @@ func (p *positionHandlerImpl) DeletePosition(ctx *gin.Context) {
 		Error:   false,
 	})
 }
+
+// positionErrorStatus map error of position catalog to http status
+func positionErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "already exists") {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range []string{"position code must", "only template position", "code pattern"} {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// salaryBandErrorStatus map error of salary band to http status
+func salaryBandErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range []string{"currency must be", "cannot be negative", "salary band must", "band enforcement must"} {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func (p *positionHandlerImpl) GetSalaryBands(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SalaryBandsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	bands, err := p.svc.GetSalaryBands(ctx, uint64(id))
+	if err != nil {
+		status := salaryBandErrorStatus(err)
+		ctx.JSON(status, models.SalaryBandsResponse{
+			Status:  status,
+			Message: "failed to get salary bands: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if len(bands) == 0 {
+		ctx.JSON(http.StatusNotFound, models.SalaryBandsResponse{
+			Status:  http.StatusNotFound,
+			Message: "salary bands not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.SalaryBandsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get salary bands",
+		Data:    &bands,
+		Error:   false,
+	})
+}
+
+// SaveSalaryBand create or replace band of the position in the currency of request
+func (p *positionHandlerImpl) SaveSalaryBand(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SalaryBandResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	bandRequest := models.SalaryBandRequest{}
+	if err := ctx.ShouldBindJSON(&bandRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SalaryBandResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save salary band: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	band, err := p.svc.SaveSalaryBand(ctx, uint64(id), bandRequest, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := salaryBandErrorStatus(err)
+		ctx.JSON(status, models.SalaryBandResponse{
+			Status:  status,
+			Message: "failed to save salary band: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.SalaryBandResponse{
+		Status:  http.StatusOK,
+		Message: "success to save salary band",
+		Data:    &band,
+		Error:   false,
+	})
+}
+
+func (p *positionHandlerImpl) DeleteSalaryBand(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.SalaryBandResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := p.svc.DeleteSalaryBand(ctx, uint64(id), ctx.Param("currency"), middleware.GetClaimUserID(ctx)); err != nil {
+		status := salaryBandErrorStatus(err)
+		ctx.JSON(status, models.SalaryBandResponse{
+			Status:  status,
+			Message: "failed to delete salary band: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.SalaryBandResponse{
+		Status:  http.StatusOK,
+		Message: "salary band deleted successfully",
+		Data:    nil,
+		Error:   false,
+	})
+}
+
+// AdoptPositionTemplate copy template position with its salary bands into a company
+func (p *positionHandlerImpl) AdoptPositionTemplate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PositionResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	adoptRequest := models.PositionAdoptRequest{}
+	if err := ctx.ShouldBindJSON(&adoptRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PositionResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to adopt position: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	position, err := p.svc.AdoptPositionTemplate(ctx, uint64(id), adoptRequest, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := positionErrorStatus(err)
+		ctx.JSON(status, models.PositionResponse{
+			Status:  status,
+			Message: "failed to adopt position: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusCreated, models.PositionResponse{
+		Status:  http.StatusCreated,
+		Message: "position adopted successfully",
+		Data:    &position,
+		Error:   false,
+	})
+}
+
+func (p *positionHandlerImpl) GetPositionCodeRule(ctx *gin.Context) {
+	companyID, err := strconv.Atoi(ctx.Param("company_id"))
+	if companyID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PositionCodeRuleResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing company ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	rule, err := p.svc.GetPositionCodeRule(ctx, uint64(companyID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.PositionCodeRuleResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get position code rule",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.PositionCodeRuleResponse{
+		Status:  http.StatusOK,
+		Message: "success to get position code rule",
+		Data:    &rule,
+		Error:   false,
+	})
+}
+
+func (p *positionHandlerImpl) SavePositionCodeRule(ctx *gin.Context) {
+	companyID, err := strconv.Atoi(ctx.Param("company_id"))
+	if companyID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PositionCodeRuleResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing company ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	rule := models.PositionCodeRule{}
+	if err := ctx.ShouldBindJSON(&rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.PositionCodeRuleResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save position code rule: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	rule.CompanyID = uint64(companyID)
+
+	savedRule, err := p.svc.SavePositionCodeRule(ctx, rule, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := positionErrorStatus(err)
+		ctx.JSON(status, models.PositionCodeRuleResponse{
+			Status:  status,
+			Message: "failed to save position code rule: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.PositionCodeRuleResponse{
+		Status:  http.StatusOK,
+		Message: "success to save position code rule",
+		Data:    &savedRule,
+		Error:   false,
+	})
+}
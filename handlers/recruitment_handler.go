@@ -0,0 +1,795 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type RecruitmentHandler interface {
+	GetStages(ctx *gin.Context)
+	SaveStages(ctx *gin.Context)
+
+	GetRequisitions(ctx *gin.Context)
+	GetRequisitionByID(ctx *gin.Context)
+	CreateRequisition(ctx *gin.Context)
+	UpdateRequisition(ctx *gin.Context)
+	GetRequisitionCandidates(ctx *gin.Context)
+
+	GetCandidateByID(ctx *gin.Context)
+	CreateCandidate(ctx *gin.Context)
+	MoveCandidate(ctx *gin.Context)
+	RejectCandidate(ctx *gin.Context)
+	AcceptCandidate(ctx *gin.Context)
+	UploadCandidateCV(ctx *gin.Context)
+	DownloadCandidateCV(ctx *gin.Context)
+
+	GetCandidateInterviews(ctx *gin.Context)
+	ScheduleInterview(ctx *gin.Context)
+	GetMyInterviews(ctx *gin.Context)
+	SaveInterviewFeedback(ctx *gin.Context)
+}
+
+type recruitmentHandlerImpl struct {
+	svc service.RecruitmentService
+}
+
+func NewRecruitmentHandler(svc service.RecruitmentService) RecruitmentHandler {
+	return &recruitmentHandlerImpl{svc: svc}
+}
+
+// error from invalid recruitment request, including error from CreateUser and CreateEmployee
+var recruitmentValidationErrors = []string{
+	"company id cannot be empty",
+	"openings must be",
+	"openings cannot be less than",
+	"employment type must be",
+	"requisition status must be",
+	"requisition is not open",
+	"pipeline must have at least one stage",
+	"stage name",
+	"stage is still used by candidate",
+	"invalid email format",
+	"candidate already applied",
+	"candidate is already",
+	"candidate must reach the final stage",
+	"cv must be",
+	"interview duration must be",
+	"interview score must be",
+	"interview is already cancelled",
+	"must belong to the same company",
+	"role must be",
+	"email already exists",
+	"employee number already exists",
+	"ptkp status must be",
+	"hire date cannot be empty",
+	"date of birth must be",
+	"reporting cycle",
+	"hiring beyond headcount plan is not allowed",
+}
+
+func recruitmentErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range recruitmentValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func (r *recruitmentHandlerImpl) GetStages(ctx *gin.Context) {
+	companyID, err := strconv.Atoi(ctx.Query("company_id"))
+	if companyID == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.RecruitmentStagesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing company_id parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	stages, err := r.svc.GetStages(ctx, uint64(companyID), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.RecruitmentStagesResponse{
+			Status:  status,
+			Message: "failed to get recruitment stages: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.RecruitmentStagesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get recruitment stages",
+		Data:    &stages,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) SaveStages(ctx *gin.Context) {
+	request := models.RecruitmentStagesRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.RecruitmentStagesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save recruitment stages: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	stages, err := r.svc.SaveStages(ctx, request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.RecruitmentStagesResponse{
+			Status:  status,
+			Message: "failed to save recruitment stages: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.RecruitmentStagesResponse{
+		Status:  http.StatusOK,
+		Message: "success to save recruitment stages",
+		Data:    &stages,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) GetRequisitions(ctx *gin.Context) {
+	filter := models.RequisitionFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.RequisitionsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	requisitions, err := r.svc.GetRequisitions(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.RequisitionsResponse{
+			Status:  status,
+			Message: "failed to get requisitions: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(requisitions) == 0 {
+		ctx.JSON(http.StatusNotFound, models.RequisitionsResponse{
+			Status:  http.StatusNotFound,
+			Message: "requisitions not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.RequisitionsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get requisitions",
+		Data:    &requisitions,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) GetRequisitionByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.RequisitionResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	requisition, err := r.svc.GetRequisitionByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.RequisitionResponse{
+			Status:  status,
+			Message: "failed to get requisition: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.RequisitionResponse{
+		Status:  http.StatusOK,
+		Message: "success to get requisition",
+		Data:    &requisition,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) CreateRequisition(ctx *gin.Context) {
+	request := models.RequisitionRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.RequisitionResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create requisition: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	requisition, err := r.svc.CreateRequisition(ctx, request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.RequisitionResponse{
+			Status:  status,
+			Message: "failed to create requisition: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.RequisitionResponse{
+		Status:  http.StatusCreated,
+		Message: "requisition created successfully",
+		Data:    &requisition,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) UpdateRequisition(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.RequisitionResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	request := models.RequisitionUpdateRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.RequisitionResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to update requisition: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	requisition, err := r.svc.UpdateRequisition(ctx, uint64(id), request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.RequisitionResponse{
+			Status:  status,
+			Message: "failed to update requisition: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.RequisitionResponse{
+		Status:  http.StatusOK,
+		Message: "success to update requisition",
+		Data:    &requisition,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) GetRequisitionCandidates(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidatesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	candidates, err := r.svc.GetCandidates(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.CandidatesResponse{
+			Status:  status,
+			Message: "failed to get candidates: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(candidates) == 0 {
+		ctx.JSON(http.StatusNotFound, models.CandidatesResponse{
+			Status:  http.StatusNotFound,
+			Message: "candidates not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.CandidatesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get candidates",
+		Data:    &candidates,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) GetCandidateByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	candidate, err := r.svc.GetCandidateByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.CandidateResponse{
+			Status:  status,
+			Message: "failed to get candidate: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CandidateResponse{
+		Status:  http.StatusOK,
+		Message: "success to get candidate",
+		Data:    &candidate,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) CreateCandidate(ctx *gin.Context) {
+	request := models.CandidateRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create candidate: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	candidate, err := r.svc.CreateCandidate(ctx, request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.CandidateResponse{
+			Status:  status,
+			Message: "failed to create candidate: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.CandidateResponse{
+		Status:  http.StatusCreated,
+		Message: "candidate created successfully",
+		Data:    &candidate,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) MoveCandidate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	request := models.CandidateStageRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to move candidate: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	candidate, err := r.svc.MoveCandidate(ctx, uint64(id), request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.CandidateResponse{
+			Status:  status,
+			Message: "failed to move candidate: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CandidateResponse{
+		Status:  http.StatusOK,
+		Message: "success to move candidate",
+		Data:    &candidate,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) RejectCandidate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	request := models.CandidateRejectRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to reject candidate: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	candidate, err := r.svc.RejectCandidate(ctx, uint64(id), request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.CandidateResponse{
+			Status:  status,
+			Message: "failed to reject candidate: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CandidateResponse{
+		Status:  http.StatusOK,
+		Message: "success to reject candidate",
+		Data:    &candidate,
+		Error:   false,
+	})
+}
+
+// AcceptCandidate hire candidate as user and employee of the requisition company
+func (r *recruitmentHandlerImpl) AcceptCandidate(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	request := models.CandidateAcceptRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to accept candidate: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	candidate, err := r.svc.AcceptCandidate(ctx, uint64(id), request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.CandidateResponse{
+			Status:  status,
+			Message: "failed to accept candidate: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CandidateResponse{
+		Status:  http.StatusOK,
+		Message: "candidate hired successfully",
+		Data:    &candidate,
+		Error:   false,
+	})
+}
+
+// UploadCandidateCV accept multipart form with the file in "cv" field
+func (r *recruitmentHandlerImpl) UploadCandidateCV(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, models.CANDIDATE_CV_MAX_SIZE+1<<20)
+	fileHeader, err := ctx.FormFile("cv")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to upload cv: cv file is required and must not exceed 5MB",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if fileHeader.Size > models.CANDIDATE_CV_MAX_SIZE {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to upload cv: cv file must not exceed 5MB",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.CandidateResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to upload cv: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	defer file.Close()
+
+	candidate, err := r.svc.UploadCandidateCV(ctx, uint64(id), fileHeader.Filename, file, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.CandidateResponse{
+			Status:  status,
+			Message: "failed to upload cv: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.CandidateResponse{
+		Status:  http.StatusOK,
+		Message: "success to upload cv",
+		Data:    &candidate,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) DownloadCandidateCV(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.CandidateResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	cvPath, fileName, err := r.svc.GetCandidateCV(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.CandidateResponse{
+			Status:  status,
+			Message: "failed to download cv: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if _, err := os.Stat(cvPath); err != nil {
+		ctx.JSON(http.StatusGone, models.CandidateResponse{
+			Status:  http.StatusGone,
+			Message: "cv file is no longer available",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	ctx.FileAttachment(cvPath, fileName)
+}
+
+func (r *recruitmentHandlerImpl) GetCandidateInterviews(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.InterviewsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	interviews, err := r.svc.GetInterviews(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.InterviewsResponse{
+			Status:  status,
+			Message: "failed to get interviews: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(interviews) == 0 {
+		ctx.JSON(http.StatusNotFound, models.InterviewsResponse{
+			Status:  http.StatusNotFound,
+			Message: "interviews not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.InterviewsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get interviews",
+		Data:    &interviews,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) ScheduleInterview(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.InterviewResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	request := models.InterviewRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.InterviewResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to schedule interview: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	interview, err := r.svc.ScheduleInterview(ctx, uint64(id), request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.InterviewResponse{
+			Status:  status,
+			Message: "failed to schedule interview: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.InterviewResponse{
+		Status:  http.StatusCreated,
+		Message: "interview scheduled successfully",
+		Data:    &interview,
+		Error:   false,
+	})
+}
+
+// GetMyInterviews return interview assigned to the logged in user
+func (r *recruitmentHandlerImpl) GetMyInterviews(ctx *gin.Context) {
+	interviews, err := r.svc.GetMyInterviews(ctx, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.InterviewsResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "failed to get interviews",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(interviews) == 0 {
+		ctx.JSON(http.StatusNotFound, models.InterviewsResponse{
+			Status:  http.StatusNotFound,
+			Message: "interviews not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.InterviewsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get interviews",
+		Data:    &interviews,
+		Error:   false,
+	})
+}
+
+func (r *recruitmentHandlerImpl) SaveInterviewFeedback(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.InterviewResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	request := models.InterviewFeedbackRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.InterviewResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to save interview feedback: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	interview, err := r.svc.SaveInterviewFeedback(ctx, uint64(id), request, middleware.GetClaimUserID(ctx), middleware.IsClaimAdmin(ctx))
+	if err != nil {
+		status := recruitmentErrorStatus(err)
+		ctx.JSON(status, models.InterviewResponse{
+			Status:  status,
+			Message: "failed to save interview feedback: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.InterviewResponse{
+		Status:  http.StatusOK,
+		Message: "success to save interview feedback",
+		Data:    &interview,
+		Error:   false,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/geedotrar/erp-api/middleware"
 	"github.com/geedotrar/erp-api/models"
 	"github.com/geedotrar/erp-api/pkg/response"
 	"github.com/geedotrar/erp-api/service"
@@ -20,6 +21,8 @@ type UserHandler interface {
 
 	DeleteUser(ctx *gin.Context)
 
+	GetReports(ctx *gin.Context)
+
 	UserSignUp(ctx *gin.Context)
 	UserLogin(ctx *gin.Context)
 }
@@ -32,8 +35,47 @@ func NewUserHandler(svc service.UserService) UserHandler {
 	return &userHandlerImpl{svc: svc}
 }
 
+// error from invalid department, manager or seat of user
+var userRelationErrors = []string{
+	"department not found",
+	"department must belong to the same company",
+	"manager not found",
+	"manager must belong to the same company",
+	"manager assignment would create a reporting cycle",
+	"company cannot be changed directly",
+	"position cannot be changed directly",
+	"hiring beyond headcount plan is not allowed",
+	"role must be user or admin",
+	"company id cannot be empty",
+}
+
+// error when company of user is outside admin access or caller cannot grant role
+func isUserForbidden(err error) bool {
+	return strings.Contains(err.Error(), "outside of your access") || strings.Contains(err.Error(), "role can only be changed by admin")
+}
+
+func isUserRelationError(err error) bool {
+	for _, msg := range userRelationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
 func (u *userHandlerImpl) GetUsers(ctx *gin.Context) {
-	users, err := u.svc.GetUsers(ctx)
+	filter := models.UserFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.UsersResponse{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	users, err := u.svc.GetUsers(ctx, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, models.UsersResponse{
 			Status:  http.StatusInternalServerError,
@@ -128,9 +170,18 @@ func (u *userHandlerImpl) CreateUser(ctx *gin.Context) {
 		}
 	}
 
-	user, err := u.svc.CreateUser(ctx, userCreate)
+	user, err := u.svc.CreateUser(ctx, userCreate, middleware.GetClaimUserID(ctx))
 	// check email already exist
 	if err != nil {
+		if isUserForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.UserResponse{
+				Status:  http.StatusForbidden,
+				Message: "Failed to create user: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		if strings.Contains(err.Error(), "email already exists") {
 			ctx.JSON(http.StatusConflict, models.UserResponse{
 				Status:  http.StatusConflict,
@@ -140,6 +191,15 @@ func (u *userHandlerImpl) CreateUser(ctx *gin.Context) {
 			})
 			return
 		}
+		if isUserRelationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.UserResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Failed to create user: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, models.UserResponse{
 			Status:  http.StatusInternalServerError,
 			Message: "Failed to create user: internal server error or email has been soft deleted",
@@ -208,8 +268,17 @@ func (u *userHandlerImpl) UpdateUser(ctx *gin.Context) {
 	}
 
 	// Call service to edit user
-	updatedUser, err := u.svc.UpdateUser(ctx, uint64(id), userEdit)
+	updatedUser, err := u.svc.UpdateUser(ctx, uint64(id), userEdit, middleware.GetClaimUserID(ctx))
 	if err != nil {
+		if isUserForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.UserResponse{
+				Status:  http.StatusForbidden,
+				Message: "Failed to update user: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		if strings.Contains(err.Error(), "email already exists") {
 			ctx.JSON(http.StatusConflict, models.UserResponse{
 				Status:  http.StatusConflict,
@@ -219,6 +288,15 @@ func (u *userHandlerImpl) UpdateUser(ctx *gin.Context) {
 			})
 			return
 		}
+		if isUserRelationError(err) {
+			ctx.JSON(http.StatusBadRequest, models.UserResponse{
+				Status:  http.StatusBadRequest,
+				Message: "Failed to update user: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, models.UserResponse{
 			Status:  http.StatusInternalServerError,
 			Message: "Status internal server error",
@@ -250,8 +328,17 @@ func (u *userHandlerImpl) DeleteUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := u.svc.DeleteUser(ctx, uint64(id))
+	user, offboarding, err := u.svc.DeleteUser(ctx, uint64(id), middleware.GetClaimUserID(ctx))
 	if err != nil {
+		if isUserForbidden(err) {
+			ctx.JSON(http.StatusForbidden, models.UserResponse{
+				Status:  http.StatusForbidden,
+				Message: "Failed Delete User: " + err.Error(),
+				Data:    nil,
+				Error:   true,
+			})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, models.UserResponse{
 			Status:  http.StatusInternalServerError,
 			Message: "Failed Delete User: internal server error",
@@ -269,6 +356,16 @@ func (u *userHandlerImpl) DeleteUser(ctx *gin.Context) {
 		})
 		return
 	}
+	// account stays active until every offboarding task is completed
+	if len(offboarding) > 0 {
+		ctx.JSON(http.StatusAccepted, models.ChecklistsResponse{
+			Status:  http.StatusAccepted,
+			Message: "Offboarding started, user is disabled after the checklist is completed",
+			Data:    &offboarding,
+			Error:   false,
+		})
+		return
+	}
 	ctx.JSON(http.StatusOK, models.UserResponse{
 		Status:  http.StatusOK,
 		Message: "User Deleted successfully",
@@ -277,6 +374,59 @@ func (u *userHandlerImpl) DeleteUser(ctx *gin.Context) {
 	})
 }
 
+func (u *userHandlerImpl) GetReports(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.UserReportsResponse{
+			Status:  http.StatusBadRequest,
+			Message: "Invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	user, err := u.svc.GetUserByID(ctx, uint64(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.UserReportsResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to get reports",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+	if user.ID == 0 {
+		ctx.JSON(http.StatusNotFound, models.UserReportsResponse{
+			Status:  http.StatusNotFound,
+			Message: "User not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+
+	// ?direct=true only return user reporting directly to this user
+	directOnly := ctx.Query("direct") == "true"
+	reports, err := u.svc.GetReports(ctx, uint64(id), directOnly)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, models.UserReportsResponse{
+			Status:  http.StatusInternalServerError,
+			Message: "Failed to get reports",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.UserReportsResponse{
+		Status:  http.StatusOK,
+		Message: "Success to get reports",
+		Data:    &reports,
+		Error:   false,
+	})
+}
+
 func (u *userHandlerImpl) UserSignUp(ctx *gin.Context) {
 	userSignUp := models.UserSignUp{}
 	if err := ctx.Bind(&userSignUp); err != nil {
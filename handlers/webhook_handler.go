@@ -0,0 +1,400 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler interface {
+	GetEvents(ctx *gin.Context)
+
+	GetWebhooks(ctx *gin.Context)
+	GetWebhookByID(ctx *gin.Context)
+	CreateWebhook(ctx *gin.Context)
+	UpdateWebhook(ctx *gin.Context)
+	DeleteWebhook(ctx *gin.Context)
+	RotateWebhookSecret(ctx *gin.Context)
+
+	GetDeliveries(ctx *gin.Context)
+	GetDeliveryByID(ctx *gin.Context)
+	Redeliver(ctx *gin.Context)
+}
+
+type webhookHandlerImpl struct {
+	svc service.WebhookService
+}
+
+func NewWebhookHandler(svc service.WebhookService) WebhookHandler {
+	return &webhookHandlerImpl{svc: svc}
+}
+
+// error from invalid webhook request
+var webhookValidationErrors = []string{
+	"company id cannot be empty",
+	"webhook url must be",
+	"webhook must subscribe",
+	"webhook event is not supported",
+	"company of webhook cannot be changed",
+	"webhook delivery is still pending",
+}
+
+func webhookErrorStatus(err error) int {
+	if strings.Contains(err.Error(), "outside of your access") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	for _, msg := range webhookValidationErrors {
+		if strings.Contains(err.Error(), msg) {
+			return http.StatusBadRequest
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// GetEvents return event that can be subscribed
+func (w *webhookHandlerImpl) GetEvents(ctx *gin.Context) {
+	events := append([]string{models.WEBHOOK_EVENT_ALL}, models.WebhookEvents...)
+	ctx.JSON(http.StatusOK, models.WebhookEventsResponse{
+		Status:  http.StatusOK,
+		Message: "success to get webhook events",
+		Data:    &events,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) GetWebhooks(ctx *gin.Context) {
+	filter := models.WebhookFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhooksResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	webhooks, err := w.svc.GetWebhooks(ctx, filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhooksResponse{
+			Status:  status,
+			Message: "failed to get webhooks: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(webhooks) == 0 {
+		ctx.JSON(http.StatusNotFound, models.WebhooksResponse{
+			Status:  http.StatusNotFound,
+			Message: "webhooks not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.WebhooksResponse{
+		Status:  http.StatusOK,
+		Message: "success to get webhooks",
+		Data:    &webhooks,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) GetWebhookByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	webhook, err := w.svc.GetWebhookByID(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhookResponse{
+			Status:  status,
+			Message: "failed to get webhook: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.WebhookResponse{
+		Status:  http.StatusOK,
+		Message: "success to get webhook",
+		Data:    &webhook,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) CreateWebhook(ctx *gin.Context) {
+	request := models.WebhookRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to create webhook: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	webhook, err := w.svc.CreateWebhook(ctx, request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhookResponse{
+			Status:  status,
+			Message: "failed to create webhook: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, models.WebhookResponse{
+		Status:  http.StatusCreated,
+		Message: "webhook created successfully, store the secret because it is not shown again",
+		Data:    &webhook,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) UpdateWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	request := models.WebhookRequest{}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookResponse{
+			Status:  http.StatusBadRequest,
+			Message: "failed to update webhook: unable to parse request body",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	webhook, err := w.svc.UpdateWebhook(ctx, uint64(id), request, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhookResponse{
+			Status:  status,
+			Message: "failed to update webhook: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.WebhookResponse{
+		Status:  http.StatusOK,
+		Message: "success to update webhook",
+		Data:    &webhook,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) DeleteWebhook(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if err := w.svc.DeleteWebhook(ctx, uint64(id), middleware.GetClaimUserID(ctx)); err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhookResponse{
+			Status:  status,
+			Message: "failed to delete webhook: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.WebhookResponse{
+		Status:  http.StatusOK,
+		Message: "success to delete webhook",
+		Data:    nil,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) RotateWebhookSecret(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	webhook, err := w.svc.RotateWebhookSecret(ctx, uint64(id), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhookResponse{
+			Status:  status,
+			Message: "failed to rotate webhook secret: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.WebhookResponse{
+		Status:  http.StatusOK,
+		Message: "success to rotate webhook secret, store the secret because it is not shown again",
+		Data:    &webhook,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) GetDeliveries(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if id == 0 || err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookDeliveriesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	filter := models.WebhookDeliveryFilter{}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookDeliveriesResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid query parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	deliveries, err := w.svc.GetDeliveries(ctx, uint64(id), filter, middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhookDeliveriesResponse{
+			Status:  status,
+			Message: "failed to get webhook deliveries: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	if len(deliveries) == 0 {
+		ctx.JSON(http.StatusNotFound, models.WebhookDeliveriesResponse{
+			Status:  http.StatusNotFound,
+			Message: "webhook deliveries not found",
+			Data:    nil,
+			Error:   false,
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, models.WebhookDeliveriesResponse{
+		Status:  http.StatusOK,
+		Message: "success to get webhook deliveries",
+		Data:    &deliveries,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) GetDeliveryByID(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	deliveryID, deliveryErr := strconv.Atoi(ctx.Param("delivery_id"))
+	if id == 0 || err != nil || deliveryID == 0 || deliveryErr != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookDeliveryResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	delivery, err := w.svc.GetDeliveryByID(ctx, uint64(id), uint64(deliveryID), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhookDeliveryResponse{
+			Status:  status,
+			Message: "failed to get webhook delivery: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.WebhookDeliveryResponse{
+		Status:  http.StatusOK,
+		Message: "success to get webhook delivery",
+		Data:    &delivery,
+		Error:   false,
+	})
+}
+
+func (w *webhookHandlerImpl) Redeliver(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	deliveryID, deliveryErr := strconv.Atoi(ctx.Param("delivery_id"))
+	if id == 0 || err != nil || deliveryID == 0 || deliveryErr != nil {
+		ctx.JSON(http.StatusBadRequest, models.WebhookDeliveryResponse{
+			Status:  http.StatusBadRequest,
+			Message: "invalid or missing ID parameter",
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	delivery, err := w.svc.Redeliver(ctx, uint64(id), uint64(deliveryID), middleware.GetClaimUserID(ctx))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		ctx.JSON(status, models.WebhookDeliveryResponse{
+			Status:  status,
+			Message: "failed to redeliver webhook: " + err.Error(),
+			Data:    nil,
+			Error:   true,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, models.WebhookDeliveryResponse{
+		Status:  http.StatusAccepted,
+		Message: "webhook delivery queued",
+		Data:    &delivery,
+		Error:   false,
+	})
+}
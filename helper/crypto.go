@@ -1,6 +1,10 @@
 package helper
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"log"
 
@@ -8,11 +12,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-const (
-	SECRET_JWT = "mysecretjwtdontsharethistoanyoneelse"
-)
-
-func GenerateToken(claim any) (token string, err error) {
+func GenerateToken(secret string, claim any) (token string, err error) {
 	jwtClaim := jwt.MapClaims{}
 	b, err := json.Marshal(claim)
 	if err != nil {
@@ -27,7 +27,7 @@ func GenerateToken(claim any) (token string, err error) {
 	// prepare
 	parseToken := jwt.NewWithClaims(jwt.SigningMethodHS512, jwtClaim)
 	// generate token
-	token, err = parseToken.SignedString([]byte(SECRET_JWT))
+	token, err = parseToken.SignedString([]byte(secret))
 	if err != nil {
 		log.Println("cannot generate token", err.Error())
 		return
@@ -35,13 +35,13 @@ func GenerateToken(claim any) (token string, err error) {
 	return
 }
 
-func ValidateToken(token string) (claim jwt.MapClaims, err error) {
+func ValidateToken(secret string, token string) (claim jwt.MapClaims, err error) {
 	jwtToken, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
 		}
 
-		return []byte(SECRET_JWT), nil
+		return []byte(secret), nil
 	})
 	if err != nil {
 		log.Println("error validating jwt token", err.Error())
@@ -65,3 +65,20 @@ func GenerateHash(in string) (out string, err error) {
 	}
 	return string(outByte), err
 }
+
+// GenerateRandomToken return hex of n random bytes, used for secret and identifier
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		log.Println("error generate random token", err.Error())
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SignHMAC return hex of HMAC-SHA256 of message
+func SignHMAC(secret string, message []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
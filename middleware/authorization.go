@@ -1,24 +1,32 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"encoding/base64"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/geedotrar/erp-api/config"
 	"github.com/geedotrar/erp-api/helper"
+	"github.com/geedotrar/erp-api/models"
 	"github.com/geedotrar/erp-api/pkg/response"
 	"github.com/gin-gonic/gin"
 )
 
 const (
-	STATIC_USERNAME = "golang006awesome"
-	STATIC_PASSWORD = "mysecretpassword"
-
 	CLAIM_USER_ID  = "claim_user_id"
 	CLAIM_USERNAME = "claim_username"
+	CLAIM_ROLE     = "claim_role"
 )
 
+// authConfig is set once at startup by SetAuthConfig, before any route is served
+var authConfig config.AuthConfig
+
+func SetAuthConfig(cfg config.AuthConfig) {
+	authConfig = cfg
+}
+
 func CheckAuthBasic(ctx *gin.Context) {
 	auth := ctx.GetHeader("Authorization")
 
@@ -48,7 +56,9 @@ func CheckAuthBasic(ctx *gin.Context) {
 		return
 	}
 
-	if string(basic) != fmt.Sprintf("%v:%v", STATIC_USERNAME, STATIC_PASSWORD) {
+	// without configured credential every request is rejected
+	expected := fmt.Sprintf("%v:%v", authConfig.BasicUsername, authConfig.BasicPassword.Value())
+	if authConfig.BasicUsername == "" || subtle.ConstantTimeCompare(basic, []byte(expected)) != 1 {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.ErrorResponse{
 			Message: "unauthorized",
 			Errors:  []string{"invalid username or password"},
@@ -78,7 +88,7 @@ func CheckAuthBearer(ctx *gin.Context) {
 	}
 
 	token := authArr[1]
-	claims, err := helper.ValidateToken(token)
+	claims, err := helper.ValidateToken(authConfig.JWTSecret.Value(), token)
 	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, response.ErrorResponse{
 			Message: "unauthorized",
@@ -88,5 +98,36 @@ func CheckAuthBearer(ctx *gin.Context) {
 	}
 	ctx.Set(CLAIM_USER_ID, claims["user_id"])
 	ctx.Set(CLAIM_USERNAME, claims["username"])
+	ctx.Set(CLAIM_ROLE, claims["role"])
+	ctx.Next()
+}
+
+// CheckRoleAdmin must be used after CheckAuthBearer
+func CheckRoleAdmin(ctx *gin.Context) {
+	if !IsClaimAdmin(ctx) {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, response.ErrorResponse{
+			Message: "forbidden",
+			Errors:  []string{"admin role is required"},
+		})
+		return
+	}
 	ctx.Next()
 }
+
+// GetClaimUserID read user id set by CheckAuthBearer, 0 when not logged in
+func GetClaimUserID(ctx *gin.Context) uint64 {
+	// jwt claim number is decoded as float64
+	userID, ok := ctx.Get(CLAIM_USER_ID)
+	if !ok {
+		return 0
+	}
+	id, ok := userID.(float64)
+	if !ok {
+		return 0
+	}
+	return uint64(id)
+}
+
+func IsClaimAdmin(ctx *gin.Context) bool {
+	return ctx.GetString(CLAIM_ROLE) == models.USER_ROLE_ADMIN
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated mark response of deprecated route with Deprecation (RFC 9745) and Sunset (RFC 8594)
+// header, so client can find out before the route is removed
+func Deprecated(deprecatedAt, sunsetAt time.Time) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	sunset := sunsetAt.UTC().Format(http.TimeFormat)
+	return func(ctx *gin.Context) {
+		ctx.Header("Deprecation", deprecation)
+		ctx.Header("Sunset", sunset)
+		ctx.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/geedotrar/erp-api/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// request path that match no route share one label, so scanning does not create series per path
+const unmatchedRoute = "unmatched"
+
+type metricsKey struct{}
+
+// Metrics record count and latency of request by route template, it must be the first
+// middleware so panic recovered by gin.Recovery is counted as 500.
+// legacy alias handle the request again through the engine, only the outer pass is
+// recorded and it is labeled with the legacy route
+func Metrics(ctx *gin.Context) {
+	if ctx.Request.Context().Value(metricsKey{}) != nil {
+		ctx.Next()
+		return
+	}
+	ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), metricsKey{}, true))
+
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	method := ctx.Request.Method
+	start := time.Now()
+	metrics.HTTPInFlight.Inc()
+	defer metrics.HTTPInFlight.Dec()
+
+	ctx.Next()
+
+	metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+	metrics.HTTPDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+}
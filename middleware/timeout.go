@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoWriteTimeout lift the server write timeout for long lived response like event stream
+// and large export, other route keep the deadline from server config
+func NoWriteTimeout(ctx *gin.Context) {
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		log.Println("cannot lift write deadline", ctx.FullPath(), err.Error())
+	}
+	ctx.Next()
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ASSIGNMENT_TYPE_HIRE      = "hire"
+	ASSIGNMENT_TYPE_TRANSFER  = "transfer"
+	ASSIGNMENT_TYPE_PROMOTION = "promotion"
+)
+
+type AssignmentsResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *[]Assignment `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+type AssignmentResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    *Assignment `json:"data"`
+	Error   bool        `json:"error"`
+}
+
+// Assignment is position, company and department of user for a period.
+// valid_to is exclusive and empty for the current assignment
+type Assignment struct {
+	ID           uint64    `json:"id" gorm:"primaryKey"`
+	UserID       uint64    `json:"user_id"`
+	CompanyID    uint64    `json:"company_id"`
+	DepartmentID *uint64   `json:"department_id"`
+	PositionID   uint64    `json:"position_id"`
+	ValidFrom    Date      `json:"valid_from"`
+	ValidTo      *Date     `json:"valid_to"`
+	ChangeType   string    `json:"change_type"`
+	Reason       string    `json:"reason"`
+	Applied      bool      `json:"applied"`
+	OverPlan     bool      `json:"over_plan"`
+	CreatedBy    *uint64   `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type AssignmentTransferRequest struct {
+	CompanyID     uint64  `json:"company_id" binding:"required"`
+	DepartmentID  *uint64 `json:"department_id"`
+	PositionID    uint64  `json:"position_id"`
+	EffectiveDate Date    `json:"effective_date"`
+	Reason        string  `json:"reason"`
+}
+
+type AssignmentPromotionRequest struct {
+	PositionID    uint64  `json:"position_id" binding:"required"`
+	DepartmentID  *uint64 `json:"department_id"`
+	EffectiveDate Date    `json:"effective_date"`
+	Reason        string  `json:"reason"`
+}
+
+// filter for assignments, as_of is "YYYY-MM-DD" and default to today
+type AssignmentFilter struct {
+	AsOf         string `form:"as_of"`
+	CompanyID    uint64 `form:"company_id"`
+	DepartmentID uint64 `form:"department_id"`
+	PositionID   uint64 `form:"position_id"`
+}
+
+// ParseAsOf return as_of date of filter, today when empty
+func ParseAsOf(asOf string) (Date, error) {
+	if asOf == "" {
+		return Today(), nil
+	}
+	date, err := ParseDate(asOf)
+	if err != nil {
+		return Date{}, errors.New("invalid as_of date, use format YYYY-MM-DD")
+	}
+	return date, nil
+}
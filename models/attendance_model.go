@@ -0,0 +1,224 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ATTENDANCE_SOURCE_WEB    = "web"
+	ATTENDANCE_SOURCE_MOBILE = "mobile"
+	ATTENDANCE_SOURCE_KIOSK  = "kiosk"
+
+	CORRECTION_STATUS_PENDING  = "pending"
+	CORRECTION_STATUS_APPROVED = "approved"
+	CORRECTION_STATUS_REJECTED = "rejected"
+
+	// format of work start and work end
+	WORK_TIME_FORMAT = "15:04"
+)
+
+type AttendancesResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *[]Attendance `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+type AttendanceResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    *Attendance `json:"data"`
+	Error   bool        `json:"error"`
+}
+
+// Attendance is clock in and clock out of user for one work date
+type Attendance struct {
+	ID             uint64     `json:"id" gorm:"primaryKey"`
+	UserID         uint64     `json:"user_id"`
+	CompanyID      uint64     `json:"company_id"`
+	WorkDate       Date       `json:"work_date"`
+	ClockInAt      time.Time  `json:"clock_in_at"`
+	ClockOutAt     *time.Time `json:"clock_out_at"`
+	ClockInSource  string     `json:"clock_in_source"`
+	ClockOutSource string     `json:"clock_out_source"`
+	ClockInLat     *float64   `json:"clock_in_lat"`
+	ClockInLng     *float64   `json:"clock_in_lng"`
+	ClockOutLat    *float64   `json:"clock_out_lat"`
+	ClockOutLng    *float64   `json:"clock_out_lng"`
+	IsLate         bool       `json:"is_late"`
+	IsEarlyLeave   bool       `json:"is_early_leave"`
+	WorkedMinutes  int        `json:"worked_minutes"`
+	WorkedHours    float64    `json:"worked_hours" gorm:"-"`
+	CorrectedByID  *uint64    `json:"corrected_by_id" gorm:"column:corrected_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type ClockRequest struct {
+	Source    string   `json:"source"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+type AttendanceSettingResponse struct {
+	Status  int                `json:"status"`
+	Message string             `json:"message"`
+	Data    *AttendanceSetting `json:"data"`
+	Error   bool               `json:"error"`
+}
+
+// AttendanceSetting is work hour and geofence rule of company.
+// work end before work start means overnight shift
+type AttendanceSetting struct {
+	CompanyID              uint64               `json:"company_id" gorm:"primaryKey"`
+	WorkStart              string               `json:"work_start"`
+	WorkEnd                string               `json:"work_end"`
+	LateGraceMinutes       int                  `json:"late_grace_minutes"`
+	EarlyLeaveGraceMinutes int                  `json:"early_leave_grace_minutes"`
+	GeofenceRequired       bool                 `json:"geofence_required"`
+	Geofences              []AttendanceGeofence `json:"geofences" gorm:"-"`
+	Timezone               string               `json:"timezone" gorm:"-"`
+	UpdatedAt              time.Time            `json:"updated_at"`
+}
+
+type AttendanceGeofenceResponse struct {
+	Status  int                 `json:"status"`
+	Message string              `json:"message"`
+	Data    *AttendanceGeofence `json:"data"`
+	Error   bool                `json:"error"`
+}
+
+type AttendanceGeofence struct {
+	ID           uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID    uint64    `json:"company_id"`
+	Name         string    `json:"name" binding:"required"`
+	Latitude     float64   `json:"latitude" binding:"required"`
+	Longitude    float64   `json:"longitude" binding:"required"`
+	RadiusMeters float64   `json:"radius_meters" binding:"required"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type AttendanceCorrectionsResponse struct {
+	Status  int                     `json:"status"`
+	Message string                  `json:"message"`
+	Data    *[]AttendanceCorrection `json:"data"`
+	Error   bool                    `json:"error"`
+}
+
+type AttendanceCorrectionResponse struct {
+	Status  int                   `json:"status"`
+	Message string                `json:"message"`
+	Data    *AttendanceCorrection `json:"data"`
+	Error   bool                  `json:"error"`
+}
+
+// AttendanceCorrection is request from user to fix clock in or clock out,
+// attendance is only changed after approved by admin
+type AttendanceCorrection struct {
+	ID         uint64     `json:"id" gorm:"primaryKey"`
+	UserID     uint64     `json:"user_id"`
+	CompanyID  uint64     `json:"company_id"`
+	WorkDate   Date       `json:"work_date"`
+	ClockInAt  time.Time  `json:"clock_in_at"`
+	ClockOutAt *time.Time `json:"clock_out_at"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	ReviewedBy *uint64    `json:"reviewed_by"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+	ReviewNote string     `json:"review_note"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type AttendanceCorrectionRequest struct {
+	WorkDate   Date       `json:"work_date"`
+	ClockInAt  time.Time  `json:"clock_in_at" binding:"required"`
+	ClockOutAt *time.Time `json:"clock_out_at"`
+	Reason     string     `json:"reason" binding:"required"`
+}
+
+type AttendanceReviewRequest struct {
+	Note string `json:"note"`
+}
+
+type AttendanceSummariesResponse struct {
+	Status  int                  `json:"status"`
+	Message string               `json:"message"`
+	Data    *[]AttendanceSummary `json:"data"`
+	Error   bool                 `json:"error"`
+}
+
+// monthly attendance of one user
+type AttendanceSummary struct {
+	UserID          uint64  `json:"user_id"`
+	FirstName       string  `json:"first_name"`
+	LastName        string  `json:"last_name"`
+	DaysPresent     int     `json:"days_present"`
+	LateCount       int     `json:"late_count"`
+	EarlyLeaveCount int     `json:"early_leave_count"`
+	WorkedMinutes   int     `json:"worked_minutes"`
+	WorkedHours     float64 `json:"worked_hours"`
+}
+
+// filter for attendance list and summary, month is "YYYY-MM"
+type AttendanceFilter struct {
+	CompanyID uint64 `form:"company_id"`
+	Month     string `form:"month"`
+	Status    string `form:"status"`
+}
+
+// ParseMonth return first day of month and first day of next month
+func ParseMonth(month string) (time.Time, time.Time, error) {
+	if month == "" {
+		now := time.Now()
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), nil
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid month, use format YYYY-MM")
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+func (c ClockRequest) ValidateClock() error {
+	if c.Source != "" && c.Source != ATTENDANCE_SOURCE_WEB && c.Source != ATTENDANCE_SOURCE_MOBILE && c.Source != ATTENDANCE_SOURCE_KIOSK {
+		return errors.New("source must be web, mobile or kiosk")
+	}
+	if (c.Latitude == nil) != (c.Longitude == nil) {
+		return errors.New("latitude and longitude must be sent together")
+	}
+	if c.Latitude != nil && (*c.Latitude < -90 || *c.Latitude > 90 || *c.Longitude < -180 || *c.Longitude > 180) {
+		return errors.New("invalid latitude or longitude")
+	}
+	return nil
+}
+
+func (s AttendanceSetting) ValidateSetting() error {
+	start, err := time.Parse(WORK_TIME_FORMAT, s.WorkStart)
+	if err != nil {
+		return errors.New("work start must use format HH:MM")
+	}
+	end, err := time.Parse(WORK_TIME_FORMAT, s.WorkEnd)
+	if err != nil {
+		return errors.New("work end must use format HH:MM")
+	}
+	if start.Equal(end) {
+		return errors.New("work start and work end cannot be the same")
+	}
+	if s.LateGraceMinutes < 0 || s.EarlyLeaveGraceMinutes < 0 {
+		return errors.New("grace minutes cannot be negative")
+	}
+	return nil
+}
+
+func (c AttendanceCorrectionRequest) ValidateCorrection() error {
+	if c.WorkDate.IsZero() {
+		return errors.New("work date cannot be empty")
+	}
+	if c.ClockOutAt != nil && !c.ClockOutAt.After(c.ClockInAt) {
+		return errors.New("clock out must be after clock in")
+	}
+	return nil
+}
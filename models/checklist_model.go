@@ -0,0 +1,181 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// checklist is started when user is created, transferred or deleted
+	CHECKLIST_TYPE_ONBOARDING  = "onboarding"
+	CHECKLIST_TYPE_TRANSFER    = "transfer"
+	CHECKLIST_TYPE_OFFBOARDING = "offboarding"
+
+	CHECKLIST_STATUS_OPEN      = "open"
+	CHECKLIST_STATUS_COMPLETED = "completed"
+
+	// task is due at most this many days after the checklist is started
+	CHECKLIST_MAX_DUE_DAYS = 365
+)
+
+type ChecklistTemplatesResponse struct {
+	Status  int                  `json:"status"`
+	Message string               `json:"message"`
+	Data    *[]ChecklistTemplate `json:"data"`
+	Error   bool                 `json:"error"`
+}
+
+type ChecklistTemplateResponse struct {
+	Status  int                `json:"status"`
+	Message string             `json:"message"`
+	Data    *ChecklistTemplate `json:"data"`
+	Error   bool               `json:"error"`
+}
+
+type ChecklistsResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    *[]Checklist `json:"data"`
+	Error   bool         `json:"error"`
+}
+
+type ChecklistResponse struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Data    *Checklist `json:"data"`
+	Error   bool       `json:"error"`
+}
+
+type ChecklistTasksResponse struct {
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
+	Data    *[]ChecklistTask `json:"data"`
+	Error   bool             `json:"error"`
+}
+
+type ChecklistTaskResponse struct {
+	Status  int            `json:"status"`
+	Message string         `json:"message"`
+	Data    *ChecklistTask `json:"data"`
+	Error   bool           `json:"error"`
+}
+
+// ChecklistTemplate is list of task started for user of company,
+// template without position apply to every position
+type ChecklistTemplate struct {
+	ID         uint64                  `json:"id" gorm:"primaryKey"`
+	CompanyID  uint64                  `json:"company_id"`
+	PositionID *uint64                 `json:"position_id"`
+	Type       string                  `json:"type"`
+	Name       string                  `json:"name"`
+	Tasks      []ChecklistTemplateTask `json:"tasks" gorm:"-"`
+	CreatedAt  time.Time               `json:"created_at"`
+	UpdatedAt  time.Time               `json:"updated_at"`
+}
+
+// ChecklistTemplateTask is due DueDays after the checklist is started, team is the
+// owner of the task like IT, finance or facilities
+type ChecklistTemplateTask struct {
+	ID          uint64  `json:"id" gorm:"primaryKey"`
+	TemplateID  uint64  `json:"template_id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Team        string  `json:"team"`
+	AssigneeID  *uint64 `json:"assignee_id"`
+	DueDays     int     `json:"due_days"`
+	SortOrder   int     `json:"sort_order"`
+}
+
+type ChecklistTemplateRequest struct {
+	CompanyID  uint64                         `json:"company_id" binding:"required"`
+	PositionID *uint64                        `json:"position_id"`
+	Type       string                         `json:"type" binding:"required"`
+	Name       string                         `json:"name" binding:"required"`
+	Tasks      []ChecklistTemplateTaskRequest `json:"tasks" binding:"required"`
+}
+
+type ChecklistTemplateTaskRequest struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	Team        string  `json:"team"`
+	AssigneeID  *uint64 `json:"assignee_id"`
+	DueDays     int     `json:"due_days"`
+}
+
+type ChecklistTemplateFilter struct {
+	CompanyID uint64 `form:"company_id" binding:"required"`
+	Type      string `form:"type"`
+}
+
+// Checklist is template started for one user, completed when every task is done.
+// user with open offboarding checklist is disabled after the last task is completed
+type Checklist struct {
+	ID          uint64          `json:"id" gorm:"primaryKey"`
+	UserID      uint64          `json:"user_id"`
+	CompanyID   uint64          `json:"company_id"`
+	TemplateID  *uint64         `json:"template_id"`
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Status      string          `json:"status"`
+	StartDate   Date            `json:"start_date"`
+	CompletedAt *time.Time      `json:"completed_at"`
+	Tasks       []ChecklistTask `json:"tasks" gorm:"-"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type ChecklistTask struct {
+	ID          uint64     `json:"id" gorm:"primaryKey"`
+	ChecklistID uint64     `json:"checklist_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Team        string     `json:"team"`
+	AssigneeID  *uint64    `json:"assignee_id"`
+	DueDate     Date       `json:"due_date"`
+	SortOrder   int        `json:"sort_order"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+	CompletedBy *uint64    `json:"completed_by"`
+	Note        string     `json:"note"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type ChecklistTaskCompleteRequest struct {
+	Note string `json:"note"`
+}
+
+func IsValidChecklistType(checklistType string) bool {
+	return checklistType == CHECKLIST_TYPE_ONBOARDING ||
+		checklistType == CHECKLIST_TYPE_TRANSFER ||
+		checklistType == CHECKLIST_TYPE_OFFBOARDING
+}
+
+func (c ChecklistTemplateRequest) ValidateTemplate() error {
+	if !IsValidChecklistType(c.Type) {
+		return errors.New("checklist type must be onboarding, transfer or offboarding")
+	}
+	if len(c.Tasks) == 0 {
+		return errors.New("checklist template must have at least one task")
+	}
+	for _, task := range c.Tasks {
+		if strings.TrimSpace(task.Title) == "" {
+			return errors.New("task title cannot be empty")
+		}
+		if task.DueDays < 0 || task.DueDays > CHECKLIST_MAX_DUE_DAYS {
+			return errors.New("task due days must be between 0 and 365")
+		}
+	}
+	return nil
+}
+
+// IsDone report whether every task of checklist is completed
+func (c Checklist) IsDone() bool {
+	for _, task := range c.Tasks {
+		if !task.Completed {
+			return false
+		}
+	}
+	return true
+}
@@ -1,11 +1,37 @@
 package models
 
 import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+const (
+	DEFAULT_COMPANY_CURRENCY = "IDR"
+	DEFAULT_COMPANY_TIMEZONE = "Asia/Jakarta"
+	DEFAULT_COMPANY_LOCALE   = "id-ID"
+
+	COMPANY_ADDRESS_REGISTERED = "registered"
+	COMPANY_ADDRESS_BILLING    = "billing"
+	COMPANY_ADDRESS_SHIPPING   = "shipping"
+	COMPANY_ADDRESS_OFFICE     = "office"
+)
+
+var (
+	companyAddressTypes = map[string]bool{
+		COMPANY_ADDRESS_REGISTERED: true,
+		COMPANY_ADDRESS_BILLING:    true,
+		COMPANY_ADDRESS_SHIPPING:   true,
+		COMPANY_ADDRESS_OFFICE:     true,
+	}
+	localePattern  = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z]{2})?$`)
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
 type CompaniesResponse struct {
 	Status  int        `json:"status"`
 	Message string     `json:"message"`
@@ -20,15 +46,278 @@ type CompanyResponse struct {
 	Error   bool     `json:"error"`
 }
 
+// Company is legal entity that employ users, tax number is NPWP.
+// currency and timezone are used by payroll and attendance of the company
 type Company struct {
-	ID          uint64         `json:"id" gorm:"primaryKey"`
-	CompanyName string         `json:"company_name"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+	ID                   uint64           `json:"id" gorm:"primaryKey"`
+	CompanyName          string           `json:"company_name"`
+	ParentID             *uint64          `json:"parent_id"`
+	LegalName            string           `json:"legal_name"`
+	TaxNumber            string           `json:"tax_number"`
+	RegistrationNumber   string           `json:"registration_number"`
+	Currency             string           `json:"currency"`
+	Timezone             string           `json:"timezone"`
+	Locale               string           `json:"locale"`
+	FiscalYearStartMonth int              `json:"fiscal_year_start_month"`
+	HeadcountPolicy      string           `json:"headcount_policy"`
+	Addresses            []CompanyAddress `json:"addresses,omitempty" gorm:"-"`
+	Contacts             []CompanyContact `json:"contacts,omitempty" gorm:"-"`
+	CreatedAt            time.Time        `json:"created_at"`
+	UpdatedAt            time.Time        `json:"updated_at"`
+	DeletedAt            gorm.DeletedAt   `json:"-" gorm:"column:deleted_at"`
+}
+
+// Public clear tax number and contacts, company is readable without login
+func (c Company) Public() Company {
+	c.TaxNumber = ""
+	c.Contacts = nil
+	return c
 }
+
+// CompanyRequest is used to create and update company, empty field is not changed on update.
+// addresses and contacts replace the existing ones when they are sent
 type CompanyRequest struct {
-	ID          uint64    `json:"id" gorm:"primaryKey"`
-	CompanyName string    `json:"company_name" validate:"required"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                   uint64            `json:"id" gorm:"primaryKey"`
+	CompanyName          string            `json:"company_name" validate:"required"`
+	LegalName            string            `json:"legal_name"`
+	TaxNumber            string            `json:"tax_number"`
+	RegistrationNumber   string            `json:"registration_number"`
+	Currency             string            `json:"currency"`
+	Timezone             string            `json:"timezone"`
+	Locale               string            `json:"locale"`
+	FiscalYearStartMonth int               `json:"fiscal_year_start_month"`
+	HeadcountPolicy      string            `json:"headcount_policy"`
+	Addresses            *[]CompanyAddress `json:"addresses,omitempty" gorm:"-"`
+	Contacts             *[]CompanyContact `json:"contacts,omitempty" gorm:"-"`
+	UpdatedAt            time.Time         `json:"updated_at"`
+}
+
+type CompanyAddress struct {
+	ID         uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID  uint64    `json:"company_id"`
+	Type       string    `json:"type"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2"`
+	City       string    `json:"city"`
+	Province   string    `json:"province"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CompanyContact struct {
+	ID        uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID uint64    `json:"company_id"`
+	Name      string    `json:"name"`
+	Title     string    `json:"title"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	IsPrimary bool      `json:"is_primary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Location is timezone of company
+func (c Company) Location() *time.Location {
+	return LoadLocation(c.Timezone)
+}
+
+// LoadLocation load IANA time zone, server local time when timezone is not set or unknown
+func LoadLocation(timezone string) *time.Location {
+	if timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// Today is the current date in timezone of company
+func (c Company) Today() Date {
+	return TodayIn(c.Location())
+}
+
+// NormalizeTaxNumber remove dot and dash from NPWP, 15 digit old format and 16 digit new format are kept as is
+func NormalizeTaxNumber(taxNumber string) string {
+	return strings.NewReplacer(".", "", "-", "", " ", "").Replace(taxNumber)
+}
+
+// ValidateCompanyProfile check every field that is set, so it works for create and partial update
+func (c CompanyRequest) ValidateCompanyProfile() error {
+	if c.TaxNumber != "" {
+		taxNumber := NormalizeTaxNumber(c.TaxNumber)
+		if (len(taxNumber) != 15 && len(taxNumber) != 16) || strings.Trim(taxNumber, "0123456789") != "" {
+			return errors.New("tax number must be NPWP of 15 or 16 digits")
+		}
+	}
+	if c.Currency != "" && !IsValidCurrency(NormalizeCurrency(c.Currency)) {
+		return errors.New("currency must be a three letter ISO 4217 code")
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil || c.Timezone == "Local" {
+			return errors.New("timezone must be an IANA time zone like Asia/Jakarta")
+		}
+	}
+	if c.Locale != "" && !localePattern.MatchString(c.Locale) {
+		return errors.New("locale must be a language tag like id-ID")
+	}
+	if c.FiscalYearStartMonth < 0 || c.FiscalYearStartMonth > 12 {
+		return errors.New("fiscal year start month must be between 1 and 12")
+	}
+	if c.HeadcountPolicy != "" && c.HeadcountPolicy != HEADCOUNT_POLICY_FLAG && c.HeadcountPolicy != HEADCOUNT_POLICY_BLOCK {
+		return errors.New("headcount policy must be flag or block")
+	}
+	if c.Addresses != nil {
+		for _, address := range *c.Addresses {
+			if err := address.ValidateAddress(); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Contacts != nil {
+		primary := 0
+		for _, contact := range *c.Contacts {
+			if err := contact.ValidateContact(); err != nil {
+				return err
+			}
+			if contact.IsPrimary {
+				primary++
+			}
+		}
+		if primary > 1 {
+			return errors.New("company can only have one primary contact")
+		}
+	}
+	return nil
+}
+
+func (a CompanyAddress) ValidateAddress() error {
+	if !companyAddressTypes[a.Type] {
+		return errors.New("address type must be registered, billing, shipping or office")
+	}
+	if strings.TrimSpace(a.Line1) == "" || strings.TrimSpace(a.City) == "" {
+		return errors.New("address line1 and city are required")
+	}
+	if !countryPattern.MatchString(a.Country) {
+		return errors.New("address country must be a two letter ISO 3166 code")
+	}
+	return nil
+}
+
+func (c CompanyContact) ValidateContact() error {
+	if strings.TrimSpace(c.Name) == "" {
+		return errors.New("contact name is required")
+	}
+	if c.Email != "" {
+		if _, err := mail.ParseAddress(c.Email); err != nil {
+			return errors.New("contact email is invalid")
+		}
+	}
+	return nil
+}
+
+// CompanyParentRequest move company under parent, null parent make it a top level company
+type CompanyParentRequest struct {
+	ParentID *uint64 `json:"parent_id"`
+}
+
+type CompanyTreeResponse struct {
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
+	Data    *CompanyTreeNode `json:"data"`
+	Error   bool             `json:"error"`
+}
+
+// one company in group with its subsidiaries
+type CompanyTreeNode struct {
+	ID           uint64             `json:"id"`
+	CompanyName  string             `json:"company_name"`
+	LegalName    string             `json:"legal_name"`
+	ParentID     *uint64            `json:"parent_id"`
+	Subsidiaries []*CompanyTreeNode `json:"subsidiaries"`
+}
+
+// flat row from company subtree query, tree is built in service
+type CompanyTreeRow struct {
+	ID          uint64
+	CompanyName string
+	LegalName   string
+	ParentID    *uint64
+	Depth       int
+}
+
+type CompanyRollupResponse struct {
+	Status  int            `json:"status"`
+	Message string         `json:"message"`
+	Data    *CompanyRollup `json:"data"`
+	Error   bool           `json:"error"`
+}
+
+// CompanyRollup is headcount and payroll of every company in a group for one period.
+// payroll total is only added up in the same currency
+type CompanyRollup struct {
+	CompanyID uint64               `json:"company_id"`
+	Period    string               `json:"period"`
+	Headcount int64                `json:"headcount"`
+	Totals    []CompanyRollupTotal `json:"totals"`
+	Companies []CompanyRollupRow   `json:"companies"`
+}
+
+type CompanyRollupTotal struct {
+	Currency       string  `json:"currency"`
+	Headcount      int64   `json:"headcount"`
+	GrossTotal     float64 `json:"gross_total"`
+	DeductionTotal float64 `json:"deduction_total"`
+	NetTotal       float64 `json:"net_total"`
+}
+
+// CompanyRollupRow is one company of group, payroll status is empty when there is no run for the period
+type CompanyRollupRow struct {
+	CompanyID      uint64  `json:"company_id"`
+	CompanyName    string  `json:"company_name"`
+	ParentID       *uint64 `json:"parent_id"`
+	Depth          int     `json:"depth"`
+	Currency       string  `json:"currency"`
+	Headcount      int64   `json:"headcount"`
+	PayrollStatus  string  `json:"payroll_status"`
+	GrossTotal     float64 `json:"gross_total"`
+	DeductionTotal float64 `json:"deduction_total"`
+	NetTotal       float64 `json:"net_total"`
+}
+
+// filter for list and export company
+type CompanyFilter struct {
+	CompanyName string `form:"company_name"`
+	// set from admin, not from query. limit to group and its subsidiaries
+	GroupID uint64 `form:"-" json:"group_id,omitempty"`
+}
+
+type OrgChartResponse struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Data    *[]OrgChartNode `json:"data"`
+	Error   bool            `json:"error"`
+}
+
+// one user in org chart with the users reporting to them
+type OrgChartNode struct {
+	UserID       uint64          `json:"user_id"`
+	FirstName    string          `json:"first_name"`
+	LastName     string          `json:"last_name"`
+	ManagerID    *uint64         `json:"manager_id"`
+	PositionID   *uint64         `json:"position_id"`
+	PositionName string          `json:"position_name"`
+	Reports      []*OrgChartNode `json:"reports"`
+}
+
+// flat row from org chart query, tree is built in service
+type OrgChartRow struct {
+	UserID       uint64
+	FirstName    string
+	LastName     string
+	ManagerID    *uint64
+	PositionID   *uint64
+	PositionName string
+	Depth        int
 }
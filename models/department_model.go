@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DepartmentsResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *[]Department `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+type DepartmentResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    *Department `json:"data"`
+	Error   bool        `json:"error"`
+}
+
+type Department struct {
+	ID             uint64         `json:"id" gorm:"primaryKey"`
+	CompanyID      uint64         `json:"company_id"`
+	ParentID       *uint64        `json:"parent_id"`
+	HeadUserID     *uint64        `json:"head_user_id"`
+	DepartmentName string         `json:"department_name"`
+	DepartmentCode string         `json:"department_code"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+}
+
+type DepartmentRequest struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID      uint64    `json:"company_id" validate:"required"`
+	ParentID       *uint64   `json:"parent_id"`
+	HeadUserID     *uint64   `json:"head_user_id"`
+	DepartmentName string    `json:"department_name" validate:"required"`
+	DepartmentCode string    `json:"department_code"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// filter for list departments
+type DepartmentFilter struct {
+	CompanyID uint64 `form:"company_id" binding:"required"`
+	ParentID  uint64 `form:"parent_id"`
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EMPLOYMENT_TYPE_PERMANENT = "permanent"
+	EMPLOYMENT_TYPE_CONTRACT  = "contract"
+	EMPLOYMENT_TYPE_PROBATION = "probation"
+
+	EMPLOYEE_STATUS_ACTIVE     = "active"
+	EMPLOYEE_STATUS_SUSPENDED  = "suspended"
+	EMPLOYEE_STATUS_TERMINATED = "terminated"
+
+	// status PTKP (penghasilan tidak kena pajak), TK is single and K is married,
+	// the number is count of dependent
+	DEFAULT_PTKP_STATUS = "TK/0"
+)
+
+var ptkpStatuses = []string{"TK/0", "TK/1", "TK/2", "TK/3", "K/0", "K/1", "K/2", "K/3"}
+
+type EmployeesResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    *[]Employee `json:"data"`
+	Error   bool        `json:"error"`
+}
+
+type EmployeeResponse struct {
+	Status  int       `json:"status"`
+	Message string    `json:"message"`
+	Data    *Employee `json:"data"`
+	Error   bool      `json:"error"`
+}
+
+// Employee is HR master record, login account (User) is optional
+type Employee struct {
+	ID                uint64         `json:"id" gorm:"primaryKey"`
+	CompanyID         uint64         `json:"company_id"`
+	UserID            *uint64        `json:"user_id"`
+	EmployeeNumber    string         `json:"employee_number"`
+	FirstName         string         `json:"first_name"`
+	LastName          string         `json:"last_name"`
+	DateOfBirth       *Date          `json:"date_of_birth"`
+	HireDate          Date           `json:"hire_date"`
+	EmploymentType    string         `json:"employment_type"`
+	Status            string         `json:"status"`
+	TerminationDate   *Date          `json:"termination_date"`
+	TerminationReason string         `json:"termination_reason"`
+	PtkpStatus        string         `json:"ptkp_status"`
+	TaxNumber         string         `json:"tax_number"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+}
+
+type EmployeeCreateRequest struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID      uint64    `json:"company_id" binding:"required"`
+	UserID         *uint64   `json:"user_id"`
+	EmployeeNumber string    `json:"employee_number" binding:"required"`
+	FirstName      string    `json:"first_name" binding:"required"`
+	LastName       string    `json:"last_name" binding:"required"`
+	DateOfBirth    *Date     `json:"date_of_birth"`
+	HireDate       Date      `json:"hire_date"`
+	EmploymentType string    `json:"employment_type" binding:"required"`
+	PtkpStatus     string    `json:"ptkp_status"`
+	TaxNumber      string    `json:"tax_number"`
+	Status         string    `json:"-"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// company and status can not be changed from update, status use lifecycle endpoint
+type EmployeeUpdateRequest struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	UserID         *uint64   `json:"user_id"`
+	EmployeeNumber string    `json:"employee_number,omitempty"`
+	FirstName      string    `json:"first_name,omitempty"`
+	LastName       string    `json:"last_name,omitempty"`
+	DateOfBirth    *Date     `json:"date_of_birth"`
+	HireDate       *Date     `json:"hire_date"`
+	EmploymentType string    `json:"employment_type,omitempty"`
+	PtkpStatus     string    `json:"ptkp_status,omitempty"`
+	TaxNumber      string    `json:"tax_number,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type EmployeeTerminateRequest struct {
+	TerminationDate Date   `json:"termination_date"`
+	Reason          string `json:"reason"`
+}
+
+// filter for list employees, employee is always scoped by company
+type EmployeeFilter struct {
+	CompanyID      uint64 `form:"company_id" binding:"required"`
+	Status         string `form:"status"`
+	EmploymentType string `form:"employment_type"`
+}
+
+func IsValidEmploymentType(employmentType string) bool {
+	return employmentType == EMPLOYMENT_TYPE_PERMANENT ||
+		employmentType == EMPLOYMENT_TYPE_CONTRACT ||
+		employmentType == EMPLOYMENT_TYPE_PROBATION
+}
+
+func IsValidPtkpStatus(status string) bool {
+	for _, ptkpStatus := range ptkpStatuses {
+		if status == ptkpStatus {
+			return true
+		}
+	}
+	return false
+}
+
+func (e EmployeeCreateRequest) ValidateCreate() error {
+	if !IsValidEmploymentType(e.EmploymentType) {
+		return errors.New("employment type must be permanent, contract or probation")
+	}
+	if e.PtkpStatus != "" && !IsValidPtkpStatus(e.PtkpStatus) {
+		return errors.New("ptkp status must be TK/0 until TK/3 or K/0 until K/3")
+	}
+	if e.HireDate.IsZero() {
+		return errors.New("hire date cannot be empty")
+	}
+	if e.DateOfBirth != nil && !e.DateOfBirth.Before(e.HireDate.Time) {
+		return errors.New("date of birth must be before hire date")
+	}
+	return nil
+}
+
+func (e EmployeeUpdateRequest) ValidateUpdate() error {
+	if e.EmploymentType != "" && !IsValidEmploymentType(e.EmploymentType) {
+		return errors.New("employment type must be permanent, contract or probation")
+	}
+	if e.PtkpStatus != "" && !IsValidPtkpStatus(e.PtkpStatus) {
+		return errors.New("ptkp status must be TK/0 until TK/3 or K/0 until K/3")
+	}
+	return nil
+}
+
+func (e EmployeeTerminateRequest) ValidateTerminate() error {
+	if e.TerminationDate.IsZero() {
+		return errors.New("termination date cannot be empty")
+	}
+	return nil
+}
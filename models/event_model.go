@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EVENT_USER_CREATED     = "user.created"
+	EVENT_USER_UPDATED     = "user.updated"
+	EVENT_USER_DELETED     = "user.deleted"
+	EVENT_COMPANY_CREATED  = "company.created"
+	EVENT_COMPANY_UPDATED  = "company.updated"
+	EVENT_COMPANY_DELETED  = "company.deleted"
+	EVENT_POSITION_CREATED = "position.created"
+	EVENT_POSITION_UPDATED = "position.updated"
+	EVENT_POSITION_DELETED = "position.deleted"
+
+	EVENT_USER_PASSWORD_CHANGED = "user.password_changed"
+	EVENT_LEAVE_REQUESTED       = "leave.requested"
+	EVENT_LEAVE_APPROVED        = "leave.approved"
+	EVENT_LEAVE_REJECTED        = "leave.rejected"
+	EVENT_LEAVE_CANCELLED       = "leave.cancelled"
+	EVENT_ASSIGNMENT_CREATED    = "assignment.created"
+
+	OUTBOX_STATUS_PENDING    = "pending"
+	OUTBOX_STATUS_DISPATCHED = "dispatched"
+	OUTBOX_STATUS_FAILED     = "failed"
+
+	// event is failed after this many dispatch, retry wait is doubled every attempt
+	OUTBOX_MAX_ATTEMPTS   = 10
+	OUTBOX_RETRY_BASE     = 5 * time.Second
+	OUTBOX_RETRY_MAX_WAIT = time.Hour
+)
+
+// DomainEvent is row of outbox, saved in the same transaction as the change.
+// payload is json of the changed entity
+type DomainEvent struct {
+	ID              uint64     `json:"id" gorm:"primaryKey"`
+	Name            string     `json:"name"`
+	CompanyID       *uint64    `json:"company_id"`
+	Payload         string     `json:"payload"`
+	Status          string     `json:"status"`
+	Attempts        int        `json:"attempts"`
+	NextAttemptAt   time.Time  `json:"next_attempt_at"`
+	DoneSubscribers string     `json:"done_subscribers"`
+	LastError       string     `json:"last_error,omitempty"`
+	OccurredAt      time.Time  `json:"occurred_at"`
+	DispatchedAt    *time.Time `json:"dispatched_at"`
+}
+
+// OutboxRetryAt return time of next dispatch after the given failed attempts,
+// zero time when event should not be retried anymore
+func OutboxRetryAt(attempts int, now time.Time) time.Time {
+	if attempts >= OUTBOX_MAX_ATTEMPTS {
+		return time.Time{}
+	}
+	wait := OUTBOX_RETRY_BASE << (attempts - 1)
+	if wait > OUTBOX_RETRY_MAX_WAIT || wait <= 0 {
+		wait = OUTBOX_RETRY_MAX_WAIT
+	}
+	return now.Add(wait)
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	EXPORT_FORMAT_CSV    = "csv"
+	EXPORT_FORMAT_XLSX   = "xlsx"
+	EXPORT_FORMAT_NDJSON = "ndjson"
+
+	EXPORT_STATUS_PENDING = "pending"
+	EXPORT_STATUS_RUNNING = "running"
+	EXPORT_STATUS_DONE    = "done"
+	EXPORT_STATUS_FAILED  = "failed"
+
+	EXPORT_ENTITY_USERS     = "users"
+	EXPORT_ENTITY_COMPANY   = "company"
+	EXPORT_ENTITY_POSITIONS = "positions"
+
+	// export with more rows than this will run as background job
+	EXPORT_ASYNC_THRESHOLD = 5000
+
+	EXPORT_DOWNLOAD_URL = "/api/v1/exports/%d/download"
+)
+
+type ExportJobResponse struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Data    *ExportJob `json:"data"`
+	Error   bool       `json:"error"`
+}
+
+type ExportJob struct {
+	ID          uint64     `json:"id" gorm:"primaryKey"`
+	Entity      string     `json:"entity"`
+	Format      string     `json:"format"`
+	Columns     string     `json:"columns"`
+	Filters     string     `json:"-"`
+	Status      string     `json:"status"`
+	FilePath    string     `json:"-"`
+	RowCount    int64      `json:"row_count"`
+	ErrorReason string     `json:"error_reason,omitempty"`
+	RequestedBy uint64     `json:"requested_by"`
+	DownloadURL string     `json:"download_url,omitempty" gorm:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// query for export endpoints, filters are bind separately per entity
+type ExportRequest struct {
+	Format  string `form:"format"`
+	Columns string `form:"columns"`
+	Async   bool   `form:"async"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// hiring beyond plan is saved and marked as over plan
+	HEADCOUNT_POLICY_FLAG = "flag"
+	// hiring beyond plan is rejected
+	HEADCOUNT_POLICY_BLOCK = "block"
+)
+
+type HeadcountPlansResponse struct {
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
+	Data    *[]HeadcountPlan `json:"data"`
+	Error   bool             `json:"error"`
+}
+
+type HeadcountPlanResponse struct {
+	Status  int            `json:"status"`
+	Message string         `json:"message"`
+	Data    *HeadcountPlan `json:"data"`
+	Error   bool           `json:"error"`
+}
+
+// HeadcountPlan is budgeted seats of position in one month, plan without department
+// cover the position in every department of company.
+// filled is counted from assignment valid in the period, open is planned minus filled
+type HeadcountPlan struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID      uint64    `json:"company_id"`
+	DepartmentID   *uint64   `json:"department_id"`
+	DepartmentName string    `json:"department_name" gorm:"->"`
+	PositionID     uint64    `json:"position_id"`
+	PositionName   string    `json:"position_name" gorm:"->"`
+	Period         Date      `json:"period"`
+	Planned        int       `json:"planned"`
+	Filled         int       `json:"filled" gorm:"-"`
+	Open           int       `json:"open" gorm:"-"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type HeadcountPlanRequest struct {
+	CompanyID    uint64  `json:"company_id" binding:"required"`
+	DepartmentID *uint64 `json:"department_id"`
+	PositionID   uint64  `json:"position_id" binding:"required"`
+	Period       string  `json:"period" binding:"required"`
+	Planned      int     `json:"planned"`
+}
+
+// filter of plan and vacancy, period is YYYY-MM and default to this month
+type HeadcountFilter struct {
+	CompanyID uint64 `form:"company_id" binding:"required"`
+	Period    string `form:"period"`
+}
+
+// HeadcountFilled is number of users placed in company, department and position on one date
+type HeadcountFilled struct {
+	CompanyID    uint64
+	DepartmentID *uint64
+	PositionID   uint64
+	Filled       int
+}
+
+func (h HeadcountPlanRequest) ValidateHeadcountPlan() error {
+	if h.Planned < 0 {
+		return errors.New("planned headcount cannot be negative")
+	}
+	if _, _, err := ParseMonth(h.Period); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Covers report whether seat in department and position count toward the plan
+func (h HeadcountPlan) Covers(departmentID *uint64, positionID uint64) bool {
+	if h.PositionID != positionID {
+		return false
+	}
+	if h.DepartmentID == nil {
+		return true
+	}
+	return departmentID != nil && *departmentID == *h.DepartmentID
+}
@@ -0,0 +1,72 @@
+package models
+
+import "time"
+
+const (
+	HEALTH_STATUS_OK   = "ok"
+	HEALTH_STATUS_FAIL = "fail"
+)
+
+type HealthResponse struct {
+	Status  int     `json:"status"`
+	Message string  `json:"message"`
+	Data    *Health `json:"data"`
+	Error   bool    `json:"error"`
+}
+
+type SystemStatusResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *SystemStatus `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+// Health is result of liveness or readiness probe, status is fail when one of the check fail
+type Health struct {
+	Status  string         `json:"status"`
+	Checks  []HealthCheck  `json:"checks,omitempty"`
+	Workers []WorkerStatus `json:"workers,omitempty"`
+}
+
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// pending migration or error of the check
+	Detail string `json:"detail,omitempty"`
+}
+
+// WorkerStatus of background worker, running is false when the worker stop before shutdown
+type WorkerStatus struct {
+	Name      string `json:"name"`
+	Running   bool   `json:"running"`
+	Restarts  int    `json:"restarts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// SystemStatus is build and runtime information for admin, it is never shown to probe
+type SystemStatus struct {
+	Version    string         `json:"version"`
+	Revision   string         `json:"revision"`
+	BuildTime  string         `json:"build_time"`
+	GoVersion  string         `json:"go_version"`
+	StartedAt  time.Time      `json:"started_at"`
+	Uptime     string         `json:"uptime"`
+	Draining   bool           `json:"draining"`
+	Goroutines int            `json:"goroutines"`
+	Database   DatabaseStatus `json:"database"`
+	Migrations []string       `json:"pending_migrations"`
+	Workers    []WorkerStatus `json:"workers"`
+	Health     Health         `json:"health"`
+}
+
+// DatabaseStatus is connection pool statistic of database/sql
+type DatabaseStatus struct {
+	MaxOpenConnections int    `json:"max_open_connections"`
+	OpenConnections    int    `json:"open_connections"`
+	InUse              int    `json:"in_use"`
+	Idle               int    `json:"idle"`
+	WaitCount          int64  `json:"wait_count"`
+	WaitDuration       string `json:"wait_duration"`
+	MaxIdleClosed      int64  `json:"max_idle_closed"`
+	MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+}
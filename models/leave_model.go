@@ -0,0 +1,185 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	LEAVE_TYPE_ANNUAL = "annual"
+	LEAVE_TYPE_SICK   = "sick"
+	LEAVE_TYPE_UNPAID = "unpaid"
+
+	// yearly entitlement is given at once on january, or 1/12 every month
+	LEAVE_ACCRUAL_YEARLY  = "yearly"
+	LEAVE_ACCRUAL_MONTHLY = "monthly"
+
+	LEAVE_STATUS_PENDING   = "pending"
+	LEAVE_STATUS_APPROVED  = "approved"
+	LEAVE_STATUS_REJECTED  = "rejected"
+	LEAVE_STATUS_CANCELLED = "cancelled"
+)
+
+type LeaveTypesResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    *[]LeaveType `json:"data"`
+	Error   bool         `json:"error"`
+}
+
+type LeaveTypeResponse struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Data    *LeaveType `json:"data"`
+	Error   bool       `json:"error"`
+}
+
+// LeaveType is leave rule of one company, entitlement and carry over are in days
+type LeaveType struct {
+	ID                uint64         `json:"id" gorm:"primaryKey"`
+	CompanyID         uint64         `json:"company_id" binding:"required"`
+	Code              string         `json:"code" binding:"required"`
+	Name              string         `json:"name" binding:"required"`
+	IsPaid            bool           `json:"is_paid"`
+	RequiresBalance   bool           `json:"requires_balance"`
+	YearlyEntitlement float64        `json:"yearly_entitlement"`
+	Accrual           string         `json:"accrual"`
+	CarryOverMax      float64        `json:"carry_over_max"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+}
+
+type LeaveBalancesResponse struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Data    *[]LeaveBalance `json:"data"`
+	Error   bool            `json:"error"`
+}
+
+// LeaveBalance is usage of one leave type by user in one year.
+// pending is reserved by waiting request, available is filled by service
+type LeaveBalance struct {
+	ID          uint64    `json:"id" gorm:"primaryKey"`
+	UserID      uint64    `json:"user_id"`
+	LeaveTypeID uint64    `json:"leave_type_id"`
+	Year        int       `json:"year"`
+	Entitled    float64   `json:"entitled"`
+	CarriedOver float64   `json:"carried_over"`
+	Used        float64   `json:"used"`
+	Pending     float64   `json:"pending"`
+	Accrued     float64   `json:"accrued" gorm:"-"`
+	Available   float64   `json:"available" gorm:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type LeaveRequestsResponse struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Data    *[]LeaveRequest `json:"data"`
+	Error   bool            `json:"error"`
+}
+
+type LeaveRequestResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *LeaveRequest `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+// LeaveRequest cover start date until end date inclusive, days only count working days
+type LeaveRequest struct {
+	ID          uint64     `json:"id" gorm:"primaryKey"`
+	UserID      uint64     `json:"user_id"`
+	CompanyID   uint64     `json:"company_id"`
+	LeaveTypeID uint64     `json:"leave_type_id"`
+	StartDate   Date       `json:"start_date"`
+	EndDate     Date       `json:"end_date"`
+	Days        float64    `json:"days"`
+	Reason      string     `json:"reason"`
+	Status      string     `json:"status"`
+	ReviewedBy  *uint64    `json:"reviewed_by"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	ReviewNote  string     `json:"review_note"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type LeaveRequestCreate struct {
+	LeaveTypeID uint64 `json:"leave_type_id" binding:"required"`
+	StartDate   Date   `json:"start_date"`
+	EndDate     Date   `json:"end_date"`
+	Reason      string `json:"reason"`
+}
+
+type LeaveReviewRequest struct {
+	Note string `json:"note"`
+}
+
+type LeaveCalendarResponse struct {
+	Status  int                   `json:"status"`
+	Message string                `json:"message"`
+	Data    *[]LeaveCalendarEntry `json:"data"`
+	Error   bool                  `json:"error"`
+}
+
+// LeaveCalendarEntry is one user out of office in the calendar range
+type LeaveCalendarEntry struct {
+	LeaveRequestID uint64  `json:"leave_request_id" gorm:"column:id"`
+	UserID         uint64  `json:"user_id"`
+	FirstName      string  `json:"first_name"`
+	LastName       string  `json:"last_name"`
+	DepartmentID   *uint64 `json:"department_id"`
+	LeaveTypeName  string  `json:"leave_type_name"`
+	StartDate      Date    `json:"start_date"`
+	EndDate        Date    `json:"end_date"`
+	Status         string  `json:"status"`
+}
+
+// filter for team calendar, from and to are "YYYY-MM-DD"
+type LeaveCalendarFilter struct {
+	From           string `form:"from" binding:"required"`
+	To             string `form:"to" binding:"required"`
+	DepartmentID   uint64 `form:"department_id"`
+	IncludePending bool   `form:"include_pending"`
+}
+
+func (l LeaveType) ValidateLeaveType() error {
+	if l.Accrual != "" && l.Accrual != LEAVE_ACCRUAL_YEARLY && l.Accrual != LEAVE_ACCRUAL_MONTHLY {
+		return errors.New("accrual must be yearly or monthly")
+	}
+	if l.YearlyEntitlement < 0 || l.CarryOverMax < 0 {
+		return errors.New("entitlement and carry over cannot be negative")
+	}
+	if l.RequiresBalance && l.YearlyEntitlement == 0 {
+		return errors.New("yearly entitlement is required when leave requires balance")
+	}
+	return nil
+}
+
+func (l LeaveRequestCreate) ValidateLeaveRequest() error {
+	if l.StartDate.IsZero() || l.EndDate.IsZero() {
+		return errors.New("start date and end date cannot be empty")
+	}
+	if l.EndDate.Before(l.StartDate.Time) {
+		return errors.New("end date cannot be before start date")
+	}
+	if l.StartDate.Year() != l.EndDate.Year() {
+		return errors.New("leave cannot span two years, split the request")
+	}
+	return nil
+}
+
+// WorkingDays count monday until friday between start and end inclusive
+func WorkingDays(start Date, end Date) float64 {
+	days := 0.0
+	for d := start.Time; !d.After(end.Time); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			days++
+		}
+	}
+	return days
+}
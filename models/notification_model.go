@@ -0,0 +1,207 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	NOTIFICATION_CHANNEL_IN_APP = "in_app"
+	NOTIFICATION_CHANNEL_EMAIL  = "email"
+	NOTIFICATION_CHANNEL_CHAT   = "chat"
+
+	// template of locale without translation fall back to this
+	DEFAULT_NOTIFICATION_LOCALE = "en"
+
+	DEFAULT_NOTIFICATION_LIMIT = 50
+	MAX_NOTIFICATION_LIMIT     = 200
+)
+
+// notification is created for these domain event
+var NotificationTypes = []string{
+	EVENT_USER_CREATED,
+	EVENT_USER_PASSWORD_CHANGED,
+	EVENT_LEAVE_REQUESTED,
+	EVENT_LEAVE_APPROVED,
+	EVENT_LEAVE_REJECTED,
+	EVENT_LEAVE_CANCELLED,
+	EVENT_ASSIGNMENT_CREATED,
+}
+
+// DefaultNotificationTemplates is used when company has not saved its own template,
+// keyed by type then locale. text/template syntax with field of event payload
+var DefaultNotificationTemplates = map[string]map[string]NotificationTemplate{
+	EVENT_USER_CREATED: {
+		"en": {Title: "Welcome, {{.first_name}}", Body: "Your account {{.email}} has been created."},
+		"id": {Title: "Selamat datang, {{.first_name}}", Body: "Akun {{.email}} Anda telah dibuat."},
+	},
+	EVENT_USER_PASSWORD_CHANGED: {
+		"en": {Title: "Your password was changed", Body: "If you did not change your password, contact your administrator."},
+		"id": {Title: "Kata sandi Anda telah diubah", Body: "Jika Anda tidak mengubah kata sandi, hubungi administrator."},
+	},
+	EVENT_LEAVE_REQUESTED: {
+		"en": {Title: "Leave request needs your approval", Body: "{{.requester_name}} requested {{.days}} day(s) of leave from {{.start_date}} to {{.end_date}}."},
+		"id": {Title: "Pengajuan cuti menunggu persetujuan Anda", Body: "{{.requester_name}} mengajukan cuti {{.days}} hari dari {{.start_date}} sampai {{.end_date}}."},
+	},
+	EVENT_LEAVE_APPROVED: {
+		"en": {Title: "Leave request approved", Body: "Your leave from {{.start_date}} to {{.end_date}} was approved. {{.review_note}}"},
+		"id": {Title: "Pengajuan cuti disetujui", Body: "Cuti Anda dari {{.start_date}} sampai {{.end_date}} telah disetujui. {{.review_note}}"},
+	},
+	EVENT_LEAVE_REJECTED: {
+		"en": {Title: "Leave request rejected", Body: "Your leave from {{.start_date}} to {{.end_date}} was rejected. {{.review_note}}"},
+		"id": {Title: "Pengajuan cuti ditolak", Body: "Cuti Anda dari {{.start_date}} sampai {{.end_date}} ditolak. {{.review_note}}"},
+	},
+	EVENT_LEAVE_CANCELLED: {
+		"en": {Title: "Leave request cancelled", Body: "{{.requester_name}} cancelled {{.days}} day(s) of leave from {{.start_date}} to {{.end_date}}."},
+		"id": {Title: "Pengajuan cuti dibatalkan", Body: "{{.requester_name}} membatalkan cuti {{.days}} hari dari {{.start_date}} sampai {{.end_date}}."},
+	},
+	EVENT_ASSIGNMENT_CREATED: {
+		"en": {Title: "Your assignment has changed", Body: "Your {{.change_type}} is effective on {{.valid_from}}."},
+		"id": {Title: "Penugasan Anda berubah", Body: "Perubahan {{.change_type}} Anda berlaku mulai {{.valid_from}}."},
+	},
+}
+
+type NotificationsResponse struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Data    *[]Notification `json:"data"`
+	Error   bool            `json:"error"`
+}
+
+type NotificationResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *Notification `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+type NotificationCountResponse struct {
+	Status  int                `json:"status"`
+	Message string             `json:"message"`
+	Data    *NotificationCount `json:"data"`
+	Error   bool               `json:"error"`
+}
+
+type NotificationPreferenceResponse struct {
+	Status  int                     `json:"status"`
+	Message string                  `json:"message"`
+	Data    *NotificationPreference `json:"data"`
+	Error   bool                    `json:"error"`
+}
+
+type NotificationTemplatesResponse struct {
+	Status  int                     `json:"status"`
+	Message string                  `json:"message"`
+	Data    *[]NotificationTemplate `json:"data"`
+	Error   bool                    `json:"error"`
+}
+
+type NotificationTemplateResponse struct {
+	Status  int                   `json:"status"`
+	Message string                `json:"message"`
+	Data    *NotificationTemplate `json:"data"`
+	Error   bool                  `json:"error"`
+}
+
+// Notification is item of in-app inbox, event id make the same event notified once
+type Notification struct {
+	ID        uint64     `json:"id" gorm:"primaryKey"`
+	UserID    uint64     `json:"user_id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Data      string     `json:"data"`
+	EventID   *uint64    `json:"event_id"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type NotificationCount struct {
+	Unread int64 `json:"unread"`
+}
+
+type NotificationFilter struct {
+	Unread   bool   `form:"unread"`
+	BeforeID uint64 `form:"before_id"`
+	Limit    int    `form:"limit"`
+}
+
+// NotificationReadRequest mark the given notification, every notification of user when ids is empty
+type NotificationReadRequest struct {
+	IDs []uint64 `json:"ids"`
+}
+
+// NotificationPreference is channel chosen by user, user without saved
+// preference only receive in-app notification
+type NotificationPreference struct {
+	UserID      uint64    `json:"user_id" gorm:"primaryKey"`
+	InApp       bool      `json:"in_app"`
+	Email       bool      `json:"email"`
+	Chat        bool      `json:"chat"`
+	ChatAddress string    `json:"chat_address"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NotificationTemplate override default template for company and locale
+type NotificationTemplate struct {
+	ID        uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID uint64    `json:"company_id"`
+	Type      string    `json:"type"`
+	Locale    string    `json:"locale"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type NotificationTemplateFilter struct {
+	CompanyID uint64 `form:"company_id" binding:"required"`
+}
+
+func DefaultNotificationPreference(userID uint64) NotificationPreference {
+	return NotificationPreference{UserID: userID, InApp: true}
+}
+
+func (p *NotificationPreference) ValidatePreference() error {
+	p.ChatAddress = strings.TrimSpace(p.ChatAddress)
+	if !p.Chat {
+		return nil
+	}
+	// address is called by server, plain http and other scheme are refused
+	target, err := url.Parse(p.ChatAddress)
+	if err != nil || target.Scheme != "https" || target.Host == "" {
+		return errors.New("chat address must be a valid https url")
+	}
+	return nil
+}
+
+func (t *NotificationTemplate) ValidateTemplate() error {
+	if t.CompanyID == 0 {
+		return errors.New("company id cannot be empty")
+	}
+	if _, ok := DefaultNotificationTemplates[t.Type]; !ok {
+		return errors.New("notification type is not supported: " + t.Type)
+	}
+	t.Locale = strings.TrimSpace(t.Locale)
+	if t.Locale == "" {
+		return errors.New("template locale cannot be empty")
+	}
+	if strings.TrimSpace(t.Title) == "" || strings.TrimSpace(t.Body) == "" {
+		return errors.New("template title and body cannot be empty")
+	}
+	return nil
+}
+
+// NotificationLocales return locale to try for template, from the most specific
+// until the default, "id-ID" try "id-ID", "id" then "en"
+func NotificationLocales(locale string) []string {
+	locales := []string{}
+	if locale != "" {
+		locales = append(locales, locale)
+		if language, _, found := strings.Cut(locale, "-"); found {
+			locales = append(locales, language)
+		}
+	}
+	return append(locales, DEFAULT_NOTIFICATION_LOCALE)
+}
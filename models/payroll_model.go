@@ -0,0 +1,225 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SALARY_COMPONENT_ALLOWANCE = "allowance"
+	SALARY_COMPONENT_DEDUCTION = "deduction"
+
+	// statutory contribution paid by employer, printed on payslip but not deducted
+	PAYSLIP_LINE_EMPLOYER = "employer"
+	// tunjangan hari raya, paid with the run when requested
+	PAYSLIP_LINE_THR = "thr"
+
+	PAYROLL_STATUS_DRAFT    = "draft"
+	PAYROLL_STATUS_APPROVED = "approved"
+)
+
+type SalaryComponentsResponse struct {
+	Status  int                `json:"status"`
+	Message string             `json:"message"`
+	Data    *[]SalaryComponent `json:"data"`
+	Error   bool               `json:"error"`
+}
+
+type SalaryComponentResponse struct {
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
+	Data    *SalaryComponent `json:"data"`
+	Error   bool             `json:"error"`
+}
+
+// SalaryComponent is allowance or deduction defined by company,
+// the amount is set per employee in EmployeeSalary
+type SalaryComponent struct {
+	ID        uint64         `json:"id" gorm:"primaryKey"`
+	CompanyID uint64         `json:"company_id" binding:"required"`
+	Code      string         `json:"code" binding:"required"`
+	Name      string         `json:"name" binding:"required"`
+	Type      string         `json:"type" binding:"required"`
+	Taxable   bool           `json:"taxable"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+}
+
+type EmployeeSalaryResponse struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Data    *EmployeeSalary `json:"data"`
+	Error   bool            `json:"error"`
+}
+
+// EmployeeSalary is salary structure of one employee, base pay plus components.
+// band override reason is kept while base salary is outside of the band of position
+type EmployeeSalary struct {
+	ID                 uint64                    `json:"id" gorm:"primaryKey"`
+	EmployeeID         uint64                    `json:"employee_id"`
+	Currency           string                    `json:"currency"`
+	BaseSalary         float64                   `json:"base_salary"`
+	BandOverrideReason string                    `json:"band_override_reason"`
+	BandWarning        string                    `json:"band_warning,omitempty" gorm:"-"`
+	Components         []EmployeeSalaryComponent `json:"components" gorm:"-"`
+	CreatedAt          time.Time                 `json:"created_at"`
+	UpdatedAt          time.Time                 `json:"updated_at"`
+}
+
+type EmployeeSalaryComponent struct {
+	ID          uint64  `json:"id" gorm:"primaryKey"`
+	EmployeeID  uint64  `json:"employee_id"`
+	ComponentID uint64  `json:"component_id" binding:"required"`
+	Amount      float64 `json:"amount"`
+}
+
+type EmployeeSalaryRequest struct {
+	Currency       string                    `json:"currency"`
+	BaseSalary     float64                   `json:"base_salary" binding:"required"`
+	Components     []EmployeeSalaryComponent `json:"components"`
+	OverrideReason string                    `json:"override_reason"`
+}
+
+type PayrollRunsResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *[]PayrollRun `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+type PayrollRunResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    *PayrollRun `json:"data"`
+	Error   bool        `json:"error"`
+}
+
+// PayrollRun is payroll of one company for one month, period is the first day of month.
+// draft run can be run again, approved run is locked
+type PayrollRun struct {
+	ID             uint64     `json:"id" gorm:"primaryKey"`
+	CompanyID      uint64     `json:"company_id"`
+	Period         Date       `json:"period"`
+	Status         string     `json:"status"`
+	EmployeeCount  int        `json:"employee_count"`
+	GrossTotal     float64    `json:"gross_total"`
+	DeductionTotal float64    `json:"deduction_total"`
+	NetTotal       float64    `json:"net_total"`
+	IncludeTHR     bool       `json:"include_thr" gorm:"column:include_thr"`
+	CreatedBy      *uint64    `json:"created_by"`
+	ApprovedBy     *uint64    `json:"approved_by"`
+	ApprovedAt     *time.Time `json:"approved_at"`
+	Payslips       []Payslip  `json:"payslips,omitempty" gorm:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type PayrollRunRequest struct {
+	CompanyID uint64 `json:"company_id" binding:"required"`
+	// "YYYY-MM"
+	Period     string `json:"period" binding:"required"`
+	IncludeTHR bool   `json:"include_thr"`
+}
+
+type PayslipsResponse struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Data    *[]Payslip `json:"data"`
+	Error   bool       `json:"error"`
+}
+
+// Payslip is snapshot of salary of one employee in a payroll run,
+// later change of salary does not change the payslip
+type Payslip struct {
+	ID             uint64       `json:"id" gorm:"primaryKey"`
+	PayrollRunID   uint64       `json:"payroll_run_id"`
+	EmployeeID     uint64       `json:"employee_id"`
+	EmployeeNumber string       `json:"employee_number"`
+	EmployeeName   string       `json:"employee_name"`
+	BaseSalary     float64      `json:"base_salary"`
+	AllowanceTotal float64      `json:"allowance_total"`
+	DeductionTotal float64      `json:"deduction_total"`
+	Gross          float64      `json:"gross"`
+	Net            float64      `json:"net"`
+	Lines          PayslipLines `json:"lines" gorm:"type:jsonb"`
+	// gross for PPh 21 including benefit paid by employer
+	TaxableIncome       float64   `json:"taxable_income"`
+	PensionContribution float64   `json:"pension_contribution"`
+	Tax                 float64   `json:"tax"`
+	FinalPeriod         bool      `json:"final_period"`
+	Period              Date      `json:"period" gorm:"->"`
+	CreatedAt           time.Time `json:"created_at"`
+}
+
+// PayslipLine is one allowance or deduction printed on payslip
+type PayslipLine struct {
+	Code    string  `json:"code"`
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Taxable bool    `json:"taxable"`
+	Amount  float64 `json:"amount"`
+}
+
+type PayslipLines []PayslipLine
+
+func (p PayslipLines) Value() (driver.Value, error) {
+	if p == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (p *PayslipLines) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*p = PayslipLines{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	}
+	return errors.New("invalid payslip lines")
+}
+
+// RoundMoney round amount to 2 decimal
+func RoundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func (s SalaryComponent) ValidateSalaryComponent() error {
+	if s.Type != SALARY_COMPONENT_ALLOWANCE && s.Type != SALARY_COMPONENT_DEDUCTION {
+		return errors.New("component type must be allowance or deduction")
+	}
+	return nil
+}
+
+func (e EmployeeSalaryRequest) ValidateEmployeeSalary() error {
+	if e.BaseSalary < 0 {
+		return errors.New("base salary cannot be negative")
+	}
+	if !IsValidCurrency(NormalizeCurrency(e.Currency)) {
+		return errors.New("currency must be a three letter ISO 4217 code")
+	}
+	seen := map[uint64]bool{}
+	for _, component := range e.Components {
+		if component.Amount < 0 {
+			return errors.New("component amount cannot be negative")
+		}
+		if seen[component.ComponentID] {
+			return errors.New("component cannot be set twice")
+		}
+		seen[component.ComponentID] = true
+	}
+	return nil
+}
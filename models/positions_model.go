@@ -1,11 +1,17 @@
 package models
 
 import (
+	"errors"
+	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// DEFAULT_POSITION_CODE_PATTERN is used by template position and company without code rule
+const DEFAULT_POSITION_CODE_PATTERN = `^[A-Z][A-Z0-9_-]{1,19}$`
+
 type PositionsResponse struct {
 	Status  int         `json:"status"`
 	Message string      `json:"message"`
@@ -20,18 +26,26 @@ type PositionResponse struct {
 	Error   bool      `json:"error"`
 }
 
+// Position belong to one company, or is a global template when company id is null.
+// name and code are unique inside the company, template is adopted by copying it into a company
 type Position struct {
 	ID           uint64         `json:"id" gorm:"primaryKey"`
+	CompanyID    *uint64        `json:"company_id"`
+	TemplateID   *uint64        `json:"template_id"`
 	PositionName string         `json:"position_name"`
 	PositionCode string         `json:"position_code"`
+	JobGrade     string         `json:"job_grade"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
 }
 type PositionCreateRequest struct {
 	ID           uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID    *uint64   `json:"company_id"`
+	TemplateID   *uint64   `json:"-"`
 	PositionName string    `json:"position_name" validate:"required"`
 	PositionCode string    `json:"position_code" validate:"required"`
+	JobGrade     string    `json:"job_grade"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
@@ -39,5 +53,59 @@ type PositionUpdateRequest struct {
 	ID           uint64    `json:"id" gorm:"primaryKey"`
 	PositionName string    `json:"position_name,omitempty"`
 	PositionCode string    `json:"position_code,omitempty"`
+	JobGrade     string    `json:"job_grade,omitempty"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
+
+// filter for list and export positions, template only return global template positions
+type PositionFilter struct {
+	CompanyID    uint64 `form:"company_id"`
+	Template     bool   `form:"template"`
+	PositionName string `form:"position_name"`
+	PositionCode string `form:"position_code"`
+}
+
+type PositionAdoptRequest struct {
+	CompanyID uint64 `json:"company_id" binding:"required"`
+}
+
+type PositionCodeRuleResponse struct {
+	Status  int               `json:"status"`
+	Message string            `json:"message"`
+	Data    *PositionCodeRule `json:"data"`
+	Error   bool              `json:"error"`
+}
+
+// PositionCodeRule is format of position code in one company, as regular expression
+type PositionCodeRule struct {
+	CompanyID   uint64    `json:"company_id" gorm:"primaryKey"`
+	Pattern     string    `json:"pattern" binding:"required"`
+	Description string    `json:"description"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (r PositionCodeRule) ValidateCodeRule() error {
+	if _, err := regexp.Compile(r.Pattern); err != nil {
+		return errors.New("code pattern is not a valid regular expression")
+	}
+	if !strings.HasPrefix(r.Pattern, "^") || !strings.HasSuffix(r.Pattern, "$") {
+		return errors.New("code pattern must match the whole code, start with ^ and end with $")
+	}
+	return nil
+}
+
+// CheckCode return error when code does not follow the rule
+func (r PositionCodeRule) CheckCode(code string) error {
+	pattern, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return err
+	}
+	if !pattern.MatchString(code) {
+		message := "position code must match " + r.Pattern
+		if r.Description != "" {
+			message = "position code must be " + r.Description
+		}
+		return errors.New(message)
+	}
+	return nil
+}
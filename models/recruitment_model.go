@@ -0,0 +1,320 @@
+package models
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/helper"
+)
+
+const (
+	REQUISITION_STATUS_OPEN    = "open"
+	REQUISITION_STATUS_ON_HOLD = "on_hold"
+	REQUISITION_STATUS_CLOSED  = "closed"
+
+	CANDIDATE_STATUS_ACTIVE    = "active"
+	CANDIDATE_STATUS_REJECTED  = "rejected"
+	CANDIDATE_STATUS_WITHDRAWN = "withdrawn"
+	CANDIDATE_STATUS_HIRED     = "hired"
+
+	INTERVIEW_STATUS_SCHEDULED = "scheduled"
+	INTERVIEW_STATUS_COMPLETED = "completed"
+	INTERVIEW_STATUS_CANCELLED = "cancelled"
+
+	// feedback score from 1 (strong no) until 5 (strong yes)
+	INTERVIEW_SCORE_MIN = 1
+	INTERVIEW_SCORE_MAX = 5
+
+	// personal data of closed candidate is purged after this many days
+	DEFAULT_CANDIDATE_RETENTION_DAYS = 180
+
+	// uploaded cv larger than this is rejected
+	CANDIDATE_CV_MAX_SIZE = 5 << 20
+)
+
+// stage of company without configured pipeline, the last stage is the offer
+var DefaultRecruitmentStages = []string{"Applied", "Screening", "Interview", "Offer"}
+
+var candidateCVExtensions = []string{".pdf", ".doc", ".docx"}
+
+type RequisitionsResponse struct {
+	Status  int            `json:"status"`
+	Message string         `json:"message"`
+	Data    *[]Requisition `json:"data"`
+	Error   bool           `json:"error"`
+}
+
+type RequisitionResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    *Requisition `json:"data"`
+	Error   bool         `json:"error"`
+}
+
+type RecruitmentStagesResponse struct {
+	Status  int                 `json:"status"`
+	Message string              `json:"message"`
+	Data    *[]RecruitmentStage `json:"data"`
+	Error   bool                `json:"error"`
+}
+
+type CandidatesResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    *[]Candidate `json:"data"`
+	Error   bool         `json:"error"`
+}
+
+type CandidateResponse struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Data    *Candidate `json:"data"`
+	Error   bool       `json:"error"`
+}
+
+type InterviewsResponse struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Data    *[]Interview `json:"data"`
+	Error   bool         `json:"error"`
+}
+
+type InterviewResponse struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Data    *Interview `json:"data"`
+	Error   bool       `json:"error"`
+}
+
+// Requisition is approved opening of position in company, closed when every opening is hired
+type Requisition struct {
+	ID             uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID      uint64    `json:"company_id"`
+	DepartmentID   *uint64   `json:"department_id"`
+	PositionID     uint64    `json:"position_id"`
+	PositionName   string    `json:"position_name" gorm:"->"`
+	Title          string    `json:"title"`
+	Description    string    `json:"description"`
+	Openings       int       `json:"openings"`
+	Hired          int       `json:"hired"`
+	EmploymentType string    `json:"employment_type"`
+	Status         string    `json:"status"`
+	CreatedBy      *uint64   `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type RequisitionRequest struct {
+	CompanyID      uint64  `json:"company_id" binding:"required"`
+	DepartmentID   *uint64 `json:"department_id"`
+	PositionID     uint64  `json:"position_id" binding:"required"`
+	Title          string  `json:"title" binding:"required"`
+	Description    string  `json:"description"`
+	Openings       int     `json:"openings"`
+	EmploymentType string  `json:"employment_type" binding:"required"`
+}
+
+// company, department and position can not be changed after requisition is opened
+type RequisitionUpdateRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Openings    int    `json:"openings"`
+	Status      string `json:"status"`
+}
+
+type RequisitionFilter struct {
+	CompanyID uint64 `form:"company_id" binding:"required"`
+	Status    string `form:"status"`
+}
+
+// RecruitmentStage is step of company pipeline, candidate move forward by sort order
+type RecruitmentStage struct {
+	ID        uint64    `json:"id" gorm:"primaryKey"`
+	CompanyID uint64    `json:"company_id"`
+	Name      string    `json:"name"`
+	SortOrder int       `json:"sort_order"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// stage without id is created, existing stage missing from the list is removed
+type RecruitmentStagesRequest struct {
+	CompanyID uint64                        `json:"company_id" binding:"required"`
+	Stages    []RecruitmentStageItemRequest `json:"stages" binding:"required"`
+}
+
+type RecruitmentStageItemRequest struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+// Candidate is applicant of one requisition. personal data is kept only in this table
+// and the cv directory, never in users or employees until the candidate is hired,
+// and is cleared by retention purge after the candidate is closed
+type Candidate struct {
+	ID            uint64     `json:"id" gorm:"primaryKey"`
+	RequisitionID uint64     `json:"requisition_id"`
+	CompanyID     uint64     `json:"company_id"`
+	StageID       uint64     `json:"stage_id"`
+	StageName     string     `json:"stage_name" gorm:"->"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	Email         string     `json:"email"`
+	PhoneNumber   string     `json:"phone_number"`
+	Source        string     `json:"source"`
+	CVPath        string     `json:"-" gorm:"column:cv_path"`
+	CVFileName    string     `json:"cv_file_name" gorm:"column:cv_file_name"`
+	Status        string     `json:"status"`
+	RejectReason  string     `json:"reject_reason"`
+	UserID        *uint64    `json:"user_id"`
+	EmployeeID    *uint64    `json:"employee_id"`
+	AverageScore  *float64   `json:"average_score" gorm:"->"`
+	ClosedAt      *time.Time `json:"closed_at"`
+	PurgedAt      *time.Time `json:"purged_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type CandidateRequest struct {
+	RequisitionID uint64 `json:"requisition_id" binding:"required"`
+	FirstName     string `json:"first_name" binding:"required"`
+	LastName      string `json:"last_name" binding:"required"`
+	Email         string `json:"email" binding:"required"`
+	PhoneNumber   string `json:"phone_number" binding:"required"`
+	Source        string `json:"source"`
+}
+
+type CandidateStageRequest struct {
+	StageID uint64 `json:"stage_id" binding:"required"`
+}
+
+type CandidateRejectRequest struct {
+	Reason string `json:"reason" binding:"required"`
+	// candidate withdraw by itself instead of rejected by company
+	Withdrawn bool `json:"withdrawn"`
+}
+
+// CandidateAcceptRequest is the data needed to create user and employee from candidate,
+// name, email, phone, company and position are taken from candidate and requisition
+type CandidateAcceptRequest struct {
+	Password       string  `json:"password" binding:"required"`
+	Role           string  `json:"role"`
+	EmployeeNumber string  `json:"employee_number" binding:"required"`
+	HireDate       Date    `json:"hire_date"`
+	DateOfBirth    *Date   `json:"date_of_birth"`
+	PtkpStatus     string  `json:"ptkp_status"`
+	TaxNumber      string  `json:"tax_number"`
+	ManagerID      *uint64 `json:"manager_id"`
+}
+
+// Interview of candidate, score and feedback are filled by the interviewer
+type Interview struct {
+	ID              uint64    `json:"id" gorm:"primaryKey"`
+	CandidateID     uint64    `json:"candidate_id"`
+	InterviewerID   uint64    `json:"interviewer_id"`
+	ScheduledAt     time.Time `json:"scheduled_at"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Location        string    `json:"location"`
+	Status          string    `json:"status"`
+	Score           *int      `json:"score"`
+	Feedback        string    `json:"feedback"`
+	CreatedBy       *uint64   `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type InterviewRequest struct {
+	InterviewerID   uint64    `json:"interviewer_id" binding:"required"`
+	ScheduledAt     time.Time `json:"scheduled_at" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Location        string    `json:"location"`
+}
+
+type InterviewFeedbackRequest struct {
+	Score    int    `json:"score" binding:"required"`
+	Feedback string `json:"feedback"`
+}
+
+func IsValidRequisitionStatus(status string) bool {
+	return status == REQUISITION_STATUS_OPEN ||
+		status == REQUISITION_STATUS_ON_HOLD ||
+		status == REQUISITION_STATUS_CLOSED
+}
+
+func (r RequisitionRequest) ValidateRequisition() error {
+	if r.Openings < 0 {
+		return errors.New("openings must be at least 1")
+	}
+	if !IsValidEmploymentType(r.EmploymentType) {
+		return errors.New("employment type must be permanent, contract or probation")
+	}
+	return nil
+}
+
+func (r RequisitionUpdateRequest) ValidateUpdate() error {
+	if r.Openings < 0 {
+		return errors.New("openings must be at least 1")
+	}
+	if r.Status != "" && !IsValidRequisitionStatus(r.Status) {
+		return errors.New("requisition status must be open, on_hold or closed")
+	}
+	return nil
+}
+
+func (r RecruitmentStagesRequest) ValidateStages() error {
+	if len(r.Stages) == 0 {
+		return errors.New("pipeline must have at least one stage")
+	}
+	names := map[string]bool{}
+	for _, stage := range r.Stages {
+		name := strings.ToLower(strings.TrimSpace(stage.Name))
+		if name == "" {
+			return errors.New("stage name cannot be empty")
+		}
+		if names[name] {
+			return errors.New("stage name must be unique")
+		}
+		names[name] = true
+	}
+	return nil
+}
+
+func (c CandidateRequest) ValidateCandidate() error {
+	if !helper.IsValidEmail(c.Email) {
+		return errors.New("invalid email format")
+	}
+	return nil
+}
+
+func (i InterviewRequest) ValidateInterview() error {
+	if i.DurationMinutes < 0 || i.DurationMinutes > 480 {
+		return errors.New("interview duration must be between 1 and 480 minutes")
+	}
+	return nil
+}
+
+func (i InterviewFeedbackRequest) ValidateFeedback() error {
+	if i.Score < INTERVIEW_SCORE_MIN || i.Score > INTERVIEW_SCORE_MAX {
+		return errors.New("interview score must be between 1 and 5")
+	}
+	return nil
+}
+
+// IsClosed report whether candidate left the pipeline
+func (c Candidate) IsClosed() bool {
+	return c.Status != CANDIDATE_STATUS_ACTIVE
+}
+
+// CandidateCVExtension return lower case extension of cv file name,
+// error when the file type is not accepted
+func CandidateCVExtension(fileName string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, allowed := range candidateCVExtensions {
+		if ext == allowed {
+			return ext, nil
+		}
+	}
+	return "", errors.New("cv must be a pdf, doc or docx file")
+}
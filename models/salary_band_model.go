@@ -0,0 +1,137 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// salary outside of band is saved with a warning
+	SALARY_BAND_ENFORCEMENT_WARN = "warn"
+	// salary outside of band is rejected unless an override reason is sent
+	SALARY_BAND_ENFORCEMENT_REJECT = "reject"
+)
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type SalaryBandsResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *[]SalaryBand `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+type SalaryBandResponse struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    *SalaryBand `json:"data"`
+	Error   bool        `json:"error"`
+}
+
+// SalaryBand is min, mid and max base salary of a position in one currency
+type SalaryBand struct {
+	ID          uint64    `json:"id" gorm:"primaryKey"`
+	PositionID  uint64    `json:"position_id"`
+	Currency    string    `json:"currency"`
+	MinSalary   float64   `json:"min_salary"`
+	MidSalary   float64   `json:"mid_salary"`
+	MaxSalary   float64   `json:"max_salary"`
+	Enforcement string    `json:"enforcement"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type SalaryBandRequest struct {
+	Currency    string  `json:"currency" binding:"required"`
+	MinSalary   float64 `json:"min_salary"`
+	MidSalary   float64 `json:"mid_salary" binding:"required"`
+	MaxSalary   float64 `json:"max_salary" binding:"required"`
+	Enforcement string  `json:"enforcement"`
+}
+
+// Contains report whether salary is inside of the band, both ends included
+func (b SalaryBand) Contains(salary float64) bool {
+	return salary >= b.MinSalary && salary <= b.MaxSalary
+}
+
+// CompaRatio is salary divided by midpoint of the band, 1 means paid at the midpoint
+func (b SalaryBand) CompaRatio(salary float64) float64 {
+	if b.MidSalary == 0 {
+		return 0
+	}
+	return RoundRatio(salary / b.MidSalary)
+}
+
+// NormalizeCurrency upper case currency and default to IDR when empty
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return DEFAULT_COMPANY_CURRENCY
+	}
+	return currency
+}
+
+func IsValidCurrency(currency string) bool {
+	return currencyPattern.MatchString(currency)
+}
+
+func (s SalaryBandRequest) ValidateSalaryBand() error {
+	if !IsValidCurrency(NormalizeCurrency(s.Currency)) {
+		return errors.New("currency must be a three letter ISO 4217 code")
+	}
+	if s.MinSalary < 0 {
+		return errors.New("min salary cannot be negative")
+	}
+	if s.MinSalary > s.MidSalary || s.MidSalary > s.MaxSalary {
+		return errors.New("salary band must satisfy min <= mid <= max")
+	}
+	if s.Enforcement != "" && s.Enforcement != SALARY_BAND_ENFORCEMENT_WARN && s.Enforcement != SALARY_BAND_ENFORCEMENT_REJECT {
+		return errors.New("band enforcement must be warn or reject")
+	}
+	return nil
+}
+
+type CompaRatioReportResponse struct {
+	Status  int                 `json:"status"`
+	Message string              `json:"message"`
+	Data    *[]CompaRatioReport `json:"data"`
+	Error   bool                `json:"error"`
+}
+
+// CompaRatioReport summarize base salary of employees in one position and currency
+// against the band, band is nil when the position has no band in that currency
+type CompaRatioReport struct {
+	PositionID        uint64               `json:"position_id"`
+	PositionName      string               `json:"position_name"`
+	PositionCode      string               `json:"position_code"`
+	JobGrade          string               `json:"job_grade"`
+	Currency          string               `json:"currency"`
+	Band              *SalaryBand          `json:"band"`
+	Headcount         int                  `json:"headcount"`
+	AverageSalary     float64              `json:"average_salary"`
+	AverageCompaRatio float64              `json:"average_compa_ratio"`
+	MinCompaRatio     float64              `json:"min_compa_ratio"`
+	MaxCompaRatio     float64              `json:"max_compa_ratio"`
+	BelowBand         int                  `json:"below_band"`
+	AboveBand         int                  `json:"above_band"`
+	Employees         []CompaRatioEmployee `json:"employees"`
+}
+
+type CompaRatioEmployee struct {
+	EmployeeID     uint64  `json:"employee_id"`
+	EmployeeNumber string  `json:"employee_number"`
+	EmployeeName   string  `json:"employee_name"`
+	PositionID     uint64  `json:"-"`
+	Currency       string  `json:"-"`
+	BaseSalary     float64 `json:"base_salary"`
+	CompaRatio     float64 `json:"compa_ratio"`
+	OverrideReason string  `json:"band_override_reason,omitempty" gorm:"column:band_override_reason"`
+}
+
+// RoundRatio round ratio to four decimal places
+func RoundRatio(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
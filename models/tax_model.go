@@ -0,0 +1,70 @@
+package models
+
+type TaxReconciliationsResponse struct {
+	Status  int                  `json:"status"`
+	Message string               `json:"message"`
+	Data    *[]TaxReconciliation `json:"data"`
+	Error   bool                 `json:"error"`
+}
+
+// TaxReconciliation compare yearly PPh 21 with tax withheld by payroll,
+// difference is zero when the final period is already run
+type TaxReconciliation struct {
+	EmployeeID          uint64  `json:"employee_id"`
+	EmployeeNumber      string  `json:"employee_number"`
+	EmployeeName        string  `json:"employee_name"`
+	PtkpStatus          string  `json:"ptkp_status"`
+	Year                int     `json:"year"`
+	Months              int     `json:"months"`
+	Gross               float64 `json:"gross"`
+	JobExpense          float64 `json:"job_expense"`
+	PensionContribution float64 `json:"pension_contribution"`
+	Net                 float64 `json:"net"`
+	PTKP                float64 `json:"ptkp"`
+	TaxableIncome       float64 `json:"taxable_income"`
+	AnnualTax           float64 `json:"annual_tax"`
+	TaxWithheld         float64 `json:"tax_withheld"`
+	Difference          float64 `json:"difference"`
+}
+
+type Form1721A1Response struct {
+	Status  int         `json:"status"`
+	Message string      `json:"message"`
+	Data    *Form1721A1 `json:"data"`
+	Error   bool        `json:"error"`
+}
+
+// Form1721A1 is bukti potong PPh 21 of permanent employee for one year,
+// json name follow the line number of the form
+type Form1721A1 struct {
+	Year              int    `json:"year"`
+	PeriodFrom        int    `json:"period_from"`
+	PeriodTo          int    `json:"period_to"`
+	EmployerName      string `json:"employer_name"`
+	EmployerTaxNumber string `json:"employer_tax_number"`
+	EmployeeNumber    string `json:"employee_number"`
+	EmployeeName      string `json:"employee_name"`
+	EmployeeTaxNumber string `json:"employee_tax_number"`
+	PtkpStatus        string `json:"ptkp_status"`
+
+	Salary              float64 `json:"line_01_salary"`
+	TaxAllowance        float64 `json:"line_02_tax_allowance"`
+	OtherAllowance      float64 `json:"line_03_other_allowance"`
+	Honorarium          float64 `json:"line_04_honorarium"`
+	InsurancePremium    float64 `json:"line_05_insurance_premium"`
+	Natura              float64 `json:"line_06_natura"`
+	BonusAndTHR         float64 `json:"line_07_bonus_and_thr"`
+	Gross               float64 `json:"line_08_gross"`
+	JobExpense          float64 `json:"line_09_job_expense"`
+	PensionContribution float64 `json:"line_10_pension_contribution"`
+	TotalDeduction      float64 `json:"line_11_total_deduction"`
+	Net                 float64 `json:"line_12_net"`
+	PreviousNet         float64 `json:"line_13_previous_net"`
+	AnnualNet           float64 `json:"line_14_annual_net"`
+	PTKP                float64 `json:"line_15_ptkp"`
+	TaxableIncome       float64 `json:"line_16_taxable_income"`
+	AnnualTax           float64 `json:"line_17_annual_tax"`
+	PreviousTax         float64 `json:"line_18_previous_tax"`
+	TaxDue              float64 `json:"line_19_tax_due"`
+	TaxWithheld         float64 `json:"line_20_tax_withheld"`
+}
@@ -8,6 +8,11 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	USER_ROLE_ADMIN = "admin"
+	USER_ROLE_USER  = "user"
+)
+
 type UsersResponse struct {
 	Status  int     `json:"status"`
 	Message string  `json:"message"`
@@ -33,6 +38,11 @@ type User struct {
 	PhoneNumber  string         `json:"phone_number"`
 	PositionName string         `json:"position_name"`
 	Company      string         `json:"company"`
+	PositionID   uint64         `json:"position_id" gorm:"->"`
+	CompanyID    uint64         `json:"company_id" gorm:"->"`
+	DepartmentID *uint64        `json:"department_id"`
+	ManagerID    *uint64        `json:"manager_id"`
+	OverPlan     bool           `json:"over_plan"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
@@ -48,6 +58,11 @@ type UserCreateRequest struct { // for validate password
 	PhoneNumber  string    `json:"phone_number" binding:"required"`
 	PositionName string    `json:"position_name" binding:"required"`
 	Company      string    `json:"company" binding:"required"`
+	PositionID   *uint64   `json:"position_id"`
+	CompanyID    uint64    `json:"company_id"`
+	DepartmentID *uint64   `json:"department_id"`
+	ManagerID    *uint64   `json:"manager_id"`
+	OverPlan     bool      `json:"-"` // set by headcount check, not by request
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
@@ -61,9 +76,33 @@ type UserEditRequest struct {
 	PhoneNumber  string    `json:"phone_number"`
 	PositionName string    `json:"position_name"`
 	Company      string    `json:"company"`
+	PositionID   *uint64   `json:"position_id"`
+	CompanyID    uint64    `json:"company_id"`
+	DepartmentID *uint64   `json:"department_id"`
+	ManagerID    *uint64   `json:"manager_id"`
+	OverPlan     bool      `json:"-"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+type UserReportsResponse struct {
+	Status  int           `json:"status"`
+	Message string        `json:"message"`
+	Data    *[]UserReport `json:"data"`
+	Error   bool          `json:"error"`
+}
+
+// direct or indirect report of a manager, depth 1 is direct report
+type UserReport struct {
+	ID           uint64  `json:"id"`
+	FirstName    string  `json:"first_name"`
+	LastName     string  `json:"last_name"`
+	Email        string  `json:"email"`
+	ManagerID    *uint64 `json:"manager_id"`
+	PositionID   *uint64 `json:"position_id"`
+	PositionName string  `json:"position_name"`
+	Depth        int     `json:"depth"`
+}
+
 type UserView struct {
 	ID       uint64    `json:"id"`
 	Username string    `json:"username" binding:"required"`
@@ -121,3 +160,12 @@ func (u UserEditRequest) ValidateUpdate() error {
 	}
 	return nil
 }
+
+// filter for list and export users
+type UserFilter struct {
+	Email        string `form:"email"`
+	Role         string `form:"role"`
+	PositionID   uint64 `form:"position_id"`
+	CompanyID    uint64 `form:"company_id"`
+	DepartmentID uint64 `form:"department_id"`
+}
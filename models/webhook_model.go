@@ -0,0 +1,197 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// domain event that is sent to webhook
+	WEBHOOK_EVENT_USER_CREATED     = EVENT_USER_CREATED
+	WEBHOOK_EVENT_USER_UPDATED     = EVENT_USER_UPDATED
+	WEBHOOK_EVENT_USER_DELETED     = EVENT_USER_DELETED
+	WEBHOOK_EVENT_COMPANY_CREATED  = EVENT_COMPANY_CREATED
+	WEBHOOK_EVENT_COMPANY_UPDATED  = EVENT_COMPANY_UPDATED
+	WEBHOOK_EVENT_COMPANY_DELETED  = EVENT_COMPANY_DELETED
+	WEBHOOK_EVENT_POSITION_CREATED = EVENT_POSITION_CREATED
+	WEBHOOK_EVENT_POSITION_UPDATED = EVENT_POSITION_UPDATED
+	WEBHOOK_EVENT_POSITION_DELETED = EVENT_POSITION_DELETED
+
+	// subscribe every event, including event added later
+	WEBHOOK_EVENT_ALL = "*"
+
+	WEBHOOK_DELIVERY_STATUS_PENDING = "pending"
+	WEBHOOK_DELIVERY_STATUS_SUCCESS = "success"
+	WEBHOOK_DELIVERY_STATUS_FAILED  = "failed"
+
+	// delivery is failed after this many attempts, retry wait is doubled every attempt
+	WEBHOOK_MAX_ATTEMPTS   = 8
+	WEBHOOK_RETRY_BASE     = 30 * time.Second
+	WEBHOOK_RETRY_MAX_WAIT = 6 * time.Hour
+	WEBHOOK_TIMEOUT        = 10 * time.Second
+
+	// only beginning of receiver response is kept in delivery log
+	WEBHOOK_RESPONSE_BODY_LIMIT = 2048
+
+	WEBHOOK_SIGNATURE_HEADER = "X-Webhook-Signature"
+	WEBHOOK_EVENT_HEADER     = "X-Webhook-Event"
+	WEBHOOK_DELIVERY_HEADER  = "X-Webhook-Delivery"
+	WEBHOOK_TIMESTAMP_HEADER = "X-Webhook-Timestamp"
+)
+
+var WebhookEvents = []string{
+	WEBHOOK_EVENT_USER_CREATED,
+	WEBHOOK_EVENT_USER_UPDATED,
+	WEBHOOK_EVENT_USER_DELETED,
+	WEBHOOK_EVENT_COMPANY_CREATED,
+	WEBHOOK_EVENT_COMPANY_UPDATED,
+	WEBHOOK_EVENT_COMPANY_DELETED,
+	WEBHOOK_EVENT_POSITION_CREATED,
+	WEBHOOK_EVENT_POSITION_UPDATED,
+	WEBHOOK_EVENT_POSITION_DELETED,
+}
+
+type WebhooksResponse struct {
+	Status  int        `json:"status"`
+	Message string     `json:"message"`
+	Data    *[]Webhook `json:"data"`
+	Error   bool       `json:"error"`
+}
+
+type WebhookResponse struct {
+	Status  int      `json:"status"`
+	Message string   `json:"message"`
+	Data    *Webhook `json:"data"`
+	Error   bool     `json:"error"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Status  int                `json:"status"`
+	Message string             `json:"message"`
+	Data    *[]WebhookDelivery `json:"data"`
+	Error   bool               `json:"error"`
+}
+
+type WebhookDeliveryResponse struct {
+	Status  int              `json:"status"`
+	Message string           `json:"message"`
+	Data    *WebhookDelivery `json:"data"`
+	Error   bool             `json:"error"`
+}
+
+type WebhookEventsResponse struct {
+	Status  int       `json:"status"`
+	Message string    `json:"message"`
+	Data    *[]string `json:"data"`
+	Error   bool      `json:"error"`
+}
+
+// Webhook is subscription of company, it also receive event of every subsidiary.
+// secret is only shown when webhook is created or the secret is rotated
+type Webhook struct {
+	ID        uint64         `json:"id" gorm:"primaryKey"`
+	CompanyID uint64         `json:"company_id"`
+	URL       string         `json:"url"`
+	Secret    string         `json:"secret,omitempty"`
+	Events    string         `json:"-"`
+	EventList []string       `json:"events" gorm:"-"`
+	Active    bool           `json:"active"`
+	CreatedBy uint64         `json:"created_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at"`
+}
+
+type WebhookRequest struct {
+	CompanyID uint64   `json:"company_id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Active    *bool    `json:"active"`
+}
+
+type WebhookFilter struct {
+	CompanyID uint64 `form:"company_id" binding:"required"`
+}
+
+// WebhookDelivery is one event sent to one webhook, the row is also the queue item
+type WebhookDelivery struct {
+	ID             uint64     `json:"id" gorm:"primaryKey"`
+	WebhookID      uint64     `json:"webhook_id"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body"`
+	ErrorReason    string     `json:"error_reason,omitempty"`
+	RedeliveryOf   *uint64    `json:"redelivery_of"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type WebhookDeliveryFilter struct {
+	Status string `form:"status"`
+	Event  string `form:"event"`
+	Limit  int    `form:"limit"`
+}
+
+// WebhookPayload is body sent to receiver, signature is computed over the raw body.
+// id is the domain event id, it is the same on every retry and redelivery
+type WebhookPayload struct {
+	ID         uint64          `json:"id"`
+	Event      string          `json:"event"`
+	CompanyID  *uint64         `json:"company_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func (r *WebhookRequest) ValidateWebhook() error {
+	if r.CompanyID == 0 {
+		return errors.New("company id cannot be empty")
+	}
+	target, err := url.Parse(strings.TrimSpace(r.URL))
+	if err != nil || target.Host == "" || (target.Scheme != "https" && target.Scheme != "http") {
+		return errors.New("webhook url must be a valid http or https url")
+	}
+	if len(r.Events) == 0 {
+		return errors.New("webhook must subscribe at least one event")
+	}
+	for _, event := range r.Events {
+		if !IsWebhookEvent(event) {
+			return errors.New("webhook event is not supported: " + event)
+		}
+	}
+	return nil
+}
+
+func IsWebhookEvent(event string) bool {
+	if event == WEBHOOK_EVENT_ALL {
+		return true
+	}
+	for _, known := range WebhookEvents {
+		if known == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookRetryAt return time of next attempt after the given failed attempts,
+// zero time when delivery should not be retried anymore
+func WebhookRetryAt(attempts int, now time.Time) time.Time {
+	if attempts >= WEBHOOK_MAX_ATTEMPTS {
+		return time.Time{}
+	}
+	wait := WEBHOOK_RETRY_BASE << (attempts - 1)
+	if wait > WEBHOOK_RETRY_MAX_WAIT || wait <= 0 {
+		wait = WEBHOOK_RETRY_MAX_WAIT
+	}
+	return now.Add(wait)
+}
@@ -0,0 +1,36 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type ndjsonWriter struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func newNDJSONWriter(w io.Writer) Writer {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+// ndjson has no header line, columns used as key of every object
+func (n *ndjsonWriter) WriteHeader(columns []string) error {
+	n.columns = columns
+	return nil
+}
+
+func (n *ndjsonWriter) WriteRow(values []any) error {
+	row := make(map[string]any, len(values))
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		row[n.columns[i]] = v
+	}
+	return n.enc.Encode(row)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Writer write export rows one by one, so big export never kept in memory
+type Writer interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []any) error
+	Close() error
+}
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case "csv":
+		return newCSVWriter(w), nil
+	case "xlsx":
+		return newXLSXWriter(w)
+	case "ndjson":
+		return newNDJSONWriter(w), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+func ContentType(format string) string {
+	switch format {
+	case "csv":
+		return "text/csv"
+	case "xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case "ndjson":
+		return "application/x-ndjson"
+	}
+	return "application/octet-stream"
+}
+
+// formatValue convert value from database into text for csv and xlsx
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case *time.Time:
+		if val == nil {
+			return ""
+		}
+		return val.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,148 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// worker that panic is started again after this delay
+const restartDelay = 5 * time.Second
+
+// Manager run background workers with one shared context, Shutdown cancel the
+// context and wait until every worker return
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// set from the first signal, before the server stop accepting request
+	draining atomic.Bool
+
+	mu      sync.Mutex
+	workers []*Status
+}
+
+// Status of one worker, Running is false when the worker return before shutdown
+type Status struct {
+	Name      string `json:"name"`
+	Running   bool   `json:"running"`
+	Restarts  int    `json:"restarts"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+func New() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ctx: ctx, cancel: cancel}
+}
+
+// Go start run in its own goroutine, run must return when ctx is done.
+// panic is logged and run is started again
+func (m *Manager) Go(name string, run func(ctx context.Context)) {
+	status := &Status{Name: name, Running: true}
+	m.mu.Lock()
+	m.workers = append(m.workers, status)
+	m.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for {
+			err := m.run(name, run)
+			if m.ctx.Err() != nil {
+				m.update(status, func(s *Status) { s.Running = false })
+				return
+			}
+			if err == nil {
+				log.Println("worker stopped", name)
+				m.update(status, func(s *Status) {
+					s.Running = false
+					s.LastError = "stopped before shutdown"
+				})
+				return
+			}
+
+			m.update(status, func(s *Status) {
+				s.Restarts++
+				s.LastError = err.Error()
+			})
+			select {
+			case <-m.ctx.Done():
+			case <-time.After(restartDelay):
+			}
+		}
+	}()
+}
+
+func (m *Manager) run(name string, run func(ctx context.Context)) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("worker %s panic, restarting in %s: %v\n%s", name, restartDelay, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	run(m.ctx)
+	return nil
+}
+
+func (m *Manager) update(status *Status, fn func(s *Status)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fn(status)
+}
+
+// Done is closed when shutdown start
+func (m *Manager) Done() <-chan struct{} {
+	return m.ctx.Done()
+}
+
+// Drain mark the process as shutting down, readiness fail from now on so the
+// load balancer stop sending new request before the server close
+func (m *Manager) Drain() {
+	m.draining.Store(true)
+}
+
+func (m *Manager) Draining() bool {
+	return m.draining.Load()
+}
+
+// Shutdown stop every worker, it return ctx error when worker is still running at the deadline
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.Drain()
+	m.cancel()
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("workers still running %v: %w", m.running(), ctx.Err())
+	}
+}
+
+// Workers return status of every worker in the order they are started
+func (m *Manager) Workers() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	workers := make([]Status, 0, len(m.workers))
+	for _, w := range m.workers {
+		workers = append(workers, *w)
+	}
+	return workers
+}
+
+func (m *Manager) running() []string {
+	names := []string{}
+	for _, w := range m.Workers() {
+		if w.Running {
+			names = append(names, w.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,74 @@
+// Package metrics hold the prometheus collectors of the application, they are
+// registered to the default registry next to the go runtime and process metrics
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	LOGIN_FAILURE_UNKNOWN_USER   = "unknown_user"
+	LOGIN_FAILURE_WRONG_PASSWORD = "wrong_password"
+	LOGIN_FAILURE_ERROR          = "error"
+)
+
+var (
+	// route is the gin route template like /api/v1/users/:id, raw path would make a series per id
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method and route template.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+	HTTPInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests being served.",
+	})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "GORM statement latency by operation and table.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "table"})
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "GORM statements that failed by operation and table, record not found is not an error.",
+	}, []string{"operation", "table"})
+
+	Logins = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "erp_logins_total",
+		Help: "Successful logins.",
+	})
+	LoginFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "erp_login_failures_total",
+		Help: "Failed logins by reason.",
+	}, []string{"reason"})
+	Signups = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "erp_signups_total",
+		Help: "Users signed up.",
+	})
+	// counted from the database write, so every service and worker is covered
+	EntityMutations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "erp_entity_mutations_total",
+		Help: "Rows created, updated or deleted by entity table and action.",
+	}, []string{"entity", "action"})
+)
+
+// RegisterDB expose connection pool gauges of db as go_sql_* labeled with name
+func RegisterDB(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serve every registered metric in prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
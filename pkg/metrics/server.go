@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Server serve /metrics on the admin port, apart from the api
+type Server struct {
+	listener net.Listener
+	srv      *http.Server
+}
+
+// Listen bind the admin port right away, so port in use fail the startup
+func Listen(addr string) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return &Server{
+		listener: listener,
+		srv: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+// Run serve until ctx is done, it is run by the lifecycle manager so metrics stay
+// available while the api drain
+func (s *Server) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("metrics listening on %s", s.listener.Addr())
+	if err := s.srv.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Println("metrics server stopped", err.Error())
+	}
+}
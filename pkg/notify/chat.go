@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const CHAT_ADAPTER_WEBHOOK = "webhook"
+
+// chat adapter is chosen by name, new adapter register itself here
+var chatAdapters = map[string]func() Sender{
+	CHAT_ADAPTER_WEBHOOK: func() Sender { return NewChatWebhookSender() },
+}
+
+// NewChatSender return adapter by name, nil when name is empty or unknown
+func NewChatSender(adapter string) Sender {
+	newAdapter, ok := chatAdapters[adapter]
+	if !ok {
+		return nil
+	}
+	return newAdapter()
+}
+
+func ChatAdapters() []string {
+	names := []string{}
+	for name := range chatAdapters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// chatWebhookSender post to incoming webhook address of the user, the payload
+// {"text": ...} is accepted by Slack, Mattermost and Rocket.Chat
+type chatWebhookSender struct {
+	client *http.Client
+}
+
+func NewChatWebhookSender() Sender {
+	return &chatWebhookSender{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (c *chatWebhookSender) Send(ctx context.Context, to string, msg Message) error {
+	body, err := json.Marshal(map[string]string{"text": "*" + msg.Title + "*\n" + msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, to, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("chat webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
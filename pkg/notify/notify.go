@@ -0,0 +1,17 @@
+package notify
+
+import (
+	"context"
+)
+
+// Message is rendered notification sent outside of the application
+type Message struct {
+	Title string
+	Body  string
+}
+
+// Sender deliver message to one address of its channel, email address for email
+// and channel or webhook address for chat adapter
+type Sender interface {
+	Send(ctx context.Context, to string, msg Message) error
+}
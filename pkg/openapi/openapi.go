@@ -0,0 +1,315 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Auth int
+
+const (
+	AuthNone Auth = iota
+	AuthBearer
+	AuthAdmin
+)
+
+const bearerScheme = "bearerAuth"
+
+// Param is parameter read directly with ctx.Query instead of bound from struct
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+func QueryParam(name, typ, description string) Param {
+	return Param{Name: name, Type: typ, Description: description}
+}
+
+// Operation document one gin route, Path is relative to the group given to Spec.Add
+// and written with gin syntax. Query, Body and Response are zero value of the model,
+// their schema is read from json, form and binding tag. Query can be []any when the
+// handler bind more than one struct from query string, OptionalBody is for handler
+// that only read the body when request has content
+type Operation struct {
+	Method       string
+	Path         string
+	Summary      string
+	Tag          string
+	Auth         Auth
+	Params       []Param
+	Query        any
+	Body         any
+	OptionalBody bool
+	Upload       string
+	Response     any
+	Status       int
+	Produces     string
+	Deprecated   bool
+}
+
+// Spec collect documented operation of every router and build openapi 3 document
+type Spec struct {
+	title      string
+	version    string
+	operations []Operation
+	documented map[string]bool
+	internal   map[string]bool
+	formats    map[reflect.Type]Schema
+}
+
+func New(title, version string) *Spec {
+	return &Spec{
+		title:      title,
+		version:    version,
+		documented: map[string]bool{},
+		internal:   map[string]bool{},
+		formats:    defaultFormats(),
+	}
+}
+
+// Format set schema of type that marshal itself, like date type of the application
+func (s *Spec) Format(model any, schema Schema) {
+	s.formats[reflect.TypeOf(model)] = schema
+}
+
+func (s *Spec) Add(basePath string, operations ...Operation) {
+	basePath = strings.TrimSuffix(basePath, "/")
+	tag := basePath[strings.LastIndex(basePath, "/")+1:]
+	for _, op := range operations {
+		op.Path = basePath + op.Path
+		if op.Tag == "" {
+			op.Tag = tag
+		}
+		if op.Status == 0 {
+			op.Status = http.StatusOK
+		}
+		s.operations = append(s.operations, op)
+		s.documented[routeKey(op.Method, op.Path)] = true
+	}
+}
+
+// Alias is deprecated path answered by another documented operation
+type Alias struct {
+	Method       string
+	Path         string
+	TargetMethod string
+	Target       string
+}
+
+// AddAliases document alias as deprecated copy of its target, alias whose target
+// is not documented is left out and reported by Check
+func (s *Spec) AddAliases(aliases ...Alias) {
+	targets := map[string]Operation{}
+	for _, op := range s.operations {
+		targets[routeKey(op.Method, op.Path)] = op
+	}
+	for _, alias := range aliases {
+		op, ok := targets[routeKey(alias.TargetMethod, alias.Target)]
+		if !ok {
+			continue
+		}
+		op.Method = alias.Method
+		op.Path = alias.Path
+		op.Tag = "legacy"
+		op.Summary += " (deprecated, use " + routeKey(alias.TargetMethod, alias.Target) + ")"
+		op.Deprecated = true
+		s.operations = append(s.operations, op)
+		s.documented[routeKey(op.Method, op.Path)] = true
+	}
+}
+
+// Check compare documented operation with route registered in gin, both route
+// without document and document without route are reported
+func (s *Spec) Check(routes gin.RoutesInfo) error {
+	registered := map[string]bool{}
+	undocumented := []string{}
+	for _, route := range routes {
+		key := routeKey(route.Method, route.Path)
+		registered[key] = true
+		if !s.documented[key] && !s.internal[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+	unregistered := []string{}
+	for key := range s.documented {
+		if !registered[key] {
+			unregistered = append(unregistered, key)
+		}
+	}
+	if len(undocumented) == 0 && len(unregistered) == 0 {
+		return nil
+	}
+
+	sort.Strings(undocumented)
+	sort.Strings(unregistered)
+	msg := []string{}
+	if len(undocumented) > 0 {
+		msg = append(msg, "routes without openapi document: "+strings.Join(undocumented, ", "))
+	}
+	if len(unregistered) > 0 {
+		msg = append(msg, "openapi document without route: "+strings.Join(unregistered, ", "))
+	}
+	return fmt.Errorf("%s", strings.Join(msg, "; "))
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+var ginParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Document build the openapi document, schema of model is generated once per type
+func (s *Spec) Document() map[string]any {
+	schemas := newSchemaRegistry(s.formats)
+	paths := map[string]map[string]any{}
+	for _, op := range s.operations {
+		path := ginParam.ReplaceAllString(op.Path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(op.Method)] = s.operation(op, schemas)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   s.title,
+			"version": s.version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				bearerScheme: map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+			},
+		},
+	}
+}
+
+func (s *Spec) operation(op Operation, schemas *schemaRegistry) map[string]any {
+	doc := map[string]any{
+		"tags":        []string{op.Tag},
+		"summary":     op.Summary,
+		"operationId": operationID(op),
+	}
+	if op.Deprecated {
+		doc["deprecated"] = true
+	}
+
+	parameters := []map[string]any{}
+	for _, match := range ginParam.FindAllStringSubmatch(op.Path, -1) {
+		parameters = append(parameters, map[string]any{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   pathParamSchema(match[1]),
+		})
+	}
+	queries, ok := op.Query.([]any)
+	if !ok && op.Query != nil {
+		queries = []any{op.Query}
+	}
+	for _, query := range queries {
+		parameters = append(parameters, schemas.queryParams(reflect.TypeOf(query))...)
+	}
+	for _, param := range op.Params {
+		parameters = append(parameters, map[string]any{
+			"name":        param.Name,
+			"in":          "query",
+			"required":    param.Required,
+			"description": param.Description,
+			"schema":      Schema{Type: param.Type},
+		})
+	}
+	if len(parameters) > 0 {
+		doc["parameters"] = parameters
+	}
+
+	if op.Body != nil {
+		doc["requestBody"] = map[string]any{
+			"required": !op.OptionalBody,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemas.schemaOf(reflect.TypeOf(op.Body))},
+			},
+		}
+	}
+	if op.Upload != "" {
+		doc["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"multipart/form-data": map[string]any{"schema": Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{op.Upload: {Type: "string", Format: "binary"}},
+					Required:   []string{op.Upload},
+				}},
+			},
+		}
+	}
+
+	responses := map[string]any{}
+	success := map[string]any{"description": http.StatusText(op.Status)}
+	content := map[string]any{}
+	// file response only use the model to answer error
+	if op.Produces != "" {
+		content[op.Produces] = map[string]any{"schema": Schema{Type: "string", Format: "binary"}}
+	} else if op.Response != nil {
+		content["application/json"] = map[string]any{"schema": schemas.schemaOf(reflect.TypeOf(op.Response))}
+	}
+	if len(content) > 0 {
+		success["content"] = content
+	}
+	responses[fmt.Sprint(op.Status)] = success
+	if op.Response != nil {
+		// handler answer error with the same response model, message explain the error
+		responses["default"] = map[string]any{
+			"description": "Error, message field explain the cause",
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": schemas.schemaOf(reflect.TypeOf(op.Response))},
+			},
+		}
+	}
+
+	if op.Auth != AuthNone {
+		doc["security"] = []map[string][]string{{bearerScheme: {}}}
+		responses["401"] = map[string]any{"description": "Missing or invalid bearer token"}
+	}
+	if op.Auth == AuthAdmin {
+		doc["description"] = "Admin only."
+		responses["403"] = map[string]any{"description": "Caller is not an admin"}
+	}
+	doc["responses"] = responses
+	return doc
+}
+
+func operationID(op Operation) string {
+	id := strings.ToLower(op.Method)
+	for _, part := range strings.Split(op.Path, "/") {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			part = "by_" + part[1:]
+		}
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '-' || r == '_' }) {
+			id += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return id
+}
+
+// id parameter is numeric, anything else like currency code is string
+func pathParamSchema(name string) Schema {
+	if name == "id" || strings.HasSuffix(name, "_id") {
+		return Schema{Type: "integer", Format: "int64"}
+	}
+	return Schema{Type: "string"}
+}
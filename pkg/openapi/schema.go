@@ -0,0 +1,181 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+func defaultFormats() map[reflect.Type]Schema {
+	return map[reflect.Type]Schema{
+		reflect.TypeOf(time.Time{}):         {Type: "string", Format: "date-time"},
+		reflect.TypeOf(gorm.DeletedAt{}):    {Type: "string", Format: "date-time", Nullable: true},
+		reflect.TypeOf(json.RawMessage{}):   {},
+		reflect.TypeOf((*any)(nil)).Elem():  {},
+		reflect.TypeOf(map[string]any(nil)): {Type: "object"},
+	}
+}
+
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// schemaRegistry turn go type into schema, named struct become component referenced by name
+type schemaRegistry struct {
+	formats    map[reflect.Type]Schema
+	components map[string]*Schema
+}
+
+func newSchemaRegistry(formats map[reflect.Type]Schema) *schemaRegistry {
+	return &schemaRegistry{formats: formats, components: map[string]*Schema{}}
+}
+
+func (r *schemaRegistry) schemaOf(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := r.baseSchema(t)
+	if nullable && schema.Ref == "" {
+		schema.Nullable = true
+	}
+	return schema
+}
+
+func (r *schemaRegistry) baseSchema(t reflect.Type) *Schema {
+	if format, ok := r.formats[t]; ok {
+		return &format
+	}
+	// type with own json encoding cannot be described from its field
+	if t.Implements(jsonMarshaler) || reflect.PointerTo(t).Implements(jsonMarshaler) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := r.components[name]; !ok {
+			// reserve the name first so recursive type like org chart terminate
+			r.components[name] = &Schema{}
+			*r.components[name] = *r.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(schema, t)
+	return schema
+}
+
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := fieldName(field, "json")
+		if !ok {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addFields(schema, embedded)
+				continue
+			}
+		}
+		schema.Properties[name] = r.schemaOf(field.Type)
+		if isRequired(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// queryParams describe struct bound with ShouldBindQuery as query parameters
+func (r *schemaRegistry) queryParams(t reflect.Type) []map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	params := []map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := fieldName(field, "form")
+		if !ok {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get("form") == "" && field.Type.Kind() == reflect.Struct {
+			params = append(params, r.queryParams(field.Type)...)
+			continue
+		}
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "query",
+			"required": isRequired(field),
+			"schema":   r.schemaOf(field.Type),
+		})
+	}
+	return params
+}
+
+// fieldName follow encoding rule of json and gin binding, unexported and "-" field is skipped
+func fieldName(field reflect.StructField, tag string) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+const (
+	SpecPath = "/openapi.json"
+	UIPath   = "/docs"
+)
+
+// swagger ui asset is served from the bundled dist, only the page is ours to point at the spec
+const uiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>API Documentation</title>
+  <link rel="stylesheet" type="text/css" href="` + UIPath + `/swagger-ui.css">
+  <link rel="icon" type="image/png" href="` + UIPath + `/favicon-32x32.png" sizes="32x32">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="` + UIPath + `/swagger-ui-bundle.js"></script>
+  <script src="` + UIPath + `/swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({
+      url: "` + SpecPath + `",
+      dom_id: "#swagger-ui",
+      deepLinking: true,
+      persistAuthorization: true,
+      presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+      layout: "StandaloneLayout"
+    });
+  </script>
+</body>
+</html>`
+
+// Mount serve the document at /openapi.json and swagger ui at /docs
+func Mount(g *gin.Engine, spec *Spec) {
+	spec.Add("", Operation{
+		Method:   http.MethodGet,
+		Path:     SpecPath,
+		Summary:  "OpenAPI document of this API",
+		Tag:      "docs",
+		Response: map[string]any{},
+	})
+	spec.internal[routeKey(http.MethodGet, UIPath+"/*filepath")] = true
+
+	g.GET(SpecPath, func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, spec.Document())
+	})
+
+	assets := http.StripPrefix(UIPath, http.FileServer(http.FS(swaggerFiles.FS)))
+	g.GET(UIPath+"/*filepath", func(ctx *gin.Context) {
+		switch ctx.Param("filepath") {
+		case "/", "/index.html":
+			ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(uiPage))
+		default:
+			assets.ServeHTTP(ctx.Writer, ctx.Request)
+		}
+	})
+}
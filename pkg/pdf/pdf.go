@@ -0,0 +1,147 @@
+// Package pdf write simple text only PDF documents, enough for payslip and
+// letters without pulling a PDF library. Only the standard Helvetica font is
+// used, so text must be latin-1.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// A4 in point
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Document struct {
+	pages []*bytes.Buffer
+}
+
+func New() *Document {
+	d := &Document{}
+	d.AddPage()
+	return d
+}
+
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// Text write s on the current page, x and y are from the bottom left corner
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.current(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escape(s))
+}
+
+// TextRight write s so it ends on x
+func (d *Document) TextRight(x, y, size float64, bold bool, s string) {
+	d.Text(x-textWidth(s, size), y, size, bold, s)
+}
+
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.current(), "%.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+func (d *Document) current() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+// Write output the document. Object 1 is catalog, 2 is page tree, 3 and 4 are
+// fonts, then every page take two object for the page and its content.
+func (d *Document) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	offsets := []int{}
+	written := 0
+	writeObject := func(body string) error {
+		offsets = append(offsets, written)
+		n, err := fmt.Fprintf(bw, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+		written += n
+		return err
+	}
+
+	n, err := bw.WriteString("%PDF-1.4\n")
+	if err != nil {
+		return err
+	}
+	written += n
+
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+	for i, page := range d.pages {
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", PageWidth, PageHeight, 6+i*2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()),
+		)
+	}
+	for _, object := range objects {
+		if err := writeObject(object); err != nil {
+			return err
+		}
+	}
+
+	xref := written
+	if _, err := fmt.Fprintf(bw, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1); err != nil {
+		return err
+	}
+	for _, offset := range offsets {
+		if _, err := fmt.Fprintf(bw, "%010d 00000 n \n", offset); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(bw, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// escape quote the string and convert it to latin-1, other rune become "?"
+func escape(s string) string {
+	b := strings.Builder{}
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\r' || r == '\n':
+			b.WriteByte(' ')
+		case r < 256:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// textWidth is an estimate for Helvetica, digits are exact
+func textWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			width += 556
+		case c == '.' || c == ',' || c == ' ':
+			width += 278
+		case c >= 'A' && c <= 'Z':
+			width += 667
+		default:
+			width += 556
+		}
+	}
+	return width * size / 1000
+}
@@ -0,0 +1,83 @@
+package statutory
+
+const (
+	BPJS_KES_EMPLOYEE_RATE = "bpjs_kes_employee_rate"
+	BPJS_KES_EMPLOYER_RATE = "bpjs_kes_employer_rate"
+	BPJS_KES_WAGE_CAP      = "bpjs_kes_wage_cap"
+
+	BPJS_JHT_EMPLOYEE_RATE = "bpjs_jht_employee_rate"
+	BPJS_JHT_EMPLOYER_RATE = "bpjs_jht_employer_rate"
+	BPJS_JP_EMPLOYEE_RATE  = "bpjs_jp_employee_rate"
+	BPJS_JP_EMPLOYER_RATE  = "bpjs_jp_employer_rate"
+	BPJS_JP_WAGE_CAP       = "bpjs_jp_wage_cap"
+	BPJS_JKK_EMPLOYER_RATE = "bpjs_jkk_employer_rate"
+	BPJS_JKM_EMPLOYER_RATE = "bpjs_jkm_employer_rate"
+)
+
+// BPJSKesehatan is health insurance, shared by employee and employer up to the wage cap.
+// employer part is taxable income of employee
+type BPJSKesehatan struct{}
+
+func (BPJSKesehatan) Code() string { return "bpjs_kesehatan" }
+
+func (BPJSKesehatan) Apply(in Input, rates RateSet, result *Result) error {
+	params, err := params(rates, BPJS_KES_EMPLOYEE_RATE, BPJS_KES_EMPLOYER_RATE, BPJS_KES_WAGE_CAP)
+	if err != nil {
+		return err
+	}
+	wage := capped(in.ContributionWage, params[BPJS_KES_WAGE_CAP])
+
+	employee := rupiah(wage * params[BPJS_KES_EMPLOYEE_RATE] / 100)
+	employer := rupiah(wage * params[BPJS_KES_EMPLOYER_RATE] / 100)
+	result.add("bpjs_kes_employee", "BPJS Kesehatan", KIND_EMPLOYEE, employee)
+	result.add("bpjs_kes_employer", "BPJS Kesehatan (employer)", KIND_EMPLOYER, employer)
+	result.TaxableIncome += employer
+	return nil
+}
+
+// BPJSKetenagakerjaan is JHT (old age saving), JP (pension, capped), JKK (work accident)
+// and JKM (death). JKK and JKM employer premium is taxable income, JHT and JP paid by
+// employee reduce yearly taxable income
+type BPJSKetenagakerjaan struct{}
+
+func (BPJSKetenagakerjaan) Code() string { return "bpjs_ketenagakerjaan" }
+
+func (BPJSKetenagakerjaan) Apply(in Input, rates RateSet, result *Result) error {
+	params, err := params(rates,
+		BPJS_JHT_EMPLOYEE_RATE, BPJS_JHT_EMPLOYER_RATE,
+		BPJS_JP_EMPLOYEE_RATE, BPJS_JP_EMPLOYER_RATE, BPJS_JP_WAGE_CAP,
+		BPJS_JKK_EMPLOYER_RATE, BPJS_JKM_EMPLOYER_RATE)
+	if err != nil {
+		return err
+	}
+	wage := in.ContributionWage
+	pensionWage := capped(wage, params[BPJS_JP_WAGE_CAP])
+
+	jhtEmployee := rupiah(wage * params[BPJS_JHT_EMPLOYEE_RATE] / 100)
+	jpEmployee := rupiah(pensionWage * params[BPJS_JP_EMPLOYEE_RATE] / 100)
+	jkk := rupiah(wage * params[BPJS_JKK_EMPLOYER_RATE] / 100)
+	jkm := rupiah(wage * params[BPJS_JKM_EMPLOYER_RATE] / 100)
+
+	result.add("bpjs_jht_employee", "BPJS JHT", KIND_EMPLOYEE, jhtEmployee)
+	result.add("bpjs_jp_employee", "BPJS JP", KIND_EMPLOYEE, jpEmployee)
+	result.add("bpjs_jht_employer", "BPJS JHT (employer)", KIND_EMPLOYER, rupiah(wage*params[BPJS_JHT_EMPLOYER_RATE]/100))
+	result.add("bpjs_jp_employer", "BPJS JP (employer)", KIND_EMPLOYER, rupiah(pensionWage*params[BPJS_JP_EMPLOYER_RATE]/100))
+	result.add("bpjs_jkk_employer", "BPJS JKK (employer)", KIND_EMPLOYER, jkk)
+	result.add("bpjs_jkm_employer", "BPJS JKM (employer)", KIND_EMPLOYER, jkm)
+
+	result.TaxableIncome += jkk + jkm
+	result.PensionContribution += jhtEmployee + jpEmployee
+	return nil
+}
+
+func params(rates RateSet, codes ...string) (map[string]float64, error) {
+	values := make(map[string]float64, len(codes))
+	for _, code := range codes {
+		value, err := rates.Param(code)
+		if err != nil {
+			return nil, err
+		}
+		values[code] = value
+	}
+	return values, nil
+}
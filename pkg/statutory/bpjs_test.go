@@ -0,0 +1,86 @@
+package statutory_test
+
+import (
+	"testing"
+
+	"github.com/geedotrar/erp-api/pkg/statutory"
+)
+
+func TestBPJSKesehatanWageCap(t *testing.T) {
+	rates := seedRates(t, "2024-01-01")
+
+	tests := []struct {
+		name         string
+		wage         float64
+		wantEmployee float64
+		wantEmployer float64
+	}{
+		{name: "below cap", wage: 10000000, wantEmployee: 100000, wantEmployer: 400000},
+		{name: "on cap", wage: 12000000, wantEmployee: 120000, wantEmployer: 480000},
+		{name: "above cap", wage: 15000000, wantEmployee: 120000, wantEmployer: 480000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := statutory.NewEngine(statutory.BPJSKesehatan{}).Calculate(statutory.Input{
+				Period:           january2024,
+				ContributionWage: tt.wage,
+				TaxableGross:     tt.wage,
+			}, rates)
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+			if got := amountOf(result, "bpjs_kes_employee"); got != tt.wantEmployee {
+				t.Errorf("employee = %v, want %v", got, tt.wantEmployee)
+			}
+			if got := amountOf(result, "bpjs_kes_employer"); got != tt.wantEmployer {
+				t.Errorf("employer = %v, want %v", got, tt.wantEmployer)
+			}
+			// employer premium is taxable income of employee
+			if result.TaxableIncome != tt.wage+tt.wantEmployer {
+				t.Errorf("TaxableIncome = %v, want %v", result.TaxableIncome, tt.wage+tt.wantEmployer)
+			}
+		})
+	}
+}
+
+// JP wage cap change every march, JHT, JKK and JKM are not capped
+func TestBPJSKetenagakerjaanWageCap(t *testing.T) {
+	tests := []struct {
+		name            string
+		asOf            string
+		wage            float64
+		wantJPEmployee  float64
+		wantJPEmployer  float64
+		wantJHTEmployee float64
+	}{
+		{name: "below cap", asOf: "2024-03-01", wage: 5000000, wantJPEmployee: 50000, wantJPEmployer: 100000, wantJHTEmployee: 100000},
+		{name: "cap of 2023 until february", asOf: "2024-02-01", wage: 15000000, wantJPEmployee: 95596, wantJPEmployer: 191192, wantJHTEmployee: 300000},
+		{name: "cap of 2024 from march", asOf: "2024-03-01", wage: 15000000, wantJPEmployee: 100423, wantJPEmployer: 200846, wantJHTEmployee: 300000},
+		{name: "cap of 2025 from march", asOf: "2025-03-01", wage: 15000000, wantJPEmployee: 105474, wantJPEmployer: 210948, wantJHTEmployee: 300000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := statutory.NewEngine(statutory.BPJSKetenagakerjaan{}).Calculate(statutory.Input{
+				Period:           january2024,
+				ContributionWage: tt.wage,
+				TaxableGross:     tt.wage,
+			}, seedRates(t, tt.asOf))
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+			if got := amountOf(result, "bpjs_jp_employee"); got != tt.wantJPEmployee {
+				t.Errorf("JP employee = %v, want %v", got, tt.wantJPEmployee)
+			}
+			if got := amountOf(result, "bpjs_jp_employer"); got != tt.wantJPEmployer {
+				t.Errorf("JP employer = %v, want %v", got, tt.wantJPEmployer)
+			}
+			if got := amountOf(result, "bpjs_jht_employee"); got != tt.wantJHTEmployee {
+				t.Errorf("JHT employee = %v, want %v", got, tt.wantJHTEmployee)
+			}
+			// JHT and JP paid by employee reduce yearly taxable income
+			if want := tt.wantJHTEmployee + tt.wantJPEmployee; result.PensionContribution != want {
+				t.Errorf("PensionContribution = %v, want %v", result.PensionContribution, want)
+			}
+		})
+	}
+}
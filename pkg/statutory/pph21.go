@@ -0,0 +1,108 @@
+package statutory
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	PPH21_JOB_EXPENSE_RATE       = "pph21_job_expense_rate"
+	PPH21_JOB_EXPENSE_ANNUAL_CAP = "pph21_job_expense_annual_cap"
+
+	// TER table is "pph21_ter_" plus lower case category, e.g. pph21_ter_a
+	PPH21_TER_TABLE_PREFIX = "pph21_ter_"
+	// yearly progressive rate of pasal 17
+	PPH21_PASAL17_TABLE = "pph21_pasal17"
+)
+
+// PPh21 withhold monthly tax with TER (tarif efektif rata-rata) on gross income.
+// On the final period the tax of the whole year is calculated with pasal 17 rate,
+// and the tax of this period is the yearly tax minus what is already withheld
+type PPh21 struct{}
+
+func (PPh21) Code() string { return "pph21" }
+
+func (PPh21) Apply(in Input, rates RateSet, result *Result) error {
+	if in.FinalPeriod {
+		annual, err := AnnualTax(rates, AnnualInput{
+			PTKPStatus:          in.PTKPStatus,
+			Gross:               in.YearToDate.TaxableIncome + result.TaxableIncome,
+			PensionContribution: in.YearToDate.PensionContribution + result.PensionContribution,
+			Months:              in.YearToDate.Months + 1,
+		})
+		if err != nil {
+			return err
+		}
+		// negative tax is over withholding returned to employee
+		result.Tax = annual.Tax - in.YearToDate.TaxWithheld
+		result.add("pph21", "PPh 21", KIND_TAX, result.Tax)
+		return nil
+	}
+
+	ptkp, err := rates.PTKPOf(in.PTKPStatus)
+	if err != nil {
+		return err
+	}
+	rate, err := rates.FlatRate(PPH21_TER_TABLE_PREFIX+strings.ToLower(ptkp.TERCategory), result.TaxableIncome)
+	if err != nil {
+		return err
+	}
+	result.Tax = math.Floor(result.TaxableIncome * rate / 100)
+	result.add("pph21", "PPh 21", KIND_TAX, result.Tax)
+	return nil
+}
+
+type AnnualInput struct {
+	PTKPStatus          string
+	Gross               float64
+	PensionContribution float64
+	// months worked in the year, job expense cap is per month
+	Months int
+}
+
+// AnnualResult follow the lines of form 1721-A1
+type AnnualResult struct {
+	Gross               float64
+	JobExpense          float64
+	PensionContribution float64
+	Net                 float64
+	PTKP                float64
+	TaxableIncome       float64
+	Tax                 float64
+}
+
+// AnnualTax calculate yearly PPh 21: gross minus job expense and pension is net income,
+// net minus PTKP rounded down to thousand is taxable, then pasal 17 rate is applied
+func AnnualTax(rates RateSet, in AnnualInput) (AnnualResult, error) {
+	ptkp, err := rates.PTKPOf(in.PTKPStatus)
+	if err != nil {
+		return AnnualResult{}, err
+	}
+	params, err := params(rates, PPH21_JOB_EXPENSE_RATE, PPH21_JOB_EXPENSE_ANNUAL_CAP)
+	if err != nil {
+		return AnnualResult{}, err
+	}
+
+	months := in.Months
+	if months < 1 || months > 12 {
+		months = 12
+	}
+	jobExpenseCap := params[PPH21_JOB_EXPENSE_ANNUAL_CAP] * float64(months) / 12
+	jobExpense := math.Min(in.Gross*params[PPH21_JOB_EXPENSE_RATE]/100, jobExpenseCap)
+
+	result := AnnualResult{
+		Gross:               in.Gross,
+		JobExpense:          rupiah(jobExpense),
+		PensionContribution: in.PensionContribution,
+		PTKP:                ptkp.Amount,
+	}
+	result.Net = result.Gross - result.JobExpense - result.PensionContribution
+	result.TaxableIncome = math.Max(0, math.Floor((result.Net-result.PTKP)/1000)*1000)
+
+	tax, err := rates.Progressive(PPH21_PASAL17_TABLE, result.TaxableIncome)
+	if err != nil {
+		return AnnualResult{}, err
+	}
+	result.Tax = math.Floor(tax)
+	return result, nil
+}
@@ -0,0 +1,195 @@
+package statutory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geedotrar/erp-api/pkg/statutory"
+)
+
+var january2024 = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// TER example of PMK 168/2023: salary 10.000.000, employer pay BPJS Kesehatan 4%,
+// JKK 0,24% and JKM 0,3%, so monthly gross is 10.454.000
+func TestTERMonthlyWithholding(t *testing.T) {
+	rates := seedRates(t, "2024-01-01")
+
+	tests := []struct {
+		name        string
+		ptkpStatus  string
+		wage        float64
+		wantTaxable float64
+		wantTax     float64
+		withBenefit bool
+	}{
+		{name: "TER A, TK/0", ptkpStatus: "TK/0", wage: 10000000, wantTaxable: 10454000, wantTax: 261350, withBenefit: true},
+		{name: "TER A, K/0", ptkpStatus: "K/0", wage: 10000000, wantTaxable: 10454000, wantTax: 261350, withBenefit: true},
+		{name: "TER B, K/1", ptkpStatus: "K/1", wage: 10000000, wantTaxable: 10454000, wantTax: 156810, withBenefit: true},
+		{name: "TER C, K/3", ptkpStatus: "K/3", wage: 10000000, wantTaxable: 10454000, wantTax: 156810, withBenefit: true},
+		// bracket lower bound is exclusive, income equal to it stay in the bracket below
+		{name: "TER A upper bound of 0%", ptkpStatus: "TK/0", wage: 5400000, wantTaxable: 5400000, wantTax: 0},
+		{name: "TER A first rate", ptkpStatus: "TK/0", wage: 5400001, wantTaxable: 5400001, wantTax: 13500},
+		{name: "TER B upper bound of 0%", ptkpStatus: "TK/2", wage: 6200000, wantTaxable: 6200000, wantTax: 0},
+		{name: "TER C first rate", ptkpStatus: "K/3", wage: 6600001, wantTaxable: 6600001, wantTax: 16500},
+		{name: "TER A top rate", ptkpStatus: "TK/0", wage: 1500000000, wantTaxable: 1500000000, wantTax: 510000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := statutory.NewEngine(statutory.PPh21{})
+			if tt.withBenefit {
+				engine = statutory.Indonesia()
+			}
+			result, err := engine.Calculate(statutory.Input{
+				Period:           january2024,
+				PTKPStatus:       tt.ptkpStatus,
+				ContributionWage: tt.wage,
+				TaxableGross:     tt.wage,
+			}, rates)
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+			if result.TaxableIncome != tt.wantTaxable {
+				t.Errorf("TaxableIncome = %v, want %v", result.TaxableIncome, tt.wantTaxable)
+			}
+			if result.Tax != tt.wantTax {
+				t.Errorf("Tax = %v, want %v", result.Tax, tt.wantTax)
+			}
+			if amountOf(result, "pph21") != tt.wantTax {
+				t.Errorf("pph21 line = %v, want %v", amountOf(result, "pph21"), tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestAnnualTax(t *testing.T) {
+	rates := seedRates(t, "2024-12-01")
+
+	tests := []struct {
+		name        string
+		in          statutory.AnnualInput
+		wantJob     float64
+		wantTaxable float64
+		wantTax     float64
+	}{
+		{
+			name:        "first bracket only",
+			in:          statutory.AnnualInput{PTKPStatus: "TK/0", Gross: 120000000, Months: 12},
+			wantJob:     6000000,
+			wantTaxable: 60000000,
+			wantTax:     3000000,
+		},
+		{
+			name:        "pension reduce net income",
+			in:          statutory.AnnualInput{PTKPStatus: "TK/0", Gross: 120000000, PensionContribution: 3600000, Months: 12},
+			wantJob:     6000000,
+			wantTaxable: 56400000,
+			wantTax:     2820000,
+		},
+		{
+			name:        "taxable income rounded down to thousand",
+			in:          statutory.AnnualInput{PTKPStatus: "TK/0", Gross: 120000000, PensionContribution: 1234567, Months: 12},
+			wantJob:     6000000,
+			wantTaxable: 58765000,
+			wantTax:     2938250,
+		},
+		{
+			name:        "every bracket up to 30%",
+			in:          statutory.AnnualInput{PTKPStatus: "TK/0", Gross: 600000000, Months: 12},
+			wantJob:     6000000,
+			wantTaxable: 540000000,
+			wantTax:     106000000,
+		},
+		{
+			name:        "job expense cap follow months worked",
+			in:          statutory.AnnualInput{PTKPStatus: "TK/0", Gross: 60000000, Months: 6},
+			wantJob:     3000000,
+			wantTaxable: 3000000,
+			wantTax:     150000,
+		},
+		{
+			name:        "job expense below cap",
+			in:          statutory.AnnualInput{PTKPStatus: "K/1", Gross: 80000000, Months: 12},
+			wantJob:     4000000,
+			wantTaxable: 13000000,
+			wantTax:     650000,
+		},
+		{
+			name:        "income below PTKP",
+			in:          statutory.AnnualInput{PTKPStatus: "K/3", Gross: 70000000, Months: 12},
+			wantJob:     3500000,
+			wantTaxable: 0,
+			wantTax:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annual, err := statutory.AnnualTax(rates, tt.in)
+			if err != nil {
+				t.Fatalf("AnnualTax() error = %v", err)
+			}
+			if annual.JobExpense != tt.wantJob {
+				t.Errorf("JobExpense = %v, want %v", annual.JobExpense, tt.wantJob)
+			}
+			if annual.TaxableIncome != tt.wantTaxable {
+				t.Errorf("TaxableIncome = %v, want %v", annual.TaxableIncome, tt.wantTaxable)
+			}
+			if annual.Tax != tt.wantTax {
+				t.Errorf("Tax = %v, want %v", annual.Tax, tt.wantTax)
+			}
+		})
+	}
+}
+
+// final period reconcile the tax of the whole year with pasal 17
+func TestFinalPeriodTrueUp(t *testing.T) {
+	rates := seedRates(t, "2024-12-01")
+
+	tests := []struct {
+		name       string
+		period     time.Time
+		gross      float64
+		yearToDate statutory.YearToDate
+		wantTax    float64
+	}{
+		{
+			// 11 month of TER A 2% on 10.000.000, yearly tax is 3.000.000
+			name:       "december pay the rest of yearly tax",
+			period:     time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+			gross:      10000000,
+			yearToDate: statutory.YearToDate{TaxableIncome: 110000000, TaxWithheld: 2200000, Months: 11},
+			wantTax:    800000,
+		},
+		{
+			name:       "over withholding is returned",
+			period:     time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC),
+			gross:      10000000,
+			yearToDate: statutory.YearToDate{TaxableIncome: 110000000, TaxWithheld: 3500000, Months: 11},
+			wantTax:    -500000,
+		},
+		{
+			// terminated in june, job expense cap is 6 month
+			name:       "termination month",
+			period:     time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+			gross:      10000000,
+			yearToDate: statutory.YearToDate{TaxableIncome: 50000000, TaxWithheld: 1000000, Months: 5},
+			wantTax:    -850000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := statutory.NewEngine(statutory.PPh21{}).Calculate(statutory.Input{
+				Period:       tt.period,
+				PTKPStatus:   "TK/0",
+				TaxableGross: tt.gross,
+				FinalPeriod:  true,
+				YearToDate:   tt.yearToDate,
+			}, rates)
+			if err != nil {
+				t.Fatalf("Calculate() error = %v", err)
+			}
+			if result.Tax != tt.wantTax {
+				t.Errorf("Tax = %v, want %v", result.Tax, tt.wantTax)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package statutory_test
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/geedotrar/erp-api/pkg/statutory"
+)
+
+// rows of the statutory seed, tests run against the same rates as the database
+var (
+	parameterRow = regexp.MustCompile(`\('([a-z0-9_]+)', ([0-9.]+), '(\d{4}-\d{2}-\d{2})', '[^']*'\)`)
+	bracketRow   = regexp.MustCompile(`\('([a-z0-9_]+)', ([0-9.]+), ([0-9.]+), '(\d{4}-\d{2}-\d{2})'\)`)
+	ptkpRow      = regexp.MustCompile(`\('([A-Z]+/[0-9])', ([0-9.]+), '([A-C])', '(\d{4}-\d{2}-\d{2})'\)`)
+)
+
+// seedRates load the rate set valid on asOf (YYYY-MM-DD) from 010_statutory.sql,
+// picking the latest valid_from like the statutory repository does
+func seedRates(t *testing.T, asOf string) statutory.RateSet {
+	t.Helper()
+	seed, err := os.ReadFile("../../database/migration/010_statutory.sql")
+	if err != nil {
+		t.Fatalf("cannot read statutory seed: %v", err)
+	}
+	sql := string(seed)
+
+	rates := statutory.RateSet{
+		Parameters: map[string]float64{},
+		Brackets:   map[string][]statutory.Bracket{},
+		PTKP:       map[string]statutory.PTKP{},
+	}
+
+	parameterFrom := map[string]string{}
+	for _, row := range parameterRow.FindAllStringSubmatch(sql, -1) {
+		code, from := row[1], row[3]
+		if from > asOf || from < parameterFrom[code] {
+			continue
+		}
+		parameterFrom[code] = from
+		rates.Parameters[code] = number(t, row[2])
+	}
+
+	tableFrom := map[string]string{}
+	for _, row := range bracketRow.FindAllStringSubmatch(sql, -1) {
+		table, from := row[1], row[4]
+		if from > asOf || from < tableFrom[table] {
+			continue
+		}
+		if from > tableFrom[table] {
+			tableFrom[table] = from
+			rates.Brackets[table] = nil
+		}
+		rates.Brackets[table] = append(rates.Brackets[table], statutory.Bracket{From: number(t, row[2]), Rate: number(t, row[3])})
+	}
+	for table := range rates.Brackets {
+		brackets := rates.Brackets[table]
+		sort.Slice(brackets, func(i, j int) bool { return brackets[i].From < brackets[j].From })
+	}
+
+	ptkpFrom := map[string]string{}
+	for _, row := range ptkpRow.FindAllStringSubmatch(sql, -1) {
+		status, from := row[1], row[4]
+		if from > asOf || from < ptkpFrom[status] {
+			continue
+		}
+		ptkpFrom[status] = from
+		rates.PTKP[status] = statutory.PTKP{Status: status, Amount: number(t, row[2]), TERCategory: row[3]}
+	}
+	return rates
+}
+
+func number(t *testing.T, value string) float64 {
+	t.Helper()
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		t.Fatalf("invalid number %q in statutory seed: %v", value, err)
+	}
+	return n
+}
+
+func amountOf(result statutory.Result, code string) float64 {
+	for _, line := range result.Lines {
+		if line.Code == code {
+			return line.Amount
+		}
+	}
+	return 0
+}
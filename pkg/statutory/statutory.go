@@ -0,0 +1,204 @@
+// Package statutory calculate government contribution and tax of payroll.
+// Rules only hold the formula, every rate, cap and bracket come from RateSet,
+// which is loaded from effective dated data for the payroll period.
+package statutory
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// line paid by employee, deducted from net pay
+	KIND_EMPLOYEE = "employee"
+	// line paid by employer on top of salary, net pay is not changed
+	KIND_EMPLOYER = "employer"
+	// tax withheld from employee
+	KIND_TAX = "tax"
+)
+
+// SupportedFrom is the first payroll period that can be calculated. monthly PPh 21 is
+// withheld with TER (PP 58/2023) from january 2024, the seeded PTKP and TER rates start
+// there and older period used a different method
+var SupportedFrom = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// CheckPeriod return error when period is before SupportedFrom
+func CheckPeriod(period time.Time) error {
+	if period.Year() < SupportedFrom.Year() ||
+		(period.Year() == SupportedFrom.Year() && period.Month() < SupportedFrom.Month()) {
+		return fmt.Errorf("statutory calculation is supported from period %s", SupportedFrom.Format("2006-01"))
+	}
+	return nil
+}
+
+// Bracket apply Rate (percent) to income above From, the next bracket
+// start where this one ends
+type Bracket struct {
+	From float64
+	Rate float64
+}
+
+// PTKP is yearly non taxable income of a tax status and its TER category
+type PTKP struct {
+	Status      string
+	Amount      float64
+	TERCategory string
+}
+
+// RateSet is every rate valid on one date
+type RateSet struct {
+	Parameters map[string]float64
+	// sorted by From ascending
+	Brackets map[string][]Bracket
+	PTKP     map[string]PTKP
+}
+
+func (r RateSet) Param(code string) (float64, error) {
+	value, ok := r.Parameters[code]
+	if !ok {
+		return 0, fmt.Errorf("statutory parameter %s is not defined", code)
+	}
+	return value, nil
+}
+
+// FlatRate return rate of the bracket where income falls in
+func (r RateSet) FlatRate(table string, income float64) (float64, error) {
+	brackets, err := r.brackets(table)
+	if err != nil {
+		return 0, err
+	}
+	i := sort.Search(len(brackets), func(i int) bool { return brackets[i].From >= income })
+	if i == 0 {
+		return 0, nil
+	}
+	return brackets[i-1].Rate, nil
+}
+
+// Progressive apply each bracket rate only to the part of income inside the bracket
+func (r RateSet) Progressive(table string, income float64) (float64, error) {
+	brackets, err := r.brackets(table)
+	if err != nil {
+		return 0, err
+	}
+	tax := 0.0
+	for i, bracket := range brackets {
+		if income <= bracket.From {
+			break
+		}
+		upper := income
+		if i+1 < len(brackets) && brackets[i+1].From < income {
+			upper = brackets[i+1].From
+		}
+		tax += (upper - bracket.From) * bracket.Rate / 100
+	}
+	return tax, nil
+}
+
+func (r RateSet) brackets(table string) ([]Bracket, error) {
+	brackets, ok := r.Brackets[table]
+	if !ok || len(brackets) == 0 {
+		return nil, fmt.Errorf("statutory table %s is not defined", table)
+	}
+	return brackets, nil
+}
+
+func (r RateSet) PTKPOf(status string) (PTKP, error) {
+	ptkp, ok := r.PTKP[status]
+	if !ok {
+		return PTKP{}, fmt.Errorf("ptkp status %s is not defined", status)
+	}
+	return ptkp, nil
+}
+
+// YearToDate is the total of approved payslip in the same year before this period
+type YearToDate struct {
+	TaxableIncome       float64
+	PensionContribution float64
+	TaxWithheld         float64
+	Months              int
+}
+
+type Input struct {
+	Period     time.Time
+	PTKPStatus string
+	// wage used for social security, base salary plus fixed allowance
+	ContributionWage float64
+	// taxable salary before statutory benefit
+	TaxableGross float64
+	// last period of the year or of employment, tax is reconciled for the whole year
+	FinalPeriod bool
+	YearToDate  YearToDate
+}
+
+type Line struct {
+	Code   string
+	Name   string
+	Kind   string
+	Amount float64
+}
+
+type Result struct {
+	Lines []Line
+	// gross income for PPh 21, taxable gross plus benefit paid by employer
+	TaxableIncome float64
+	// pension paid by employee, reduce yearly taxable income
+	PensionContribution float64
+	Tax                 float64
+}
+
+func (r *Result) add(code, name, kind string, amount float64) {
+	r.Lines = append(r.Lines, Line{Code: code, Name: name, Kind: kind, Amount: amount})
+}
+
+// Rule is one statutory calculation, rules run in the order they are registered
+// and can read what the previous rule put in the result
+type Rule interface {
+	Code() string
+	Apply(in Input, rates RateSet, result *Result) error
+}
+
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+func (e *Engine) Register(rule Rule) {
+	e.rules = append(e.rules, rule)
+}
+
+func (e *Engine) Calculate(in Input, rates RateSet) (Result, error) {
+	if err := CheckPeriod(in.Period); err != nil {
+		return Result{}, err
+	}
+	result := Result{TaxableIncome: in.TaxableGross}
+	for _, rule := range e.rules {
+		if err := rule.Apply(in, rates, &result); err != nil {
+			return Result{}, fmt.Errorf("%s: %w", rule.Code(), err)
+		}
+	}
+	return result, nil
+}
+
+// Indonesia is BPJS Kesehatan, BPJS Ketenagakerjaan then PPh 21,
+// tax is last because employer premium is part of taxable income
+func Indonesia() *Engine {
+	return NewEngine(BPJSKesehatan{}, BPJSKetenagakerjaan{}, PPh21{})
+}
+
+// rupiah round contribution to whole rupiah
+func rupiah(amount float64) float64 {
+	return math.Round(amount)
+}
+
+// capped return wage limited by cap, cap 0 means no limit
+func capped(wage float64, cap float64) float64 {
+	if cap > 0 && wage > cap {
+		return cap
+	}
+	return wage
+}
@@ -0,0 +1,87 @@
+package statutory_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geedotrar/erp-api/pkg/statutory"
+)
+
+func TestCheckPeriod(t *testing.T) {
+	tests := []struct {
+		name    string
+		period  time.Time
+		wantErr bool
+	}{
+		{name: "before TER", period: time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC), wantErr: true},
+		{name: "first TER period", period: january2024},
+		{name: "later period", period: time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := statutory.CheckPeriod(tt.period)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckPeriod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// every rate needed by the engine is seeded for the first supported period
+func TestSeedCoverSupportedFrom(t *testing.T) {
+	rates := seedRates(t, statutory.SupportedFrom.Format("2006-01-02"))
+
+	for _, status := range []string{"TK/0", "TK/1", "TK/2", "TK/3", "K/0", "K/1", "K/2", "K/3"} {
+		result, err := statutory.Indonesia().Calculate(statutory.Input{
+			Period:           statutory.SupportedFrom,
+			PTKPStatus:       status,
+			ContributionWage: 10000000,
+			TaxableGross:     10000000,
+		}, rates)
+		if err != nil {
+			t.Fatalf("Calculate(%s) error = %v", status, err)
+		}
+		if result.Tax <= 0 {
+			t.Errorf("Calculate(%s) tax = %v, want positive", status, result.Tax)
+		}
+	}
+}
+
+func TestProgressive(t *testing.T) {
+	rates := seedRates(t, "2024-01-01")
+
+	tests := []struct {
+		name    string
+		income  float64
+		wantTax float64
+	}{
+		{name: "zero", income: 0, wantTax: 0},
+		{name: "end of 5%", income: 60000000, wantTax: 3000000},
+		{name: "end of 15%", income: 250000000, wantTax: 31500000},
+		{name: "end of 25%", income: 500000000, wantTax: 94000000},
+		{name: "inside 35%", income: 6000000000, wantTax: 1794000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tax, err := rates.Progressive(statutory.PPH21_PASAL17_TABLE, tt.income)
+			if err != nil {
+				t.Fatalf("Progressive() error = %v", err)
+			}
+			if tax != tt.wantTax {
+				t.Errorf("Progressive() = %v, want %v", tax, tt.wantTax)
+			}
+		})
+	}
+}
+
+func TestCalculateBeforeSupportedPeriod(t *testing.T) {
+	_, err := statutory.Indonesia().Calculate(statutory.Input{
+		Period:           time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC),
+		PTKPStatus:       "TK/0",
+		ContributionWage: 10000000,
+		TaxableGross:     10000000,
+	}, seedRates(t, "2023-03-01"))
+	if err == nil {
+		t.Fatal("Calculate() error = nil, want unsupported period")
+	}
+}
@@ -0,0 +1,105 @@
+package tlsreload
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// file is checked for change this often, SIGHUP reload right away
+const pollInterval = 30 * time.Second
+
+// Certificate serve the key pair through tls.Config.GetCertificate so renewed
+// certificate is picked up without restart. failed reload keep the previous pair
+type Certificate struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// Load read the key pair, error is returned so bad file fail the startup
+func Load(certFile string, keyFile string) (*Certificate, error) {
+	c := &Certificate{certFile: certFile, keyFile: keyFile}
+	if err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+// Watch reload the key pair when cert or key file change or on SIGHUP until ctx is done
+func (c *Certificate) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			c.tryReload()
+		case <-ticker.C:
+			if c.changed() {
+				c.tryReload()
+			}
+		}
+	}
+}
+
+func (c *Certificate) tryReload() {
+	if err := c.reload(); err != nil {
+		log.Println("cannot reload tls certificate, keep the previous one", err.Error())
+		return
+	}
+	log.Println("tls certificate reloaded", c.certFile)
+}
+
+func (c *Certificate) reload() error {
+	modTime := c.latestModTime()
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = &cert
+	c.modTime = modTime
+	return nil
+}
+
+func (c *Certificate) changed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.latestModTime().After(c.modTime)
+}
+
+// cert and key are usually replaced together, the newest of both is compared
+func (c *Certificate) latestModTime() time.Time {
+	latest := time.Time{}
+	for _, path := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
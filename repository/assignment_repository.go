@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+)
+
+type AssignmentQuery interface {
+	GetAssignmentsByUserID(ctx context.Context, userID uint64) ([]models.Assignment, error)
+	GetAssignmentAsOf(ctx context.Context, userID uint64, asOf models.Date) (models.Assignment, error)
+	GetAssignments(ctx context.Context, filter models.AssignmentFilter, asOf models.Date) ([]models.Assignment, error)
+	GetLatestAssignment(ctx context.Context, userID uint64) (models.Assignment, error)
+
+	// close current assignment and open the new one in one transaction,
+	// opening is the first history of user created from users table
+	CreateAssignment(ctx context.Context, assignment models.Assignment, opening *models.Assignment) (models.Assignment, error)
+
+	// not applied assignment starting on or before asOf, ordered by start
+	GetDueAssignments(ctx context.Context, asOf models.Date) ([]models.Assignment, error)
+	// copy assignment which already started into users table
+	ApplyAssignment(ctx context.Context, assignment models.Assignment) error
+
+	// validasi relasi
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+	GetPositionByID(ctx context.Context, id uint64) (models.Position, error)
+	GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error)
+}
+
+type assignmentQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewAssignmentQuery(db config.GormPostgres) AssignmentQuery {
+	return &assignmentQueryImpl{db: db}
+}
+
+func (a *assignmentQueryImpl) GetAssignmentsByUserID(ctx context.Context, userID uint64) ([]models.Assignment, error) {
+	db := conn(ctx, a.db)
+	assignments := []models.Assignment{}
+	if err := db.
+		WithContext(ctx).
+		Table("assignments").
+		Where("user_id = ?", userID).
+		Order("valid_from").
+		Find(&assignments).Error; err != nil {
+		return []models.Assignment{}, err
+	}
+	return assignments, nil
+}
+
+func (a *assignmentQueryImpl) GetAssignmentAsOf(ctx context.Context, userID uint64, asOf models.Date) (models.Assignment, error) {
+	db := conn(ctx, a.db)
+	assignment := models.Assignment{}
+	if err := db.
+		WithContext(ctx).
+		Table("assignments").
+		Where("user_id = ?", userID).
+		Scopes(assignmentAsOfScope(asOf)).
+		Find(&assignment).Error; err != nil {
+		return models.Assignment{}, err
+	}
+	return assignment, nil
+}
+
+func (a *assignmentQueryImpl) GetAssignments(ctx context.Context, filter models.AssignmentFilter, asOf models.Date) ([]models.Assignment, error) {
+	db := conn(ctx, a.db)
+	assignments := []models.Assignment{}
+	query := db.
+		WithContext(ctx).
+		Table("assignments").
+		Scopes(assignmentAsOfScope(asOf))
+	if filter.CompanyID != 0 {
+		query = query.Where("company_id = ?", filter.CompanyID)
+	}
+	if filter.DepartmentID != 0 {
+		query = query.Where("department_id = ?", filter.DepartmentID)
+	}
+	if filter.PositionID != 0 {
+		query = query.Where("position_id = ?", filter.PositionID)
+	}
+	if err := query.Order("user_id").Find(&assignments).Error; err != nil {
+		return []models.Assignment{}, err
+	}
+	return assignments, nil
+}
+
+func (a *assignmentQueryImpl) GetLatestAssignment(ctx context.Context, userID uint64) (models.Assignment, error) {
+	db := conn(ctx, a.db)
+	assignment := models.Assignment{}
+	if err := db.
+		WithContext(ctx).
+		Table("assignments").
+		Where("user_id = ?", userID).
+		Order("valid_from DESC").
+		Limit(1).
+		Find(&assignment).Error; err != nil {
+		return models.Assignment{}, err
+	}
+	return assignment, nil
+}
+
+func (a *assignmentQueryImpl) CreateAssignment(ctx context.Context, assignment models.Assignment, opening *models.Assignment) (models.Assignment, error) {
+	db := conn(ctx, a.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if opening != nil {
+			if err := tx.Table("assignments").Create(opening).Error; err != nil {
+				return err
+			}
+		}
+
+		// close open assignment at the start of new assignment
+		if err := tx.
+			Table("assignments").
+			Where("user_id = ?", assignment.UserID).
+			Where("valid_to IS NULL").
+			Updates(map[string]any{"valid_to": assignment.ValidFrom, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Table("assignments").Create(&assignment).Error; err != nil {
+			return err
+		}
+
+		if assignment.Applied {
+			return applyAssignment(tx, assignment)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	return assignment, nil
+}
+
+func (a *assignmentQueryImpl) GetDueAssignments(ctx context.Context, asOf models.Date) ([]models.Assignment, error) {
+	db := conn(ctx, a.db)
+	due := []models.Assignment{}
+	if err := db.
+		WithContext(ctx).
+		Table("assignments").
+		Where("applied = ?", false).
+		Where("valid_from <= ?", asOf).
+		Order("valid_from, id").
+		Find(&due).Error; err != nil {
+		return []models.Assignment{}, err
+	}
+	return due, nil
+}
+
+func (a *assignmentQueryImpl) ApplyAssignment(ctx context.Context, assignment models.Assignment) error {
+	db := conn(ctx, a.db)
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return applyAssignment(tx, assignment)
+	})
+}
+
+// applyAssignment copy assignment into users table and mark it as applied
+func applyAssignment(tx *gorm.DB, assignment models.Assignment) error {
+	if err := tx.
+		Table("users").
+		Where("id = ?", assignment.UserID).
+		Updates(map[string]any{
+			"company_id":    assignment.CompanyID,
+			"department_id": assignment.DepartmentID,
+			"position_id":   assignment.PositionID,
+			"updated_at":    time.Now(),
+		}).Error; err != nil {
+		return err
+	}
+
+	// manager line does not cross company, moving user leave its manager and
+	// its reports behind in the old company
+	if err := tx.
+		Table("users").
+		Where("id = ?", assignment.UserID).
+		Where("manager_id IN (SELECT id FROM users WHERE company_id <> ?)", assignment.CompanyID).
+		Updates(map[string]any{"manager_id": nil, "updated_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	if err := tx.
+		Table("users").
+		Where("manager_id = ?", assignment.UserID).
+		Where("company_id <> ?", assignment.CompanyID).
+		Updates(map[string]any{"manager_id": nil, "updated_at": time.Now()}).Error; err != nil {
+		return err
+	}
+
+	return tx.
+		Table("assignments").
+		Where("id = ?", assignment.ID).
+		Updates(map[string]any{"applied": true, "updated_at": time.Now()}).Error
+}
+
+func assignmentAsOfScope(asOf models.Date) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("valid_from <= ?", asOf).
+			Where("valid_to IS NULL OR valid_to > ?", asOf)
+	}
+}
+
+func (a *assignmentQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, a.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (a *assignmentQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, a.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
+
+func (a *assignmentQueryImpl) GetPositionByID(ctx context.Context, id uint64) (models.Position, error) {
+	db := conn(ctx, a.db)
+	position := models.Position{}
+	if err := db.
+		WithContext(ctx).
+		Table("positions").
+		Where("id = ?", id).
+		Find(&position).Error; err != nil {
+		return models.Position{}, err
+	}
+	return position, nil
+}
+
+func (a *assignmentQueryImpl) GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error) {
+	db := conn(ctx, a.db)
+	department := models.Department{}
+	if err := db.
+		WithContext(ctx).
+		Table("departments").
+		Where("id = ?", id).
+		Find(&department).Error; err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
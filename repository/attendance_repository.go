@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AttendanceQuery interface {
+	GetAttendanceSetting(ctx context.Context, companyID uint64) (models.AttendanceSetting, error)
+	SaveAttendanceSetting(ctx context.Context, setting models.AttendanceSetting) (models.AttendanceSetting, error)
+
+	GetGeofences(ctx context.Context, companyID uint64) ([]models.AttendanceGeofence, error)
+	GetGeofenceByID(ctx context.Context, id uint64) (models.AttendanceGeofence, error)
+	CreateGeofence(ctx context.Context, geofence models.AttendanceGeofence) (models.AttendanceGeofence, error)
+	DeleteGeofence(ctx context.Context, id uint64) error
+
+	GetAttendanceByWorkDate(ctx context.Context, userID uint64, workDate models.Date) (models.Attendance, error)
+	// attendance without clock out, started after since
+	GetOpenAttendance(ctx context.Context, userID uint64, since time.Time) (models.Attendance, error)
+	GetAttendances(ctx context.Context, userID uint64, from time.Time, to time.Time) ([]models.Attendance, error)
+
+	CreateAttendance(ctx context.Context, attendance models.Attendance) (models.Attendance, error)
+	UpdateAttendance(ctx context.Context, attendance models.Attendance) (models.Attendance, error)
+
+	GetMonthlySummary(ctx context.Context, companyID uint64, from time.Time, to time.Time) ([]models.AttendanceSummary, error)
+
+	GetCorrections(ctx context.Context, companyID uint64, status string) ([]models.AttendanceCorrection, error)
+	GetCorrectionByID(ctx context.Context, id uint64) (models.AttendanceCorrection, error)
+	CreateCorrection(ctx context.Context, correction models.AttendanceCorrection) (models.AttendanceCorrection, error)
+	// save corrected attendance and approved correction in one transaction
+	ApproveCorrection(ctx context.Context, correction models.AttendanceCorrection, attendance models.Attendance) (models.AttendanceCorrection, error)
+	RejectCorrection(ctx context.Context, correction models.AttendanceCorrection) (models.AttendanceCorrection, error)
+
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+}
+
+type attendanceQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewAttendanceQuery(db config.GormPostgres) AttendanceQuery {
+	return &attendanceQueryImpl{db: db}
+}
+
+func (a *attendanceQueryImpl) GetAttendanceSetting(ctx context.Context, companyID uint64) (models.AttendanceSetting, error) {
+	db := a.db.GetConnection()
+	setting := models.AttendanceSetting{}
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_settings").
+		Where("company_id = ?", companyID).
+		Find(&setting).Error; err != nil {
+		return models.AttendanceSetting{}, err
+	}
+	return setting, nil
+}
+
+func (a *attendanceQueryImpl) SaveAttendanceSetting(ctx context.Context, setting models.AttendanceSetting) (models.AttendanceSetting, error) {
+	db := a.db.GetConnection()
+	setting.UpdatedAt = time.Now()
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_settings").
+		Clauses(clause.OnConflict{UpdateAll: true}).
+		Create(&setting).Error; err != nil {
+		return models.AttendanceSetting{}, err
+	}
+	return setting, nil
+}
+
+func (a *attendanceQueryImpl) GetGeofences(ctx context.Context, companyID uint64) ([]models.AttendanceGeofence, error) {
+	db := a.db.GetConnection()
+	geofences := []models.AttendanceGeofence{}
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_geofences").
+		Where("company_id = ?", companyID).
+		Order("id").
+		Find(&geofences).Error; err != nil {
+		return []models.AttendanceGeofence{}, err
+	}
+	return geofences, nil
+}
+
+func (a *attendanceQueryImpl) GetGeofenceByID(ctx context.Context, id uint64) (models.AttendanceGeofence, error) {
+	db := a.db.GetConnection()
+	geofence := models.AttendanceGeofence{}
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_geofences").
+		Where("id = ?", id).
+		Find(&geofence).Error; err != nil {
+		return models.AttendanceGeofence{}, err
+	}
+	return geofence, nil
+}
+
+func (a *attendanceQueryImpl) CreateGeofence(ctx context.Context, geofence models.AttendanceGeofence) (models.AttendanceGeofence, error) {
+	db := a.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_geofences").
+		Create(&geofence).Error; err != nil {
+		return models.AttendanceGeofence{}, err
+	}
+	return geofence, nil
+}
+
+func (a *attendanceQueryImpl) DeleteGeofence(ctx context.Context, id uint64) error {
+	db := a.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_geofences").
+		Where("id = ?", id).
+		Delete(&models.AttendanceGeofence{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (a *attendanceQueryImpl) GetAttendanceByWorkDate(ctx context.Context, userID uint64, workDate models.Date) (models.Attendance, error) {
+	db := a.db.GetConnection()
+	attendance := models.Attendance{}
+	if err := db.
+		WithContext(ctx).
+		Table("attendances").
+		Where("user_id = ?", userID).
+		Where("work_date = ?", workDate).
+		Find(&attendance).Error; err != nil {
+		return models.Attendance{}, err
+	}
+	return attendance, nil
+}
+
+func (a *attendanceQueryImpl) GetOpenAttendance(ctx context.Context, userID uint64, since time.Time) (models.Attendance, error) {
+	db := a.db.GetConnection()
+	attendance := models.Attendance{}
+	if err := db.
+		WithContext(ctx).
+		Table("attendances").
+		Where("user_id = ?", userID).
+		Where("clock_out_at IS NULL").
+		Where("clock_in_at >= ?", since).
+		Order("clock_in_at DESC").
+		Limit(1).
+		Find(&attendance).Error; err != nil {
+		return models.Attendance{}, err
+	}
+	return attendance, nil
+}
+
+func (a *attendanceQueryImpl) GetAttendances(ctx context.Context, userID uint64, from time.Time, to time.Time) ([]models.Attendance, error) {
+	db := a.db.GetConnection()
+	attendances := []models.Attendance{}
+	if err := db.
+		WithContext(ctx).
+		Table("attendances").
+		Where("user_id = ?", userID).
+		Where("work_date >= ? AND work_date < ?", from, to).
+		Order("work_date").
+		Find(&attendances).Error; err != nil {
+		return []models.Attendance{}, err
+	}
+	return attendances, nil
+}
+
+func (a *attendanceQueryImpl) CreateAttendance(ctx context.Context, attendance models.Attendance) (models.Attendance, error) {
+	db := a.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("attendances").
+		Create(&attendance).Error; err != nil {
+		return models.Attendance{}, err
+	}
+	return attendance, nil
+}
+
+func (a *attendanceQueryImpl) UpdateAttendance(ctx context.Context, attendance models.Attendance) (models.Attendance, error) {
+	db := a.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("attendances").
+		Save(&attendance).Error; err != nil {
+		return models.Attendance{}, err
+	}
+	return attendance, nil
+}
+
+func (a *attendanceQueryImpl) GetMonthlySummary(ctx context.Context, companyID uint64, from time.Time, to time.Time) ([]models.AttendanceSummary, error) {
+	db := a.db.GetConnection()
+	summaries := []models.AttendanceSummary{}
+	if err := db.
+		WithContext(ctx).
+		Table("attendances a").
+		Select(`a.user_id, u.first_name, u.last_name,
+			COUNT(*) AS days_present,
+			COUNT(*) FILTER (WHERE a.is_late) AS late_count,
+			COUNT(*) FILTER (WHERE a.is_early_leave) AS early_leave_count,
+			COALESCE(SUM(a.worked_minutes), 0) AS worked_minutes`).
+		Joins("JOIN users u ON u.id = a.user_id").
+		Where("a.company_id = ?", companyID).
+		Where("a.work_date >= ? AND a.work_date < ?", from, to).
+		Group("a.user_id, u.first_name, u.last_name").
+		Order("a.user_id").
+		Scan(&summaries).Error; err != nil {
+		return []models.AttendanceSummary{}, err
+	}
+	return summaries, nil
+}
+
+func (a *attendanceQueryImpl) GetCorrections(ctx context.Context, companyID uint64, status string) ([]models.AttendanceCorrection, error) {
+	db := a.db.GetConnection()
+	corrections := []models.AttendanceCorrection{}
+	query := db.
+		WithContext(ctx).
+		Table("attendance_corrections").
+		Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Order("created_at").Find(&corrections).Error; err != nil {
+		return []models.AttendanceCorrection{}, err
+	}
+	return corrections, nil
+}
+
+func (a *attendanceQueryImpl) GetCorrectionByID(ctx context.Context, id uint64) (models.AttendanceCorrection, error) {
+	db := a.db.GetConnection()
+	correction := models.AttendanceCorrection{}
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_corrections").
+		Where("id = ?", id).
+		Find(&correction).Error; err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	return correction, nil
+}
+
+func (a *attendanceQueryImpl) CreateCorrection(ctx context.Context, correction models.AttendanceCorrection) (models.AttendanceCorrection, error) {
+	db := a.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_corrections").
+		Create(&correction).Error; err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	return correction, nil
+}
+
+func (a *attendanceQueryImpl) ApproveCorrection(ctx context.Context, correction models.AttendanceCorrection, attendance models.Attendance) (models.AttendanceCorrection, error) {
+	db := a.db.GetConnection()
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("attendances").Save(&attendance).Error; err != nil {
+			return err
+		}
+		return tx.Table("attendance_corrections").Save(&correction).Error
+	})
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	return correction, nil
+}
+
+func (a *attendanceQueryImpl) RejectCorrection(ctx context.Context, correction models.AttendanceCorrection) (models.AttendanceCorrection, error) {
+	db := a.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("attendance_corrections").
+		Save(&correction).Error; err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	return correction, nil
+}
+
+func (a *attendanceQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := a.db.GetConnection()
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (a *attendanceQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := a.db.GetConnection()
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
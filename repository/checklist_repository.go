@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChecklistQuery interface {
+	GetTemplates(ctx context.Context, filter models.ChecklistTemplateFilter) ([]models.ChecklistTemplate, error)
+	GetTemplateByID(ctx context.Context, id uint64) (models.ChecklistTemplate, error)
+	// template of company and type for position, including template without position
+	GetMatchingTemplates(ctx context.Context, companyID uint64, positionID uint64, checklistType string) ([]models.ChecklistTemplate, error)
+	CreateTemplate(ctx context.Context, template models.ChecklistTemplate) (models.ChecklistTemplate, error)
+	// update template and replace all of its tasks
+	UpdateTemplate(ctx context.Context, template models.ChecklistTemplate) (models.ChecklistTemplate, error)
+	DeleteTemplate(ctx context.Context, id uint64) error
+
+	GetChecklistByID(ctx context.Context, id uint64) (models.Checklist, error)
+	GetChecklistsByUserID(ctx context.Context, userID uint64) ([]models.Checklist, error)
+	GetOpenChecklists(ctx context.Context, userID uint64, checklistType string) ([]models.Checklist, error)
+	CreateChecklist(ctx context.Context, checklist models.Checklist) (models.Checklist, error)
+
+	GetTaskByID(ctx context.Context, id uint64) (models.ChecklistTask, error)
+	GetOpenTasksByAssignee(ctx context.Context, assigneeID uint64) ([]models.ChecklistTask, error)
+	// complete task, the checklist is completed with its last task and user is
+	// soft deleted when the last open offboarding checklist is completed
+	CompleteTask(ctx context.Context, id uint64, completedBy uint64, note string) (task models.ChecklistTask, userDeleted bool, err error)
+
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+	GetPositionByID(ctx context.Context, id uint64) (models.Position, error)
+}
+
+type checklistQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewChecklistQuery(db config.GormPostgres) ChecklistQuery {
+	return &checklistQueryImpl{db: db}
+}
+
+func (c *checklistQueryImpl) GetTemplates(ctx context.Context, filter models.ChecklistTemplateFilter) ([]models.ChecklistTemplate, error) {
+	db := conn(ctx, c.db)
+	templates := []models.ChecklistTemplate{}
+	query := db.
+		WithContext(ctx).
+		Table("checklist_templates").
+		Where("company_id = ?", filter.CompanyID)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if err := query.Order("type, id").Find(&templates).Error; err != nil {
+		return []models.ChecklistTemplate{}, err
+	}
+	if err := c.loadTemplateTasks(db.WithContext(ctx), templates); err != nil {
+		return []models.ChecklistTemplate{}, err
+	}
+	return templates, nil
+}
+
+func (c *checklistQueryImpl) GetTemplateByID(ctx context.Context, id uint64) (models.ChecklistTemplate, error) {
+	db := conn(ctx, c.db)
+	templates := []models.ChecklistTemplate{}
+	if err := db.
+		WithContext(ctx).
+		Table("checklist_templates").
+		Where("id = ?", id).
+		Find(&templates).Error; err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	if len(templates) == 0 {
+		return models.ChecklistTemplate{}, nil
+	}
+	if err := c.loadTemplateTasks(db.WithContext(ctx), templates); err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	return templates[0], nil
+}
+
+func (c *checklistQueryImpl) GetMatchingTemplates(ctx context.Context, companyID uint64, positionID uint64, checklistType string) ([]models.ChecklistTemplate, error) {
+	db := conn(ctx, c.db)
+	templates := []models.ChecklistTemplate{}
+	if err := db.
+		WithContext(ctx).
+		Table("checklist_templates").
+		Where("company_id = ? AND type = ?", companyID, checklistType).
+		Where("position_id IS NULL OR position_id = ?", positionID).
+		Order("id").
+		Find(&templates).Error; err != nil {
+		return []models.ChecklistTemplate{}, err
+	}
+	if err := c.loadTemplateTasks(db.WithContext(ctx), templates); err != nil {
+		return []models.ChecklistTemplate{}, err
+	}
+	return templates, nil
+}
+
+// loadTemplateTasks fill tasks of templates with one query
+func (c *checklistQueryImpl) loadTemplateTasks(db *gorm.DB, templates []models.ChecklistTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+	ids := make([]uint64, 0, len(templates))
+	for _, template := range templates {
+		ids = append(ids, template.ID)
+	}
+	tasks := []models.ChecklistTemplateTask{}
+	if err := db.
+		Table("checklist_template_tasks").
+		Where("template_id IN ?", ids).
+		Order("sort_order").
+		Find(&tasks).Error; err != nil {
+		return err
+	}
+	byTemplate := map[uint64][]models.ChecklistTemplateTask{}
+	for _, task := range tasks {
+		byTemplate[task.TemplateID] = append(byTemplate[task.TemplateID], task)
+	}
+	for i := range templates {
+		templates[i].Tasks = byTemplate[templates[i].ID]
+		if templates[i].Tasks == nil {
+			templates[i].Tasks = []models.ChecklistTemplateTask{}
+		}
+	}
+	return nil
+}
+
+func (c *checklistQueryImpl) CreateTemplate(ctx context.Context, template models.ChecklistTemplate) (models.ChecklistTemplate, error) {
+	db := conn(ctx, c.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("checklist_templates").Create(&template).Error; err != nil {
+			return err
+		}
+		return createTemplateTasks(tx, template)
+	})
+	if err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	return c.GetTemplateByID(ctx, template.ID)
+}
+
+func (c *checklistQueryImpl) UpdateTemplate(ctx context.Context, template models.ChecklistTemplate) (models.ChecklistTemplate, error) {
+	db := conn(ctx, c.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Table("checklist_templates").
+			Where("id = ?", template.ID).
+			Updates(map[string]any{
+				"position_id": template.PositionID,
+				"type":        template.Type,
+				"name":        template.Name,
+				"updated_at":  time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Table("checklist_template_tasks").
+			Where("template_id = ?", template.ID).
+			Delete(&models.ChecklistTemplateTask{}).Error; err != nil {
+			return err
+		}
+		return createTemplateTasks(tx, template)
+	})
+	if err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	return c.GetTemplateByID(ctx, template.ID)
+}
+
+func createTemplateTasks(tx *gorm.DB, template models.ChecklistTemplate) error {
+	for i := range template.Tasks {
+		template.Tasks[i].ID = 0
+		template.Tasks[i].TemplateID = template.ID
+		template.Tasks[i].SortOrder = i + 1
+	}
+	return tx.Table("checklist_template_tasks").Create(&template.Tasks).Error
+}
+
+func (c *checklistQueryImpl) DeleteTemplate(ctx context.Context, id uint64) error {
+	db := conn(ctx, c.db)
+	return db.
+		WithContext(ctx).
+		Table("checklist_templates").
+		Where("id = ?", id).
+		Delete(&models.ChecklistTemplate{}).Error
+}
+
+func (c *checklistQueryImpl) GetChecklistByID(ctx context.Context, id uint64) (models.Checklist, error) {
+	db := conn(ctx, c.db)
+	checklists := []models.Checklist{}
+	if err := db.
+		WithContext(ctx).
+		Table("checklists").
+		Where("id = ?", id).
+		Find(&checklists).Error; err != nil {
+		return models.Checklist{}, err
+	}
+	if len(checklists) == 0 {
+		return models.Checklist{}, nil
+	}
+	if err := c.loadChecklistTasks(db.WithContext(ctx), checklists); err != nil {
+		return models.Checklist{}, err
+	}
+	return checklists[0], nil
+}
+
+func (c *checklistQueryImpl) GetChecklistsByUserID(ctx context.Context, userID uint64) ([]models.Checklist, error) {
+	db := conn(ctx, c.db)
+	checklists := []models.Checklist{}
+	if err := db.
+		WithContext(ctx).
+		Table("checklists").
+		Where("user_id = ?", userID).
+		Order("start_date DESC, id DESC").
+		Find(&checklists).Error; err != nil {
+		return []models.Checklist{}, err
+	}
+	if err := c.loadChecklistTasks(db.WithContext(ctx), checklists); err != nil {
+		return []models.Checklist{}, err
+	}
+	return checklists, nil
+}
+
+func (c *checklistQueryImpl) GetOpenChecklists(ctx context.Context, userID uint64, checklistType string) ([]models.Checklist, error) {
+	db := conn(ctx, c.db)
+	checklists := []models.Checklist{}
+	if err := db.
+		WithContext(ctx).
+		Table("checklists").
+		Where("user_id = ? AND type = ? AND status = ?", userID, checklistType, models.CHECKLIST_STATUS_OPEN).
+		Order("id").
+		Find(&checklists).Error; err != nil {
+		return []models.Checklist{}, err
+	}
+	if err := c.loadChecklistTasks(db.WithContext(ctx), checklists); err != nil {
+		return []models.Checklist{}, err
+	}
+	return checklists, nil
+}
+
+func (c *checklistQueryImpl) loadChecklistTasks(db *gorm.DB, checklists []models.Checklist) error {
+	if len(checklists) == 0 {
+		return nil
+	}
+	ids := make([]uint64, 0, len(checklists))
+	for _, checklist := range checklists {
+		ids = append(ids, checklist.ID)
+	}
+	tasks := []models.ChecklistTask{}
+	if err := db.
+		Table("checklist_tasks").
+		Where("checklist_id IN ?", ids).
+		Order("sort_order").
+		Find(&tasks).Error; err != nil {
+		return err
+	}
+	byChecklist := map[uint64][]models.ChecklistTask{}
+	for _, task := range tasks {
+		byChecklist[task.ChecklistID] = append(byChecklist[task.ChecklistID], task)
+	}
+	for i := range checklists {
+		checklists[i].Tasks = byChecklist[checklists[i].ID]
+		if checklists[i].Tasks == nil {
+			checklists[i].Tasks = []models.ChecklistTask{}
+		}
+	}
+	return nil
+}
+
+func (c *checklistQueryImpl) CreateChecklist(ctx context.Context, checklist models.Checklist) (models.Checklist, error) {
+	db := conn(ctx, c.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("checklists").Create(&checklist).Error; err != nil {
+			return err
+		}
+		for i := range checklist.Tasks {
+			checklist.Tasks[i].ChecklistID = checklist.ID
+		}
+		return tx.Table("checklist_tasks").Create(&checklist.Tasks).Error
+	})
+	if err != nil {
+		return models.Checklist{}, err
+	}
+	return checklist, nil
+}
+
+func (c *checklistQueryImpl) GetTaskByID(ctx context.Context, id uint64) (models.ChecklistTask, error) {
+	db := conn(ctx, c.db)
+	task := models.ChecklistTask{}
+	if err := db.
+		WithContext(ctx).
+		Table("checklist_tasks").
+		Where("id = ?", id).
+		Find(&task).Error; err != nil {
+		return models.ChecklistTask{}, err
+	}
+	return task, nil
+}
+
+func (c *checklistQueryImpl) GetOpenTasksByAssignee(ctx context.Context, assigneeID uint64) ([]models.ChecklistTask, error) {
+	db := conn(ctx, c.db)
+	tasks := []models.ChecklistTask{}
+	if err := db.
+		WithContext(ctx).
+		Table("checklist_tasks").
+		Where("assignee_id = ? AND completed = FALSE", assigneeID).
+		Order("due_date, id").
+		Find(&tasks).Error; err != nil {
+		return []models.ChecklistTask{}, err
+	}
+	return tasks, nil
+}
+
+func (c *checklistQueryImpl) CompleteTask(ctx context.Context, id uint64, completedBy uint64, note string) (models.ChecklistTask, bool, error) {
+	db := conn(ctx, c.db)
+	userDeleted := false
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		task := models.ChecklistTask{}
+		if err := tx.
+			Table("checklist_tasks").
+			Where("id = ?", id).
+			First(&task).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Table("checklist_tasks").
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"completed":    true,
+				"completed_at": now,
+				"completed_by": completedBy,
+				"note":         note,
+				"updated_at":   now,
+			}).Error; err != nil {
+			return err
+		}
+
+		// lock checklist so two last tasks completed together close it once
+		checklist := models.Checklist{}
+		if err := tx.
+			Table("checklists").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", task.ChecklistID).
+			First(&checklist).Error; err != nil {
+			return err
+		}
+		var remaining int64
+		if err := tx.
+			Table("checklist_tasks").
+			Where("checklist_id = ? AND completed = FALSE", checklist.ID).
+			Count(&remaining).Error; err != nil {
+			return err
+		}
+		if remaining > 0 || checklist.Status == models.CHECKLIST_STATUS_COMPLETED {
+			return nil
+		}
+		if err := tx.
+			Table("checklists").
+			Where("id = ?", checklist.ID).
+			Updates(map[string]any{"status": models.CHECKLIST_STATUS_COMPLETED, "completed_at": now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if checklist.Type != models.CHECKLIST_TYPE_OFFBOARDING {
+			return nil
+		}
+
+		var openOffboarding int64
+		if err := tx.
+			Table("checklists").
+			Where("user_id = ? AND type = ? AND status = ?", checklist.UserID, models.CHECKLIST_TYPE_OFFBOARDING, models.CHECKLIST_STATUS_OPEN).
+			Count(&openOffboarding).Error; err != nil {
+			return err
+		}
+		if openOffboarding > 0 {
+			return nil
+		}
+		userDeleted = true
+		return tx.
+			Table("users").
+			Delete(&models.User{ID: checklist.UserID}).Error
+	})
+	if err != nil {
+		return models.ChecklistTask{}, false, err
+	}
+	task, err := c.GetTaskByID(ctx, id)
+	return task, userDeleted, err
+}
+
+func (c *checklistQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, c.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (c *checklistQueryImpl) GetPositionByID(ctx context.Context, id uint64) (models.Position, error) {
+	db := conn(ctx, c.db)
+	position := models.Position{}
+	if err := db.
+		WithContext(ctx).
+		Table("positions").
+		Where("id = ?", id).
+		Find(&position).Error; err != nil {
+		return models.Position{}, err
+	}
+	return position, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/geedotrar/erp-api/config"
 	"github.com/geedotrar/erp-api/models"
@@ -9,7 +10,7 @@ import (
 )
 
 type CompanyQuery interface {
-	GetCompany(ctx context.Context) ([]models.Company, error)
+	GetCompany(ctx context.Context, filter models.CompanyFilter) ([]models.Company, error)
 	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
 	GetCompanyByCompanyName(ctx context.Context, companyName string) (models.Company, error)
 
@@ -20,10 +21,38 @@ type CompanyQuery interface {
 
 	// mencari user yang menggunakan companyID
 	GetUserByCompanyID(ctx context.Context, companyID uint64) ([]models.User, error)
+	// GetCompanyUsage return the first kind of record that still refer to company, empty when none
+	GetCompanyUsage(ctx context.Context, companyID uint64) (string, error)
 
 	GetSoftDeletedCompanies(ctx context.Context) ([]models.Company, error)
 
 	RestoreCompany(ctx context.Context, id uint64) error
+
+	GetCompanyAddresses(ctx context.Context, companyID uint64) ([]models.CompanyAddress, error)
+	GetCompanyContacts(ctx context.Context, companyID uint64) ([]models.CompanyContact, error)
+	// replace every address or contact of company in one transaction
+	SaveCompanyAddresses(ctx context.Context, companyID uint64, addresses []models.CompanyAddress) error
+	SaveCompanyContacts(ctx context.Context, companyID uint64, contacts []models.CompanyContact) error
+
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+
+	// company and every subsidiary below it ordered by depth
+	GetCompanySubtree(ctx context.Context, rootID uint64) ([]models.CompanyTreeRow, error)
+	// LockCompanyHierarchy hold the hierarchy lock until the transaction of ctx end,
+	// hierarchy changes run one at a time
+	LockCompanyHierarchy(ctx context.Context) error
+	// SetParentCompany reject parent that is the company itself or one of its subsidiaries
+	SetParentCompany(ctx context.Context, id uint64, parentID *uint64) error
+	// IsCompanyInGroup report whether company is group or one of subsidiaries of group
+	IsCompanyInGroup(ctx context.Context, groupID uint64, companyID uint64) (bool, error)
+	// headcount and payroll run of period for every company in subtree of root
+	GetCompanyRollup(ctx context.Context, rootID uint64, period models.Date) ([]models.CompanyRollupRow, error)
+
+	// all active user of company ordered from top of reporting line
+	GetOrgChart(ctx context.Context, companyID uint64) ([]models.OrgChartRow, error)
+
+	CountCompany(ctx context.Context, filter models.CompanyFilter) (int64, error)
+	StreamCompany(ctx context.Context, filter models.CompanyFilter, columns []string, fn func(values []any) error) error
 }
 
 type companyQueryImpl struct {
@@ -34,12 +63,13 @@ func NewCompanyQuery(db config.GormPostgres) CompanyQuery {
 	return &companyQueryImpl{db: db}
 }
 
-func (c *companyQueryImpl) GetCompany(ctx context.Context) ([]models.Company, error) {
-	db := c.db.GetConnection()
+func (c *companyQueryImpl) GetCompany(ctx context.Context, filter models.CompanyFilter) ([]models.Company, error) {
+	db := conn(ctx, c.db)
 	company := []models.Company{}
 	if err := db.
 		WithContext(ctx).
 		Table("companies").
+		Scopes(companyFilterScope(filter)).
 		Find(&company).Error; err != nil {
 		return []models.Company{}, err
 	}
@@ -47,7 +77,7 @@ func (c *companyQueryImpl) GetCompany(ctx context.Context) ([]models.Company, er
 }
 
 func (c *companyQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	company := models.Company{}
 	if err := db.
 		WithContext(ctx).
@@ -63,7 +93,7 @@ func (c *companyQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (model
 }
 
 func (c *companyQueryImpl) GetCompanyByCompanyName(ctx context.Context, companyName string) (models.Company, error) {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	company := models.Company{}
 	if err := db.WithContext(ctx).Where("company_name = ?", companyName).Find(&company).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -76,7 +106,7 @@ func (c *companyQueryImpl) GetCompanyByCompanyName(ctx context.Context, companyN
 
 // search user using companyID
 func (c *companyQueryImpl) GetUserByCompanyID(ctx context.Context, companyID uint64) ([]models.User, error) {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	users := []models.User{}
 	if err := db.WithContext(ctx).
 		Where("company_id = ?", companyID).
@@ -86,8 +116,39 @@ func (c *companyQueryImpl) GetUserByCompanyID(ctx context.Context, companyID uin
 	return users, nil
 }
 
+// company is soft deleted so foreign keys do not stop it, every referring table is checked here
+var companyUsages = []struct {
+	name        string
+	table       string
+	column      string
+	softDeleted bool
+}{
+	{"department", "departments", "company_id", true},
+	{"employee", "employees", "company_id", true},
+	{"subsidiary company", "companies", "parent_id", true},
+	{"payroll run", "payroll_runs", "company_id", false},
+}
+
+func (c *companyQueryImpl) GetCompanyUsage(ctx context.Context, companyID uint64) (string, error) {
+	db := conn(ctx, c.db)
+	for _, usage := range companyUsages {
+		query := db.WithContext(ctx).Table(usage.table).Where(usage.column+" = ?", companyID)
+		if usage.softDeleted {
+			query = query.Where("deleted_at IS NULL")
+		}
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count > 0 {
+			return usage.name, nil
+		}
+	}
+	return "", nil
+}
+
 func (c *companyQueryImpl) GetSoftDeletedCompanies(ctx context.Context) ([]models.Company, error) {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	company := []models.Company{}
 	if err := db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Find(&company).Error; err != nil {
 		return nil, err
@@ -96,7 +157,7 @@ func (c *companyQueryImpl) GetSoftDeletedCompanies(ctx context.Context) ([]model
 }
 
 func (c companyQueryImpl) CreateCompany(ctx context.Context, company models.CompanyRequest) (models.CompanyRequest, error) {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	if err := db.
 		WithContext(ctx).
 		Table("companies").
@@ -107,7 +168,7 @@ func (c companyQueryImpl) CreateCompany(ctx context.Context, company models.Comp
 }
 
 func (c *companyQueryImpl) UpdateCompany(ctx context.Context, id uint64, company models.CompanyRequest) (models.CompanyRequest, error) {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	updatedCompany := models.CompanyRequest{}
 	if err := db.
 		WithContext(ctx).
@@ -123,7 +184,7 @@ func (c *companyQueryImpl) UpdateCompany(ctx context.Context, id uint64, company
 }
 
 func (c *companyQueryImpl) DeleteCompany(ctx context.Context, id uint64) error {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	if err := db.
 		WithContext(ctx).
 		Table("companies").
@@ -135,7 +196,7 @@ func (c *companyQueryImpl) DeleteCompany(ctx context.Context, id uint64) error {
 }
 
 // func (c *companyQueryImpl) RestoreCompany(ctx context.Context, id uint64) error {
-// 	db := c.db.GetConnection()
+// 	db := conn(ctx, c.db)
 // 	query := "UPDATE companies SET deleted_at = NULL WHERE id = ?"
 // 	if err := db.Exec(query, id).Error; err != nil {
 // 		return err
@@ -144,7 +205,7 @@ func (c *companyQueryImpl) DeleteCompany(ctx context.Context, id uint64) error {
 // }
 
 func (c *companyQueryImpl) RestoreCompany(ctx context.Context, id uint64) error {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	if err := db.
 		WithContext(ctx).
 		Unscoped().
@@ -156,3 +217,287 @@ func (c *companyQueryImpl) RestoreCompany(ctx context.Context, id uint64) error
 	}
 	return nil
 }
+
+func (c *companyQueryImpl) GetCompanyAddresses(ctx context.Context, companyID uint64) ([]models.CompanyAddress, error) {
+	db := conn(ctx, c.db)
+	addresses := []models.CompanyAddress{}
+	if err := db.
+		WithContext(ctx).
+		Table("company_addresses").
+		Where("company_id = ?", companyID).
+		Order("id").
+		Find(&addresses).Error; err != nil {
+		return []models.CompanyAddress{}, err
+	}
+	return addresses, nil
+}
+
+func (c *companyQueryImpl) GetCompanyContacts(ctx context.Context, companyID uint64) ([]models.CompanyContact, error) {
+	db := conn(ctx, c.db)
+	contacts := []models.CompanyContact{}
+	if err := db.
+		WithContext(ctx).
+		Table("company_contacts").
+		Where("company_id = ?", companyID).
+		Order("is_primary DESC, id").
+		Find(&contacts).Error; err != nil {
+		return []models.CompanyContact{}, err
+	}
+	return contacts, nil
+}
+
+func (c *companyQueryImpl) SaveCompanyAddresses(ctx context.Context, companyID uint64, addresses []models.CompanyAddress) error {
+	db := conn(ctx, c.db)
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Table("company_addresses").
+			Where("company_id = ?", companyID).
+			Delete(&models.CompanyAddress{}).Error; err != nil {
+			return err
+		}
+		if len(addresses) == 0 {
+			return nil
+		}
+		for i := range addresses {
+			addresses[i].ID = 0
+			addresses[i].CompanyID = companyID
+		}
+		return tx.Table("company_addresses").Create(&addresses).Error
+	})
+}
+
+func (c *companyQueryImpl) SaveCompanyContacts(ctx context.Context, companyID uint64, contacts []models.CompanyContact) error {
+	db := conn(ctx, c.db)
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Table("company_contacts").
+			Where("company_id = ?", companyID).
+			Delete(&models.CompanyContact{}).Error; err != nil {
+			return err
+		}
+		if len(contacts) == 0 {
+			return nil
+		}
+		for i := range contacts {
+			contacts[i].ID = 0
+			contacts[i].CompanyID = companyID
+		}
+		return tx.Table("company_contacts").Create(&contacts).Error
+	})
+}
+
+func (c *companyQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, c.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// company hierarchy is limited in depth so broken data can not loop forever
+const maxCompanyDepth = 20
+
+func (c *companyQueryImpl) GetCompanySubtree(ctx context.Context, rootID uint64) ([]models.CompanyTreeRow, error) {
+	db := conn(ctx, c.db)
+	rows := []models.CompanyTreeRow{}
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id, 0 AS depth FROM companies WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id, s.depth + 1
+			FROM companies c
+			JOIN subtree s ON c.parent_id = s.id
+			WHERE c.deleted_at IS NULL AND s.depth < ?
+		)
+		SELECT c.id, c.company_name, c.legal_name, c.parent_id, s.depth
+		FROM subtree s
+		JOIN companies c ON c.id = s.id
+		ORDER BY s.depth, c.id`
+	if err := db.
+		WithContext(ctx).
+		Raw(query, rootID, maxCompanyDepth).
+		Scan(&rows).Error; err != nil {
+		return []models.CompanyTreeRow{}, err
+	}
+	return rows, nil
+}
+
+func (c *companyQueryImpl) LockCompanyHierarchy(ctx context.Context) error {
+	db := conn(ctx, c.db)
+	return lockCompanyHierarchy(db.WithContext(ctx))
+}
+
+// two concurrent moves could build a cycle together, advisory lock is reentrant
+// so caller already holding it in the same transaction is not blocked
+func lockCompanyHierarchy(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext('company_hierarchy'))").Error
+}
+
+func (c *companyQueryImpl) SetParentCompany(ctx context.Context, id uint64, parentID *uint64) error {
+	db := conn(ctx, c.db)
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCompanyHierarchy(tx); err != nil {
+			return err
+		}
+		if parentID != nil {
+			// parent inside the subtree of company means company is an ancestor of parent
+			cycle, err := companyInGroup(tx, id, *parentID)
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return errors.New("parent company cannot be the company itself or one of its subsidiaries")
+			}
+		}
+		return tx.
+			Table("companies").
+			Where("id = ?", id).
+			Update("parent_id", parentID).Error
+	})
+}
+
+func (c *companyQueryImpl) IsCompanyInGroup(ctx context.Context, groupID uint64, companyID uint64) (bool, error) {
+	db := conn(ctx, c.db)
+	return companyInGroup(db.WithContext(ctx), groupID, companyID)
+}
+
+func (c *companyQueryImpl) GetCompanyRollup(ctx context.Context, rootID uint64, period models.Date) ([]models.CompanyRollupRow, error) {
+	db := conn(ctx, c.db)
+	rows := []models.CompanyRollupRow{}
+	query := `
+		WITH RECURSIVE subtree AS (
+			SELECT id, 0 AS depth FROM companies WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id, s.depth + 1
+			FROM companies c
+			JOIN subtree s ON c.parent_id = s.id
+			WHERE c.deleted_at IS NULL AND s.depth < ?
+		)
+		SELECT c.id AS company_id, c.company_name, c.parent_id, s.depth, c.currency,
+			(SELECT COUNT(*) FROM employees e
+				WHERE e.company_id = c.id AND e.status = ? AND e.deleted_at IS NULL) AS headcount,
+			COALESCE(pr.status, '') AS payroll_status,
+			COALESCE(pr.gross_total, 0) AS gross_total,
+			COALESCE(pr.deduction_total, 0) AS deduction_total,
+			COALESCE(pr.net_total, 0) AS net_total
+		FROM subtree s
+		JOIN companies c ON c.id = s.id
+		LEFT JOIN payroll_runs pr ON pr.company_id = c.id AND pr.period = ?
+		ORDER BY s.depth, c.id`
+	if err := db.
+		WithContext(ctx).
+		Raw(query, rootID, maxCompanyDepth, models.EMPLOYEE_STATUS_ACTIVE, period).
+		Scan(&rows).Error; err != nil {
+		return []models.CompanyRollupRow{}, err
+	}
+	return rows, nil
+}
+
+// companyInGroup walk up from company through parent companies looking for group,
+// behind IsCompanyInGroup that service.CompanyScope use, and the cycle check of SetParentCompany
+func companyInGroup(db *gorm.DB, groupID uint64, companyID uint64) (bool, error) {
+	if groupID == companyID {
+		return true, nil
+	}
+	var found bool
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id, 0 AS depth FROM companies WHERE id = ?
+			UNION ALL
+			SELECT c.id, c.parent_id, a.depth + 1
+			FROM companies c
+			JOIN ancestors a ON c.id = a.parent_id
+			WHERE a.depth < ?
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = ?)`
+	if err := db.Raw(query, companyID, maxCompanyDepth, groupID).Scan(&found).Error; err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+func (c *companyQueryImpl) GetOrgChart(ctx context.Context, companyID uint64) ([]models.OrgChartRow, error) {
+	db := conn(ctx, c.db)
+	rows := []models.OrgChartRow{}
+	// root is user without active manager in the same company
+	query := `
+		WITH RECURSIVE chart AS (
+			SELECT u.id, 0 AS depth
+			FROM users u
+			WHERE u.company_id = ? AND u.deleted_at IS NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM users m
+					WHERE m.id = u.manager_id AND m.company_id = u.company_id AND m.deleted_at IS NULL
+				)
+			UNION ALL
+			SELECT u.id, c.depth + 1
+			FROM users u
+			JOIN chart c ON u.manager_id = c.id
+			WHERE u.company_id = ? AND u.deleted_at IS NULL AND c.depth < 50
+		)
+		SELECT u.id AS user_id, u.first_name, u.last_name, u.manager_id, u.position_id,
+			COALESCE(p.position_name, '') AS position_name, c.depth
+		FROM chart c
+		JOIN users u ON u.id = c.id
+		LEFT JOIN positions p ON p.id = u.position_id
+		ORDER BY c.depth, u.id`
+	if err := db.
+		WithContext(ctx).
+		Raw(query, companyID, companyID).
+		Scan(&rows).Error; err != nil {
+		return []models.OrgChartRow{}, err
+	}
+	return rows, nil
+}
+
+func (c *companyQueryImpl) CountCompany(ctx context.Context, filter models.CompanyFilter) (int64, error) {
+	db := conn(ctx, c.db)
+	var count int64
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("deleted_at IS NULL").
+		Scopes(companyFilterScope(filter)).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (c *companyQueryImpl) StreamCompany(ctx context.Context, filter models.CompanyFilter, columns []string, fn func(values []any) error) error {
+	db := conn(ctx, c.db)
+	query := db.
+		WithContext(ctx).
+		Table("companies").
+		Select(columns).
+		Where("deleted_at IS NULL").
+		Scopes(companyFilterScope(filter)).
+		Order("id")
+	return streamRows(query, fn)
+}
+
+func companyFilterScope(filter models.CompanyFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.CompanyName != "" {
+			db = db.Where("company_name ILIKE ?", "%"+filter.CompanyName+"%")
+		}
+		if filter.GroupID != 0 {
+			db = db.Where(`id IN (
+				WITH RECURSIVE subtree AS (
+					SELECT id, 0 AS depth FROM companies WHERE id = ?
+					UNION ALL
+					SELECT c.id, s.depth + 1
+					FROM companies c
+					JOIN subtree s ON c.parent_id = s.id
+					WHERE s.depth < ?
+				)
+				SELECT id FROM subtree)`, filter.GroupID, maxCompanyDepth)
+		}
+		return db
+	}
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+)
+
+type DepartmentQuery interface {
+	GetDepartment(ctx context.Context, filter models.DepartmentFilter) ([]models.Department, error)
+	GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error)
+	GetDepartmentByDepartmentName(ctx context.Context, companyID uint64, departmentName string) (models.Department, error)
+
+	CreateDepartment(ctx context.Context, department models.DepartmentRequest) (models.DepartmentRequest, error)
+	UpdateDepartment(ctx context.Context, id uint64, department models.DepartmentRequest) (models.DepartmentRequest, error)
+
+	DeleteDepartment(ctx context.Context, id uint64) error
+
+	// mencari user dan sub department yang menggunakan departmentID
+	GetUserByDepartmentID(ctx context.Context, departmentID uint64) ([]models.User, error)
+	GetDepartmentByParentID(ctx context.Context, parentID uint64) ([]models.Department, error)
+
+	// validasi relasi company dan head department
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+
+	GetSoftDeletedDepartments(ctx context.Context, companyID uint64) ([]models.Department, error)
+	GetDeletedDepartmentByID(ctx context.Context, id uint64) (models.Department, error)
+
+	RestoreDepartment(ctx context.Context, id uint64) error
+}
+
+type departmentQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewDepartmentQuery(db config.GormPostgres) DepartmentQuery {
+	return &departmentQueryImpl{db: db}
+}
+
+func (d *departmentQueryImpl) GetDepartment(ctx context.Context, filter models.DepartmentFilter) ([]models.Department, error) {
+	db := conn(ctx, d.db)
+	departments := []models.Department{}
+	query := db.
+		WithContext(ctx).
+		Table("departments")
+	if filter.CompanyID != 0 {
+		query = query.Where("company_id = ?", filter.CompanyID)
+	}
+	if filter.ParentID != 0 {
+		query = query.Where("parent_id = ?", filter.ParentID)
+	}
+	if err := query.Find(&departments).Error; err != nil {
+		return []models.Department{}, err
+	}
+	return departments, nil
+}
+
+func (d *departmentQueryImpl) GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error) {
+	db := conn(ctx, d.db)
+	department := models.Department{}
+	if err := db.
+		WithContext(ctx).
+		Table("departments").
+		Where("id = ?", id).
+		Find(&department).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.Department{}, nil
+		}
+		return models.Department{}, err
+	}
+	return department, nil
+}
+
+func (d *departmentQueryImpl) GetDepartmentByDepartmentName(ctx context.Context, companyID uint64, departmentName string) (models.Department, error) {
+	db := conn(ctx, d.db)
+	department := models.Department{}
+	if err := db.
+		WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Where("department_name = ?", departmentName).
+		Find(&department).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.Department{}, nil
+		}
+		return models.Department{}, err
+	}
+	return department, nil
+}
+
+// search user using departmentID
+func (d *departmentQueryImpl) GetUserByDepartmentID(ctx context.Context, departmentID uint64) ([]models.User, error) {
+	db := conn(ctx, d.db)
+	users := []models.User{}
+	if err := db.WithContext(ctx).
+		Where("department_id = ?", departmentID).
+		Find(&users).Error; err != nil {
+		return []models.User{}, err
+	}
+	return users, nil
+}
+
+func (d *departmentQueryImpl) GetDepartmentByParentID(ctx context.Context, parentID uint64) ([]models.Department, error) {
+	db := conn(ctx, d.db)
+	departments := []models.Department{}
+	if err := db.WithContext(ctx).
+		Where("parent_id = ?", parentID).
+		Find(&departments).Error; err != nil {
+		return []models.Department{}, err
+	}
+	return departments, nil
+}
+
+func (d *departmentQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, d.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
+
+func (d *departmentQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, d.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (d *departmentQueryImpl) GetSoftDeletedDepartments(ctx context.Context, companyID uint64) ([]models.Department, error) {
+	db := conn(ctx, d.db)
+	departments := []models.Department{}
+	if err := db.WithContext(ctx).
+		Unscoped().
+		Where("company_id = ?", companyID).
+		Where("deleted_at IS NOT NULL").
+		Find(&departments).Error; err != nil {
+		return nil, err
+	}
+	return departments, nil
+}
+
+func (d *departmentQueryImpl) GetDeletedDepartmentByID(ctx context.Context, id uint64) (models.Department, error) {
+	db := conn(ctx, d.db)
+	department := models.Department{}
+	if err := db.WithContext(ctx).
+		Unscoped().
+		Where("id = ?", id).
+		Where("deleted_at IS NOT NULL").
+		Find(&department).Error; err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
+
+func (d *departmentQueryImpl) CreateDepartment(ctx context.Context, department models.DepartmentRequest) (models.DepartmentRequest, error) {
+	db := conn(ctx, d.db)
+	if err := db.
+		WithContext(ctx).
+		Table("departments").
+		Save(&department).Error; err != nil {
+		return models.DepartmentRequest{}, err
+	}
+	return department, nil
+}
+
+func (d *departmentQueryImpl) UpdateDepartment(ctx context.Context, id uint64, department models.DepartmentRequest) (models.DepartmentRequest, error) {
+	db := conn(ctx, d.db)
+	updatedDepartment := models.DepartmentRequest{}
+	if err := db.
+		WithContext(ctx).
+		Table("departments").
+		Where("id = ?", id).
+		Updates(&department).
+		First(&updatedDepartment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.DepartmentRequest{}, nil
+		}
+		return models.DepartmentRequest{}, err
+	}
+	return updatedDepartment, nil
+}
+
+func (d *departmentQueryImpl) DeleteDepartment(ctx context.Context, id uint64) error {
+	db := conn(ctx, d.db)
+	if err := db.
+		WithContext(ctx).
+		Table("departments").
+		Delete(&models.Department{ID: id}).
+		Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (d *departmentQueryImpl) RestoreDepartment(ctx context.Context, id uint64) error {
+	db := conn(ctx, d.db)
+	if err := db.
+		WithContext(ctx).
+		Unscoped().
+		Model(&models.Department{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).
+		Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+)
+
+type EmployeeQuery interface {
+	GetEmployees(ctx context.Context, filter models.EmployeeFilter) ([]models.Employee, error)
+	GetEmployeeByID(ctx context.Context, id uint64) (models.Employee, error)
+	GetEmployeeByEmployeeNumber(ctx context.Context, companyID uint64, employeeNumber string) (models.Employee, error)
+	GetEmployeeByUserID(ctx context.Context, userID uint64) (models.Employee, error)
+
+	CreateEmployee(ctx context.Context, employee models.EmployeeCreateRequest) (models.EmployeeCreateRequest, error)
+	UpdateEmployee(ctx context.Context, id uint64, employee models.EmployeeUpdateRequest) (models.Employee, error)
+
+	// update status, employment type and termination of employee
+	UpdateEmployeeLifecycle(ctx context.Context, id uint64, fields map[string]any) (models.Employee, error)
+
+	DeleteEmployee(ctx context.Context, id uint64) error
+
+	// validasi relasi company dan user
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+}
+
+type employeeQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewEmployeeQuery(db config.GormPostgres) EmployeeQuery {
+	return &employeeQueryImpl{db: db}
+}
+
+func (e *employeeQueryImpl) GetEmployees(ctx context.Context, filter models.EmployeeFilter) ([]models.Employee, error) {
+	db := conn(ctx, e.db)
+	employees := []models.Employee{}
+	query := db.
+		WithContext(ctx).
+		Table("employees").
+		Where("company_id = ?", filter.CompanyID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EmploymentType != "" {
+		query = query.Where("employment_type = ?", filter.EmploymentType)
+	}
+	if err := query.Order("employee_number").Find(&employees).Error; err != nil {
+		return []models.Employee{}, err
+	}
+	return employees, nil
+}
+
+func (e *employeeQueryImpl) GetEmployeeByID(ctx context.Context, id uint64) (models.Employee, error) {
+	db := conn(ctx, e.db)
+	employee := models.Employee{}
+	if err := db.
+		WithContext(ctx).
+		Table("employees").
+		Where("id = ?", id).
+		Find(&employee).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.Employee{}, nil
+		}
+		return models.Employee{}, err
+	}
+	return employee, nil
+}
+
+func (e *employeeQueryImpl) GetEmployeeByEmployeeNumber(ctx context.Context, companyID uint64, employeeNumber string) (models.Employee, error) {
+	db := conn(ctx, e.db)
+	employee := models.Employee{}
+	if err := db.
+		WithContext(ctx).
+		Where("company_id = ?", companyID).
+		Where("employee_number = ?", employeeNumber).
+		Find(&employee).Error; err != nil {
+		return models.Employee{}, err
+	}
+	return employee, nil
+}
+
+func (e *employeeQueryImpl) GetEmployeeByUserID(ctx context.Context, userID uint64) (models.Employee, error) {
+	db := conn(ctx, e.db)
+	employee := models.Employee{}
+	if err := db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Find(&employee).Error; err != nil {
+		return models.Employee{}, err
+	}
+	return employee, nil
+}
+
+func (e *employeeQueryImpl) CreateEmployee(ctx context.Context, employee models.EmployeeCreateRequest) (models.EmployeeCreateRequest, error) {
+	db := conn(ctx, e.db)
+	if err := db.
+		WithContext(ctx).
+		Table("employees").
+		Save(&employee).Error; err != nil {
+		return models.EmployeeCreateRequest{}, err
+	}
+	return employee, nil
+}
+
+func (e *employeeQueryImpl) UpdateEmployee(ctx context.Context, id uint64, employee models.EmployeeUpdateRequest) (models.Employee, error) {
+	db := conn(ctx, e.db)
+	updatedEmployee := models.Employee{}
+	if err := db.
+		WithContext(ctx).
+		Table("employees").
+		Where("id = ?", id).
+		Updates(&employee).
+		First(&updatedEmployee).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.Employee{}, nil
+		}
+		return models.Employee{}, err
+	}
+	return updatedEmployee, nil
+}
+
+func (e *employeeQueryImpl) UpdateEmployeeLifecycle(ctx context.Context, id uint64, fields map[string]any) (models.Employee, error) {
+	db := conn(ctx, e.db)
+	fields["updated_at"] = time.Now()
+	updatedEmployee := models.Employee{}
+	if err := db.
+		WithContext(ctx).
+		Table("employees").
+		Where("id = ?", id).
+		Updates(fields).
+		First(&updatedEmployee).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.Employee{}, nil
+		}
+		return models.Employee{}, err
+	}
+	return updatedEmployee, nil
+}
+
+func (e *employeeQueryImpl) DeleteEmployee(ctx context.Context, id uint64) error {
+	db := conn(ctx, e.db)
+	if err := db.
+		WithContext(ctx).
+		Table("employees").
+		Delete(&models.Employee{ID: id}).
+		Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *employeeQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, e.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
+
+func (e *employeeQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, e.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
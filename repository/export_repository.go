@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExportQuery interface {
+	GetExportJobByID(ctx context.Context, id uint64) (models.ExportJob, error)
+
+	CreateExportJob(ctx context.Context, job models.ExportJob) (models.ExportJob, error)
+
+	// ambil job pending paling lama dan tandai running
+	ClaimNextExportJob(ctx context.Context) (models.ExportJob, error)
+	FinishExportJob(ctx context.Context, id uint64, status string, filePath string, rowCount int64, reason string) error
+	// kembalikan job running ke pending, dipakai saat worker berhenti di tengah job
+	ReleaseExportJob(ctx context.Context, id uint64) error
+}
+
+type exportQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewExportQuery(db config.GormPostgres) ExportQuery {
+	return &exportQueryImpl{db: db}
+}
+
+func (e *exportQueryImpl) GetExportJobByID(ctx context.Context, id uint64) (models.ExportJob, error) {
+	db := e.db.GetConnection()
+	job := models.ExportJob{}
+	if err := db.
+		WithContext(ctx).
+		Table("export_jobs").
+		Where("id = ?", id).
+		Find(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.ExportJob{}, nil
+		}
+		return models.ExportJob{}, err
+	}
+	return job, nil
+}
+
+func (e *exportQueryImpl) CreateExportJob(ctx context.Context, job models.ExportJob) (models.ExportJob, error) {
+	db := e.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("export_jobs").
+		Create(&job).Error; err != nil {
+		return models.ExportJob{}, err
+	}
+	return job, nil
+}
+
+func (e *exportQueryImpl) ClaimNextExportJob(ctx context.Context) (models.ExportJob, error) {
+	db := e.db.GetConnection()
+	job := models.ExportJob{}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Table("export_jobs").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.EXPORT_STATUS_PENDING).
+			Order("id").
+			Limit(1).
+			Find(&job).Error; err != nil {
+			return err
+		}
+		if job.ID == 0 {
+			return nil
+		}
+		job.Status = models.EXPORT_STATUS_RUNNING
+		return tx.
+			Table("export_jobs").
+			Where("id = ?", job.ID).
+			Updates(map[string]any{"status": job.Status, "updated_at": time.Now()}).Error
+	})
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+	return job, nil
+}
+
+func (e *exportQueryImpl) FinishExportJob(ctx context.Context, id uint64, status string, filePath string, rowCount int64, reason string) error {
+	db := e.db.GetConnection()
+	now := time.Now()
+	if err := db.
+		WithContext(ctx).
+		Table("export_jobs").
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":       status,
+			"file_path":    filePath,
+			"row_count":    rowCount,
+			"error_reason": reason,
+			"updated_at":   now,
+			"finished_at":  now,
+		}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (e *exportQueryImpl) ReleaseExportJob(ctx context.Context, id uint64) error {
+	db := e.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("export_jobs").
+		Where("id = ? AND status = ?", id, models.EXPORT_STATUS_RUNNING).
+		Updates(map[string]any{
+			"status":     models.EXPORT_STATUS_PENDING,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		return err
+	}
+	return nil
+}
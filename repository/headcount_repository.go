@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+)
+
+type HeadcountQuery interface {
+	GetHeadcountPlans(ctx context.Context, companyID uint64, period models.Date) ([]models.HeadcountPlan, error)
+	GetHeadcountPlanByID(ctx context.Context, id uint64) (models.HeadcountPlan, error)
+	// plan of the same company, department and position in period, zero plan when not found
+	GetHeadcountPlan(ctx context.Context, plan models.HeadcountPlan) (models.HeadcountPlan, error)
+	CreateHeadcountPlan(ctx context.Context, plan models.HeadcountPlan) (models.HeadcountPlan, error)
+	UpdateHeadcountPlan(ctx context.Context, plan models.HeadcountPlan) (models.HeadcountPlan, error)
+	DeleteHeadcountPlan(ctx context.Context, id uint64) error
+
+	// users placed in company on asOf, from assignment valid on that date.
+	// user without any assignment history is counted on the placement of users table
+	GetFilledSeats(ctx context.Context, companyID uint64, asOf models.Date) ([]models.HeadcountFilled, error)
+
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+	GetPositionByID(ctx context.Context, id uint64) (models.Position, error)
+	GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error)
+}
+
+type headcountQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewHeadcountQuery(db config.GormPostgres) HeadcountQuery {
+	return &headcountQueryImpl{db: db}
+}
+
+const headcountPlanColumns = "hp.*, COALESCE(d.department_name, '') AS department_name, COALESCE(p.position_name, '') AS position_name"
+
+func (h *headcountQueryImpl) GetHeadcountPlans(ctx context.Context, companyID uint64, period models.Date) ([]models.HeadcountPlan, error) {
+	db := conn(ctx, h.db)
+	plans := []models.HeadcountPlan{}
+	if err := db.
+		WithContext(ctx).
+		Table("headcount_plans hp").
+		Select(headcountPlanColumns).
+		Joins("LEFT JOIN departments d ON d.id = hp.department_id").
+		Joins("LEFT JOIN positions p ON p.id = hp.position_id").
+		Where("hp.company_id = ? AND hp.period = ?", companyID, period).
+		Order("hp.position_id, hp.department_id NULLS FIRST").
+		Find(&plans).Error; err != nil {
+		return []models.HeadcountPlan{}, err
+	}
+	return plans, nil
+}
+
+func (h *headcountQueryImpl) GetHeadcountPlanByID(ctx context.Context, id uint64) (models.HeadcountPlan, error) {
+	db := conn(ctx, h.db)
+	plan := models.HeadcountPlan{}
+	if err := db.
+		WithContext(ctx).
+		Table("headcount_plans hp").
+		Select(headcountPlanColumns).
+		Joins("LEFT JOIN departments d ON d.id = hp.department_id").
+		Joins("LEFT JOIN positions p ON p.id = hp.position_id").
+		Where("hp.id = ?", id).
+		Find(&plan).Error; err != nil {
+		return models.HeadcountPlan{}, err
+	}
+	return plan, nil
+}
+
+func (h *headcountQueryImpl) GetHeadcountPlan(ctx context.Context, plan models.HeadcountPlan) (models.HeadcountPlan, error) {
+	db := conn(ctx, h.db)
+	existing := models.HeadcountPlan{}
+	query := db.
+		WithContext(ctx).
+		Table("headcount_plans").
+		Where("company_id = ? AND position_id = ? AND period = ?", plan.CompanyID, plan.PositionID, plan.Period)
+	if plan.DepartmentID == nil {
+		query = query.Where("department_id IS NULL")
+	} else {
+		query = query.Where("department_id = ?", *plan.DepartmentID)
+	}
+	if err := query.Find(&existing).Error; err != nil {
+		return models.HeadcountPlan{}, err
+	}
+	return existing, nil
+}
+
+func (h *headcountQueryImpl) CreateHeadcountPlan(ctx context.Context, plan models.HeadcountPlan) (models.HeadcountPlan, error) {
+	db := conn(ctx, h.db)
+	if err := db.
+		WithContext(ctx).
+		Table("headcount_plans").
+		Omit("department_name", "position_name").
+		Create(&plan).Error; err != nil {
+		return models.HeadcountPlan{}, err
+	}
+	return h.GetHeadcountPlanByID(ctx, plan.ID)
+}
+
+func (h *headcountQueryImpl) UpdateHeadcountPlan(ctx context.Context, plan models.HeadcountPlan) (models.HeadcountPlan, error) {
+	db := conn(ctx, h.db)
+	if err := db.
+		WithContext(ctx).
+		Table("headcount_plans").
+		Where("id = ?", plan.ID).
+		Updates(map[string]any{"planned": plan.Planned, "updated_at": time.Now()}).Error; err != nil {
+		return models.HeadcountPlan{}, err
+	}
+	return h.GetHeadcountPlanByID(ctx, plan.ID)
+}
+
+func (h *headcountQueryImpl) DeleteHeadcountPlan(ctx context.Context, id uint64) error {
+	db := conn(ctx, h.db)
+	return db.
+		WithContext(ctx).
+		Table("headcount_plans").
+		Where("id = ?", id).
+		Delete(&models.HeadcountPlan{}).Error
+}
+
+func (h *headcountQueryImpl) GetFilledSeats(ctx context.Context, companyID uint64, asOf models.Date) ([]models.HeadcountFilled, error) {
+	db := conn(ctx, h.db)
+	filled := []models.HeadcountFilled{}
+	query := `
+		WITH placement AS (
+			SELECT a.company_id, a.department_id, a.position_id
+			FROM assignments a
+			JOIN users u ON u.id = a.user_id AND u.deleted_at IS NULL
+			WHERE a.valid_from <= @as_of AND (a.valid_to IS NULL OR a.valid_to > @as_of)
+			UNION ALL
+			SELECT u.company_id, u.department_id, u.position_id
+			FROM users u
+			WHERE u.deleted_at IS NULL AND u.created_at::date <= @as_of
+				AND NOT EXISTS (SELECT 1 FROM assignments a WHERE a.user_id = u.id)
+		)
+		SELECT company_id, department_id, position_id, COUNT(*) AS filled
+		FROM placement
+		WHERE company_id = @company_id
+		GROUP BY company_id, department_id, position_id`
+	if err := db.
+		WithContext(ctx).
+		Raw(query, map[string]any{"as_of": asOf, "company_id": companyID}).
+		Scan(&filled).Error; err != nil {
+		return []models.HeadcountFilled{}, err
+	}
+	return filled, nil
+}
+
+func (h *headcountQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, h.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
+
+func (h *headcountQueryImpl) GetPositionByID(ctx context.Context, id uint64) (models.Position, error) {
+	db := conn(ctx, h.db)
+	position := models.Position{}
+	if err := db.
+		WithContext(ctx).
+		Table("positions").
+		Where("id = ?", id).
+		Find(&position).Error; err != nil {
+		return models.Position{}, err
+	}
+	return position, nil
+}
+
+func (h *headcountQueryImpl) GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error) {
+	db := conn(ctx, h.db)
+	department := models.Department{}
+	if err := db.
+		WithContext(ctx).
+		Table("departments").
+		Where("id = ?", id).
+		Find(&department).Error; err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+)
+
+type HealthQuery interface {
+	Ping(ctx context.Context) error
+	// version yang sudah dijalankan dari tabel schema_migrations
+	GetAppliedMigrations(ctx context.Context) ([]string, error)
+	GetPoolStats() (models.DatabaseStatus, error)
+}
+
+type healthQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewHealthQuery(db config.GormPostgres) HealthQuery {
+	return &healthQueryImpl{db: db}
+}
+
+func (h *healthQueryImpl) Ping(ctx context.Context) error {
+	db, err := h.db.GetConnection().DB()
+	if err != nil {
+		return err
+	}
+	return db.PingContext(ctx)
+}
+
+func (h *healthQueryImpl) GetAppliedMigrations(ctx context.Context) ([]string, error) {
+	db := h.db.GetConnection()
+	versions := []string{}
+	if err := db.
+		WithContext(ctx).
+		Table("schema_migrations").
+		Order("version").
+		Pluck("version", &versions).Error; err != nil {
+		return []string{}, err
+	}
+	return versions, nil
+}
+
+func (h *healthQueryImpl) GetPoolStats() (models.DatabaseStatus, error) {
+	db, err := h.db.GetConnection().DB()
+	if err != nil {
+		return models.DatabaseStatus{}, err
+	}
+	stats := db.Stats()
+	return models.DatabaseStatus{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.String(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
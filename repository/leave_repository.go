@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeaveQuery interface {
+	GetLeaveTypes(ctx context.Context, companyID uint64) ([]models.LeaveType, error)
+	GetLeaveTypeByID(ctx context.Context, id uint64) (models.LeaveType, error)
+	GetLeaveTypeByCode(ctx context.Context, companyID uint64, code string) (models.LeaveType, error)
+	CreateLeaveType(ctx context.Context, leaveType models.LeaveType) (models.LeaveType, error)
+	UpdateLeaveType(ctx context.Context, leaveType models.LeaveType) (models.LeaveType, error)
+	DeleteLeaveType(ctx context.Context, id uint64) error
+
+	GetLeaveBalance(ctx context.Context, userID uint64, leaveTypeID uint64, year int) (models.LeaveBalance, error)
+	GetLeaveBalances(ctx context.Context, userID uint64, year int) ([]models.LeaveBalance, error)
+	// create balance when it does not exist yet, existing balance is kept
+	CreateLeaveBalance(ctx context.Context, balance models.LeaveBalance) error
+
+	GetLeaveRequestByID(ctx context.Context, id uint64) (models.LeaveRequest, error)
+	GetLeaveRequestsByUserID(ctx context.Context, userID uint64) ([]models.LeaveRequest, error)
+	// pending request of the whole company for admin, or of direct reports for manager
+	GetPendingLeaveRequests(ctx context.Context, companyID uint64, managerID uint64, isAdmin bool) ([]models.LeaveRequest, error)
+
+	// CreateLeaveRequest check overlap and reserve balance in one transaction,
+	// credit nil means the leave type has no balance limit
+	CreateLeaveRequest(ctx context.Context, request models.LeaveRequest, credit *float64) (models.LeaveRequest, error)
+	// UpdateLeaveRequestStatus move request from status and adjust balance in one transaction
+	UpdateLeaveRequestStatus(ctx context.Context, request models.LeaveRequest, fromStatus string, pendingDelta float64, usedDelta float64) (models.LeaveRequest, error)
+
+	GetLeaveCalendar(ctx context.Context, companyID uint64, from models.Date, to models.Date, departmentID uint64, statuses []string) ([]models.LeaveCalendarEntry, error)
+
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+}
+
+type leaveQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewLeaveQuery(db config.GormPostgres) LeaveQuery {
+	return &leaveQueryImpl{db: db}
+}
+
+func (l *leaveQueryImpl) GetLeaveTypes(ctx context.Context, companyID uint64) ([]models.LeaveType, error) {
+	db := conn(ctx, l.db)
+	leaveTypes := []models.LeaveType{}
+	if err := db.
+		WithContext(ctx).
+		Table("leave_types").
+		Where("company_id = ?", companyID).
+		Where("deleted_at IS NULL").
+		Order("id").
+		Find(&leaveTypes).Error; err != nil {
+		return []models.LeaveType{}, err
+	}
+	return leaveTypes, nil
+}
+
+func (l *leaveQueryImpl) GetLeaveTypeByID(ctx context.Context, id uint64) (models.LeaveType, error) {
+	db := conn(ctx, l.db)
+	leaveType := models.LeaveType{}
+	if err := db.
+		WithContext(ctx).
+		Table("leave_types").
+		Where("id = ?", id).
+		Where("deleted_at IS NULL").
+		Find(&leaveType).Error; err != nil {
+		return models.LeaveType{}, err
+	}
+	return leaveType, nil
+}
+
+func (l *leaveQueryImpl) GetLeaveTypeByCode(ctx context.Context, companyID uint64, code string) (models.LeaveType, error) {
+	db := conn(ctx, l.db)
+	leaveType := models.LeaveType{}
+	if err := db.
+		WithContext(ctx).
+		Table("leave_types").
+		Where("company_id = ?", companyID).
+		Where("code = ?", code).
+		Where("deleted_at IS NULL").
+		Find(&leaveType).Error; err != nil {
+		return models.LeaveType{}, err
+	}
+	return leaveType, nil
+}
+
+func (l *leaveQueryImpl) CreateLeaveType(ctx context.Context, leaveType models.LeaveType) (models.LeaveType, error) {
+	db := conn(ctx, l.db)
+	if err := db.
+		WithContext(ctx).
+		Table("leave_types").
+		Create(&leaveType).Error; err != nil {
+		return models.LeaveType{}, err
+	}
+	return leaveType, nil
+}
+
+func (l *leaveQueryImpl) UpdateLeaveType(ctx context.Context, leaveType models.LeaveType) (models.LeaveType, error) {
+	db := conn(ctx, l.db)
+	if err := db.
+		WithContext(ctx).
+		Table("leave_types").
+		Where("id = ?", leaveType.ID).
+		Select("name", "is_paid", "requires_balance", "yearly_entitlement", "accrual", "carry_over_max", "updated_at").
+		Updates(&leaveType).Error; err != nil {
+		return models.LeaveType{}, err
+	}
+	return leaveType, nil
+}
+
+func (l *leaveQueryImpl) DeleteLeaveType(ctx context.Context, id uint64) error {
+	db := conn(ctx, l.db)
+	if err := db.
+		WithContext(ctx).
+		Table("leave_types").
+		Where("id = ?", id).
+		Delete(&models.LeaveType{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *leaveQueryImpl) GetLeaveBalance(ctx context.Context, userID uint64, leaveTypeID uint64, year int) (models.LeaveBalance, error) {
+	db := conn(ctx, l.db)
+	balance := models.LeaveBalance{}
+	if err := db.
+		WithContext(ctx).
+		Table("leave_balances").
+		Where("user_id = ? AND leave_type_id = ? AND year = ?", userID, leaveTypeID, year).
+		Find(&balance).Error; err != nil {
+		return models.LeaveBalance{}, err
+	}
+	return balance, nil
+}
+
+func (l *leaveQueryImpl) GetLeaveBalances(ctx context.Context, userID uint64, year int) ([]models.LeaveBalance, error) {
+	db := conn(ctx, l.db)
+	balances := []models.LeaveBalance{}
+	if err := db.
+		WithContext(ctx).
+		Table("leave_balances").
+		Where("user_id = ? AND year = ?", userID, year).
+		Order("leave_type_id").
+		Find(&balances).Error; err != nil {
+		return []models.LeaveBalance{}, err
+	}
+	return balances, nil
+}
+
+func (l *leaveQueryImpl) CreateLeaveBalance(ctx context.Context, balance models.LeaveBalance) error {
+	db := conn(ctx, l.db)
+	if err := db.
+		WithContext(ctx).
+		Table("leave_balances").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&balance).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (l *leaveQueryImpl) GetLeaveRequestByID(ctx context.Context, id uint64) (models.LeaveRequest, error) {
+	db := conn(ctx, l.db)
+	request := models.LeaveRequest{}
+	if err := db.
+		WithContext(ctx).
+		Table("leave_requests").
+		Where("id = ?", id).
+		Find(&request).Error; err != nil {
+		return models.LeaveRequest{}, err
+	}
+	return request, nil
+}
+
+func (l *leaveQueryImpl) GetLeaveRequestsByUserID(ctx context.Context, userID uint64) ([]models.LeaveRequest, error) {
+	db := conn(ctx, l.db)
+	requests := []models.LeaveRequest{}
+	if err := db.
+		WithContext(ctx).
+		Table("leave_requests").
+		Where("user_id = ?", userID).
+		Order("start_date DESC").
+		Find(&requests).Error; err != nil {
+		return []models.LeaveRequest{}, err
+	}
+	return requests, nil
+}
+
+func (l *leaveQueryImpl) GetPendingLeaveRequests(ctx context.Context, companyID uint64, managerID uint64, isAdmin bool) ([]models.LeaveRequest, error) {
+	db := conn(ctx, l.db)
+	requests := []models.LeaveRequest{}
+	query := db.
+		WithContext(ctx).
+		Table("leave_requests").
+		Where("status = ?", models.LEAVE_STATUS_PENDING)
+	if isAdmin {
+		query = query.Where("company_id = ?", companyID)
+	} else {
+		query = query.Where("user_id IN (?)", db.Table("users").Select("id").Where("manager_id = ?", managerID))
+	}
+	if err := query.Order("start_date").Find(&requests).Error; err != nil {
+		return []models.LeaveRequest{}, err
+	}
+	return requests, nil
+}
+
+func (l *leaveQueryImpl) CreateLeaveRequest(ctx context.Context, request models.LeaveRequest, credit *float64) (models.LeaveRequest, error) {
+	// balance is debited for the year of start date only
+	if request.StartDate.Year() != request.EndDate.Year() {
+		return models.LeaveRequest{}, errors.New("leave cannot span two years, split the request")
+	}
+	db := conn(ctx, l.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// lock the user so two request of the same user can not pass overlap check together
+		if err := tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", request.UserID).Error; err != nil {
+			return err
+		}
+
+		var overlap int64
+		if err := tx.
+			Table("leave_requests").
+			Where("user_id = ?", request.UserID).
+			Where("status IN ?", []string{models.LEAVE_STATUS_PENDING, models.LEAVE_STATUS_APPROVED}).
+			Where("start_date <= ? AND end_date >= ?", request.EndDate, request.StartDate).
+			Count(&overlap).Error; err != nil {
+			return err
+		}
+		if overlap > 0 {
+			return errors.New("leave overlaps with another request")
+		}
+
+		balance := models.LeaveBalance{}
+		if err := tx.
+			Table("leave_balances").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND leave_type_id = ? AND year = ?", request.UserID, request.LeaveTypeID, request.StartDate.Year()).
+			Find(&balance).Error; err != nil {
+			return err
+		}
+		if balance.ID == 0 {
+			return errors.New("leave balance not found")
+		}
+		if credit != nil && balance.Used+balance.Pending+request.Days > *credit {
+			return errors.New("insufficient leave balance")
+		}
+
+		if err := tx.
+			Table("leave_balances").
+			Where("id = ?", balance.ID).
+			Updates(map[string]any{"pending": gorm.Expr("pending + ?", request.Days), "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Table("leave_requests").Create(&request).Error
+	})
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	return request, nil
+}
+
+func (l *leaveQueryImpl) UpdateLeaveRequestStatus(ctx context.Context, request models.LeaveRequest, fromStatus string, pendingDelta float64, usedDelta float64) (models.LeaveRequest, error) {
+	db := conn(ctx, l.db)
+	request.UpdatedAt = time.Now()
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// only one reviewer win when request is reviewed at the same time
+		result := tx.
+			Table("leave_requests").
+			Where("id = ? AND status = ?", request.ID, fromStatus).
+			Select("status", "reviewed_by", "reviewed_at", "review_note", "updated_at").
+			Updates(&request)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("leave request is no longer " + fromStatus)
+		}
+
+		return tx.
+			Table("leave_balances").
+			Where("user_id = ? AND leave_type_id = ? AND year = ?", request.UserID, request.LeaveTypeID, request.StartDate.Year()).
+			Updates(map[string]any{
+				"pending":    gorm.Expr("pending + ?", pendingDelta),
+				"used":       gorm.Expr("used + ?", usedDelta),
+				"updated_at": time.Now(),
+			}).Error
+	})
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	return request, nil
+}
+
+func (l *leaveQueryImpl) GetLeaveCalendar(ctx context.Context, companyID uint64, from models.Date, to models.Date, departmentID uint64, statuses []string) ([]models.LeaveCalendarEntry, error) {
+	db := conn(ctx, l.db)
+	entries := []models.LeaveCalendarEntry{}
+	query := db.
+		WithContext(ctx).
+		Table("leave_requests lr").
+		Select("lr.id, lr.user_id, u.first_name, u.last_name, u.department_id, lt.name AS leave_type_name, lr.start_date, lr.end_date, lr.status").
+		Joins("JOIN users u ON u.id = lr.user_id").
+		Joins("JOIN leave_types lt ON lt.id = lr.leave_type_id").
+		Where("lr.company_id = ?", companyID).
+		Where("lr.status IN ?", statuses).
+		Where("lr.start_date <= ? AND lr.end_date >= ?", to, from)
+	if departmentID != 0 {
+		query = query.Where("u.department_id = ?", departmentID)
+	}
+	if err := query.Order("lr.start_date, lr.user_id").Scan(&entries).Error; err != nil {
+		return []models.LeaveCalendarEntry{}, err
+	}
+	return entries, nil
+}
+
+func (l *leaveQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, l.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (l *leaveQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, l.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationQuery interface {
+	GetNotifications(ctx context.Context, userID uint64, filter models.NotificationFilter) ([]models.Notification, error)
+	GetNotificationByID(ctx context.Context, id uint64) (models.Notification, error)
+	CountUnread(ctx context.Context, userID uint64) (int64, error)
+	// created is false when notification of the same event already exists
+	CreateNotification(ctx context.Context, notification models.Notification) (saved models.Notification, created bool, err error)
+	// mark notification of user read or unread, every notification of user when ids is empty
+	SetRead(ctx context.Context, userID uint64, ids []uint64, read bool) (int64, error)
+
+	GetPreference(ctx context.Context, userID uint64) (models.NotificationPreference, error)
+	SavePreference(ctx context.Context, preference models.NotificationPreference) (models.NotificationPreference, error)
+
+	GetTemplates(ctx context.Context, companyID uint64) ([]models.NotificationTemplate, error)
+	GetTemplateByID(ctx context.Context, id uint64) (models.NotificationTemplate, error)
+	// template of company for type in the first locale that has one
+	FindTemplate(ctx context.Context, companyID uint64, notificationType string, locales []string) (models.NotificationTemplate, error)
+	SaveTemplate(ctx context.Context, template models.NotificationTemplate) (models.NotificationTemplate, error)
+	DeleteTemplate(ctx context.Context, id uint64) error
+
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+}
+
+type notificationQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewNotificationQuery(db config.GormPostgres) NotificationQuery {
+	return &notificationQueryImpl{db: db}
+}
+
+func (n *notificationQueryImpl) GetNotifications(ctx context.Context, userID uint64, filter models.NotificationFilter) ([]models.Notification, error) {
+	db := conn(ctx, n.db)
+	notifications := []models.Notification{}
+	query := db.
+		WithContext(ctx).
+		Table("notifications").
+		Where("user_id = ?", userID)
+	if filter.Unread {
+		query = query.Where("read_at IS NULL")
+	}
+	// newest first, next page start before the last id
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	if err := query.Order("id DESC").Limit(filter.Limit).Find(&notifications).Error; err != nil {
+		return []models.Notification{}, err
+	}
+	return notifications, nil
+}
+
+func (n *notificationQueryImpl) GetNotificationByID(ctx context.Context, id uint64) (models.Notification, error) {
+	db := conn(ctx, n.db)
+	notification := models.Notification{}
+	if err := db.
+		WithContext(ctx).
+		Table("notifications").
+		Where("id = ?", id).
+		Find(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.Notification{}, nil
+		}
+		return models.Notification{}, err
+	}
+	return notification, nil
+}
+
+func (n *notificationQueryImpl) CountUnread(ctx context.Context, userID uint64) (int64, error) {
+	db := conn(ctx, n.db)
+	var count int64
+	if err := db.
+		WithContext(ctx).
+		Table("notifications").
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (n *notificationQueryImpl) CreateNotification(ctx context.Context, notification models.Notification) (models.Notification, bool, error) {
+	db := conn(ctx, n.db)
+	result := db.
+		WithContext(ctx).
+		Table("notifications").
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&notification)
+	if result.Error != nil {
+		return models.Notification{}, false, result.Error
+	}
+	return notification, result.RowsAffected > 0, nil
+}
+
+func (n *notificationQueryImpl) SetRead(ctx context.Context, userID uint64, ids []uint64, read bool) (int64, error) {
+	db := conn(ctx, n.db)
+	query := db.
+		WithContext(ctx).
+		Table("notifications").
+		Where("user_id = ?", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	var readAt *time.Time
+	if read {
+		now := time.Now()
+		readAt = &now
+		query = query.Where("read_at IS NULL")
+	} else {
+		query = query.Where("read_at IS NOT NULL")
+	}
+	result := query.Update("read_at", readAt)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+func (n *notificationQueryImpl) GetPreference(ctx context.Context, userID uint64) (models.NotificationPreference, error) {
+	db := conn(ctx, n.db)
+	preference := models.NotificationPreference{}
+	if err := db.
+		WithContext(ctx).
+		Table("notification_preferences").
+		Where("user_id = ?", userID).
+		Find(&preference).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.NotificationPreference{}, nil
+		}
+		return models.NotificationPreference{}, err
+	}
+	return preference, nil
+}
+
+func (n *notificationQueryImpl) SavePreference(ctx context.Context, preference models.NotificationPreference) (models.NotificationPreference, error) {
+	db := conn(ctx, n.db)
+	preference.UpdatedAt = time.Now()
+	if err := db.
+		WithContext(ctx).
+		Table("notification_preferences").
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "chat", "chat_address", "updated_at"}),
+		}).
+		Create(&preference).Error; err != nil {
+		return models.NotificationPreference{}, err
+	}
+	return preference, nil
+}
+
+func (n *notificationQueryImpl) GetTemplates(ctx context.Context, companyID uint64) ([]models.NotificationTemplate, error) {
+	db := conn(ctx, n.db)
+	templates := []models.NotificationTemplate{}
+	if err := db.
+		WithContext(ctx).
+		Table("notification_templates").
+		Where("company_id = ?", companyID).
+		Order("type, locale").
+		Find(&templates).Error; err != nil {
+		return []models.NotificationTemplate{}, err
+	}
+	return templates, nil
+}
+
+func (n *notificationQueryImpl) GetTemplateByID(ctx context.Context, id uint64) (models.NotificationTemplate, error) {
+	db := conn(ctx, n.db)
+	template := models.NotificationTemplate{}
+	if err := db.
+		WithContext(ctx).
+		Table("notification_templates").
+		Where("id = ?", id).
+		Find(&template).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.NotificationTemplate{}, nil
+		}
+		return models.NotificationTemplate{}, err
+	}
+	return template, nil
+}
+
+func (n *notificationQueryImpl) FindTemplate(ctx context.Context, companyID uint64, notificationType string, locales []string) (models.NotificationTemplate, error) {
+	db := conn(ctx, n.db)
+	templates := []models.NotificationTemplate{}
+	if err := db.
+		WithContext(ctx).
+		Table("notification_templates").
+		Where("company_id = ? AND type = ? AND locale IN ?", companyID, notificationType, locales).
+		Find(&templates).Error; err != nil {
+		return models.NotificationTemplate{}, err
+	}
+	for _, locale := range locales {
+		for _, template := range templates {
+			if template.Locale == locale {
+				return template, nil
+			}
+		}
+	}
+	return models.NotificationTemplate{}, nil
+}
+
+func (n *notificationQueryImpl) SaveTemplate(ctx context.Context, template models.NotificationTemplate) (models.NotificationTemplate, error) {
+	db := conn(ctx, n.db)
+	template.UpdatedAt = time.Now()
+	if err := db.
+		WithContext(ctx).
+		Table("notification_templates").
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "company_id"}, {Name: "type"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"title", "body", "updated_at"}),
+		}).
+		Create(&template).Error; err != nil {
+		return models.NotificationTemplate{}, err
+	}
+	// id is not returned on conflict, read the saved row
+	saved := models.NotificationTemplate{}
+	if err := db.
+		WithContext(ctx).
+		Table("notification_templates").
+		Where("company_id = ? AND type = ? AND locale = ?", template.CompanyID, template.Type, template.Locale).
+		First(&saved).Error; err != nil {
+		return models.NotificationTemplate{}, err
+	}
+	return saved, nil
+}
+
+func (n *notificationQueryImpl) DeleteTemplate(ctx context.Context, id uint64) error {
+	db := conn(ctx, n.db)
+	if err := db.
+		WithContext(ctx).
+		Table("notification_templates").
+		Where("id = ?", id).
+		Delete(&models.NotificationTemplate{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (n *notificationQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, n.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.User{}, nil
+		}
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (n *notificationQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, n.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.Company{}, nil
+		}
+		return models.Company{}, err
+	}
+	return company, nil
+}
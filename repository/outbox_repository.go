@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// event claimed by dispatcher is hidden for this long, so event of crashed
+// dispatcher is picked again
+const outboxClaimLease = time.Minute
+
+type OutboxQuery interface {
+	// WithTransaction run fn in one transaction, repository using ctx of fn join it
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// AddEvent must be called inside WithTransaction so event is saved with the change
+	AddEvent(ctx context.Context, event models.DomainEvent) error
+
+	// ambil event pending yang sudah waktunya dan sembunyikan selama lease
+	ClaimNextEvent(ctx context.Context) (models.DomainEvent, error)
+	FinishEvent(ctx context.Context, event models.DomainEvent) error
+}
+
+type outboxQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewOutboxQuery(db config.GormPostgres) OutboxQuery {
+	return &outboxQueryImpl{db: db}
+}
+
+func (o *outboxQueryImpl) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// nested call join the outer transaction
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	db := o.db.GetConnection()
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(withTx(ctx, tx))
+	})
+}
+
+func (o *outboxQueryImpl) AddEvent(ctx context.Context, event models.DomainEvent) error {
+	db := conn(ctx, o.db)
+	if err := db.
+		WithContext(ctx).
+		Table("outbox_events").
+		Create(&event).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (o *outboxQueryImpl) ClaimNextEvent(ctx context.Context) (models.DomainEvent, error) {
+	db := o.db.GetConnection()
+	event := models.DomainEvent{}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.
+			Table("outbox_events").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OUTBOX_STATUS_PENDING, now).
+			Order("next_attempt_at, id").
+			Limit(1).
+			Find(&event).Error; err != nil {
+			return err
+		}
+		if event.ID == 0 {
+			return nil
+		}
+		return tx.
+			Table("outbox_events").
+			Where("id = ?", event.ID).
+			Update("next_attempt_at", now.Add(outboxClaimLease)).Error
+	})
+	if err != nil {
+		return models.DomainEvent{}, err
+	}
+	return event, nil
+}
+
+func (o *outboxQueryImpl) FinishEvent(ctx context.Context, event models.DomainEvent) error {
+	db := o.db.GetConnection()
+	if err := db.
+		WithContext(ctx).
+		Table("outbox_events").
+		Where("id = ?", event.ID).
+		Updates(map[string]any{
+			"status":           event.Status,
+			"attempts":         event.Attempts,
+			"next_attempt_at":  event.NextAttemptAt,
+			"done_subscribers": event.DoneSubscribers,
+			"last_error":       event.LastError,
+			"dispatched_at":    event.DispatchedAt,
+		}).Error; err != nil {
+		return err
+	}
+	return nil
+}
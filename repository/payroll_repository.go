@@ -0,0 +1,520 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PayrollQuery interface {
+	GetSalaryComponents(ctx context.Context, companyID uint64) ([]models.SalaryComponent, error)
+	GetSalaryComponentByID(ctx context.Context, id uint64) (models.SalaryComponent, error)
+	GetSalaryComponentByCode(ctx context.Context, companyID uint64, code string) (models.SalaryComponent, error)
+	CreateSalaryComponent(ctx context.Context, component models.SalaryComponent) (models.SalaryComponent, error)
+	UpdateSalaryComponent(ctx context.Context, component models.SalaryComponent) (models.SalaryComponent, error)
+	DeleteSalaryComponent(ctx context.Context, id uint64) error
+
+	GetEmployeeSalary(ctx context.Context, employeeID uint64) (models.EmployeeSalary, error)
+	// salary of many employee at once, keyed by employee id
+	GetEmployeeSalaries(ctx context.Context, employeeIDs []uint64) (map[uint64]models.EmployeeSalary, error)
+	// SaveEmployeeSalary replace base salary and all components in one transaction
+	SaveEmployeeSalary(ctx context.Context, salary models.EmployeeSalary) (models.EmployeeSalary, error)
+
+	// employee paid in the period: active, or terminated inside the period
+	GetPayrollEmployees(ctx context.Context, companyID uint64, periodStart models.Date, periodEnd models.Date) ([]models.Employee, error)
+
+	GetPayrollRuns(ctx context.Context, companyID uint64) ([]models.PayrollRun, error)
+	GetPayrollRunByID(ctx context.Context, id uint64) (models.PayrollRun, error)
+	// SavePayrollRun create the run of the period or reuse the draft one,
+	// and replace its payslips in one transaction
+	SavePayrollRun(ctx context.Context, run models.PayrollRun, payslips []models.Payslip) (models.PayrollRun, error)
+	ApprovePayrollRun(ctx context.Context, run models.PayrollRun) (models.PayrollRun, error)
+
+	GetPayslipsByRunID(ctx context.Context, runID uint64) ([]models.Payslip, error)
+	GetPayslipByID(ctx context.Context, id uint64) (models.Payslip, error)
+	// payslip of approved run only
+	GetPayslipsByEmployeeID(ctx context.Context, employeeID uint64) ([]models.Payslip, error)
+
+	GetEmployeeByID(ctx context.Context, id uint64) (models.Employee, error)
+	GetEmployeeByUserID(ctx context.Context, userID uint64) (models.Employee, error)
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+
+	// salary band of position in currency, zero band when not set
+	GetSalaryBand(ctx context.Context, positionID uint64, currency string) (models.SalaryBand, error)
+	GetSalaryBandsByPositionIDs(ctx context.Context, positionIDs []uint64) ([]models.SalaryBand, error)
+	GetPositionsByIDs(ctx context.Context, ids []uint64) ([]models.Position, error)
+	// base salary of active employees in company with position of their login account
+	GetCompaRatioEmployees(ctx context.Context, companyID uint64) ([]models.CompaRatioEmployee, error)
+}
+
+type payrollQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewPayrollQuery(db config.GormPostgres) PayrollQuery {
+	return &payrollQueryImpl{db: db}
+}
+
+func (p *payrollQueryImpl) GetSalaryComponents(ctx context.Context, companyID uint64) ([]models.SalaryComponent, error) {
+	db := conn(ctx, p.db)
+	components := []models.SalaryComponent{}
+	if err := db.
+		WithContext(ctx).
+		Table("salary_components").
+		Where("company_id = ?", companyID).
+		Where("deleted_at IS NULL").
+		Order("id").
+		Find(&components).Error; err != nil {
+		return []models.SalaryComponent{}, err
+	}
+	return components, nil
+}
+
+func (p *payrollQueryImpl) GetSalaryComponentByID(ctx context.Context, id uint64) (models.SalaryComponent, error) {
+	db := conn(ctx, p.db)
+	component := models.SalaryComponent{}
+	if err := db.
+		WithContext(ctx).
+		Table("salary_components").
+		Where("id = ?", id).
+		Where("deleted_at IS NULL").
+		Find(&component).Error; err != nil {
+		return models.SalaryComponent{}, err
+	}
+	return component, nil
+}
+
+func (p *payrollQueryImpl) GetSalaryComponentByCode(ctx context.Context, companyID uint64, code string) (models.SalaryComponent, error) {
+	db := conn(ctx, p.db)
+	component := models.SalaryComponent{}
+	if err := db.
+		WithContext(ctx).
+		Table("salary_components").
+		Where("company_id = ? AND code = ?", companyID, code).
+		Where("deleted_at IS NULL").
+		Find(&component).Error; err != nil {
+		return models.SalaryComponent{}, err
+	}
+	return component, nil
+}
+
+func (p *payrollQueryImpl) CreateSalaryComponent(ctx context.Context, component models.SalaryComponent) (models.SalaryComponent, error) {
+	db := conn(ctx, p.db)
+	if err := db.
+		WithContext(ctx).
+		Table("salary_components").
+		Create(&component).Error; err != nil {
+		return models.SalaryComponent{}, err
+	}
+	return component, nil
+}
+
+func (p *payrollQueryImpl) UpdateSalaryComponent(ctx context.Context, component models.SalaryComponent) (models.SalaryComponent, error) {
+	db := conn(ctx, p.db)
+	if err := db.
+		WithContext(ctx).
+		Table("salary_components").
+		Where("id = ?", component.ID).
+		Select("name", "taxable", "updated_at").
+		Updates(&component).Error; err != nil {
+		return models.SalaryComponent{}, err
+	}
+	return component, nil
+}
+
+func (p *payrollQueryImpl) DeleteSalaryComponent(ctx context.Context, id uint64) error {
+	db := conn(ctx, p.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// deleted component is no longer paid, payslip keep its own snapshot
+		if err := tx.
+			Table("employee_salary_components").
+			Where("component_id = ?", id).
+			Delete(&models.EmployeeSalaryComponent{}).Error; err != nil {
+			return err
+		}
+		return tx.
+			Table("salary_components").
+			Where("id = ?", id).
+			Delete(&models.SalaryComponent{}).Error
+	})
+	return err
+}
+
+func (p *payrollQueryImpl) GetEmployeeSalary(ctx context.Context, employeeID uint64) (models.EmployeeSalary, error) {
+	salaries, err := p.GetEmployeeSalaries(ctx, []uint64{employeeID})
+	if err != nil {
+		return models.EmployeeSalary{}, err
+	}
+	return salaries[employeeID], nil
+}
+
+func (p *payrollQueryImpl) GetEmployeeSalaries(ctx context.Context, employeeIDs []uint64) (map[uint64]models.EmployeeSalary, error) {
+	db := conn(ctx, p.db)
+	result := map[uint64]models.EmployeeSalary{}
+	if len(employeeIDs) == 0 {
+		return result, nil
+	}
+
+	salaries := []models.EmployeeSalary{}
+	if err := db.
+		WithContext(ctx).
+		Table("employee_salaries").
+		Where("employee_id IN ?", employeeIDs).
+		Find(&salaries).Error; err != nil {
+		return nil, err
+	}
+	components := []models.EmployeeSalaryComponent{}
+	if err := db.
+		WithContext(ctx).
+		Table("employee_salary_components").
+		Where("employee_id IN ?", employeeIDs).
+		Order("component_id").
+		Find(&components).Error; err != nil {
+		return nil, err
+	}
+
+	for _, salary := range salaries {
+		salary.Components = []models.EmployeeSalaryComponent{}
+		result[salary.EmployeeID] = salary
+	}
+	for _, component := range components {
+		salary, ok := result[component.EmployeeID]
+		if !ok {
+			continue
+		}
+		salary.Components = append(salary.Components, component)
+		result[component.EmployeeID] = salary
+	}
+	return result, nil
+}
+
+func (p *payrollQueryImpl) SaveEmployeeSalary(ctx context.Context, salary models.EmployeeSalary) (models.EmployeeSalary, error) {
+	db := conn(ctx, p.db)
+	salary.UpdatedAt = time.Now()
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Table("employee_salaries").
+			Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "employee_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"currency", "base_salary", "band_override_reason", "updated_at"}),
+			}).
+			Create(&salary).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Table("employee_salary_components").
+			Where("employee_id = ?", salary.EmployeeID).
+			Delete(&models.EmployeeSalaryComponent{}).Error; err != nil {
+			return err
+		}
+		for i := range salary.Components {
+			salary.Components[i].ID = 0
+			salary.Components[i].EmployeeID = salary.EmployeeID
+		}
+		if len(salary.Components) == 0 {
+			return nil
+		}
+		return tx.Table("employee_salary_components").Create(&salary.Components).Error
+	})
+	if err != nil {
+		return models.EmployeeSalary{}, err
+	}
+	return p.GetEmployeeSalary(ctx, salary.EmployeeID)
+}
+
+func (p *payrollQueryImpl) GetPayrollEmployees(ctx context.Context, companyID uint64, periodStart models.Date, periodEnd models.Date) ([]models.Employee, error) {
+	db := conn(ctx, p.db)
+	employees := []models.Employee{}
+	if err := db.
+		WithContext(ctx).
+		Table("employees").
+		Where("company_id = ?", companyID).
+		Where("deleted_at IS NULL").
+		Where("hire_date <= ?", periodEnd).
+		Where("status = ? OR (status = ? AND termination_date >= ?)",
+			models.EMPLOYEE_STATUS_ACTIVE, models.EMPLOYEE_STATUS_TERMINATED, periodStart).
+		Order("employee_number").
+		Find(&employees).Error; err != nil {
+		return []models.Employee{}, err
+	}
+	return employees, nil
+}
+
+func (p *payrollQueryImpl) GetPayrollRuns(ctx context.Context, companyID uint64) ([]models.PayrollRun, error) {
+	db := conn(ctx, p.db)
+	runs := []models.PayrollRun{}
+	if err := db.
+		WithContext(ctx).
+		Table("payroll_runs").
+		Where("company_id = ?", companyID).
+		Order("period DESC").
+		Find(&runs).Error; err != nil {
+		return []models.PayrollRun{}, err
+	}
+	return runs, nil
+}
+
+func (p *payrollQueryImpl) GetPayrollRunByID(ctx context.Context, id uint64) (models.PayrollRun, error) {
+	db := conn(ctx, p.db)
+	run := models.PayrollRun{}
+	if err := db.
+		WithContext(ctx).
+		Table("payroll_runs").
+		Where("id = ?", id).
+		Find(&run).Error; err != nil {
+		return models.PayrollRun{}, err
+	}
+	return run, nil
+}
+
+func (p *payrollQueryImpl) SavePayrollRun(ctx context.Context, run models.PayrollRun, payslips []models.Payslip) (models.PayrollRun, error) {
+	db := conn(ctx, p.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Table("payroll_runs").
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.PayrollRun{
+				CompanyID: run.CompanyID,
+				Period:    run.Period,
+				Status:    models.PAYROLL_STATUS_DRAFT,
+				CreatedBy: run.CreatedBy,
+			}).Error; err != nil {
+			return err
+		}
+
+		// lock the run so two run of the same period wait for each other
+		existing := models.PayrollRun{}
+		if err := tx.
+			Table("payroll_runs").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("company_id = ? AND period = ?", run.CompanyID, run.Period).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		if existing.Status != models.PAYROLL_STATUS_DRAFT {
+			return errors.New("payroll run is already approved")
+		}
+
+		if err := tx.
+			Table("payslips").
+			Where("payroll_run_id = ?", existing.ID).
+			Delete(&models.Payslip{}).Error; err != nil {
+			return err
+		}
+		for i := range payslips {
+			payslips[i].PayrollRunID = existing.ID
+		}
+		if len(payslips) > 0 {
+			if err := tx.Table("payslips").CreateInBatches(&payslips, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		existing.EmployeeCount = run.EmployeeCount
+		existing.GrossTotal = run.GrossTotal
+		existing.DeductionTotal = run.DeductionTotal
+		existing.NetTotal = run.NetTotal
+		existing.IncludeTHR = run.IncludeTHR
+		existing.UpdatedAt = time.Now()
+		if err := tx.
+			Table("payroll_runs").
+			Where("id = ?", existing.ID).
+			Select("employee_count", "gross_total", "deduction_total", "net_total", "include_thr", "updated_at").
+			Updates(&existing).Error; err != nil {
+			return err
+		}
+		run = existing
+		return nil
+	})
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	run.Payslips = payslips
+	return run, nil
+}
+
+func (p *payrollQueryImpl) ApprovePayrollRun(ctx context.Context, run models.PayrollRun) (models.PayrollRun, error) {
+	db := conn(ctx, p.db)
+	run.UpdatedAt = time.Now()
+	result := db.
+		WithContext(ctx).
+		Table("payroll_runs").
+		Where("id = ? AND status = ?", run.ID, models.PAYROLL_STATUS_DRAFT).
+		Select("status", "approved_by", "approved_at", "updated_at").
+		Updates(&run)
+	if result.Error != nil {
+		return models.PayrollRun{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.PayrollRun{}, errors.New("payroll run is already approved")
+	}
+	return run, nil
+}
+
+func (p *payrollQueryImpl) GetPayslipsByRunID(ctx context.Context, runID uint64) ([]models.Payslip, error) {
+	db := conn(ctx, p.db)
+	payslips := []models.Payslip{}
+	if err := db.
+		WithContext(ctx).
+		Table("payslips").
+		Where("payroll_run_id = ?", runID).
+		Order("employee_number").
+		Find(&payslips).Error; err != nil {
+		return []models.Payslip{}, err
+	}
+	return payslips, nil
+}
+
+func (p *payrollQueryImpl) GetPayslipByID(ctx context.Context, id uint64) (models.Payslip, error) {
+	db := conn(ctx, p.db)
+	payslip := models.Payslip{}
+	if err := db.
+		WithContext(ctx).
+		Table("payslips").
+		Where("id = ?", id).
+		Find(&payslip).Error; err != nil {
+		return models.Payslip{}, err
+	}
+	return payslip, nil
+}
+
+func (p *payrollQueryImpl) GetPayslipsByEmployeeID(ctx context.Context, employeeID uint64) ([]models.Payslip, error) {
+	db := conn(ctx, p.db)
+	payslips := []models.Payslip{}
+	if err := db.
+		WithContext(ctx).
+		Table("payslips ps").
+		Select("ps.*").
+		Joins("JOIN payroll_runs pr ON pr.id = ps.payroll_run_id").
+		Where("ps.employee_id = ?", employeeID).
+		Where("pr.status = ?", models.PAYROLL_STATUS_APPROVED).
+		Order("pr.period DESC").
+		Find(&payslips).Error; err != nil {
+		return []models.Payslip{}, err
+	}
+	return payslips, nil
+}
+
+func (p *payrollQueryImpl) GetEmployeeByID(ctx context.Context, id uint64) (models.Employee, error) {
+	db := conn(ctx, p.db)
+	employee := models.Employee{}
+	if err := db.
+		WithContext(ctx).
+		Table("employees").
+		Where("id = ?", id).
+		Where("deleted_at IS NULL").
+		Find(&employee).Error; err != nil {
+		return models.Employee{}, err
+	}
+	return employee, nil
+}
+
+func (p *payrollQueryImpl) GetEmployeeByUserID(ctx context.Context, userID uint64) (models.Employee, error) {
+	db := conn(ctx, p.db)
+	employee := models.Employee{}
+	if err := db.
+		WithContext(ctx).
+		Table("employees").
+		Where("user_id = ?", userID).
+		Where("deleted_at IS NULL").
+		Find(&employee).Error; err != nil {
+		return models.Employee{}, err
+	}
+	return employee, nil
+}
+
+func (p *payrollQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, p.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (p *payrollQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, p.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
+
+func (p *payrollQueryImpl) GetSalaryBand(ctx context.Context, positionID uint64, currency string) (models.SalaryBand, error) {
+	db := conn(ctx, p.db)
+	band := models.SalaryBand{}
+	if err := db.
+		WithContext(ctx).
+		Table("salary_bands").
+		Where("position_id = ? AND currency = ?", positionID, currency).
+		Find(&band).Error; err != nil {
+		return models.SalaryBand{}, err
+	}
+	return band, nil
+}
+
+func (p *payrollQueryImpl) GetSalaryBandsByPositionIDs(ctx context.Context, positionIDs []uint64) ([]models.SalaryBand, error) {
+	db := conn(ctx, p.db)
+	bands := []models.SalaryBand{}
+	if len(positionIDs) == 0 {
+		return bands, nil
+	}
+	if err := db.
+		WithContext(ctx).
+		Table("salary_bands").
+		Where("position_id IN ?", positionIDs).
+		Find(&bands).Error; err != nil {
+		return []models.SalaryBand{}, err
+	}
+	return bands, nil
+}
+
+func (p *payrollQueryImpl) GetPositionsByIDs(ctx context.Context, ids []uint64) ([]models.Position, error) {
+	db := conn(ctx, p.db)
+	positions := []models.Position{}
+	if len(ids) == 0 {
+		return positions, nil
+	}
+	if err := db.
+		WithContext(ctx).
+		Table("positions").
+		Where("id IN ?", ids).
+		Order("id").
+		Find(&positions).Error; err != nil {
+		return []models.Position{}, err
+	}
+	return positions, nil
+}
+
+func (p *payrollQueryImpl) GetCompaRatioEmployees(ctx context.Context, companyID uint64) ([]models.CompaRatioEmployee, error) {
+	db := conn(ctx, p.db)
+	employees := []models.CompaRatioEmployee{}
+	if err := db.
+		WithContext(ctx).
+		Table("employees e").
+		Select("e.id AS employee_id, e.employee_number, TRIM(e.first_name || ' ' || e.last_name) AS employee_name, "+
+			"u.position_id, es.currency, es.base_salary, es.band_override_reason").
+		Joins("JOIN users u ON u.id = e.user_id").
+		Joins("JOIN employee_salaries es ON es.employee_id = e.id").
+		Where("e.company_id = ? AND e.status = ? AND e.deleted_at IS NULL", companyID, models.EMPLOYEE_STATUS_ACTIVE).
+		Order("u.position_id, es.currency, es.base_salary").
+		Scan(&employees).Error; err != nil {
+		return []models.CompaRatioEmployee{}, err
+	}
+	return employees, nil
+}
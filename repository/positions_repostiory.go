@@ -2,16 +2,20 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/geedotrar/erp-api/config"
 	"github.com/geedotrar/erp-api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PositionQuery interface {
-	GetPosition(ctx context.Context) ([]models.Position, error)
+	GetPosition(ctx context.Context, filter models.PositionFilter) ([]models.Position, error)
 	GetPositionByID(ctx context.Context, id uint64) (models.Position, error)
-	GetPositionByPositionName(ctx context.Context, positionName string) (models.Position, error)
+	// name and code are unique inside company, nil company is the template catalog
+	GetPositionByName(ctx context.Context, companyID *uint64, positionName string) (models.Position, error)
+	GetPositionByCode(ctx context.Context, companyID *uint64, positionCode string) (models.Position, error)
 
 	CreatePosition(ctx context.Context, position models.PositionCreateRequest) (models.PositionCreateRequest, error)
 	UpdatePosition(ctx context.Context, id uint64, position models.PositionUpdateRequest) (models.PositionUpdateRequest, error)
@@ -21,7 +25,23 @@ type PositionQuery interface {
 	// check user using positionID
 	GetUserByPositionID(ctx context.Context, positionID uint64) ([]models.User, error)
 
-	GetSoftDeletedPosition(ctx context.Context) ([]models.Position, error)
+	GetSoftDeletedPosition(ctx context.Context, companyID *uint64) ([]models.Position, error)
+
+	// copy template position and its salary bands into company
+	AdoptPositionTemplate(ctx context.Context, template models.Position, companyID uint64) (models.Position, error)
+
+	GetPositionCodeRule(ctx context.Context, companyID uint64) (models.PositionCodeRule, error)
+	SavePositionCodeRule(ctx context.Context, rule models.PositionCodeRule) (models.PositionCodeRule, error)
+
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+
+	// salary band of position, one per currency
+	GetSalaryBands(ctx context.Context, positionID uint64) ([]models.SalaryBand, error)
+	SaveSalaryBand(ctx context.Context, band models.SalaryBand) (models.SalaryBand, error)
+	DeleteSalaryBand(ctx context.Context, positionID uint64, currency string) (int64, error)
+
+	CountPosition(ctx context.Context, filter models.PositionFilter) (int64, error)
+	StreamPosition(ctx context.Context, filter models.PositionFilter, columns []string, fn func(values []any) error) error
 }
 
 type positionQueryImpl struct {
@@ -32,12 +52,13 @@ func NewPositionQuery(db config.GormPostgres) PositionQuery {
 	return &positionQueryImpl{db: db}
 }
 
-func (p *positionQueryImpl) GetPosition(ctx context.Context) ([]models.Position, error) {
-	db := p.db.GetConnection()
+func (p *positionQueryImpl) GetPosition(ctx context.Context, filter models.PositionFilter) ([]models.Position, error) {
+	db := conn(ctx, p.db)
 	position := []models.Position{}
 	if err := db.
 		WithContext(ctx).
 		Table("positions").
+		Scopes(positionFilterScope(filter)).
 		Find(&position).Error; err != nil {
 		return []models.Position{}, err
 	}
@@ -45,7 +66,7 @@ func (p *positionQueryImpl) GetPosition(ctx context.Context) ([]models.Position,
 }
 
 func (p *positionQueryImpl) GetPositionByID(ctx context.Context, id uint64) (models.Position, error) {
-	db := p.db.GetConnection()
+	db := conn(ctx, p.db)
 	position := models.Position{}
 	if err := db.
 		WithContext(ctx).
@@ -60,13 +81,29 @@ func (p *positionQueryImpl) GetPositionByID(ctx context.Context, id uint64) (mod
 	return position, nil
 }
 
-func (p *positionQueryImpl) GetPositionByPositionName(ctx context.Context, positionName string) (models.Position, error) {
-	db := p.db.GetConnection()
+func (p *positionQueryImpl) GetPositionByName(ctx context.Context, companyID *uint64, positionName string) (models.Position, error) {
+	db := conn(ctx, p.db)
 	position := models.Position{}
-	if err := db.WithContext(ctx).Where("position_name = ?", positionName).Find(&position).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return models.Position{}, nil
-		}
+	if err := db.
+		WithContext(ctx).
+		Table("positions").
+		Scopes(positionCompanyScope(companyID)).
+		Where("LOWER(position_name) = LOWER(?) AND deleted_at IS NULL", positionName).
+		Find(&position).Error; err != nil {
+		return models.Position{}, err
+	}
+	return position, nil
+}
+
+func (p *positionQueryImpl) GetPositionByCode(ctx context.Context, companyID *uint64, positionCode string) (models.Position, error) {
+	db := conn(ctx, p.db)
+	position := models.Position{}
+	if err := db.
+		WithContext(ctx).
+		Table("positions").
+		Scopes(positionCompanyScope(companyID)).
+		Where("position_code = ? AND deleted_at IS NULL", positionCode).
+		Find(&position).Error; err != nil {
 		return models.Position{}, err
 	}
 	return position, nil
@@ -74,7 +111,7 @@ func (p *positionQueryImpl) GetPositionByPositionName(ctx context.Context, posit
 
 // search user using positionID
 func (p *positionQueryImpl) GetUserByPositionID(ctx context.Context, positionID uint64) ([]models.User, error) {
-	db := p.db.GetConnection()
+	db := conn(ctx, p.db)
 	users := []models.User{}
 	if err := db.WithContext(ctx).
 		Where("position_id", positionID).
@@ -84,17 +121,17 @@ func (p *positionQueryImpl) GetUserByPositionID(ctx context.Context, positionID
 	return users, nil
 }
 
-func (p *positionQueryImpl) GetSoftDeletedPosition(ctx context.Context) ([]models.Position, error) {
-	db := p.db.GetConnection()
+func (p *positionQueryImpl) GetSoftDeletedPosition(ctx context.Context, companyID *uint64) ([]models.Position, error) {
+	db := conn(ctx, p.db)
 	position := []models.Position{}
-	if err := db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").Find(&position).Error; err != nil {
+	if err := db.WithContext(ctx).Unscoped().Scopes(positionCompanyScope(companyID)).Where("deleted_at IS NOT NULL").Find(&position).Error; err != nil {
 		return nil, err
 	}
 	return position, nil
 }
 
 func (p positionQueryImpl) CreatePosition(ctx context.Context, position models.PositionCreateRequest) (models.PositionCreateRequest, error) {
-	db := p.db.GetConnection()
+	db := conn(ctx, p.db)
 	if err := db.
 		WithContext(ctx).
 		Table("positions").
@@ -105,7 +142,7 @@ func (p positionQueryImpl) CreatePosition(ctx context.Context, position models.P
 }
 
 func (p *positionQueryImpl) UpdatePosition(ctx context.Context, id uint64, position models.PositionUpdateRequest) (models.PositionUpdateRequest, error) {
-	db := p.db.GetConnection()
+	db := conn(ctx, p.db)
 	updatedPosition := models.PositionUpdateRequest{}
 	if err := db.
 		WithContext(ctx).
@@ -121,7 +158,7 @@ func (p *positionQueryImpl) UpdatePosition(ctx context.Context, id uint64, posit
 }
 
 func (c *positionQueryImpl) DeletePosition(ctx context.Context, id uint64) error {
-	db := c.db.GetConnection()
+	db := conn(ctx, c.db)
 	if err := db.
 		WithContext(ctx).
 		Table("positions").
@@ -131,3 +168,186 @@ func (c *positionQueryImpl) DeletePosition(ctx context.Context, id uint64) error
 	}
 	return nil
 }
+
+func (p *positionQueryImpl) GetSalaryBands(ctx context.Context, positionID uint64) ([]models.SalaryBand, error) {
+	db := conn(ctx, p.db)
+	bands := []models.SalaryBand{}
+	if err := db.
+		WithContext(ctx).
+		Table("salary_bands").
+		Where("position_id = ?", positionID).
+		Order("currency").
+		Find(&bands).Error; err != nil {
+		return []models.SalaryBand{}, err
+	}
+	return bands, nil
+}
+
+// SaveSalaryBand create band or replace the band of the same position and currency
+func (p *positionQueryImpl) SaveSalaryBand(ctx context.Context, band models.SalaryBand) (models.SalaryBand, error) {
+	db := conn(ctx, p.db)
+	band.UpdatedAt = time.Now()
+	if err := db.
+		WithContext(ctx).
+		Table("salary_bands").
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "position_id"}, {Name: "currency"}},
+			DoUpdates: clause.AssignmentColumns([]string{"min_salary", "mid_salary", "max_salary", "enforcement", "updated_at"}),
+		}).
+		Create(&band).Error; err != nil {
+		return models.SalaryBand{}, err
+	}
+
+	savedBand := models.SalaryBand{}
+	if err := db.
+		WithContext(ctx).
+		Table("salary_bands").
+		Where("position_id = ? AND currency = ?", band.PositionID, band.Currency).
+		Find(&savedBand).Error; err != nil {
+		return models.SalaryBand{}, err
+	}
+	return savedBand, nil
+}
+
+func (p *positionQueryImpl) DeleteSalaryBand(ctx context.Context, positionID uint64, currency string) (int64, error) {
+	db := conn(ctx, p.db)
+	result := db.
+		WithContext(ctx).
+		Table("salary_bands").
+		Where("position_id = ? AND currency = ?", positionID, currency).
+		Delete(&models.SalaryBand{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+func (p *positionQueryImpl) AdoptPositionTemplate(ctx context.Context, template models.Position, companyID uint64) (models.Position, error) {
+	db := conn(ctx, p.db)
+	position := models.Position{
+		CompanyID:    &companyID,
+		TemplateID:   &template.ID,
+		PositionName: template.PositionName,
+		PositionCode: template.PositionCode,
+		JobGrade:     template.JobGrade,
+	}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("positions").Create(&position).Error; err != nil {
+			return err
+		}
+		bands := []models.SalaryBand{}
+		if err := tx.
+			Table("salary_bands").
+			Where("position_id = ?", template.ID).
+			Find(&bands).Error; err != nil {
+			return err
+		}
+		if len(bands) == 0 {
+			return nil
+		}
+		for i := range bands {
+			bands[i].ID = 0
+			bands[i].PositionID = position.ID
+		}
+		return tx.Table("salary_bands").Create(&bands).Error
+	})
+	if err != nil {
+		return models.Position{}, err
+	}
+	return position, nil
+}
+
+func (p *positionQueryImpl) GetPositionCodeRule(ctx context.Context, companyID uint64) (models.PositionCodeRule, error) {
+	db := conn(ctx, p.db)
+	rule := models.PositionCodeRule{}
+	if err := db.
+		WithContext(ctx).
+		Table("position_code_rules").
+		Where("company_id = ?", companyID).
+		Find(&rule).Error; err != nil {
+		return models.PositionCodeRule{}, err
+	}
+	return rule, nil
+}
+
+func (p *positionQueryImpl) SavePositionCodeRule(ctx context.Context, rule models.PositionCodeRule) (models.PositionCodeRule, error) {
+	db := conn(ctx, p.db)
+	rule.UpdatedAt = time.Now()
+	if err := db.
+		WithContext(ctx).
+		Table("position_code_rules").
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "company_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"pattern", "description", "updated_at"}),
+		}).
+		Create(&rule).Error; err != nil {
+		return models.PositionCodeRule{}, err
+	}
+	return rule, nil
+}
+
+func (p *positionQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, p.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
+
+func (p *positionQueryImpl) CountPosition(ctx context.Context, filter models.PositionFilter) (int64, error) {
+	db := conn(ctx, p.db)
+	var count int64
+	if err := db.
+		WithContext(ctx).
+		Table("positions").
+		Where("deleted_at IS NULL").
+		Scopes(positionFilterScope(filter)).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (p *positionQueryImpl) StreamPosition(ctx context.Context, filter models.PositionFilter, columns []string, fn func(values []any) error) error {
+	db := conn(ctx, p.db)
+	query := db.
+		WithContext(ctx).
+		Table("positions").
+		Select(columns).
+		Where("deleted_at IS NULL").
+		Scopes(positionFilterScope(filter)).
+		Order("id")
+	return streamRows(query, fn)
+}
+
+func positionFilterScope(filter models.PositionFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Template {
+			db = db.Where("company_id IS NULL")
+		} else if filter.CompanyID != 0 {
+			db = db.Where("company_id = ?", filter.CompanyID)
+		}
+		if filter.PositionName != "" {
+			db = db.Where("position_name ILIKE ?", "%"+filter.PositionName+"%")
+		}
+		if filter.PositionCode != "" {
+			db = db.Where("position_code = ?", filter.PositionCode)
+		}
+		return db
+	}
+}
+
+// positionCompanyScope limit to positions of company, or to template positions when company is nil
+func positionCompanyScope(companyID *uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if companyID == nil {
+			return db.Where("company_id IS NULL")
+		}
+		return db.Where("company_id = ?", *companyID)
+	}
+}
@@ -0,0 +1,512 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RecruitmentQuery interface {
+	GetStages(ctx context.Context, companyID uint64) ([]models.RecruitmentStage, error)
+	GetStageByID(ctx context.Context, id uint64) (models.RecruitmentStage, error)
+	// replace pipeline of company, stage still used by candidate can not be removed
+	SaveStages(ctx context.Context, companyID uint64, stages []models.RecruitmentStage) ([]models.RecruitmentStage, error)
+
+	GetRequisitions(ctx context.Context, filter models.RequisitionFilter) ([]models.Requisition, error)
+	GetRequisitionByID(ctx context.Context, id uint64) (models.Requisition, error)
+	CreateRequisition(ctx context.Context, requisition models.Requisition) (models.Requisition, error)
+	UpdateRequisition(ctx context.Context, id uint64, update models.RequisitionUpdateRequest) (models.Requisition, error)
+
+	GetCandidates(ctx context.Context, requisitionID uint64) ([]models.Candidate, error)
+	GetCandidateByID(ctx context.Context, id uint64) (models.Candidate, error)
+	GetCandidateByEmail(ctx context.Context, requisitionID uint64, email string) (models.Candidate, error)
+	CreateCandidate(ctx context.Context, candidate models.Candidate) (models.Candidate, error)
+	UpdateCandidateStage(ctx context.Context, id uint64, stageID uint64) (models.Candidate, error)
+	UpdateCandidateCV(ctx context.Context, id uint64, cvPath string, cvFileName string) (models.Candidate, error)
+	// close candidate as rejected or withdrawn and cancel the scheduled interview
+	CloseCandidate(ctx context.Context, id uint64, status string, reason string) (models.Candidate, error)
+	// mark candidate hired and count the hire on requisition, requisition is closed when full
+	HireCandidate(ctx context.Context, id uint64, userID uint64, employeeID uint64) (models.Candidate, error)
+
+	// closed candidate not purged yet, closed before the given time
+	GetCandidatesToPurge(ctx context.Context, closedBefore time.Time, limit int) ([]models.Candidate, error)
+	// empty personal data of candidate and feedback text of its interview, score is kept
+	PurgeCandidate(ctx context.Context, id uint64) error
+
+	GetInterviews(ctx context.Context, candidateID uint64) ([]models.Interview, error)
+	GetInterviewsByInterviewer(ctx context.Context, interviewerID uint64) ([]models.Interview, error)
+	GetInterviewByID(ctx context.Context, id uint64) (models.Interview, error)
+	CreateInterview(ctx context.Context, interview models.Interview) (models.Interview, error)
+	SaveInterviewFeedback(ctx context.Context, id uint64, score int, feedback string) (models.Interview, error)
+
+	GetUserByID(ctx context.Context, id uint64) (models.User, error)
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+	GetPositionByID(ctx context.Context, id uint64) (models.Position, error)
+	GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error)
+}
+
+type recruitmentQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewRecruitmentQuery(db config.GormPostgres) RecruitmentQuery {
+	return &recruitmentQueryImpl{db: db}
+}
+
+func (r *recruitmentQueryImpl) GetStages(ctx context.Context, companyID uint64) ([]models.RecruitmentStage, error) {
+	db := conn(ctx, r.db)
+	stages := []models.RecruitmentStage{}
+	if err := db.
+		WithContext(ctx).
+		Table("recruitment_stages").
+		Where("company_id = ?", companyID).
+		Order("sort_order").
+		Find(&stages).Error; err != nil {
+		return []models.RecruitmentStage{}, err
+	}
+	return stages, nil
+}
+
+func (r *recruitmentQueryImpl) GetStageByID(ctx context.Context, id uint64) (models.RecruitmentStage, error) {
+	db := conn(ctx, r.db)
+	stage := models.RecruitmentStage{}
+	if err := db.
+		WithContext(ctx).
+		Table("recruitment_stages").
+		Where("id = ?", id).
+		Find(&stage).Error; err != nil {
+		return models.RecruitmentStage{}, err
+	}
+	return stage, nil
+}
+
+func (r *recruitmentQueryImpl) SaveStages(ctx context.Context, companyID uint64, stages []models.RecruitmentStage) ([]models.RecruitmentStage, error) {
+	db := conn(ctx, r.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		keep := []uint64{}
+		for i := range stages {
+			stages[i].CompanyID = companyID
+			stages[i].SortOrder = i + 1
+			if stages[i].ID == 0 {
+				continue
+			}
+			result := tx.
+				Table("recruitment_stages").
+				Where("id = ? AND company_id = ?", stages[i].ID, companyID).
+				Updates(map[string]any{"name": stages[i].Name, "sort_order": stages[i].SortOrder, "updated_at": time.Now()})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errors.New("stage not found")
+			}
+			keep = append(keep, stages[i].ID)
+		}
+
+		removed := tx.Table("recruitment_stages").Select("id").Where("company_id = ?", companyID)
+		if len(keep) > 0 {
+			removed = removed.Where("id NOT IN ?", keep)
+		}
+		var used int64
+		if err := tx.
+			Table("candidates").
+			Where("stage_id IN (?)", removed).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return errors.New("stage is still used by candidate")
+		}
+		query := tx.Table("recruitment_stages").Where("company_id = ?", companyID)
+		if len(keep) > 0 {
+			query = query.Where("id NOT IN ?", keep)
+		}
+		if err := query.Delete(&models.RecruitmentStage{}).Error; err != nil {
+			return err
+		}
+
+		for i := range stages {
+			if stages[i].ID != 0 {
+				continue
+			}
+			if err := tx.Table("recruitment_stages").Create(&stages[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return []models.RecruitmentStage{}, err
+	}
+	return r.GetStages(ctx, companyID)
+}
+
+func (r *recruitmentQueryImpl) requisitionQuery(ctx context.Context) *gorm.DB {
+	db := conn(ctx, r.db)
+	return db.
+		WithContext(ctx).
+		Table("requisitions r").
+		Select("r.*, COALESCE(p.position_name, '') AS position_name").
+		Joins("LEFT JOIN positions p ON p.id = r.position_id")
+}
+
+func (r *recruitmentQueryImpl) GetRequisitions(ctx context.Context, filter models.RequisitionFilter) ([]models.Requisition, error) {
+	requisitions := []models.Requisition{}
+	query := r.requisitionQuery(ctx).Where("r.company_id = ?", filter.CompanyID)
+	if filter.Status != "" {
+		query = query.Where("r.status = ?", filter.Status)
+	}
+	if err := query.Order("r.created_at DESC").Find(&requisitions).Error; err != nil {
+		return []models.Requisition{}, err
+	}
+	return requisitions, nil
+}
+
+func (r *recruitmentQueryImpl) GetRequisitionByID(ctx context.Context, id uint64) (models.Requisition, error) {
+	requisition := models.Requisition{}
+	if err := r.requisitionQuery(ctx).Where("r.id = ?", id).Find(&requisition).Error; err != nil {
+		return models.Requisition{}, err
+	}
+	return requisition, nil
+}
+
+func (r *recruitmentQueryImpl) CreateRequisition(ctx context.Context, requisition models.Requisition) (models.Requisition, error) {
+	db := conn(ctx, r.db)
+	if err := db.
+		WithContext(ctx).
+		Table("requisitions").
+		Create(&requisition).Error; err != nil {
+		return models.Requisition{}, err
+	}
+	return r.GetRequisitionByID(ctx, requisition.ID)
+}
+
+func (r *recruitmentQueryImpl) UpdateRequisition(ctx context.Context, id uint64, update models.RequisitionUpdateRequest) (models.Requisition, error) {
+	db := conn(ctx, r.db)
+	if err := db.
+		WithContext(ctx).
+		Table("requisitions").
+		Where("id = ?", id).
+		Updates(&update).Error; err != nil {
+		return models.Requisition{}, err
+	}
+	return r.GetRequisitionByID(ctx, id)
+}
+
+func (r *recruitmentQueryImpl) candidateQuery(ctx context.Context) *gorm.DB {
+	db := conn(ctx, r.db)
+	return db.
+		WithContext(ctx).
+		Table("candidates c").
+		Select(`c.*, COALESCE(s.name, '') AS stage_name,
+			(SELECT AVG(i.score) FROM interviews i WHERE i.candidate_id = c.id AND i.score IS NOT NULL) AS average_score`).
+		Joins("LEFT JOIN recruitment_stages s ON s.id = c.stage_id")
+}
+
+func (r *recruitmentQueryImpl) GetCandidates(ctx context.Context, requisitionID uint64) ([]models.Candidate, error) {
+	candidates := []models.Candidate{}
+	if err := r.candidateQuery(ctx).
+		Where("c.requisition_id = ?", requisitionID).
+		Order("s.sort_order, c.created_at").
+		Find(&candidates).Error; err != nil {
+		return []models.Candidate{}, err
+	}
+	return candidates, nil
+}
+
+func (r *recruitmentQueryImpl) GetCandidateByID(ctx context.Context, id uint64) (models.Candidate, error) {
+	candidate := models.Candidate{}
+	if err := r.candidateQuery(ctx).Where("c.id = ?", id).Find(&candidate).Error; err != nil {
+		return models.Candidate{}, err
+	}
+	return candidate, nil
+}
+
+func (r *recruitmentQueryImpl) GetCandidateByEmail(ctx context.Context, requisitionID uint64, email string) (models.Candidate, error) {
+	candidate := models.Candidate{}
+	if err := r.candidateQuery(ctx).
+		Where("c.requisition_id = ? AND LOWER(c.email) = LOWER(?) AND c.purged_at IS NULL", requisitionID, email).
+		Find(&candidate).Error; err != nil {
+		return models.Candidate{}, err
+	}
+	return candidate, nil
+}
+
+func (r *recruitmentQueryImpl) CreateCandidate(ctx context.Context, candidate models.Candidate) (models.Candidate, error) {
+	db := conn(ctx, r.db)
+	if err := db.
+		WithContext(ctx).
+		Table("candidates").
+		Create(&candidate).Error; err != nil {
+		return models.Candidate{}, err
+	}
+	return r.GetCandidateByID(ctx, candidate.ID)
+}
+
+func (r *recruitmentQueryImpl) UpdateCandidateStage(ctx context.Context, id uint64, stageID uint64) (models.Candidate, error) {
+	db := conn(ctx, r.db)
+	if err := db.
+		WithContext(ctx).
+		Table("candidates").
+		Where("id = ?", id).
+		Updates(map[string]any{"stage_id": stageID, "updated_at": time.Now()}).Error; err != nil {
+		return models.Candidate{}, err
+	}
+	return r.GetCandidateByID(ctx, id)
+}
+
+func (r *recruitmentQueryImpl) UpdateCandidateCV(ctx context.Context, id uint64, cvPath string, cvFileName string) (models.Candidate, error) {
+	db := conn(ctx, r.db)
+	if err := db.
+		WithContext(ctx).
+		Table("candidates").
+		Where("id = ?", id).
+		Updates(map[string]any{"cv_path": cvPath, "cv_file_name": cvFileName, "updated_at": time.Now()}).Error; err != nil {
+		return models.Candidate{}, err
+	}
+	return r.GetCandidateByID(ctx, id)
+}
+
+func (r *recruitmentQueryImpl) CloseCandidate(ctx context.Context, id uint64, status string, reason string) (models.Candidate, error) {
+	db := conn(ctx, r.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.
+			Table("candidates").
+			Where("id = ?", id).
+			Updates(map[string]any{"status": status, "reject_reason": reason, "closed_at": now, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.
+			Table("interviews").
+			Where("candidate_id = ? AND status = ?", id, models.INTERVIEW_STATUS_SCHEDULED).
+			Updates(map[string]any{"status": models.INTERVIEW_STATUS_CANCELLED, "updated_at": now}).Error
+	})
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	return r.GetCandidateByID(ctx, id)
+}
+
+func (r *recruitmentQueryImpl) HireCandidate(ctx context.Context, id uint64, userID uint64, employeeID uint64) (models.Candidate, error) {
+	db := conn(ctx, r.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		candidate := models.Candidate{}
+		if err := tx.
+			Table("candidates").
+			Where("id = ?", id).
+			First(&candidate).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.
+			Table("candidates").
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"status":      models.CANDIDATE_STATUS_HIRED,
+				"user_id":     userID,
+				"employee_id": employeeID,
+				"closed_at":   now,
+				"updated_at":  now,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Table("interviews").
+			Where("candidate_id = ? AND status = ?", id, models.INTERVIEW_STATUS_SCHEDULED).
+			Updates(map[string]any{"status": models.INTERVIEW_STATUS_CANCELLED, "updated_at": now}).Error; err != nil {
+			return err
+		}
+
+		// lock requisition so two accepted candidates do not overfill it
+		requisition := models.Requisition{}
+		if err := tx.
+			Table("requisitions").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", candidate.RequisitionID).
+			First(&requisition).Error; err != nil {
+			return err
+		}
+		updates := map[string]any{"hired": requisition.Hired + 1, "updated_at": now}
+		if requisition.Hired+1 >= requisition.Openings {
+			updates["status"] = models.REQUISITION_STATUS_CLOSED
+		}
+		return tx.
+			Table("requisitions").
+			Where("id = ?", requisition.ID).
+			Updates(updates).Error
+	})
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	return r.GetCandidateByID(ctx, id)
+}
+
+func (r *recruitmentQueryImpl) GetCandidatesToPurge(ctx context.Context, closedBefore time.Time, limit int) ([]models.Candidate, error) {
+	db := conn(ctx, r.db)
+	candidates := []models.Candidate{}
+	if err := db.
+		WithContext(ctx).
+		Table("candidates").
+		Where("closed_at IS NOT NULL AND closed_at < ? AND purged_at IS NULL", closedBefore).
+		Order("closed_at").
+		Limit(limit).
+		Find(&candidates).Error; err != nil {
+		return []models.Candidate{}, err
+	}
+	return candidates, nil
+}
+
+func (r *recruitmentQueryImpl) PurgeCandidate(ctx context.Context, id uint64) error {
+	db := conn(ctx, r.db)
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.
+			Table("candidates").
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"first_name":    "",
+				"last_name":     "",
+				"email":         "",
+				"phone_number":  "",
+				"cv_path":       "",
+				"cv_file_name":  "",
+				"reject_reason": "",
+				"purged_at":     now,
+				"updated_at":    now,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.
+			Table("interviews").
+			Where("candidate_id = ?", id).
+			Updates(map[string]any{"feedback": "", "location": "", "updated_at": now}).Error
+	})
+}
+
+func (r *recruitmentQueryImpl) GetInterviews(ctx context.Context, candidateID uint64) ([]models.Interview, error) {
+	db := conn(ctx, r.db)
+	interviews := []models.Interview{}
+	if err := db.
+		WithContext(ctx).
+		Table("interviews").
+		Where("candidate_id = ?", candidateID).
+		Order("scheduled_at").
+		Find(&interviews).Error; err != nil {
+		return []models.Interview{}, err
+	}
+	return interviews, nil
+}
+
+func (r *recruitmentQueryImpl) GetInterviewsByInterviewer(ctx context.Context, interviewerID uint64) ([]models.Interview, error) {
+	db := conn(ctx, r.db)
+	interviews := []models.Interview{}
+	if err := db.
+		WithContext(ctx).
+		Table("interviews").
+		Where("interviewer_id = ? AND status <> ?", interviewerID, models.INTERVIEW_STATUS_CANCELLED).
+		Order("scheduled_at DESC").
+		Find(&interviews).Error; err != nil {
+		return []models.Interview{}, err
+	}
+	return interviews, nil
+}
+
+func (r *recruitmentQueryImpl) GetInterviewByID(ctx context.Context, id uint64) (models.Interview, error) {
+	db := conn(ctx, r.db)
+	interview := models.Interview{}
+	if err := db.
+		WithContext(ctx).
+		Table("interviews").
+		Where("id = ?", id).
+		Find(&interview).Error; err != nil {
+		return models.Interview{}, err
+	}
+	return interview, nil
+}
+
+func (r *recruitmentQueryImpl) CreateInterview(ctx context.Context, interview models.Interview) (models.Interview, error) {
+	db := conn(ctx, r.db)
+	if err := db.
+		WithContext(ctx).
+		Table("interviews").
+		Create(&interview).Error; err != nil {
+		return models.Interview{}, err
+	}
+	return interview, nil
+}
+
+func (r *recruitmentQueryImpl) SaveInterviewFeedback(ctx context.Context, id uint64, score int, feedback string) (models.Interview, error) {
+	db := conn(ctx, r.db)
+	if err := db.
+		WithContext(ctx).
+		Table("interviews").
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"score":      score,
+			"feedback":   feedback,
+			"status":     models.INTERVIEW_STATUS_COMPLETED,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		return models.Interview{}, err
+	}
+	return r.GetInterviewByID(ctx, id)
+}
+
+func (r *recruitmentQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
+	db := conn(ctx, r.db)
+	user := models.User{}
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("id = ?", id).
+		Find(&user).Error; err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (r *recruitmentQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, r.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
+
+func (r *recruitmentQueryImpl) GetPositionByID(ctx context.Context, id uint64) (models.Position, error) {
+	db := conn(ctx, r.db)
+	position := models.Position{}
+	if err := db.
+		WithContext(ctx).
+		Table("positions").
+		Where("id = ?", id).
+		Find(&position).Error; err != nil {
+		return models.Position{}, err
+	}
+	return position, nil
+}
+
+func (r *recruitmentQueryImpl) GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error) {
+	db := conn(ctx, r.db)
+	department := models.Department{}
+	if err := db.
+		WithContext(ctx).
+		Table("departments").
+		Where("id = ?", id).
+		Find(&department).Error; err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/statutory"
+)
+
+type StatutoryQuery interface {
+	// GetRateSet return every statutory rate valid on asOf
+	GetRateSet(ctx context.Context, asOf models.Date) (statutory.RateSet, error)
+	// GetYearToDates sum approved payslip of the same year before the period, keyed by employee id
+	GetYearToDates(ctx context.Context, employeeIDs []uint64, period models.Date) (map[uint64]statutory.YearToDate, error)
+	// approved payslip of one year with its period, employeeID 0 means whole company
+	GetApprovedPayslipsOfYear(ctx context.Context, companyID uint64, employeeID uint64, year int) ([]models.Payslip, error)
+}
+
+type statutoryQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewStatutoryQuery(db config.GormPostgres) StatutoryQuery {
+	return &statutoryQueryImpl{db: db}
+}
+
+func (s *statutoryQueryImpl) GetRateSet(ctx context.Context, asOf models.Date) (statutory.RateSet, error) {
+	db := s.db.GetConnection()
+	rates := statutory.RateSet{
+		Parameters: map[string]float64{},
+		Brackets:   map[string][]statutory.Bracket{},
+		PTKP:       map[string]statutory.PTKP{},
+	}
+
+	parameters := []struct {
+		Code  string
+		Value float64
+	}{}
+	if err := db.
+		WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (code) code, value FROM statutory_parameters
+			WHERE valid_from <= ? ORDER BY code, valid_from DESC`, asOf).
+		Scan(&parameters).Error; err != nil {
+		return statutory.RateSet{}, err
+	}
+	for _, parameter := range parameters {
+		rates.Parameters[parameter.Code] = parameter.Value
+	}
+
+	brackets := []struct {
+		TableCode  string
+		IncomeFrom float64
+		Rate       float64
+	}{}
+	if err := db.
+		WithContext(ctx).
+		Raw(`SELECT table_code, income_from, rate FROM statutory_brackets
+			WHERE (table_code, valid_from) IN (
+				SELECT table_code, MAX(valid_from) FROM statutory_brackets
+				WHERE valid_from <= ? GROUP BY table_code
+			)
+			ORDER BY table_code, income_from`, asOf).
+		Scan(&brackets).Error; err != nil {
+		return statutory.RateSet{}, err
+	}
+	for _, bracket := range brackets {
+		rates.Brackets[bracket.TableCode] = append(rates.Brackets[bracket.TableCode], statutory.Bracket{
+			From: bracket.IncomeFrom,
+			Rate: bracket.Rate,
+		})
+	}
+
+	ptkps := []struct {
+		Status      string
+		Amount      float64
+		TerCategory string
+	}{}
+	if err := db.
+		WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (status) status, amount, ter_category FROM pph21_ptkp
+			WHERE valid_from <= ? ORDER BY status, valid_from DESC`, asOf).
+		Scan(&ptkps).Error; err != nil {
+		return statutory.RateSet{}, err
+	}
+	for _, ptkp := range ptkps {
+		rates.PTKP[ptkp.Status] = statutory.PTKP{
+			Status:      ptkp.Status,
+			Amount:      ptkp.Amount,
+			TERCategory: ptkp.TerCategory,
+		}
+	}
+	return rates, nil
+}
+
+func (s *statutoryQueryImpl) GetYearToDates(ctx context.Context, employeeIDs []uint64, period models.Date) (map[uint64]statutory.YearToDate, error) {
+	db := s.db.GetConnection()
+	result := map[uint64]statutory.YearToDate{}
+	if len(employeeIDs) == 0 {
+		return result, nil
+	}
+
+	rows := []struct {
+		EmployeeID          uint64
+		TaxableIncome       float64
+		PensionContribution float64
+		TaxWithheld         float64
+		Months              int
+	}{}
+	yearStart := time.Date(period.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	if err := db.
+		WithContext(ctx).
+		Table("payslips ps").
+		Select(`ps.employee_id,
+			SUM(ps.taxable_income) AS taxable_income,
+			SUM(ps.pension_contribution) AS pension_contribution,
+			SUM(ps.tax) AS tax_withheld,
+			COUNT(*) AS months`).
+		Joins("JOIN payroll_runs pr ON pr.id = ps.payroll_run_id").
+		Where("pr.status = ?", models.PAYROLL_STATUS_APPROVED).
+		Where("pr.period >= ? AND pr.period < ?", yearStart, period).
+		Where("ps.employee_id IN ?", employeeIDs).
+		Group("ps.employee_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.EmployeeID] = statutory.YearToDate{
+			TaxableIncome:       row.TaxableIncome,
+			PensionContribution: row.PensionContribution,
+			TaxWithheld:         row.TaxWithheld,
+			Months:              row.Months,
+		}
+	}
+	return result, nil
+}
+
+func (s *statutoryQueryImpl) GetApprovedPayslipsOfYear(ctx context.Context, companyID uint64, employeeID uint64, year int) ([]models.Payslip, error) {
+	db := s.db.GetConnection()
+	payslips := []models.Payslip{}
+	query := db.
+		WithContext(ctx).
+		Table("payslips ps").
+		Select("ps.*, pr.period").
+		Joins("JOIN payroll_runs pr ON pr.id = ps.payroll_run_id").
+		Where("pr.company_id = ?", companyID).
+		Where("pr.status = ?", models.PAYROLL_STATUS_APPROVED).
+		Where("EXTRACT(YEAR FROM pr.period) = ?", year)
+	if employeeID != 0 {
+		query = query.Where("ps.employee_id = ?", employeeID)
+	}
+	if err := query.Order("ps.employee_id, pr.period").Find(&payslips).Error; err != nil {
+		return []models.Payslip{}, err
+	}
+	return payslips, nil
+}
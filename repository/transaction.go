@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/geedotrar/erp-api/config"
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// withTx return ctx carrying transaction, query using conn on this ctx join the transaction
+func withTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// conn return transaction of ctx when query run inside WithTransaction,
+// otherwise the connection pool
+func conn(ctx context.Context, db config.GormPostgres) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.GetConnection()
+}
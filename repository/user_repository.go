@@ -9,7 +9,7 @@ import (
 )
 
 type UserQuery interface {
-	GetUsers(ctx context.Context) ([]models.User, error)
+	GetUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error)
 	GetUserByID(ctx context.Context, id uint64) (models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (models.User, error)
 
@@ -21,6 +21,22 @@ type UserQuery interface {
 	DeleteUser(ctx context.Context, id uint64) error
 
 	SignUp(ctx context.Context, user models.User) (models.User, error)
+
+	// check department of user
+	GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error)
+	// timezone of company of user
+	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
+
+	// direct and indirect report of manager using recursive query
+	GetReports(ctx context.Context, managerID uint64, directOnly bool) ([]models.UserReport, error)
+	// IsReportOf walk up the manager chain of report without depth limit, used for cycle check
+	IsReportOf(ctx context.Context, reportID uint64, managerID uint64) (bool, error)
+	// LockReportingLines hold the reporting line lock until the transaction of ctx end,
+	// manager changes run one at a time
+	LockReportingLines(ctx context.Context) error
+
+	CountUsers(ctx context.Context, filter models.UserFilter) (int64, error)
+	StreamUsers(ctx context.Context, filter models.UserFilter, columns []string, fn func(values []any) error) error
 }
 
 type userQueryImpl struct {
@@ -31,12 +47,13 @@ func NewUserQuery(db config.GormPostgres) UserQuery {
 	return &userQueryImpl{db: db}
 }
 
-func (u *userQueryImpl) GetUsers(ctx context.Context) ([]models.User, error) {
-	db := u.db.GetConnection()
+func (u *userQueryImpl) GetUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	db := conn(ctx, u.db)
 	users := []models.User{}
 	if err := db.
 		WithContext(ctx).
 		Table("users").
+		Scopes(userFilterScope(filter)).
 		Find(&users).Error; err != nil {
 		return []models.User{}, err
 	}
@@ -44,7 +61,7 @@ func (u *userQueryImpl) GetUsers(ctx context.Context) ([]models.User, error) {
 }
 
 func (u *userQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User, error) {
-	db := u.db.GetConnection()
+	db := conn(ctx, u.db)
 	users := models.User{}
 	if err := db.
 		WithContext(ctx).
@@ -60,7 +77,7 @@ func (u *userQueryImpl) GetUserByID(ctx context.Context, id uint64) (models.User
 }
 
 func (u *userQueryImpl) GetUserByEmail(ctx context.Context, email string) (models.User, error) {
-	db := u.db.GetConnection()
+	db := conn(ctx, u.db)
 	user := models.User{}
 	if err := db.WithContext(ctx).Where("email = ?", email).Find(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -72,7 +89,7 @@ func (u *userQueryImpl) GetUserByEmail(ctx context.Context, email string) (model
 }
 
 func (u *userQueryImpl) CreateUser(ctx context.Context, user models.UserCreateRequest) (models.UserCreateRequest, error) {
-	db := u.db.GetConnection()
+	db := conn(ctx, u.db)
 	if err := db.
 		WithContext(ctx).
 		Table("users").
@@ -83,7 +100,7 @@ func (u *userQueryImpl) CreateUser(ctx context.Context, user models.UserCreateRe
 }
 
 func (u *userQueryImpl) UpdateUser(ctx context.Context, id uint64, user models.UserEditRequest) (models.UserEditRequest, error) {
-	db := u.db.GetConnection()
+	db := conn(ctx, u.db)
 	updatedUser := models.UserEditRequest{}
 	if err := db.
 		WithContext(ctx).
@@ -99,7 +116,7 @@ func (u *userQueryImpl) UpdateUser(ctx context.Context, id uint64, user models.U
 }
 
 func (u *userQueryImpl) DeleteUser(ctx context.Context, id uint64) error {
-	db := u.db.GetConnection()
+	db := conn(ctx, u.db)
 	if err := db.
 		WithContext(ctx).
 		Table("users").
@@ -111,7 +128,7 @@ func (u *userQueryImpl) DeleteUser(ctx context.Context, id uint64) error {
 }
 
 func (u *userQueryImpl) SignUp(ctx context.Context, user models.User) (models.User, error) {
-	db := u.db.GetConnection()
+	db := conn(ctx, u.db)
 	if err := db.
 		WithContext(ctx).
 		Table("users").
@@ -122,7 +139,7 @@ func (u *userQueryImpl) SignUp(ctx context.Context, user models.User) (models.Us
 }
 
 func (u *userQueryImpl) CheckSoftDeletedUserByEmail(ctx context.Context, email string) bool {
-	db := u.db.GetConnection()
+	db := conn(ctx, u.db)
 	var count int64
 	if err := db.WithContext(ctx).
 		Table("users").
@@ -133,3 +150,143 @@ func (u *userQueryImpl) CheckSoftDeletedUserByEmail(ctx context.Context, email s
 	}
 	return count > 0
 }
+
+func (u *userQueryImpl) GetDepartmentByID(ctx context.Context, id uint64) (models.Department, error) {
+	db := conn(ctx, u.db)
+	department := models.Department{}
+	if err := db.
+		WithContext(ctx).
+		Table("departments").
+		Where("id = ?", id).
+		Find(&department).Error; err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
+
+// reporting line deeper than this is treated as broken data
+const maxReportDepth = 50
+
+func (u *userQueryImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
+	db := conn(ctx, u.db)
+	company := models.Company{}
+	if err := db.
+		WithContext(ctx).
+		Table("companies").
+		Where("id = ?", id).
+		Find(&company).Error; err != nil {
+		return models.Company{}, err
+	}
+	return company, nil
+}
+
+// two concurrent manager changes could build a cycle together
+func (u *userQueryImpl) LockReportingLines(ctx context.Context) error {
+	db := conn(ctx, u.db)
+	return db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(hashtext('reporting_lines'))").Error
+}
+
+func (u *userQueryImpl) GetReports(ctx context.Context, managerID uint64, directOnly bool) ([]models.UserReport, error) {
+	db := conn(ctx, u.db)
+	reports := []models.UserReport{}
+	maxDepth := maxReportDepth
+	if directOnly {
+		maxDepth = 1
+	}
+	query := `
+		WITH RECURSIVE reports AS (
+			SELECT id, 1 AS depth
+			FROM users
+			WHERE manager_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT u.id, r.depth + 1
+			FROM users u
+			JOIN reports r ON u.manager_id = r.id
+			WHERE u.deleted_at IS NULL AND r.depth < ?
+		)
+		SELECT u.id, u.first_name, u.last_name, u.email, u.manager_id, u.position_id,
+			COALESCE(p.position_name, '') AS position_name, r.depth
+		FROM reports r
+		JOIN users u ON u.id = r.id
+		LEFT JOIN positions p ON p.id = u.position_id
+		ORDER BY r.depth, u.id`
+	if err := db.
+		WithContext(ctx).
+		Raw(query, managerID, maxDepth).
+		Scan(&reports).Error; err != nil {
+		return []models.UserReport{}, err
+	}
+	return reports, nil
+}
+
+func (u *userQueryImpl) IsReportOf(ctx context.Context, reportID uint64, managerID uint64) (bool, error) {
+	db := conn(ctx, u.db)
+	var found bool
+	// path stop the walk on cycle that is already in the data
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, manager_id, ARRAY[id] AS path
+			FROM users
+			WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT u.id, u.manager_id, c.path || u.id
+			FROM users u
+			JOIN chain c ON u.id = c.manager_id
+			WHERE u.deleted_at IS NULL AND NOT u.id = ANY(c.path)
+		)
+		SELECT EXISTS (SELECT 1 FROM chain WHERE id = ? AND id <> ?)`
+	if err := db.
+		WithContext(ctx).
+		Raw(query, reportID, managerID, reportID).
+		Scan(&found).Error; err != nil {
+		return false, err
+	}
+	return found, nil
+}
+
+func (u *userQueryImpl) CountUsers(ctx context.Context, filter models.UserFilter) (int64, error) {
+	db := conn(ctx, u.db)
+	var count int64
+	if err := db.
+		WithContext(ctx).
+		Table("users").
+		Where("deleted_at IS NULL").
+		Scopes(userFilterScope(filter)).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (u *userQueryImpl) StreamUsers(ctx context.Context, filter models.UserFilter, columns []string, fn func(values []any) error) error {
+	db := conn(ctx, u.db)
+	query := db.
+		WithContext(ctx).
+		Table("users").
+		Select(columns).
+		Where("deleted_at IS NULL").
+		Scopes(userFilterScope(filter)).
+		Order("id")
+	return streamRows(query, fn)
+}
+
+func userFilterScope(filter models.UserFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Email != "" {
+			db = db.Where("email ILIKE ?", "%"+filter.Email+"%")
+		}
+		if filter.Role != "" {
+			db = db.Where("role = ?", filter.Role)
+		}
+		if filter.PositionID != 0 {
+			db = db.Where("position_id = ?", filter.PositionID)
+		}
+		if filter.CompanyID != 0 {
+			db = db.Where("company_id = ?", filter.CompanyID)
+		}
+		if filter.DepartmentID != 0 {
+			db = db.Where("department_id = ?", filter.DepartmentID)
+		}
+		return db
+	}
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/config"
+	"github.com/geedotrar/erp-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// delivery claimed by worker is hidden for this long, so delivery of crashed
+// worker is picked again without separate running status
+const webhookClaimLease = 2 * time.Minute
+
+type WebhookQuery interface {
+	GetWebhooks(ctx context.Context, filter models.WebhookFilter) ([]models.Webhook, error)
+	GetWebhookByID(ctx context.Context, id uint64) (models.Webhook, error)
+	// active webhook subscribed to event of company, including webhook of parent company.
+	// event without company, like template position, is sent to every subscriber
+	GetSubscribedWebhooks(ctx context.Context, companyID *uint64, event string) ([]models.Webhook, error)
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	UpdateWebhookSecret(ctx context.Context, id uint64, secret string) error
+	DeleteWebhook(ctx context.Context, id uint64) error
+
+	GetDeliveries(ctx context.Context, webhookID uint64, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+	GetDeliveryByID(ctx context.Context, id uint64) (models.WebhookDelivery, error)
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	// ambil delivery pending yang sudah waktunya dan sembunyikan selama lease
+	ClaimNextDelivery(ctx context.Context) (models.WebhookDelivery, error)
+	FinishDelivery(ctx context.Context, delivery models.WebhookDelivery) error
+}
+
+type webhookQueryImpl struct {
+	db config.GormPostgres
+}
+
+func NewWebhookQuery(db config.GormPostgres) WebhookQuery {
+	return &webhookQueryImpl{db: db}
+}
+
+func (w *webhookQueryImpl) GetWebhooks(ctx context.Context, filter models.WebhookFilter) ([]models.Webhook, error) {
+	db := conn(ctx, w.db)
+	webhooks := []models.Webhook{}
+	if err := db.
+		WithContext(ctx).
+		Table("webhooks").
+		Where("company_id = ?", filter.CompanyID).
+		Order("id").
+		Find(&webhooks).Error; err != nil {
+		return []models.Webhook{}, err
+	}
+	for i := range webhooks {
+		webhooks[i].EventList = strings.Split(webhooks[i].Events, ",")
+	}
+	return webhooks, nil
+}
+
+func (w *webhookQueryImpl) GetWebhookByID(ctx context.Context, id uint64) (models.Webhook, error) {
+	db := conn(ctx, w.db)
+	webhook := models.Webhook{}
+	if err := db.
+		WithContext(ctx).
+		Table("webhooks").
+		Where("id = ?", id).
+		Find(&webhook).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.Webhook{}, nil
+		}
+		return models.Webhook{}, err
+	}
+	if webhook.ID != 0 {
+		webhook.EventList = strings.Split(webhook.Events, ",")
+	}
+	return webhook, nil
+}
+
+func (w *webhookQueryImpl) GetSubscribedWebhooks(ctx context.Context, companyID *uint64, event string) ([]models.Webhook, error) {
+	db := conn(ctx, w.db)
+	webhooks := []models.Webhook{}
+	query := db.
+		WithContext(ctx).
+		Table("webhooks").
+		Where("active = TRUE").
+		Where("(? = ANY(string_to_array(events, ',')) OR ? = ANY(string_to_array(events, ',')))", event, models.WEBHOOK_EVENT_ALL)
+	if companyID != nil {
+		query = query.Where(`company_id IN (
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id, 0 AS depth FROM companies WHERE id = ?
+				UNION ALL
+				SELECT c.id, c.parent_id, a.depth + 1
+				FROM companies c
+				JOIN ancestors a ON c.id = a.parent_id
+				WHERE a.depth < ?
+			)
+			SELECT id FROM ancestors)`, *companyID, maxCompanyDepth)
+	}
+	if err := query.Order("id").Find(&webhooks).Error; err != nil {
+		return []models.Webhook{}, err
+	}
+	return webhooks, nil
+}
+
+func (w *webhookQueryImpl) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	db := conn(ctx, w.db)
+	if err := db.
+		WithContext(ctx).
+		Table("webhooks").
+		Create(&webhook).Error; err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+func (w *webhookQueryImpl) UpdateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	db := conn(ctx, w.db)
+	if err := db.
+		WithContext(ctx).
+		Table("webhooks").
+		Where("id = ?", webhook.ID).
+		Updates(map[string]any{
+			"url":        webhook.URL,
+			"events":     webhook.Events,
+			"active":     webhook.Active,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		return models.Webhook{}, err
+	}
+	return w.GetWebhookByID(ctx, webhook.ID)
+}
+
+func (w *webhookQueryImpl) UpdateWebhookSecret(ctx context.Context, id uint64, secret string) error {
+	db := conn(ctx, w.db)
+	if err := db.
+		WithContext(ctx).
+		Table("webhooks").
+		Where("id = ?", id).
+		Updates(map[string]any{"secret": secret, "updated_at": time.Now()}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteWebhook soft delete webhook, its delivery log is kept
+func (w *webhookQueryImpl) DeleteWebhook(ctx context.Context, id uint64) error {
+	db := conn(ctx, w.db)
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// pending delivery of removed webhook is never sent
+		if err := tx.
+			Table("webhook_deliveries").
+			Where("webhook_id = ? AND status = ?", id, models.WEBHOOK_DELIVERY_STATUS_PENDING).
+			Updates(map[string]any{
+				"status":       models.WEBHOOK_DELIVERY_STATUS_FAILED,
+				"error_reason": "webhook deleted",
+				"updated_at":   time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Table("webhooks").Delete(&models.Webhook{ID: id}).Error
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *webhookQueryImpl) GetDeliveries(ctx context.Context, webhookID uint64, filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	db := conn(ctx, w.db)
+	deliveries := []models.WebhookDelivery{}
+	query := db.
+		WithContext(ctx).
+		Table("webhook_deliveries").
+		Where("webhook_id = ?", webhookID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Event != "" {
+		query = query.Where("event = ?", filter.Event)
+	}
+	if err := query.Order("id DESC").Limit(filter.Limit).Find(&deliveries).Error; err != nil {
+		return []models.WebhookDelivery{}, err
+	}
+	return deliveries, nil
+}
+
+func (w *webhookQueryImpl) GetDeliveryByID(ctx context.Context, id uint64) (models.WebhookDelivery, error) {
+	db := conn(ctx, w.db)
+	delivery := models.WebhookDelivery{}
+	if err := db.
+		WithContext(ctx).
+		Table("webhook_deliveries").
+		Where("id = ?", id).
+		Find(&delivery).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return models.WebhookDelivery{}, nil
+		}
+		return models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+func (w *webhookQueryImpl) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	db := conn(ctx, w.db)
+	if err := db.
+		WithContext(ctx).
+		Table("webhook_deliveries").
+		Create(&deliveries).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *webhookQueryImpl) ClaimNextDelivery(ctx context.Context) (models.WebhookDelivery, error) {
+	db := conn(ctx, w.db)
+	delivery := models.WebhookDelivery{}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.
+			Table("webhook_deliveries").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WEBHOOK_DELIVERY_STATUS_PENDING, now).
+			Order("next_attempt_at, id").
+			Limit(1).
+			Find(&delivery).Error; err != nil {
+			return err
+		}
+		if delivery.ID == 0 {
+			return nil
+		}
+		return tx.
+			Table("webhook_deliveries").
+			Where("id = ?", delivery.ID).
+			Updates(map[string]any{"next_attempt_at": now.Add(webhookClaimLease), "updated_at": now}).Error
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return delivery, nil
+}
+
+func (w *webhookQueryImpl) FinishDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	db := conn(ctx, w.db)
+	if err := db.
+		WithContext(ctx).
+		Table("webhook_deliveries").
+		Where("id = ?", delivery.ID).
+		Updates(map[string]any{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"response_status": delivery.ResponseStatus,
+			"response_body":   delivery.ResponseBody,
+			"error_reason":    delivery.ErrorReason,
+			"delivered_at":    delivery.DeliveredAt,
+			"updated_at":      time.Now(),
+		}).Error; err != nil {
+		return err
+	}
+	return nil
+}
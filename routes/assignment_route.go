@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type AssignmentRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type assignmentRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.AssignmentHandler
+}
+
+func NewAssignmentRouter(v *gin.RouterGroup, handler handlers.AssignmentHandler) AssignmentRouter {
+	return &assignmentRouterImpl{v: v, handler: handler}
+}
+
+func (a *assignmentRouterImpl) Mount() {
+	a.v.Use(middleware.CheckAuthBearer)
+
+	a.v.GET("/", a.handler.GetAssignments)
+	a.v.GET("/users/:id", a.handler.GetUserAssignments)
+
+	a.v.POST("/users/:id/transfer", middleware.CheckRoleAdmin, a.handler.TransferUser)
+	a.v.POST("/users/:id/promote", middleware.CheckRoleAdmin, a.handler.PromoteUser)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (a *assignmentRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/", Summary: "List assignments", Auth: openapi.AuthBearer, Query: models.AssignmentFilter{}, Response: models.AssignmentsResponse{}},
+		{Method: http.MethodGet, Path: "/users/:id", Summary: "Get assignment history of user, or the assignment effective at as_of", Auth: openapi.AuthBearer, Params: []openapi.Param{openapi.QueryParam("as_of", "string", "date, YYYY-MM-DD")}, Response: models.AssignmentsResponse{}},
+
+		{Method: http.MethodPost, Path: "/users/:id/transfer", Summary: "Transfer user to another company, department or position, manager outside the new company is cleared", Auth: openapi.AuthAdmin, Body: models.AssignmentTransferRequest{}, Response: models.AssignmentResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/users/:id/promote", Summary: "Promote user to another position", Auth: openapi.AuthAdmin, Body: models.AssignmentPromotionRequest{}, Response: models.AssignmentResponse{}, Status: http.StatusCreated},
+	}
+}
@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type AttendanceRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type attendanceRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.AttendanceHandler
+}
+
+func NewAttendanceRouter(v *gin.RouterGroup, handler handlers.AttendanceHandler) AttendanceRouter {
+	return &attendanceRouterImpl{v: v, handler: handler}
+}
+
+func (a *attendanceRouterImpl) Mount() {
+	a.v.Use(middleware.CheckAuthBearer)
+
+	a.v.POST("/clock-in", a.handler.ClockIn)
+	a.v.POST("/clock-out", a.handler.ClockOut)
+	a.v.GET("/me", a.handler.GetMyAttendances)
+	a.v.POST("/corrections", a.handler.CreateCorrection)
+
+	admin := a.v.Group("", middleware.CheckRoleAdmin)
+	admin.GET("/corrections", a.handler.GetCorrections)
+	admin.POST("/corrections/:id/approve", a.handler.ApproveCorrection)
+	admin.POST("/corrections/:id/reject", a.handler.RejectCorrection)
+	admin.GET("/summary", a.handler.GetMonthlySummary)
+
+	admin.GET("/settings/:company_id", a.handler.GetAttendanceSetting)
+	admin.PUT("/settings/:company_id", a.handler.SaveAttendanceSetting)
+	admin.POST("/settings/:company_id/geofences", a.handler.CreateGeofence)
+	admin.DELETE("/geofences/:id", a.handler.DeleteGeofence)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (a *attendanceRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Path: "/clock-in", Summary: "Clock in", Auth: openapi.AuthBearer, Body: models.ClockRequest{}, Response: models.AttendanceResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/clock-out", Summary: "Clock out", Auth: openapi.AuthBearer, Body: models.ClockRequest{}, Response: models.AttendanceResponse{}},
+		{Method: http.MethodGet, Path: "/me", Summary: "List attendances of logged in user", Auth: openapi.AuthBearer, Params: []openapi.Param{openapi.QueryParam("month", "string", "YYYY-MM")}, Response: models.AttendancesResponse{}},
+		{Method: http.MethodPost, Path: "/corrections", Summary: "Request attendance correction", Auth: openapi.AuthBearer, Body: models.AttendanceCorrectionRequest{}, Response: models.AttendanceCorrectionResponse{}, Status: http.StatusCreated},
+
+		{Method: http.MethodGet, Path: "/corrections", Summary: "List attendance corrections", Auth: openapi.AuthAdmin, Params: []openapi.Param{openapi.QueryParam("status", "string", "correction status")}, Response: models.AttendanceCorrectionsResponse{}},
+		{Method: http.MethodPost, Path: "/corrections/:id/approve", Summary: "Approve attendance correction", Auth: openapi.AuthAdmin, Body: models.AttendanceReviewRequest{}, OptionalBody: true, Response: models.AttendanceCorrectionResponse{}},
+		{Method: http.MethodPost, Path: "/corrections/:id/reject", Summary: "Reject attendance correction", Auth: openapi.AuthAdmin, Body: models.AttendanceReviewRequest{}, OptionalBody: true, Response: models.AttendanceCorrectionResponse{}},
+		{Method: http.MethodGet, Path: "/summary", Summary: "Monthly attendance summary", Auth: openapi.AuthAdmin, Query: models.AttendanceFilter{}, Response: models.AttendanceSummariesResponse{}},
+
+		{Method: http.MethodGet, Path: "/settings/:company_id", Summary: "Get attendance setting of company", Auth: openapi.AuthAdmin, Response: models.AttendanceSettingResponse{}},
+		{Method: http.MethodPut, Path: "/settings/:company_id", Summary: "Save attendance setting of company", Auth: openapi.AuthAdmin, Body: models.AttendanceSetting{}, Response: models.AttendanceSettingResponse{}},
+		{Method: http.MethodPost, Path: "/settings/:company_id/geofences", Summary: "Add geofence to company", Auth: openapi.AuthAdmin, Body: models.AttendanceGeofence{}, Response: models.AttendanceGeofenceResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodDelete, Path: "/geofences/:id", Summary: "Delete geofence", Auth: openapi.AuthAdmin, Response: models.AttendanceGeofenceResponse{}},
+	}
+}
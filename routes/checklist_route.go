@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type ChecklistRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type checklistRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.ChecklistHandler
+}
+
+func NewChecklistRouter(v *gin.RouterGroup, handler handlers.ChecklistHandler) ChecklistRouter {
+	return &checklistRouterImpl{v: v, handler: handler}
+}
+
+func (c *checklistRouterImpl) Mount() {
+	c.v.Use(middleware.CheckAuthBearer)
+
+	// assignee or company admin, checked in service
+	c.v.GET("/tasks/me", c.handler.GetMyTasks)
+	c.v.PUT("/tasks/:id/complete", c.handler.CompleteTask)
+
+	admin := c.v.Group("", middleware.CheckRoleAdmin)
+	admin.GET("/templates", c.handler.GetTemplates)
+	admin.POST("/templates", c.handler.CreateTemplate)
+	admin.GET("/templates/:id", c.handler.GetTemplateByID)
+	admin.PUT("/templates/:id", c.handler.UpdateTemplate)
+	admin.DELETE("/templates/:id", c.handler.DeleteTemplate)
+
+	admin.GET("/users/:id", c.handler.GetUserChecklists)
+	admin.GET("/:id", c.handler.GetChecklistByID)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (c *checklistRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/tasks/me", Summary: "List open checklist tasks assigned to logged in user", Auth: openapi.AuthBearer, Response: models.ChecklistTasksResponse{}},
+		{Method: http.MethodPut, Path: "/tasks/:id/complete", Summary: "Complete checklist task", Auth: openapi.AuthBearer, Body: models.ChecklistTaskCompleteRequest{}, OptionalBody: true, Response: models.ChecklistTaskResponse{}},
+
+		{Method: http.MethodGet, Path: "/templates", Summary: "List checklist templates", Auth: openapi.AuthAdmin, Query: models.ChecklistTemplateFilter{}, Response: models.ChecklistTemplatesResponse{}},
+		{Method: http.MethodPost, Path: "/templates", Summary: "Create checklist template", Auth: openapi.AuthAdmin, Body: models.ChecklistTemplateRequest{}, Response: models.ChecklistTemplateResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/templates/:id", Summary: "Get checklist template by id", Auth: openapi.AuthAdmin, Response: models.ChecklistTemplateResponse{}},
+		{Method: http.MethodPut, Path: "/templates/:id", Summary: "Update checklist template", Auth: openapi.AuthAdmin, Body: models.ChecklistTemplateRequest{}, Response: models.ChecklistTemplateResponse{}},
+		{Method: http.MethodDelete, Path: "/templates/:id", Summary: "Delete checklist template", Auth: openapi.AuthAdmin, Response: models.ChecklistTemplateResponse{}},
+
+		{Method: http.MethodGet, Path: "/users/:id", Summary: "List checklists of user", Auth: openapi.AuthAdmin, Response: models.ChecklistsResponse{}},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get checklist with its tasks", Auth: openapi.AuthAdmin, Response: models.ChecklistResponse{}},
+	}
+}
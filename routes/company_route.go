@@ -1,32 +1,65 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
 	"github.com/gin-gonic/gin"
 )
 
 type CompanyRouter interface {
 	Mount()
+	Docs() []openapi.Operation
 }
 
 type companyRouterImpl struct {
-	v       *gin.RouterGroup
-	handler handlers.CompanyHandler
+	v             *gin.RouterGroup
+	handler       handlers.CompanyHandler
+	exportHandler handlers.ExportHandler
 }
 
-func NewCompanyRouter(v *gin.RouterGroup, handler handlers.CompanyHandler) CompanyRouter {
-	return &companyRouterImpl{v: v, handler: handler}
+func NewCompanyRouter(v *gin.RouterGroup, handler handlers.CompanyHandler, exportHandler handlers.ExportHandler) CompanyRouter {
+	return &companyRouterImpl{v: v, handler: handler, exportHandler: exportHandler}
 }
 
 func (c *companyRouterImpl) Mount() {
 	// u.v.Use(middleware.CheckAuthBearer)
 
 	c.v.GET("/", c.handler.GetCompany)
+	c.v.GET("/export", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, middleware.NoWriteTimeout, c.exportHandler.ExportCompany)
 	c.v.GET("/:id", c.handler.GetCompanyByID)
+	c.v.GET("/:id/org-chart", middleware.CheckAuthBearer, c.handler.GetOrgChart)
+
+	c.v.POST("/", c.handler.CreateCompany)
+	c.v.PUT("/:id", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, c.handler.UpdateCompany)
+	c.v.DELETE("/:id", c.handler.DeleteCompany)
+	c.v.POST("/:id/restore", c.handler.RestoreCompany)
+
+	// company group, admin of parent company see every subsidiary
+	c.v.PUT("/:id/parent", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, c.handler.SetParentCompany)
+	c.v.GET("/:id/subtree", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, c.handler.GetCompanySubtree)
+	c.v.GET("/:id/rollup", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, c.handler.GetCompanyRollup)
+
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (c *companyRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/", Summary: "List companies, without tax number and contacts", Query: models.CompanyFilter{}, Response: models.CompaniesResponse{}},
+		{Method: http.MethodGet, Path: "/export", Summary: "Export companies, large export is answered with an export job", Auth: openapi.AuthAdmin, Query: []any{models.CompanyFilter{}, models.ExportRequest{}}, Response: models.ExportJobResponse{}, Produces: "application/octet-stream"},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get company by id, without tax number and contacts", Response: models.CompanyResponse{}},
+		{Method: http.MethodGet, Path: "/:id/org-chart", Summary: "Get org chart of company, limited to the company group of user", Auth: openapi.AuthBearer, Response: models.OrgChartResponse{}},
 
-	c.v.POST("/create", c.handler.CreateCompany)
-	c.v.PUT("/update/:id", c.handler.UpdateCompany)
-	c.v.DELETE("/delete/:id", c.handler.DeleteCompany)
-	c.v.PUT("/restore/:id", c.handler.RestoreCompany)
+		{Method: http.MethodPost, Path: "/", Summary: "Create company", Body: models.CompanyRequest{}, Response: models.CompanyResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/:id", Summary: "Update company", Auth: openapi.AuthAdmin, Body: models.CompanyRequest{}, Response: models.CompanyResponse{}},
+		{Method: http.MethodDelete, Path: "/:id", Summary: "Delete company", Response: models.CompanyResponse{}},
+		{Method: http.MethodPost, Path: "/:id/restore", Summary: "Restore deleted company", Response: models.CompanyResponse{}},
 
+		{Method: http.MethodPut, Path: "/:id/parent", Summary: "Set parent company, admin need scope over the company and the new parent", Auth: openapi.AuthAdmin, Body: models.CompanyParentRequest{}, Response: models.CompanyResponse{}},
+		{Method: http.MethodGet, Path: "/:id/subtree", Summary: "Get company with its subsidiaries", Auth: openapi.AuthAdmin, Response: models.CompanyTreeResponse{}},
+		{Method: http.MethodGet, Path: "/:id/rollup", Summary: "Get headcount and payroll rollup of company group", Auth: openapi.AuthAdmin, Params: []openapi.Param{openapi.QueryParam("period", "string", "payroll period, YYYY-MM")}, Response: models.CompanyRollupResponse{}},
+	}
 }
@@ -0,0 +1,50 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type DepartmentRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type departmentRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.DepartmentHandler
+}
+
+func NewDepartmentRouter(v *gin.RouterGroup, handler handlers.DepartmentHandler) DepartmentRouter {
+	return &departmentRouterImpl{v: v, handler: handler}
+}
+
+func (d *departmentRouterImpl) Mount() {
+	d.v.Use(middleware.CheckAuthBearer, middleware.CheckRoleAdmin)
+
+	d.v.GET("/", d.handler.GetDepartment)
+	d.v.GET("/:id", d.handler.GetDepartmentByID)
+
+	d.v.POST("/", d.handler.CreateDepartment)
+	d.v.PUT("/:id", d.handler.UpdateDepartment)
+	d.v.DELETE("/:id", d.handler.DeleteDepartment)
+	d.v.POST("/:id/restore", d.handler.RestoreDepartment)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (d *departmentRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/", Summary: "List departments", Auth: openapi.AuthAdmin, Query: models.DepartmentFilter{}, Response: models.DepartmentsResponse{}},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get department by id", Auth: openapi.AuthAdmin, Response: models.DepartmentResponse{}},
+
+		{Method: http.MethodPost, Path: "/", Summary: "Create department", Auth: openapi.AuthAdmin, Body: models.DepartmentRequest{}, Response: models.DepartmentResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/:id", Summary: "Update department", Auth: openapi.AuthAdmin, Body: models.DepartmentRequest{}, Response: models.DepartmentResponse{}},
+		{Method: http.MethodDelete, Path: "/:id", Summary: "Delete department", Auth: openapi.AuthAdmin, Response: models.DepartmentResponse{}},
+		{Method: http.MethodPost, Path: "/:id/restore", Summary: "Restore deleted department", Auth: openapi.AuthAdmin, Response: models.DepartmentResponse{}},
+	}
+}
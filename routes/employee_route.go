@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type EmployeeRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type employeeRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.EmployeeHandler
+}
+
+func NewEmployeeRouter(v *gin.RouterGroup, handler handlers.EmployeeHandler) EmployeeRouter {
+	return &employeeRouterImpl{v: v, handler: handler}
+}
+
+func (e *employeeRouterImpl) Mount() {
+	e.v.Use(middleware.CheckAuthBearer, middleware.CheckRoleAdmin)
+
+	e.v.GET("/", e.handler.GetEmployees)
+	e.v.GET("/:id", e.handler.GetEmployeeByID)
+
+	e.v.POST("/", e.handler.CreateEmployee)
+	e.v.PUT("/:id", e.handler.UpdateEmployee)
+	e.v.DELETE("/:id", e.handler.DeleteEmployee)
+
+	e.v.POST("/:id/confirm", e.handler.ConfirmEmployee)
+	e.v.POST("/:id/suspend", e.handler.SuspendEmployee)
+	e.v.POST("/:id/reactivate", e.handler.ReactivateEmployee)
+	e.v.POST("/:id/terminate", e.handler.TerminateEmployee)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (e *employeeRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/", Summary: "List employees", Auth: openapi.AuthAdmin, Query: models.EmployeeFilter{}, Response: models.EmployeesResponse{}},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get employee by id", Auth: openapi.AuthAdmin, Response: models.EmployeeResponse{}},
+
+		{Method: http.MethodPost, Path: "/", Summary: "Create employee", Auth: openapi.AuthAdmin, Body: models.EmployeeCreateRequest{}, Response: models.EmployeeResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/:id", Summary: "Update employee", Auth: openapi.AuthAdmin, Body: models.EmployeeUpdateRequest{}, Response: models.EmployeeResponse{}},
+		{Method: http.MethodDelete, Path: "/:id", Summary: "Delete employee", Auth: openapi.AuthAdmin, Response: models.EmployeeResponse{}},
+
+		{Method: http.MethodPost, Path: "/:id/confirm", Summary: "Confirm employee after probation", Auth: openapi.AuthAdmin, Response: models.EmployeeResponse{}},
+		{Method: http.MethodPost, Path: "/:id/suspend", Summary: "Suspend employee", Auth: openapi.AuthAdmin, Response: models.EmployeeResponse{}},
+		{Method: http.MethodPost, Path: "/:id/reactivate", Summary: "Reactivate suspended employee", Auth: openapi.AuthAdmin, Response: models.EmployeeResponse{}},
+		{Method: http.MethodPost, Path: "/:id/terminate", Summary: "Terminate employee", Auth: openapi.AuthAdmin, Body: models.EmployeeTerminateRequest{}, Response: models.EmployeeResponse{}},
+	}
+}
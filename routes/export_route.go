@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type ExportRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type exportRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.ExportHandler
+}
+
+func NewExportRouter(v *gin.RouterGroup, handler handlers.ExportHandler) ExportRouter {
+	return &exportRouterImpl{v: v, handler: handler}
+}
+
+func (e *exportRouterImpl) Mount() {
+	e.v.Use(middleware.CheckAuthBearer)
+
+	e.v.GET("/:id", e.handler.GetExportJobByID)
+	e.v.GET("/:id/download", middleware.NoWriteTimeout, e.handler.DownloadExportJob)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (e *exportRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get export job", Auth: openapi.AuthBearer, Response: models.ExportJobResponse{}},
+		{Method: http.MethodGet, Path: "/:id/download", Summary: "Download file of finished export job", Auth: openapi.AuthBearer, Response: models.ExportJobResponse{}, Produces: "application/octet-stream"},
+	}
+}
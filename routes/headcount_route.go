@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type HeadcountRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type headcountRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.HeadcountHandler
+}
+
+func NewHeadcountRouter(v *gin.RouterGroup, handler handlers.HeadcountHandler) HeadcountRouter {
+	return &headcountRouterImpl{v: v, handler: handler}
+}
+
+func (h *headcountRouterImpl) Mount() {
+	h.v.Use(middleware.CheckAuthBearer, middleware.CheckRoleAdmin)
+
+	h.v.GET("/plans", h.handler.GetHeadcountPlans)
+	h.v.PUT("/plans", h.handler.SaveHeadcountPlan)
+	h.v.DELETE("/plans/:id", h.handler.DeleteHeadcountPlan)
+
+	h.v.GET("/vacancies", h.handler.GetVacancies)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (h *headcountRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/plans", Summary: "List headcount plans", Auth: openapi.AuthAdmin, Query: models.HeadcountFilter{}, Response: models.HeadcountPlansResponse{}},
+		{Method: http.MethodPut, Path: "/plans", Summary: "Save headcount plan of position", Auth: openapi.AuthAdmin, Body: models.HeadcountPlanRequest{}, Response: models.HeadcountPlanResponse{}},
+		{Method: http.MethodDelete, Path: "/plans/:id", Summary: "Delete headcount plan", Auth: openapi.AuthAdmin, Response: models.HeadcountPlanResponse{}},
+
+		{Method: http.MethodGet, Path: "/vacancies", Summary: "List positions with open vacancy", Auth: openapi.AuthAdmin, Query: models.HeadcountFilter{}, Response: models.HeadcountPlansResponse{}},
+	}
+}
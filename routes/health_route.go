@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type healthRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.HealthHandler
+}
+
+// NewHealthRouter take the root group, probe path is not versioned
+func NewHealthRouter(v *gin.RouterGroup, handler handlers.HealthHandler) HealthRouter {
+	return &healthRouterImpl{v: v, handler: handler}
+}
+
+func (h *healthRouterImpl) Mount() {
+	h.v.GET("/healthz", h.handler.Liveness)
+	h.v.GET("/readyz", h.handler.Readiness)
+	h.v.GET("/status", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, h.handler.Status)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (h *healthRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/healthz", Summary: "Liveness probe, process is serving", Tag: "health", Response: models.HealthResponse{}},
+		{Method: http.MethodGet, Path: "/readyz", Summary: "Readiness probe, 503 when database, migration or worker check fail or during shutdown", Tag: "health", Response: models.HealthResponse{}},
+		{Method: http.MethodGet, Path: "/status", Summary: "Build info, uptime, pool stats and worker status", Tag: "health", Auth: openapi.AuthAdmin, Response: models.SystemStatusResponse{}},
+	}
+}
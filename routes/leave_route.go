@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type LeaveRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type leaveRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.LeaveHandler
+}
+
+func NewLeaveRouter(v *gin.RouterGroup, handler handlers.LeaveHandler) LeaveRouter {
+	return &leaveRouterImpl{v: v, handler: handler}
+}
+
+func (l *leaveRouterImpl) Mount() {
+	l.v.Use(middleware.CheckAuthBearer)
+
+	l.v.GET("/types", l.handler.GetLeaveTypes)
+	l.v.POST("/types", middleware.CheckRoleAdmin, l.handler.CreateLeaveType)
+	l.v.PUT("/types/:id", middleware.CheckRoleAdmin, l.handler.UpdateLeaveType)
+	l.v.DELETE("/types/:id", middleware.CheckRoleAdmin, l.handler.DeleteLeaveType)
+
+	l.v.GET("/balances", l.handler.GetLeaveBalances)
+
+	l.v.POST("/requests", l.handler.CreateLeaveRequest)
+	l.v.GET("/requests/me", l.handler.GetMyLeaveRequests)
+	// approver is manager of the requester or admin of the company, checked in service
+	l.v.GET("/requests/pending", l.handler.GetPendingLeaveRequests)
+	l.v.POST("/requests/:id/approve", l.handler.ApproveLeaveRequest)
+	l.v.POST("/requests/:id/reject", l.handler.RejectLeaveRequest)
+	l.v.POST("/requests/:id/cancel", l.handler.CancelLeaveRequest)
+
+	l.v.GET("/calendar", l.handler.GetLeaveCalendar)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (l *leaveRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/types", Summary: "List leave types", Auth: openapi.AuthBearer, Params: []openapi.Param{openapi.QueryParam("company_id", "integer", "company of the leave types")}, Response: models.LeaveTypesResponse{}},
+		{Method: http.MethodPost, Path: "/types", Summary: "Create leave type", Auth: openapi.AuthAdmin, Body: models.LeaveType{}, Response: models.LeaveTypeResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/types/:id", Summary: "Update leave type", Auth: openapi.AuthAdmin, Body: models.LeaveType{}, Response: models.LeaveTypeResponse{}},
+		{Method: http.MethodDelete, Path: "/types/:id", Summary: "Delete leave type", Auth: openapi.AuthAdmin, Response: models.LeaveTypeResponse{}},
+
+		{Method: http.MethodGet, Path: "/balances", Summary: "List leave balances of logged in user", Auth: openapi.AuthBearer, Params: []openapi.Param{openapi.QueryParam("year", "integer", "defaults to current year")}, Response: models.LeaveBalancesResponse{}},
+
+		{Method: http.MethodPost, Path: "/requests", Summary: "Request leave", Auth: openapi.AuthBearer, Body: models.LeaveRequestCreate{}, Response: models.LeaveRequestResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/requests/me", Summary: "List leave requests of logged in user", Auth: openapi.AuthBearer, Response: models.LeaveRequestsResponse{}},
+
+		{Method: http.MethodGet, Path: "/requests/pending", Summary: "List leave requests waiting for review of logged in user", Auth: openapi.AuthBearer, Response: models.LeaveRequestsResponse{}},
+		{Method: http.MethodPost, Path: "/requests/:id/approve", Summary: "Approve leave request", Auth: openapi.AuthBearer, Body: models.LeaveReviewRequest{}, OptionalBody: true, Response: models.LeaveRequestResponse{}},
+		{Method: http.MethodPost, Path: "/requests/:id/reject", Summary: "Reject leave request", Auth: openapi.AuthBearer, Body: models.LeaveReviewRequest{}, OptionalBody: true, Response: models.LeaveRequestResponse{}},
+		{Method: http.MethodPost, Path: "/requests/:id/cancel", Summary: "Cancel own leave request", Auth: openapi.AuthBearer, Body: models.LeaveReviewRequest{}, OptionalBody: true, Response: models.LeaveRequestResponse{}},
+
+		{Method: http.MethodGet, Path: "/calendar", Summary: "Leave calendar", Auth: openapi.AuthBearer, Query: models.LeaveCalendarFilter{}, Response: models.LeaveCalendarResponse{}},
+	}
+}
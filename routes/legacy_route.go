@@ -0,0 +1,129 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+// API_V1 prefix of the current api, a new version is mounted on its own group next to it
+const API_V1 = "/api/v1"
+
+// unversioned path from before API_V1 is answered by the v1 route until sunset
+var (
+	LEGACY_DEPRECATED_AT = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	LEGACY_SUNSET_AT     = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// old group path and its group under API_V1, every v1 route of the group keep its old path
+var legacyGroups = []struct {
+	path   string
+	target string
+}{
+	{"/users", "/users"},
+	{"/company", "/companies"},
+	{"/positions", "/positions"},
+	{"/departments", "/departments"},
+	{"/employees", "/employees"},
+	{"/exports", "/exports"},
+	{"/checklists", "/checklists"},
+	{"/headcount", "/headcount"},
+	{"/assignments", "/assignments"},
+	{"/attendance", "/attendance"},
+	{"/leaves", "/leaves"},
+	{"/recruitment", "/recruitment"},
+	{"/payroll", "/payroll"},
+	{"/webhooks", "/webhooks"},
+	{"/notifications", "/notifications"},
+}
+
+// old crud route with verb in path, target is relative to API_V1
+var legacyVerbRoutes = []openapi.Alias{
+	{Method: http.MethodPost, Path: "/company/create", TargetMethod: http.MethodPost, Target: "/companies/"},
+	{Method: http.MethodPut, Path: "/company/update/:id", TargetMethod: http.MethodPut, Target: "/companies/:id"},
+	{Method: http.MethodDelete, Path: "/company/delete/:id", TargetMethod: http.MethodDelete, Target: "/companies/:id"},
+	{Method: http.MethodPut, Path: "/company/restore/:id", TargetMethod: http.MethodPost, Target: "/companies/:id/restore"},
+
+	{Method: http.MethodPost, Path: "/positions/create", TargetMethod: http.MethodPost, Target: "/positions/"},
+	{Method: http.MethodPut, Path: "/positions/update/:id", TargetMethod: http.MethodPut, Target: "/positions/:id"},
+	{Method: http.MethodDelete, Path: "/positions/delete/:id", TargetMethod: http.MethodDelete, Target: "/positions/:id"},
+
+	{Method: http.MethodPost, Path: "/departments/create", TargetMethod: http.MethodPost, Target: "/departments/"},
+	{Method: http.MethodPut, Path: "/departments/update/:id", TargetMethod: http.MethodPut, Target: "/departments/:id"},
+	{Method: http.MethodDelete, Path: "/departments/delete/:id", TargetMethod: http.MethodDelete, Target: "/departments/:id"},
+	{Method: http.MethodPut, Path: "/departments/restore/:id", TargetMethod: http.MethodPost, Target: "/departments/:id/restore"},
+}
+
+type LegacyRouter interface {
+	Mount()
+	Aliases() []openapi.Alias
+}
+
+type legacyRouterImpl struct {
+	g       *gin.Engine
+	aliases []openapi.Alias
+}
+
+// NewLegacyRouter alias old path to the v1 route, Mount must run after every v1 router is mounted
+func NewLegacyRouter(g *gin.Engine) LegacyRouter {
+	return &legacyRouterImpl{g: g}
+}
+
+func (l *legacyRouterImpl) Mount() {
+	// route replaced by verb route did not exist under the old path
+	replaced := map[string]bool{}
+	for _, alias := range legacyVerbRoutes {
+		alias.Target = API_V1 + alias.Target
+		replaced[alias.TargetMethod+" "+alias.Target] = true
+		l.aliases = append(l.aliases, alias)
+	}
+
+	for _, route := range l.g.Routes() {
+		for _, group := range legacyGroups {
+			prefix := API_V1 + group.target
+			if !strings.HasPrefix(route.Path, prefix+"/") || replaced[route.Method+" "+route.Path] {
+				continue
+			}
+			l.aliases = append(l.aliases, openapi.Alias{
+				Method:       route.Method,
+				Path:         group.path + strings.TrimPrefix(route.Path, prefix),
+				TargetMethod: route.Method,
+				Target:       route.Path,
+			})
+		}
+	}
+
+	legacy := l.g.Group("", middleware.Deprecated(LEGACY_DEPRECATED_AT, LEGACY_SUNSET_AT))
+	for _, alias := range l.aliases {
+		legacy.Handle(alias.Method, alias.Path, l.forward(alias))
+	}
+}
+
+func (l *legacyRouterImpl) Aliases() []openapi.Alias {
+	return l.aliases
+}
+
+// forward rewrite the request to the v1 route and handle it again, so the v1 middleware run too
+func (l *legacyRouterImpl) forward(alias openapi.Alias) gin.HandlerFunc {
+	segments := strings.Split(alias.Target, "/")
+	return func(ctx *gin.Context) {
+		path := make([]string, len(segments))
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segment = ctx.Param(segment[1:])
+			}
+			path[i] = segment
+		}
+		target := strings.Join(path, "/")
+
+		ctx.Header("Link", "<"+target+`>; rel="successor-version"`)
+		ctx.Request.Method = alias.TargetMethod
+		ctx.Request.URL.Path = target
+		ctx.Request.URL.RawPath = ""
+		l.g.HandleContext(ctx)
+	}
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/pkg/metrics"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type MetricsRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type metricsRouterImpl struct {
+	v *gin.RouterGroup
+}
+
+// NewMetricsRouter take the root group, it is only mounted when metrics has no admin port
+func NewMetricsRouter(v *gin.RouterGroup) MetricsRouter {
+	return &metricsRouterImpl{v: v}
+}
+
+func (m *metricsRouterImpl) Mount() {
+	m.v.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (m *metricsRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/metrics", Summary: "Prometheus metrics", Tag: "health", Produces: "text/plain"},
+	}
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type notificationRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.NotificationHandler
+}
+
+func NewNotificationRouter(v *gin.RouterGroup, handler handlers.NotificationHandler) NotificationRouter {
+	return &notificationRouterImpl{v: v, handler: handler}
+}
+
+func (n *notificationRouterImpl) Mount() {
+	n.v.Use(middleware.CheckAuthBearer)
+
+	// inbox of logged in user
+	n.v.GET("/", n.handler.GetNotifications)
+	n.v.GET("/unread-count", n.handler.GetUnreadCount)
+	n.v.GET("/stream", middleware.NoWriteTimeout, n.handler.Stream)
+	n.v.PUT("/read", n.handler.MarkRead)
+	n.v.PUT("/:id/read", n.handler.MarkNotificationRead)
+	n.v.PUT("/:id/unread", n.handler.MarkNotificationUnread)
+
+	n.v.GET("/preferences", n.handler.GetPreference)
+	n.v.PUT("/preferences", n.handler.SavePreference)
+
+	admin := n.v.Group("", middleware.CheckRoleAdmin)
+	admin.GET("/templates", n.handler.GetTemplates)
+	admin.PUT("/templates", n.handler.SaveTemplate)
+	admin.DELETE("/templates/:id", n.handler.DeleteTemplate)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (n *notificationRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/", Summary: "List notifications of logged in user, newest first", Auth: openapi.AuthBearer, Query: models.NotificationFilter{}, Response: models.NotificationsResponse{}},
+		{Method: http.MethodGet, Path: "/unread-count", Summary: "Count unread notifications", Auth: openapi.AuthBearer, Response: models.NotificationCountResponse{}},
+		{Method: http.MethodGet, Path: "/stream", Summary: "Stream new notifications as server-sent events", Auth: openapi.AuthBearer, Produces: "text/event-stream"},
+		{Method: http.MethodPut, Path: "/read", Summary: "Mark notifications read, every notification when ids is empty", Auth: openapi.AuthBearer, Body: models.NotificationReadRequest{}, OptionalBody: true, Response: models.NotificationCountResponse{}},
+		{Method: http.MethodPut, Path: "/:id/read", Summary: "Mark notification read", Auth: openapi.AuthBearer, Response: models.NotificationResponse{}},
+		{Method: http.MethodPut, Path: "/:id/unread", Summary: "Mark notification unread", Auth: openapi.AuthBearer, Response: models.NotificationResponse{}},
+
+		{Method: http.MethodGet, Path: "/preferences", Summary: "Get notification channel preference", Auth: openapi.AuthBearer, Response: models.NotificationPreferenceResponse{}},
+		{Method: http.MethodPut, Path: "/preferences", Summary: "Save notification channel preference", Auth: openapi.AuthBearer, Body: models.NotificationPreference{}, Response: models.NotificationPreferenceResponse{}},
+
+		{Method: http.MethodGet, Path: "/templates", Summary: "List notification templates of company", Auth: openapi.AuthAdmin, Query: models.NotificationTemplateFilter{}, Response: models.NotificationTemplatesResponse{}},
+		{Method: http.MethodPut, Path: "/templates", Summary: "Save notification template of company for type and locale", Auth: openapi.AuthAdmin, Body: models.NotificationTemplate{}, Response: models.NotificationTemplateResponse{}},
+		{Method: http.MethodDelete, Path: "/templates/:id", Summary: "Delete notification template", Auth: openapi.AuthAdmin, Response: models.NotificationTemplateResponse{}},
+	}
+}
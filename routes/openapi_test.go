@@ -0,0 +1,91 @@
+package routes_test
+
+import (
+	"testing"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/geedotrar/erp-api/routes"
+	"github.com/gin-gonic/gin"
+)
+
+// router is what every router of the package share
+type router interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+// TestOpenAPICoverRoutes mount every router like main does, handlers have no
+// service because no request is served, and check every route is documented
+func TestOpenAPICoverRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	g := gin.New()
+	spec := openapi.New("ERP API", "test")
+	spec.Format(models.Date{}, openapi.Schema{Type: "string", Format: "date"})
+	v1 := g.Group(routes.API_V1)
+
+	exportHdl := handlers.NewExportHandler(nil)
+	mounts := []struct {
+		group  *gin.RouterGroup
+		router func(v *gin.RouterGroup) router
+	}{
+		{g.Group(""), func(v *gin.RouterGroup) router { return routes.NewHealthRouter(v, handlers.NewHealthHandler(nil)) }},
+		{g.Group(""), func(v *gin.RouterGroup) router { return routes.NewMetricsRouter(v) }},
+		{v1.Group("/webhooks"), func(v *gin.RouterGroup) router { return routes.NewWebhookRouter(v, handlers.NewWebhookHandler(nil)) }},
+		{v1.Group("/notifications"), func(v *gin.RouterGroup) router {
+			return routes.NewNotificationRouter(v, handlers.NewNotificationHandler(nil))
+		}},
+		{v1.Group("/exports"), func(v *gin.RouterGroup) router { return routes.NewExportRouter(v, exportHdl) }},
+		{v1.Group("/users"), func(v *gin.RouterGroup) router {
+			return routes.NewUserRouter(v, handlers.NewUserHandler(nil), exportHdl)
+		}},
+		{v1.Group("/companies"), func(v *gin.RouterGroup) router {
+			return routes.NewCompanyRouter(v, handlers.NewCompanyHandler(nil), exportHdl)
+		}},
+		{v1.Group("/positions"), func(v *gin.RouterGroup) router {
+			return routes.NewPositionRouter(v, handlers.NewPositionHandler(nil), exportHdl)
+		}},
+		{v1.Group("/departments"), func(v *gin.RouterGroup) router {
+			return routes.NewDepartmentRouter(v, handlers.NewDepartmentHandler(nil))
+		}},
+		{v1.Group("/employees"), func(v *gin.RouterGroup) router { return routes.NewEmployeeRouter(v, handlers.NewEmployeeHandler(nil)) }},
+		{v1.Group("/checklists"), func(v *gin.RouterGroup) router {
+			return routes.NewChecklistRouter(v, handlers.NewChecklistHandler(nil))
+		}},
+		{v1.Group("/headcount"), func(v *gin.RouterGroup) router {
+			return routes.NewHeadcountRouter(v, handlers.NewHeadcountHandler(nil))
+		}},
+		{v1.Group("/assignments"), func(v *gin.RouterGroup) router {
+			return routes.NewAssignmentRouter(v, handlers.NewAssignmentHandler(nil))
+		}},
+		{v1.Group("/attendance"), func(v *gin.RouterGroup) router {
+			return routes.NewAttendanceRouter(v, handlers.NewAttendanceHandler(nil))
+		}},
+		{v1.Group("/leaves"), func(v *gin.RouterGroup) router { return routes.NewLeaveRouter(v, handlers.NewLeaveHandler(nil)) }},
+		{v1.Group("/recruitment"), func(v *gin.RouterGroup) router {
+			return routes.NewRecruitmentRouter(v, handlers.NewRecruitmentHandler(nil))
+		}},
+		{v1.Group("/payroll"), func(v *gin.RouterGroup) router { return routes.NewPayrollRouter(v, handlers.NewPayrollHandler(nil)) }},
+	}
+	for _, m := range mounts {
+		r := m.router(m.group)
+		r.Mount()
+		spec.Add(m.group.BasePath(), r.Docs()...)
+	}
+
+	legacyRouter := routes.NewLegacyRouter(g)
+	legacyRouter.Mount()
+	spec.AddAliases(legacyRouter.Aliases()...)
+
+	openapi.Mount(g, spec)
+	if len(g.Routes()) == 0 {
+		t.Fatal("no route is mounted")
+	}
+	if err := spec.Check(g.Routes()); err != nil {
+		t.Fatalf("openapi document is out of date: %v", err)
+	}
+	if len(spec.Document()) == 0 {
+		t.Fatal("openapi document is empty")
+	}
+}
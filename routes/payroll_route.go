@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type PayrollRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type payrollRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.PayrollHandler
+}
+
+func NewPayrollRouter(v *gin.RouterGroup, handler handlers.PayrollHandler) PayrollRouter {
+	return &payrollRouterImpl{v: v, handler: handler}
+}
+
+func (p *payrollRouterImpl) Mount() {
+	p.v.Use(middleware.CheckAuthBearer)
+
+	// owner or company admin, checked in service
+	p.v.GET("/payslips/me", p.handler.GetMyPayslips)
+	p.v.GET("/payslips/:id/pdf", p.handler.DownloadPayslip)
+
+	admin := p.v.Group("", middleware.CheckRoleAdmin)
+	admin.GET("/components", p.handler.GetSalaryComponents)
+	admin.POST("/components", p.handler.CreateSalaryComponent)
+	admin.PUT("/components/:id", p.handler.UpdateSalaryComponent)
+	admin.DELETE("/components/:id", p.handler.DeleteSalaryComponent)
+
+	admin.GET("/salaries/:employee_id", p.handler.GetEmployeeSalary)
+	admin.PUT("/salaries/:employee_id", p.handler.SaveEmployeeSalary)
+
+	admin.GET("/runs", p.handler.GetPayrollRuns)
+	admin.POST("/runs", p.handler.RunPayroll)
+	admin.GET("/runs/:id", p.handler.GetPayrollRunByID)
+	admin.POST("/runs/:id/approve", p.handler.ApprovePayrollRun)
+
+	admin.GET("/reports/compa-ratio", p.handler.GetCompaRatioReport)
+
+	admin.GET("/tax/reconciliation", p.handler.GetTaxReconciliation)
+	admin.GET("/tax/1721-a1/:employee_id", p.handler.GetForm1721A1)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (p *payrollRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/payslips/me", Summary: "List payslips of logged in user", Auth: openapi.AuthBearer, Response: models.PayslipsResponse{}},
+		{Method: http.MethodGet, Path: "/payslips/:id/pdf", Summary: "Download payslip as pdf", Auth: openapi.AuthBearer, Response: models.PayslipsResponse{}, Produces: "application/pdf"},
+
+		{Method: http.MethodGet, Path: "/components", Summary: "List salary components", Auth: openapi.AuthAdmin, Response: models.SalaryComponentsResponse{}},
+		{Method: http.MethodPost, Path: "/components", Summary: "Create salary component", Auth: openapi.AuthAdmin, Body: models.SalaryComponent{}, Response: models.SalaryComponentResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/components/:id", Summary: "Update salary component", Auth: openapi.AuthAdmin, Body: models.SalaryComponent{}, Response: models.SalaryComponentResponse{}},
+		{Method: http.MethodDelete, Path: "/components/:id", Summary: "Delete salary component", Auth: openapi.AuthAdmin, Response: models.SalaryComponentResponse{}},
+
+		{Method: http.MethodGet, Path: "/salaries/:employee_id", Summary: "Get salary of employee", Auth: openapi.AuthAdmin, Response: models.EmployeeSalaryResponse{}},
+		{Method: http.MethodPut, Path: "/salaries/:employee_id", Summary: "Save salary of employee", Auth: openapi.AuthAdmin, Body: models.EmployeeSalaryRequest{}, Response: models.EmployeeSalaryResponse{}},
+
+		{Method: http.MethodGet, Path: "/runs", Summary: "List payroll runs", Auth: openapi.AuthAdmin, Response: models.PayrollRunsResponse{}},
+		{Method: http.MethodPost, Path: "/runs", Summary: "Run payroll for a period", Auth: openapi.AuthAdmin, Body: models.PayrollRunRequest{}, Response: models.PayrollRunResponse{}},
+		{Method: http.MethodGet, Path: "/runs/:id", Summary: "Get payroll run with its payslips", Auth: openapi.AuthAdmin, Response: models.PayrollRunResponse{}},
+		{Method: http.MethodPost, Path: "/runs/:id/approve", Summary: "Approve payroll run", Auth: openapi.AuthAdmin, Response: models.PayrollRunResponse{}},
+
+		{Method: http.MethodGet, Path: "/reports/compa-ratio", Summary: "Compa-ratio report against salary bands", Auth: openapi.AuthAdmin, Params: []openapi.Param{openapi.QueryParam("year", "integer", "defaults to current year")}, Response: models.CompaRatioReportResponse{}},
+
+		{Method: http.MethodGet, Path: "/tax/reconciliation", Summary: "Yearly tax reconciliation per employee", Auth: openapi.AuthAdmin, Params: []openapi.Param{openapi.QueryParam("year", "integer", "defaults to current year")}, Response: models.TaxReconciliationsResponse{}},
+		{Method: http.MethodGet, Path: "/tax/1721-a1/:employee_id", Summary: "Form 1721-A1 of employee", Auth: openapi.AuthAdmin, Params: []openapi.Param{openapi.QueryParam("year", "integer", "defaults to current year")}, Response: models.Form1721A1Response{}},
+	}
+}
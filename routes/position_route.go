@@ -1,31 +1,69 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
 	"github.com/gin-gonic/gin"
 )
 
 type PositionRouter interface {
 	Mount()
+	Docs() []openapi.Operation
 }
 
 type positionRouterImpl struct {
-	v       *gin.RouterGroup
-	handler handlers.PositionHandler
+	v             *gin.RouterGroup
+	handler       handlers.PositionHandler
+	exportHandler handlers.ExportHandler
 }
 
-func NewPositionRouter(v *gin.RouterGroup, handler handlers.PositionHandler) PositionRouter {
-	return &positionRouterImpl{v: v, handler: handler}
+func NewPositionRouter(v *gin.RouterGroup, handler handlers.PositionHandler, exportHandler handlers.ExportHandler) PositionRouter {
+	return &positionRouterImpl{v: v, handler: handler, exportHandler: exportHandler}
 }
 
 func (p *positionRouterImpl) Mount() {
 	// u.v.Use(middleware.CheckAuthBearer)
 
 	p.v.GET("/", p.handler.GetPosition)
+	p.v.GET("/export", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, middleware.NoWriteTimeout, p.exportHandler.ExportPositions)
 	p.v.GET("/:id", p.handler.GetPositionByID)
+	p.v.GET("/code-rules/:company_id", p.handler.GetPositionCodeRule)
+
+	p.v.POST("/", p.handler.CreatePosition)
+	p.v.PUT("/:id", p.handler.UpdatePosition)
+	p.v.DELETE("/:id", p.handler.DeletePosition)
+
+	p.v.POST("/:id/adopt", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, p.handler.AdoptPositionTemplate)
+	p.v.PUT("/code-rules/:company_id", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, p.handler.SavePositionCodeRule)
+
+	// salary is confidential, band is only for logged in user and changed by admin
+	p.v.GET("/:id/bands", middleware.CheckAuthBearer, p.handler.GetSalaryBands)
+	p.v.PUT("/:id/bands", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, p.handler.SaveSalaryBand)
+	p.v.DELETE("/:id/bands/:currency", middleware.CheckAuthBearer, middleware.CheckRoleAdmin, p.handler.DeleteSalaryBand)
+
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (p *positionRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/", Summary: "List positions", Query: models.PositionFilter{}, Response: models.PositionsResponse{}},
+		{Method: http.MethodGet, Path: "/export", Summary: "Export positions, large export is answered with an export job", Auth: openapi.AuthAdmin, Query: []any{models.PositionFilter{}, models.ExportRequest{}}, Response: models.ExportJobResponse{}, Produces: "application/octet-stream"},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get position by id", Response: models.PositionResponse{}},
+		{Method: http.MethodGet, Path: "/code-rules/:company_id", Summary: "Get position code rule of company", Response: models.PositionCodeRuleResponse{}},
+
+		{Method: http.MethodPost, Path: "/", Summary: "Create position", Body: models.PositionCreateRequest{}, Response: models.PositionResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/:id", Summary: "Update position", Body: models.PositionUpdateRequest{}, Response: models.PositionResponse{}},
+		{Method: http.MethodDelete, Path: "/:id", Summary: "Delete position", Response: models.PositionResponse{}},
 
-	p.v.POST("/create", p.handler.CreatePosition)
-	p.v.PUT("/update/:id", p.handler.UpdatePosition)
-	p.v.DELETE("/delete/:id", p.handler.DeletePosition)
+		{Method: http.MethodPost, Path: "/:id/adopt", Summary: "Adopt group position template into a subsidiary", Auth: openapi.AuthAdmin, Body: models.PositionAdoptRequest{}, Response: models.PositionResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/code-rules/:company_id", Summary: "Save position code rule of company", Auth: openapi.AuthAdmin, Body: models.PositionCodeRule{}, Response: models.PositionCodeRuleResponse{}},
 
+		{Method: http.MethodGet, Path: "/:id/bands", Summary: "List salary bands of position", Auth: openapi.AuthBearer, Response: models.SalaryBandsResponse{}},
+		{Method: http.MethodPut, Path: "/:id/bands", Summary: "Save salary band of position for a currency", Auth: openapi.AuthAdmin, Body: models.SalaryBandRequest{}, Response: models.SalaryBandResponse{}},
+		{Method: http.MethodDelete, Path: "/:id/bands/:currency", Summary: "Delete salary band of position", Auth: openapi.AuthAdmin, Response: models.SalaryBandResponse{}},
+	}
 }
@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type RecruitmentRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type recruitmentRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.RecruitmentHandler
+}
+
+func NewRecruitmentRouter(v *gin.RouterGroup, handler handlers.RecruitmentHandler) RecruitmentRouter {
+	return &recruitmentRouterImpl{v: v, handler: handler}
+}
+
+func (r *recruitmentRouterImpl) Mount() {
+	r.v.Use(middleware.CheckAuthBearer)
+
+	// interviewer or company admin, checked in service
+	r.v.GET("/interviews/me", r.handler.GetMyInterviews)
+	r.v.PUT("/interviews/:id/feedback", r.handler.SaveInterviewFeedback)
+
+	admin := r.v.Group("", middleware.CheckRoleAdmin)
+	admin.GET("/stages", r.handler.GetStages)
+	admin.PUT("/stages", r.handler.SaveStages)
+
+	admin.GET("/requisitions", r.handler.GetRequisitions)
+	admin.POST("/requisitions", r.handler.CreateRequisition)
+	admin.GET("/requisitions/:id", r.handler.GetRequisitionByID)
+	admin.PUT("/requisitions/:id", r.handler.UpdateRequisition)
+	admin.GET("/requisitions/:id/candidates", r.handler.GetRequisitionCandidates)
+
+	admin.POST("/candidates", r.handler.CreateCandidate)
+	admin.GET("/candidates/:id", r.handler.GetCandidateByID)
+	admin.PUT("/candidates/:id/stage", r.handler.MoveCandidate)
+	admin.POST("/candidates/:id/reject", r.handler.RejectCandidate)
+	admin.POST("/candidates/:id/accept", r.handler.AcceptCandidate)
+	admin.POST("/candidates/:id/cv", r.handler.UploadCandidateCV)
+	admin.GET("/candidates/:id/cv", r.handler.DownloadCandidateCV)
+	admin.GET("/candidates/:id/interviews", r.handler.GetCandidateInterviews)
+	admin.POST("/candidates/:id/interviews", r.handler.ScheduleInterview)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (r *recruitmentRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/interviews/me", Summary: "List interviews assigned to logged in user", Auth: openapi.AuthBearer, Response: models.InterviewsResponse{}},
+		{Method: http.MethodPut, Path: "/interviews/:id/feedback", Summary: "Save interview feedback", Auth: openapi.AuthBearer, Body: models.InterviewFeedbackRequest{}, Response: models.InterviewResponse{}},
+
+		{Method: http.MethodGet, Path: "/stages", Summary: "List recruitment stages of company", Auth: openapi.AuthAdmin, Params: []openapi.Param{{Name: "company_id", Type: "integer", Required: true}}, Response: models.RecruitmentStagesResponse{}},
+		{Method: http.MethodPut, Path: "/stages", Summary: "Replace recruitment stages of company", Auth: openapi.AuthAdmin, Body: models.RecruitmentStagesRequest{}, Response: models.RecruitmentStagesResponse{}},
+
+		{Method: http.MethodGet, Path: "/requisitions", Summary: "List requisitions", Auth: openapi.AuthAdmin, Query: models.RequisitionFilter{}, Response: models.RequisitionsResponse{}},
+		{Method: http.MethodPost, Path: "/requisitions", Summary: "Create requisition", Auth: openapi.AuthAdmin, Body: models.RequisitionRequest{}, Response: models.RequisitionResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/requisitions/:id", Summary: "Get requisition by id", Auth: openapi.AuthAdmin, Response: models.RequisitionResponse{}},
+		{Method: http.MethodPut, Path: "/requisitions/:id", Summary: "Update requisition", Auth: openapi.AuthAdmin, Body: models.RequisitionUpdateRequest{}, Response: models.RequisitionResponse{}},
+		{Method: http.MethodGet, Path: "/requisitions/:id/candidates", Summary: "List candidates of requisition", Auth: openapi.AuthAdmin, Response: models.CandidatesResponse{}},
+
+		{Method: http.MethodPost, Path: "/candidates", Summary: "Create candidate", Auth: openapi.AuthAdmin, Body: models.CandidateRequest{}, Response: models.CandidateResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/candidates/:id", Summary: "Get candidate by id", Auth: openapi.AuthAdmin, Response: models.CandidateResponse{}},
+		{Method: http.MethodPut, Path: "/candidates/:id/stage", Summary: "Move candidate to another stage", Auth: openapi.AuthAdmin, Body: models.CandidateStageRequest{}, Response: models.CandidateResponse{}},
+		{Method: http.MethodPost, Path: "/candidates/:id/reject", Summary: "Reject candidate", Auth: openapi.AuthAdmin, Body: models.CandidateRejectRequest{}, Response: models.CandidateResponse{}},
+		{Method: http.MethodPost, Path: "/candidates/:id/accept", Summary: "Accept candidate and create the user", Auth: openapi.AuthAdmin, Body: models.CandidateAcceptRequest{}, Response: models.CandidateResponse{}},
+		{Method: http.MethodPost, Path: "/candidates/:id/cv", Summary: "Upload cv of candidate", Auth: openapi.AuthAdmin, Upload: "cv", Response: models.CandidateResponse{}},
+		{Method: http.MethodGet, Path: "/candidates/:id/cv", Summary: "Download cv of candidate", Auth: openapi.AuthAdmin, Response: models.CandidateResponse{}, Produces: "application/octet-stream"},
+		{Method: http.MethodGet, Path: "/candidates/:id/interviews", Summary: "List interviews of candidate", Auth: openapi.AuthAdmin, Response: models.InterviewsResponse{}},
+		{Method: http.MethodPost, Path: "/candidates/:id/interviews", Summary: "Schedule interview for candidate", Auth: openapi.AuthAdmin, Body: models.InterviewRequest{}, Response: models.InterviewResponse{}, Status: http.StatusCreated},
+	}
+}
@@ -1,22 +1,28 @@
 package routes
 
 import (
+	"net/http"
+
 	"github.com/geedotrar/erp-api/handlers"
 	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
 	"github.com/gin-gonic/gin"
 )
 
 type UserRouter interface {
 	Mount()
+	Docs() []openapi.Operation
 }
 
 type userRouterImpl struct {
-	v       *gin.RouterGroup
-	handler handlers.UserHandler
+	v             *gin.RouterGroup
+	handler       handlers.UserHandler
+	exportHandler handlers.ExportHandler
 }
 
-func NewUserRouter(v *gin.RouterGroup, handler handlers.UserHandler) UserRouter {
-	return &userRouterImpl{v: v, handler: handler}
+func NewUserRouter(v *gin.RouterGroup, handler handlers.UserHandler, exportHandler handlers.ExportHandler) UserRouter {
+	return &userRouterImpl{v: v, handler: handler, exportHandler: exportHandler}
 }
 
 func (u *userRouterImpl) Mount() {
@@ -26,10 +32,33 @@ func (u *userRouterImpl) Mount() {
 	u.v.Use(middleware.CheckAuthBearer)
 
 	u.v.GET("/", u.handler.GetUsers)
+	u.v.GET("/export", middleware.CheckRoleAdmin, middleware.NoWriteTimeout, u.exportHandler.ExportUsers)
 	u.v.GET("/:id", u.handler.GetUserByID)
+	u.v.GET("/:id/reports", u.handler.GetReports)
+
+	u.v.POST("/", middleware.CheckRoleAdmin, u.handler.CreateUser)
+	u.v.PUT("/:id", middleware.CheckRoleAdmin, u.handler.UpdateUser)
+	u.v.DELETE("/:id", middleware.CheckRoleAdmin, u.handler.DeleteUser)
+
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (u *userRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodPost, Path: "/register", Summary: "Register a new user", Body: models.UserSignUp{}, Response: map[string]models.UserView{}, Status: http.StatusCreated},
+		{Method: http.MethodPost, Path: "/login", Summary: "Login and get bearer token", Body: models.UserLogin{}, Response: struct {
+			Success bool   `json:"success"`
+			Message string `json:"message"`
+			Token   string `json:"token"`
+		}{}},
 
-	u.v.POST("/", u.handler.CreateUser)
-	u.v.PUT("/:id", u.handler.UpdateUser)
-	u.v.DELETE("/:id", u.handler.DeleteUser)
+		{Method: http.MethodGet, Path: "/", Summary: "List users", Auth: openapi.AuthBearer, Query: models.UserFilter{}, Response: models.UsersResponse{}},
+		{Method: http.MethodGet, Path: "/export", Summary: "Export users, large export is answered with an export job", Auth: openapi.AuthAdmin, Query: []any{models.UserFilter{}, models.ExportRequest{}}, Response: models.ExportJobResponse{}, Produces: "application/octet-stream"},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get user by id", Auth: openapi.AuthBearer, Response: models.UserResponse{}},
+		{Method: http.MethodGet, Path: "/:id/reports", Summary: "List direct and indirect reports of user", Auth: openapi.AuthBearer, Params: []openapi.Param{openapi.QueryParam("direct", "boolean", "only direct reports")}, Response: models.UserReportsResponse{}},
 
+		{Method: http.MethodPost, Path: "/", Summary: "Create user", Auth: openapi.AuthAdmin, Body: models.UserCreateRequest{}, Response: models.UserResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/:id", Summary: "Update user", Auth: openapi.AuthAdmin, Body: models.UserEditRequest{}, Response: models.UserResponse{}},
+		{Method: http.MethodDelete, Path: "/:id", Summary: "Delete user, answered 202 with offboarding checklist when it has to be completed first", Auth: openapi.AuthAdmin, Response: models.UserResponse{}},
+	}
 }
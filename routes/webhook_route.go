@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/geedotrar/erp-api/handlers"
+	"github.com/geedotrar/erp-api/middleware"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookRouter interface {
+	Mount()
+	Docs() []openapi.Operation
+}
+
+type webhookRouterImpl struct {
+	v       *gin.RouterGroup
+	handler handlers.WebhookHandler
+}
+
+func NewWebhookRouter(v *gin.RouterGroup, handler handlers.WebhookHandler) WebhookRouter {
+	return &webhookRouterImpl{v: v, handler: handler}
+}
+
+func (w *webhookRouterImpl) Mount() {
+	w.v.Use(middleware.CheckAuthBearer, middleware.CheckRoleAdmin)
+
+	w.v.GET("/events", w.handler.GetEvents)
+
+	w.v.GET("/", w.handler.GetWebhooks)
+	w.v.POST("/", w.handler.CreateWebhook)
+	w.v.GET("/:id", w.handler.GetWebhookByID)
+	w.v.PUT("/:id", w.handler.UpdateWebhook)
+	w.v.DELETE("/:id", w.handler.DeleteWebhook)
+	w.v.POST("/:id/rotate-secret", w.handler.RotateWebhookSecret)
+
+	// delivery log, failed delivery can be sent again
+	w.v.GET("/:id/deliveries", w.handler.GetDeliveries)
+	w.v.GET("/:id/deliveries/:delivery_id", w.handler.GetDeliveryByID)
+	w.v.POST("/:id/deliveries/:delivery_id/redeliver", w.handler.Redeliver)
+}
+
+// Docs describe the routes registered by Mount for the openapi document
+func (w *webhookRouterImpl) Docs() []openapi.Operation {
+	return []openapi.Operation{
+		{Method: http.MethodGet, Path: "/events", Summary: "List events a webhook can subscribe to", Auth: openapi.AuthAdmin, Response: models.WebhookEventsResponse{}},
+
+		{Method: http.MethodGet, Path: "/", Summary: "List webhooks", Auth: openapi.AuthAdmin, Query: models.WebhookFilter{}, Response: models.WebhooksResponse{}},
+		{Method: http.MethodPost, Path: "/", Summary: "Create webhook, secret is only shown in this response", Auth: openapi.AuthAdmin, Body: models.WebhookRequest{}, Response: models.WebhookResponse{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get webhook by id", Auth: openapi.AuthAdmin, Response: models.WebhookResponse{}},
+		{Method: http.MethodPut, Path: "/:id", Summary: "Update webhook", Auth: openapi.AuthAdmin, Body: models.WebhookRequest{}, Response: models.WebhookResponse{}},
+		{Method: http.MethodDelete, Path: "/:id", Summary: "Delete webhook", Auth: openapi.AuthAdmin, Response: models.WebhookResponse{}},
+		{Method: http.MethodPost, Path: "/:id/rotate-secret", Summary: "Rotate signing secret, new secret is only shown in this response", Auth: openapi.AuthAdmin, Response: models.WebhookResponse{}},
+
+		{Method: http.MethodGet, Path: "/:id/deliveries", Summary: "List deliveries of webhook", Auth: openapi.AuthAdmin, Query: models.WebhookDeliveryFilter{}, Response: models.WebhookDeliveriesResponse{}},
+		{Method: http.MethodGet, Path: "/:id/deliveries/:delivery_id", Summary: "Get delivery of webhook", Auth: openapi.AuthAdmin, Response: models.WebhookDeliveryResponse{}},
+		{Method: http.MethodPost, Path: "/:id/deliveries/:delivery_id/redeliver", Summary: "Queue delivery again with the same payload", Auth: openapi.AuthAdmin, Response: models.WebhookDeliveryResponse{}, Status: http.StatusAccepted},
+	}
+}
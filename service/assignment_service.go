@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type AssignmentService interface {
+	// history of user, or only assignment valid on as_of date
+	GetAssignmentsByUserID(ctx context.Context, userID uint64) ([]models.Assignment, error)
+	GetAssignmentAsOf(ctx context.Context, userID uint64, asOf models.Date) (models.Assignment, error)
+	GetAssignments(ctx context.Context, filter models.AssignmentFilter, asOf models.Date) ([]models.Assignment, error)
+
+	// admin need access to the current and the new company of user
+	TransferUser(ctx context.Context, userID uint64, transfer models.AssignmentTransferRequest, adminID uint64) (models.Assignment, error)
+	PromoteUser(ctx context.Context, userID uint64, promotion models.AssignmentPromotionRequest, adminID uint64) (models.Assignment, error)
+
+	// apply future dated assignment when the date arrives, stop when ctx done
+	RunWorker(ctx context.Context)
+}
+
+type assignmentServiceImpl struct {
+	repo          repository.AssignmentQuery
+	scope         CompanyScope
+	headcountRepo repository.HeadcountQuery
+	checklistRepo repository.ChecklistQuery
+	outbox        repository.OutboxQuery
+}
+
+func NewAssignmentService(repo repository.AssignmentQuery, scope CompanyScope, headcountRepo repository.HeadcountQuery, checklistRepo repository.ChecklistQuery, outbox repository.OutboxQuery) AssignmentService {
+	return &assignmentServiceImpl{repo: repo, scope: scope, headcountRepo: headcountRepo, checklistRepo: checklistRepo, outbox: outbox}
+}
+
+func (a *assignmentServiceImpl) GetAssignmentsByUserID(ctx context.Context, userID uint64) ([]models.Assignment, error) {
+	assignments, err := a.repo.GetAssignmentsByUserID(ctx, userID)
+	if err != nil {
+		return []models.Assignment{}, err
+	}
+	return assignments, nil
+}
+
+func (a *assignmentServiceImpl) GetAssignmentAsOf(ctx context.Context, userID uint64, asOf models.Date) (models.Assignment, error) {
+	assignment, err := a.repo.GetAssignmentAsOf(ctx, userID, asOf)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	return assignment, nil
+}
+
+func (a *assignmentServiceImpl) GetAssignments(ctx context.Context, filter models.AssignmentFilter, asOf models.Date) ([]models.Assignment, error) {
+	assignments, err := a.repo.GetAssignments(ctx, filter, asOf)
+	if err != nil {
+		return []models.Assignment{}, err
+	}
+	return assignments, nil
+}
+
+func (a *assignmentServiceImpl) TransferUser(ctx context.Context, userID uint64, transfer models.AssignmentTransferRequest, adminID uint64) (models.Assignment, error) {
+	if _, err := a.getScopedUser(ctx, userID, adminID); err != nil {
+		return models.Assignment{}, err
+	}
+	company, err := a.repo.GetCompanyByID(ctx, transfer.CompanyID)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if company.ID == 0 {
+		return models.Assignment{}, errors.New("company not found")
+	}
+	if err := a.scope.Check(ctx, adminID, company.ID); err != nil {
+		return models.Assignment{}, err
+	}
+
+	assignment := models.Assignment{
+		UserID:       userID,
+		CompanyID:    transfer.CompanyID,
+		DepartmentID: transfer.DepartmentID,
+		PositionID:   transfer.PositionID,
+		ValidFrom:    transfer.EffectiveDate,
+		ChangeType:   models.ASSIGNMENT_TYPE_TRANSFER,
+		Reason:       transfer.Reason,
+	}
+	// assignment and checklist of the new company, starting on the effective date, are saved together
+	createdAssignment := models.Assignment{}
+	err = a.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		createdAssignment, err = a.createAssignment(ctx, assignment, adminID)
+		if err != nil {
+			return err
+		}
+		_, err = startChecklists(ctx, a.checklistRepo, userID, createdAssignment.CompanyID, createdAssignment.PositionID, models.CHECKLIST_TYPE_TRANSFER, createdAssignment.ValidFrom)
+		return err
+	})
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	return createdAssignment, nil
+}
+
+func (a *assignmentServiceImpl) PromoteUser(ctx context.Context, userID uint64, promotion models.AssignmentPromotionRequest, adminID uint64) (models.Assignment, error) {
+	// promotion stay in the company of the user
+	user, err := a.getScopedUser(ctx, userID, adminID)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+
+	assignment := models.Assignment{
+		UserID:       userID,
+		CompanyID:    user.CompanyID,
+		DepartmentID: promotion.DepartmentID,
+		PositionID:   promotion.PositionID,
+		ValidFrom:    promotion.EffectiveDate,
+		ChangeType:   models.ASSIGNMENT_TYPE_PROMOTION,
+		Reason:       promotion.Reason,
+	}
+	if assignment.DepartmentID == nil {
+		assignment.DepartmentID = user.DepartmentID
+	}
+	return a.createAssignment(ctx, assignment, adminID)
+}
+
+// getScopedUser load user and make sure admin can access its current company
+func (a *assignmentServiceImpl) getScopedUser(ctx context.Context, userID uint64, adminID uint64) (models.User, error) {
+	user, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.ID == 0 {
+		return models.User{}, errors.New("user not found")
+	}
+	if err := a.scope.Check(ctx, adminID, user.CompanyID); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// createAssignment validate new assignment, close the old one and open the new one.
+// assignment starting today or earlier is applied to users table directly
+func (a *assignmentServiceImpl) createAssignment(ctx context.Context, assignment models.Assignment, createdBy uint64) (models.Assignment, error) {
+	user, err := a.repo.GetUserByID(ctx, assignment.UserID)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if user.ID == 0 {
+		return models.Assignment{}, errors.New("user not found")
+	}
+
+	// transfer without position keep the current position
+	if assignment.PositionID == 0 {
+		assignment.PositionID = user.PositionID
+	}
+	position, err := a.repo.GetPositionByID(ctx, assignment.PositionID)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if position.ID == 0 {
+		return models.Assignment{}, errors.New("position not found")
+	}
+	// template position is shared, company position only inside its company
+	if position.CompanyID != nil && *position.CompanyID != assignment.CompanyID {
+		return models.Assignment{}, errors.New("position must belong to the same company")
+	}
+
+	if assignment.DepartmentID != nil {
+		department, err := a.repo.GetDepartmentByID(ctx, *assignment.DepartmentID)
+		if err != nil {
+			return models.Assignment{}, err
+		}
+		if department.ID == 0 {
+			return models.Assignment{}, errors.New("department not found")
+		}
+		if department.CompanyID != assignment.CompanyID {
+			return models.Assignment{}, errors.New("department must belong to the same company")
+		}
+	}
+
+	// effective date is a date in the company the user move to
+	company, err := a.repo.GetCompanyByID(ctx, assignment.CompanyID)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	if company.ID == 0 {
+		return models.Assignment{}, errors.New("company not found")
+	}
+	today := company.Today()
+	if assignment.ValidFrom.IsZero() {
+		assignment.ValidFrom = today
+	}
+
+	latest, err := a.repo.GetLatestAssignment(ctx, assignment.UserID)
+	if err != nil {
+		return models.Assignment{}, err
+	}
+
+	// history starts with the current data of user when there is no assignment yet,
+	// skipped when the user is created on the effective date itself
+	var opening *models.Assignment
+	openingFrom := models.NewDate(user.CreatedAt)
+	if latest.ID == 0 && openingFrom.Before(assignment.ValidFrom.Time) {
+		opening = &models.Assignment{
+			UserID:       user.ID,
+			CompanyID:    user.CompanyID,
+			DepartmentID: user.DepartmentID,
+			PositionID:   user.PositionID,
+			ValidFrom:    openingFrom,
+			ChangeType:   models.ASSIGNMENT_TYPE_HIRE,
+			Applied:      true,
+		}
+	} else if !latest.ValidFrom.Before(assignment.ValidFrom.Time) {
+		return models.Assignment{}, errors.New("effective date must be after the start of the latest assignment")
+	}
+
+	// moving inside the same seat does not take a new one
+	if !sameSeat(user, assignment) {
+		overPlan, err := checkHeadcount(ctx, a.headcountRepo, assignment.CompanyID, assignment.DepartmentID, assignment.PositionID, assignment.ValidFrom)
+		if err != nil {
+			return models.Assignment{}, err
+		}
+		assignment.OverPlan = overPlan
+	}
+
+	if createdBy != 0 {
+		assignment.CreatedBy = &createdBy
+	}
+	assignment.Applied = !assignment.ValidFrom.After(today.Time)
+
+	createdAssignment := models.Assignment{}
+	err = a.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		createdAssignment, err = a.repo.CreateAssignment(ctx, assignment, opening)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, a.outbox, models.EVENT_ASSIGNMENT_CREATED, &createdAssignment.CompanyID, createdAssignment)
+	})
+	if err != nil {
+		return models.Assignment{}, err
+	}
+	return createdAssignment, nil
+}
+
+func sameSeat(user models.User, assignment models.Assignment) bool {
+	if user.CompanyID != assignment.CompanyID || user.PositionID != assignment.PositionID {
+		return false
+	}
+	if user.DepartmentID == nil || assignment.DepartmentID == nil {
+		return user.DepartmentID == nil && assignment.DepartmentID == nil
+	}
+	return *user.DepartmentID == *assignment.DepartmentID
+}
+
+func (a *assignmentServiceImpl) RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		applied, err := a.applyDueAssignments(ctx)
+		if err != nil {
+			log.Println("cannot apply due assignments", err.Error())
+		}
+		if applied > 0 {
+			log.Println("applied due assignments", applied)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// furthest ahead timezone, no company has reached a later date
+var latestZone = time.FixedZone("UTC+14", 14*60*60)
+
+// applyDueAssignments apply assignment whose start date has arrived in its own company.
+// assignment of user after one that is not due yet wait, so they are applied in order
+func (a *assignmentServiceImpl) applyDueAssignments(ctx context.Context) (int, error) {
+	due, err := a.repo.GetDueAssignments(ctx, models.TodayIn(latestZone))
+	if err != nil {
+		return 0, err
+	}
+
+	todays := map[uint64]models.Date{}
+	waiting := map[uint64]bool{}
+	applied := 0
+	for _, assignment := range due {
+		if waiting[assignment.UserID] {
+			continue
+		}
+		today, ok := todays[assignment.CompanyID]
+		if !ok {
+			company, err := a.repo.GetCompanyByID(ctx, assignment.CompanyID)
+			if err != nil {
+				return applied, err
+			}
+			today = company.Today()
+			todays[assignment.CompanyID] = today
+		}
+		if assignment.ValidFrom.After(today.Time) {
+			waiting[assignment.UserID] = true
+			continue
+		}
+		if err := a.repo.ApplyAssignment(ctx, assignment); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+const (
+	// used when company has no attendance setting yet
+	defaultWorkStart = "08:00"
+	defaultWorkEnd   = "17:00"
+
+	// clock out is only matched with clock in of the last day
+	maxShiftDuration = 24 * time.Hour
+
+	earthRadiusMeters = 6371000.0
+)
+
+type AttendanceService interface {
+	ClockIn(ctx context.Context, userID uint64, clock models.ClockRequest) (models.Attendance, error)
+	ClockOut(ctx context.Context, userID uint64, clock models.ClockRequest) (models.Attendance, error)
+	GetMyAttendances(ctx context.Context, userID uint64, month string) ([]models.Attendance, error)
+
+	CreateCorrection(ctx context.Context, userID uint64, correction models.AttendanceCorrectionRequest) (models.AttendanceCorrection, error)
+
+	// admin only, scoped to the company of the admin
+	GetCorrections(ctx context.Context, adminID uint64, status string) ([]models.AttendanceCorrection, error)
+	ApproveCorrection(ctx context.Context, adminID uint64, id uint64, note string) (models.AttendanceCorrection, error)
+	RejectCorrection(ctx context.Context, adminID uint64, id uint64, note string) (models.AttendanceCorrection, error)
+	GetMonthlySummary(ctx context.Context, adminID uint64, filter models.AttendanceFilter) ([]models.AttendanceSummary, error)
+
+	GetAttendanceSetting(ctx context.Context, adminID uint64, companyID uint64) (models.AttendanceSetting, error)
+	SaveAttendanceSetting(ctx context.Context, adminID uint64, setting models.AttendanceSetting) (models.AttendanceSetting, error)
+	CreateGeofence(ctx context.Context, adminID uint64, geofence models.AttendanceGeofence) (models.AttendanceGeofence, error)
+	DeleteGeofence(ctx context.Context, adminID uint64, id uint64) error
+}
+
+type attendanceServiceImpl struct {
+	repo  repository.AttendanceQuery
+	scope CompanyScope
+}
+
+func NewAttendanceService(repo repository.AttendanceQuery, scope CompanyScope) AttendanceService {
+	return &attendanceServiceImpl{repo: repo, scope: scope}
+}
+
+func (a *attendanceServiceImpl) ClockIn(ctx context.Context, userID uint64, clock models.ClockRequest) (models.Attendance, error) {
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	setting, err := a.getSetting(ctx, user.CompanyID)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	if err := a.checkGeofence(setting, clock); err != nil {
+		return models.Attendance{}, err
+	}
+
+	now := time.Now().In(a.location(setting))
+	workDate := models.NewDate(now)
+	existing, err := a.repo.GetAttendanceByWorkDate(ctx, userID, workDate)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	if existing.ID != 0 {
+		return models.Attendance{}, errors.New("already clocked in on this work date")
+	}
+
+	attendance := models.Attendance{
+		UserID:        userID,
+		CompanyID:     user.CompanyID,
+		WorkDate:      workDate,
+		ClockInAt:     now,
+		ClockInSource: clockSource(clock),
+		ClockInLat:    clock.Latitude,
+		ClockInLng:    clock.Longitude,
+	}
+	a.evaluate(setting, &attendance)
+
+	createdAttendance, err := a.repo.CreateAttendance(ctx, attendance)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	return createdAttendance, nil
+}
+
+func (a *attendanceServiceImpl) ClockOut(ctx context.Context, userID uint64, clock models.ClockRequest) (models.Attendance, error) {
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	setting, err := a.getSetting(ctx, user.CompanyID)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	if err := a.checkGeofence(setting, clock); err != nil {
+		return models.Attendance{}, err
+	}
+
+	// overnight shift clock out on the next day, so look for the last open clock in
+	now := time.Now().In(a.location(setting))
+	attendance, err := a.repo.GetOpenAttendance(ctx, userID, now.Add(-maxShiftDuration))
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	if attendance.ID == 0 {
+		return models.Attendance{}, errors.New("not clocked in yet")
+	}
+
+	attendance.ClockOutAt = &now
+	attendance.ClockOutSource = clockSource(clock)
+	attendance.ClockOutLat = clock.Latitude
+	attendance.ClockOutLng = clock.Longitude
+	a.evaluate(setting, &attendance)
+
+	updatedAttendance, err := a.repo.UpdateAttendance(ctx, attendance)
+	if err != nil {
+		return models.Attendance{}, err
+	}
+	return withWorkedHours(updatedAttendance), nil
+}
+
+func (a *attendanceServiceImpl) GetMyAttendances(ctx context.Context, userID uint64, month string) ([]models.Attendance, error) {
+	from, to, err := models.ParseMonth(month)
+	if err != nil {
+		return []models.Attendance{}, err
+	}
+	attendances, err := a.repo.GetAttendances(ctx, userID, from, to)
+	if err != nil {
+		return []models.Attendance{}, err
+	}
+	for i := range attendances {
+		attendances[i] = withWorkedHours(attendances[i])
+	}
+	return attendances, nil
+}
+
+func (a *attendanceServiceImpl) CreateCorrection(ctx context.Context, userID uint64, correctionRequest models.AttendanceCorrectionRequest) (models.AttendanceCorrection, error) {
+	if err := correctionRequest.ValidateCorrection(); err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	user, err := a.getUser(ctx, userID)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	setting, err := a.getSetting(ctx, user.CompanyID)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	if correctionRequest.WorkDate.After(models.TodayIn(a.location(setting)).Time) {
+		return models.AttendanceCorrection{}, errors.New("work date cannot be in the future")
+	}
+
+	correction := models.AttendanceCorrection{
+		UserID:     userID,
+		CompanyID:  user.CompanyID,
+		WorkDate:   correctionRequest.WorkDate,
+		ClockInAt:  correctionRequest.ClockInAt,
+		ClockOutAt: correctionRequest.ClockOutAt,
+		Reason:     correctionRequest.Reason,
+		Status:     models.CORRECTION_STATUS_PENDING,
+	}
+	createdCorrection, err := a.repo.CreateCorrection(ctx, correction)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	return createdCorrection, nil
+}
+
+func (a *attendanceServiceImpl) GetCorrections(ctx context.Context, adminID uint64, status string) ([]models.AttendanceCorrection, error) {
+	admin, err := a.getUser(ctx, adminID)
+	if err != nil {
+		return []models.AttendanceCorrection{}, err
+	}
+	corrections, err := a.repo.GetCorrections(ctx, admin.CompanyID, status)
+	if err != nil {
+		return []models.AttendanceCorrection{}, err
+	}
+	return corrections, nil
+}
+
+// ApproveCorrection overwrite the attendance of the work date with the corrected time
+func (a *attendanceServiceImpl) ApproveCorrection(ctx context.Context, adminID uint64, id uint64, note string) (models.AttendanceCorrection, error) {
+	correction, err := a.getPendingCorrection(ctx, adminID, id)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	setting, err := a.getSetting(ctx, correction.CompanyID)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+
+	attendance, err := a.repo.GetAttendanceByWorkDate(ctx, correction.UserID, correction.WorkDate)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	if attendance.ID == 0 {
+		attendance = models.Attendance{
+			UserID:        correction.UserID,
+			CompanyID:     correction.CompanyID,
+			WorkDate:      correction.WorkDate,
+			ClockInSource: models.ATTENDANCE_SOURCE_WEB,
+		}
+	}
+	attendance.ClockInAt = correction.ClockInAt
+	attendance.ClockOutAt = correction.ClockOutAt
+	attendance.CorrectedByID = &adminID
+	a.evaluate(setting, &attendance)
+
+	now := time.Now()
+	correction.Status = models.CORRECTION_STATUS_APPROVED
+	correction.ReviewedBy = &adminID
+	correction.ReviewedAt = &now
+	correction.ReviewNote = note
+
+	approvedCorrection, err := a.repo.ApproveCorrection(ctx, correction, attendance)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	return approvedCorrection, nil
+}
+
+func (a *attendanceServiceImpl) RejectCorrection(ctx context.Context, adminID uint64, id uint64, note string) (models.AttendanceCorrection, error) {
+	correction, err := a.getPendingCorrection(ctx, adminID, id)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+
+	now := time.Now()
+	correction.Status = models.CORRECTION_STATUS_REJECTED
+	correction.ReviewedBy = &adminID
+	correction.ReviewedAt = &now
+	correction.ReviewNote = note
+
+	rejectedCorrection, err := a.repo.RejectCorrection(ctx, correction)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	return rejectedCorrection, nil
+}
+
+func (a *attendanceServiceImpl) GetMonthlySummary(ctx context.Context, adminID uint64, filter models.AttendanceFilter) ([]models.AttendanceSummary, error) {
+	from, to, err := models.ParseMonth(filter.Month)
+	if err != nil {
+		return []models.AttendanceSummary{}, err
+	}
+	if err := a.scope.Check(ctx, adminID, filter.CompanyID); err != nil {
+		return []models.AttendanceSummary{}, err
+	}
+
+	summaries, err := a.repo.GetMonthlySummary(ctx, filter.CompanyID, from, to)
+	if err != nil {
+		return []models.AttendanceSummary{}, err
+	}
+	for i := range summaries {
+		summaries[i].WorkedHours = minutesToHours(summaries[i].WorkedMinutes)
+	}
+	return summaries, nil
+}
+
+func (a *attendanceServiceImpl) GetAttendanceSetting(ctx context.Context, adminID uint64, companyID uint64) (models.AttendanceSetting, error) {
+	if err := a.scope.Check(ctx, adminID, companyID); err != nil {
+		return models.AttendanceSetting{}, err
+	}
+	return a.getSetting(ctx, companyID)
+}
+
+func (a *attendanceServiceImpl) SaveAttendanceSetting(ctx context.Context, adminID uint64, setting models.AttendanceSetting) (models.AttendanceSetting, error) {
+	if err := setting.ValidateSetting(); err != nil {
+		return models.AttendanceSetting{}, err
+	}
+	if err := a.scope.Check(ctx, adminID, setting.CompanyID); err != nil {
+		return models.AttendanceSetting{}, err
+	}
+
+	if _, err := a.repo.SaveAttendanceSetting(ctx, setting); err != nil {
+		return models.AttendanceSetting{}, err
+	}
+	return a.getSetting(ctx, setting.CompanyID)
+}
+
+func (a *attendanceServiceImpl) CreateGeofence(ctx context.Context, adminID uint64, geofence models.AttendanceGeofence) (models.AttendanceGeofence, error) {
+	if geofence.RadiusMeters <= 0 {
+		return models.AttendanceGeofence{}, errors.New("radius must be greater than 0")
+	}
+	if geofence.Latitude < -90 || geofence.Latitude > 90 || geofence.Longitude < -180 || geofence.Longitude > 180 {
+		return models.AttendanceGeofence{}, errors.New("invalid latitude or longitude")
+	}
+	if err := a.scope.Check(ctx, adminID, geofence.CompanyID); err != nil {
+		return models.AttendanceGeofence{}, err
+	}
+
+	createdGeofence, err := a.repo.CreateGeofence(ctx, geofence)
+	if err != nil {
+		return models.AttendanceGeofence{}, err
+	}
+	return createdGeofence, nil
+}
+
+func (a *attendanceServiceImpl) DeleteGeofence(ctx context.Context, adminID uint64, id uint64) error {
+	geofence, err := a.repo.GetGeofenceByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if geofence.ID == 0 {
+		return errors.New("geofence not found")
+	}
+	if err := a.scope.Check(ctx, adminID, geofence.CompanyID); err != nil {
+		return err
+	}
+	return a.repo.DeleteGeofence(ctx, id)
+}
+
+func (a *attendanceServiceImpl) getUser(ctx context.Context, userID uint64) (models.User, error) {
+	user, err := a.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.ID == 0 {
+		return models.User{}, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (a *attendanceServiceImpl) getPendingCorrection(ctx context.Context, adminID uint64, id uint64) (models.AttendanceCorrection, error) {
+	correction, err := a.repo.GetCorrectionByID(ctx, id)
+	if err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	if correction.ID == 0 {
+		return models.AttendanceCorrection{}, errors.New("correction not found")
+	}
+	if err := a.scope.Check(ctx, adminID, correction.CompanyID); err != nil {
+		return models.AttendanceCorrection{}, err
+	}
+	if correction.Status != models.CORRECTION_STATUS_PENDING {
+		return models.AttendanceCorrection{}, errors.New("correction is already reviewed")
+	}
+	return correction, nil
+}
+
+// getSetting return setting of company with its geofences, or the default work hour
+func (a *attendanceServiceImpl) getSetting(ctx context.Context, companyID uint64) (models.AttendanceSetting, error) {
+	setting, err := a.repo.GetAttendanceSetting(ctx, companyID)
+	if err != nil {
+		return models.AttendanceSetting{}, err
+	}
+	if setting.CompanyID == 0 {
+		setting = models.AttendanceSetting{
+			CompanyID: companyID,
+			WorkStart: defaultWorkStart,
+			WorkEnd:   defaultWorkEnd,
+		}
+	}
+	geofences, err := a.repo.GetGeofences(ctx, companyID)
+	if err != nil {
+		return models.AttendanceSetting{}, err
+	}
+	setting.Geofences = geofences
+
+	company, err := a.repo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return models.AttendanceSetting{}, err
+	}
+	setting.Timezone = company.Timezone
+	return setting, nil
+}
+
+// location of work hour is the timezone of company
+func (a *attendanceServiceImpl) location(setting models.AttendanceSetting) *time.Location {
+	return models.LoadLocation(setting.Timezone)
+}
+
+// checkGeofence reject clock outside every geofence of company.
+// company without geofence accept any location
+func (a *attendanceServiceImpl) checkGeofence(setting models.AttendanceSetting, clock models.ClockRequest) error {
+	if err := clock.ValidateClock(); err != nil {
+		return err
+	}
+	if !setting.GeofenceRequired || len(setting.Geofences) == 0 {
+		return nil
+	}
+	if clock.Latitude == nil {
+		return errors.New("location is required to clock in or out")
+	}
+	for _, geofence := range setting.Geofences {
+		if distanceMeters(*clock.Latitude, *clock.Longitude, geofence.Latitude, geofence.Longitude) <= geofence.RadiusMeters {
+			return nil
+		}
+	}
+	return errors.New("location is outside of the allowed area")
+}
+
+// evaluate set late, early leave and worked minutes against the shift of the work date.
+// work end before work start means the shift ends on the next day
+func (a *attendanceServiceImpl) evaluate(setting models.AttendanceSetting, attendance *models.Attendance) {
+	loc := a.location(setting)
+	workStart, _ := time.Parse(models.WORK_TIME_FORMAT, setting.WorkStart)
+	workEnd, _ := time.Parse(models.WORK_TIME_FORMAT, setting.WorkEnd)
+
+	date := attendance.WorkDate
+	shiftStart := time.Date(date.Year(), date.Month(), date.Day(), workStart.Hour(), workStart.Minute(), 0, 0, loc)
+	shiftEnd := time.Date(date.Year(), date.Month(), date.Day(), workEnd.Hour(), workEnd.Minute(), 0, 0, loc)
+	if !shiftEnd.After(shiftStart) {
+		shiftEnd = shiftEnd.AddDate(0, 0, 1)
+	}
+
+	lateGrace := time.Duration(setting.LateGraceMinutes) * time.Minute
+	attendance.IsLate = attendance.ClockInAt.After(shiftStart.Add(lateGrace))
+
+	attendance.IsEarlyLeave = false
+	attendance.WorkedMinutes = 0
+	if attendance.ClockOutAt != nil {
+		earlyGrace := time.Duration(setting.EarlyLeaveGraceMinutes) * time.Minute
+		attendance.IsEarlyLeave = attendance.ClockOutAt.Before(shiftEnd.Add(-earlyGrace))
+		attendance.WorkedMinutes = int(attendance.ClockOutAt.Sub(attendance.ClockInAt).Minutes())
+	}
+}
+
+func clockSource(clock models.ClockRequest) string {
+	if clock.Source == "" {
+		return models.ATTENDANCE_SOURCE_WEB
+	}
+	return clock.Source
+}
+
+func withWorkedHours(attendance models.Attendance) models.Attendance {
+	attendance.WorkedHours = minutesToHours(attendance.WorkedMinutes)
+	return attendance
+}
+
+func minutesToHours(minutes int) float64 {
+	return math.Round(float64(minutes)/60*100) / 100
+}
+
+// distanceMeters is haversine distance between two coordinates
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type ChecklistService interface {
+	GetTemplates(ctx context.Context, filter models.ChecklistTemplateFilter, adminID uint64) ([]models.ChecklistTemplate, error)
+	GetTemplateByID(ctx context.Context, id uint64, adminID uint64) (models.ChecklistTemplate, error)
+	CreateTemplate(ctx context.Context, request models.ChecklistTemplateRequest, adminID uint64) (models.ChecklistTemplate, error)
+	UpdateTemplate(ctx context.Context, id uint64, request models.ChecklistTemplateRequest, adminID uint64) (models.ChecklistTemplate, error)
+	DeleteTemplate(ctx context.Context, id uint64, adminID uint64) error
+
+	GetChecklistByID(ctx context.Context, id uint64, adminID uint64) (models.Checklist, error)
+	GetUserChecklists(ctx context.Context, userID uint64, adminID uint64) ([]models.Checklist, error)
+
+	GetMyTasks(ctx context.Context, userID uint64) ([]models.ChecklistTask, error)
+	// task is completed by its assignee, or admin of the checklist company
+	CompleteTask(ctx context.Context, id uint64, request models.ChecklistTaskCompleteRequest, userID uint64, isAdmin bool) (models.ChecklistTask, error)
+}
+
+type checklistServiceImpl struct {
+	repo   repository.ChecklistQuery
+	scope  CompanyScope
+	outbox repository.OutboxQuery
+}
+
+func NewChecklistService(repo repository.ChecklistQuery, scope CompanyScope, outbox repository.OutboxQuery) ChecklistService {
+	return &checklistServiceImpl{repo: repo, scope: scope, outbox: outbox}
+}
+
+func (c *checklistServiceImpl) GetTemplates(ctx context.Context, filter models.ChecklistTemplateFilter, adminID uint64) ([]models.ChecklistTemplate, error) {
+	if err := c.scope.Check(ctx, adminID, filter.CompanyID); err != nil {
+		return []models.ChecklistTemplate{}, err
+	}
+	return c.repo.GetTemplates(ctx, filter)
+}
+
+func (c *checklistServiceImpl) GetTemplateByID(ctx context.Context, id uint64, adminID uint64) (models.ChecklistTemplate, error) {
+	template, err := c.repo.GetTemplateByID(ctx, id)
+	if err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	if template.ID == 0 {
+		return models.ChecklistTemplate{}, errors.New("checklist template not found")
+	}
+	if err := c.scope.Check(ctx, adminID, template.CompanyID); err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	return template, nil
+}
+
+func (c *checklistServiceImpl) CreateTemplate(ctx context.Context, request models.ChecklistTemplateRequest, adminID uint64) (models.ChecklistTemplate, error) {
+	template, err := c.checklistTemplate(ctx, request, adminID)
+	if err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	return c.repo.CreateTemplate(ctx, template)
+}
+
+func (c *checklistServiceImpl) UpdateTemplate(ctx context.Context, id uint64, request models.ChecklistTemplateRequest, adminID uint64) (models.ChecklistTemplate, error) {
+	existing, err := c.GetTemplateByID(ctx, id, adminID)
+	if err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	// started checklist keep its copied task, only new checklist use the change
+	if request.CompanyID != existing.CompanyID {
+		return models.ChecklistTemplate{}, errors.New("company of checklist template cannot be changed")
+	}
+	template, err := c.checklistTemplate(ctx, request, adminID)
+	if err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	template.ID = existing.ID
+	return c.repo.UpdateTemplate(ctx, template)
+}
+
+// checklistTemplate validate request and build template with its tasks
+func (c *checklistServiceImpl) checklistTemplate(ctx context.Context, request models.ChecklistTemplateRequest, adminID uint64) (models.ChecklistTemplate, error) {
+	if err := request.ValidateTemplate(); err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	if err := c.scope.Check(ctx, adminID, request.CompanyID); err != nil {
+		return models.ChecklistTemplate{}, err
+	}
+	if request.PositionID != nil {
+		position, err := c.repo.GetPositionByID(ctx, *request.PositionID)
+		if err != nil {
+			return models.ChecklistTemplate{}, err
+		}
+		if position.ID == 0 {
+			return models.ChecklistTemplate{}, errors.New("position not found")
+		}
+		// template position is shared, company position only inside its company
+		if position.CompanyID != nil && *position.CompanyID != request.CompanyID {
+			return models.ChecklistTemplate{}, errors.New("position must belong to the same company")
+		}
+	}
+
+	template := models.ChecklistTemplate{
+		CompanyID:  request.CompanyID,
+		PositionID: request.PositionID,
+		Type:       request.Type,
+		Name:       request.Name,
+	}
+	for _, task := range request.Tasks {
+		if task.AssigneeID != nil {
+			if err := c.checkAssignee(ctx, *task.AssigneeID, request.CompanyID); err != nil {
+				return models.ChecklistTemplate{}, err
+			}
+		}
+		template.Tasks = append(template.Tasks, models.ChecklistTemplateTask{
+			Title:       strings.TrimSpace(task.Title),
+			Description: task.Description,
+			Team:        task.Team,
+			AssigneeID:  task.AssigneeID,
+			DueDays:     task.DueDays,
+		})
+	}
+	return template, nil
+}
+
+// checkAssignee make sure assignee is staff of the company group
+func (c *checklistServiceImpl) checkAssignee(ctx context.Context, assigneeID uint64, companyID uint64) error {
+	assignee, err := c.repo.GetUserByID(ctx, assigneeID)
+	if err != nil {
+		return err
+	}
+	if assignee.ID == 0 {
+		return errors.New("assignee not found")
+	}
+	inGroup, err := c.scope.InGroup(ctx, assignee.CompanyID, companyID)
+	if err != nil {
+		return err
+	}
+	if !inGroup {
+		return errors.New("assignee must belong to the same company")
+	}
+	return nil
+}
+
+func (c *checklistServiceImpl) DeleteTemplate(ctx context.Context, id uint64, adminID uint64) error {
+	if _, err := c.GetTemplateByID(ctx, id, adminID); err != nil {
+		return err
+	}
+	return c.repo.DeleteTemplate(ctx, id)
+}
+
+func (c *checklistServiceImpl) GetChecklistByID(ctx context.Context, id uint64, adminID uint64) (models.Checklist, error) {
+	checklist, err := c.repo.GetChecklistByID(ctx, id)
+	if err != nil {
+		return models.Checklist{}, err
+	}
+	if checklist.ID == 0 {
+		return models.Checklist{}, errors.New("checklist not found")
+	}
+	if err := c.scope.Check(ctx, adminID, checklist.CompanyID); err != nil {
+		return models.Checklist{}, err
+	}
+	return checklist, nil
+}
+
+func (c *checklistServiceImpl) GetUserChecklists(ctx context.Context, userID uint64, adminID uint64) ([]models.Checklist, error) {
+	checklists, err := c.repo.GetChecklistsByUserID(ctx, userID)
+	if err != nil {
+		return []models.Checklist{}, err
+	}
+	// checklist keep the company of the event, user may have moved since
+	scoped := []models.Checklist{}
+	for _, checklist := range checklists {
+		err := c.scope.Check(ctx, adminID, checklist.CompanyID)
+		if err != nil && !strings.Contains(err.Error(), "outside of your access") {
+			return []models.Checklist{}, err
+		}
+		if err == nil {
+			scoped = append(scoped, checklist)
+		}
+	}
+	return scoped, nil
+}
+
+func (c *checklistServiceImpl) GetMyTasks(ctx context.Context, userID uint64) ([]models.ChecklistTask, error) {
+	return c.repo.GetOpenTasksByAssignee(ctx, userID)
+}
+
+func (c *checklistServiceImpl) CompleteTask(ctx context.Context, id uint64, request models.ChecklistTaskCompleteRequest, userID uint64, isAdmin bool) (models.ChecklistTask, error) {
+	task, err := c.repo.GetTaskByID(ctx, id)
+	if err != nil {
+		return models.ChecklistTask{}, err
+	}
+	if task.ID == 0 {
+		return models.ChecklistTask{}, errors.New("checklist task not found")
+	}
+	// task without assignee is done by company admin
+	if task.AssigneeID == nil || *task.AssigneeID != userID {
+		if !isAdmin {
+			return models.ChecklistTask{}, errors.New("checklist task is outside of your access")
+		}
+		if _, err := c.GetChecklistByID(ctx, task.ChecklistID, userID); err != nil {
+			return models.ChecklistTask{}, err
+		}
+	}
+	if task.Completed {
+		return models.ChecklistTask{}, errors.New("checklist task is already completed")
+	}
+
+	// user may be disabled by the last offboarding task, keep its data for the event
+	checklist, err := c.repo.GetChecklistByID(ctx, task.ChecklistID)
+	if err != nil {
+		return models.ChecklistTask{}, err
+	}
+	offboardedUser := models.User{}
+	if checklist.Type == models.CHECKLIST_TYPE_OFFBOARDING {
+		if offboardedUser, err = c.repo.GetUserByID(ctx, checklist.UserID); err != nil {
+			return models.ChecklistTask{}, err
+		}
+	}
+
+	completedTask := models.ChecklistTask{}
+	err = c.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		var userDeleted bool
+		completedTask, userDeleted, err = c.repo.CompleteTask(ctx, id, userID, request.Note)
+		if err != nil || !userDeleted {
+			return err
+		}
+		return publishEvent(ctx, c.outbox, models.EVENT_USER_DELETED, &offboardedUser.CompanyID, offboardedUser)
+	})
+	if err != nil {
+		return models.ChecklistTask{}, err
+	}
+	return completedTask, nil
+}
+
+// startChecklists copy every template of company matching position and type into
+// a new checklist of user, due date of task is counted from start date.
+// return no checklist when company has no matching template
+func startChecklists(ctx context.Context, repo repository.ChecklistQuery, userID uint64, companyID uint64, positionID uint64, checklistType string, start models.Date) ([]models.Checklist, error) {
+	templates, err := repo.GetMatchingTemplates(ctx, companyID, positionID, checklistType)
+	if err != nil {
+		return []models.Checklist{}, err
+	}
+
+	checklists := []models.Checklist{}
+	for _, template := range templates {
+		if len(template.Tasks) == 0 {
+			continue
+		}
+		templateID := template.ID
+		checklist := models.Checklist{
+			UserID:     userID,
+			CompanyID:  companyID,
+			TemplateID: &templateID,
+			Type:       checklistType,
+			Name:       template.Name,
+			Status:     models.CHECKLIST_STATUS_OPEN,
+			StartDate:  start,
+		}
+		for _, task := range template.Tasks {
+			checklist.Tasks = append(checklist.Tasks, models.ChecklistTask{
+				Title:       task.Title,
+				Description: task.Description,
+				Team:        task.Team,
+				AssigneeID:  task.AssigneeID,
+				DueDate:     models.NewDate(start.AddDate(0, 0, task.DueDays)),
+				SortOrder:   task.SortOrder,
+			})
+		}
+		createdChecklist, err := repo.CreateChecklist(ctx, checklist)
+		if err != nil {
+			return []models.Checklist{}, err
+		}
+		checklists = append(checklists, createdChecklist)
+	}
+	return checklists, nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/geedotrar/erp-api/repository"
+)
+
+// CompanyScope decide which company an admin can manage, admin of parent company
+// also manage every subsidiary. every service check company access through it
+type CompanyScope interface {
+	// Check return error when company is outside of the access of admin
+	Check(ctx context.Context, adminID uint64, companyID uint64) error
+	// InGroup report whether company is group or one of subsidiaries of group
+	InGroup(ctx context.Context, groupID uint64, companyID uint64) (bool, error)
+}
+
+type companyScopeImpl struct {
+	users     repository.UserQuery
+	companies repository.CompanyQuery
+}
+
+func NewCompanyScope(users repository.UserQuery, companies repository.CompanyQuery) CompanyScope {
+	return &companyScopeImpl{users: users, companies: companies}
+}
+
+func (c *companyScopeImpl) Check(ctx context.Context, adminID uint64, companyID uint64) error {
+	if companyID == 0 {
+		return errors.New("company id cannot be empty")
+	}
+	admin, err := c.users.GetUserByID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if admin.ID == 0 {
+		return errors.New("user not found")
+	}
+	inGroup, err := c.InGroup(ctx, admin.CompanyID, companyID)
+	if err != nil {
+		return err
+	}
+	if !inGroup {
+		return errors.New("company is outside of your access")
+	}
+	return nil
+}
+
+func (c *companyScopeImpl) InGroup(ctx context.Context, groupID uint64, companyID uint64) (bool, error) {
+	return c.companies.IsCompanyInGroup(ctx, groupID, companyID)
+}
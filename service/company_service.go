@@ -3,45 +3,69 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/geedotrar/erp-api/models"
 	"github.com/geedotrar/erp-api/repository"
 )
 
 type CompanyService interface {
-	GetCompany(ctx context.Context) ([]models.Company, error)
+	GetCompany(ctx context.Context, filter models.CompanyFilter) ([]models.Company, error)
 	GetCompanyByID(ctx context.Context, id uint64) (models.Company, error)
 
 	CreateCompany(ctx context.Context, createCompany models.CompanyRequest) (models.CompanyResponse, error)
-	UpdateCompany(ctx context.Context, id uint64, updateCompany models.CompanyRequest) (models.CompanyResponse, error)
+	UpdateCompany(ctx context.Context, id uint64, updateCompany models.CompanyRequest, adminID uint64) (models.CompanyResponse, error)
 
 	DeleteCompany(ctx context.Context, id uint64) (models.Company, error)
 
 	IsCompanySoftDeleted(ctx context.Context, companyName string) (bool, error)
 	RestoreCompany(ctx context.Context, id uint64) error
+
+	// org chart is visible to user of the company or of its parent companies
+	GetOrgChart(ctx context.Context, id uint64, userID uint64) ([]models.OrgChartNode, error)
+
+	// SetParentCompany need scope over the company, the new parent and, when detaching, the current parent
+	SetParentCompany(ctx context.Context, id uint64, parentRequest models.CompanyParentRequest, adminID uint64) (models.Company, error)
+	// admin of group see every subsidiary below the company of admin
+	GetCompanySubtree(ctx context.Context, adminID uint64, id uint64) (models.CompanyTreeNode, error)
+	GetCompanyRollup(ctx context.Context, adminID uint64, id uint64, period string) (models.CompanyRollup, error)
 }
 
 type companyServiceImpl struct {
-	repo repository.CompanyQuery
+	repo   repository.CompanyQuery
+	scope  CompanyScope
+	outbox repository.OutboxQuery
 }
 
-func NewCompanyService(repo repository.CompanyQuery) CompanyService {
-	return &companyServiceImpl{repo: repo}
+func NewCompanyService(repo repository.CompanyQuery, scope CompanyScope, outbox repository.OutboxQuery) CompanyService {
+	return &companyServiceImpl{repo: repo, scope: scope, outbox: outbox}
 }
 
-func (c *companyServiceImpl) GetCompany(ctx context.Context) ([]models.Company, error) {
-	company, err := c.repo.GetCompany(ctx)
+func (c *companyServiceImpl) GetCompany(ctx context.Context, filter models.CompanyFilter) ([]models.Company, error) {
+	company, err := c.repo.GetCompany(ctx, filter)
 	if err != nil {
 		return []models.Company{}, err
 	}
 	return company, nil
 }
 
+// GetCompanyByID return company with addresses and contacts
 func (c *companyServiceImpl) GetCompanyByID(ctx context.Context, id uint64) (models.Company, error) {
 	company, err := c.repo.GetCompanyByID(ctx, id)
 	if err != nil {
 		return models.Company{}, err
 	}
+	if company.ID == 0 {
+		return company, nil
+	}
+	company.Addresses, err = c.repo.GetCompanyAddresses(ctx, id)
+	if err != nil {
+		return models.Company{}, err
+	}
+	company.Contacts, err = c.repo.GetCompanyContacts(ctx, id)
+	if err != nil {
+		return models.Company{}, err
+	}
 	return company, nil
 }
 
@@ -55,27 +79,55 @@ func (c *companyServiceImpl) CreateCompany(ctx context.Context, createCompany mo
 		return models.CompanyResponse{}, errors.New("company already exists")
 	}
 
-	// create req
-	company := models.CompanyRequest{
-		CompanyName: createCompany.CompanyName,
+	if err := createCompany.ValidateCompanyProfile(); err != nil {
+		return models.CompanyResponse{}, err
+	}
+
+	// create req, profile not sent use the default of Indonesia company
+	company := companyProfile(createCompany)
+	company.Currency = models.NormalizeCurrency(company.Currency)
+	if company.Timezone == "" {
+		company.Timezone = models.DEFAULT_COMPANY_TIMEZONE
+	}
+	if company.Locale == "" {
+		company.Locale = models.DEFAULT_COMPANY_LOCALE
+	}
+	if company.FiscalYearStartMonth == 0 {
+		company.FiscalYearStartMonth = 1
 	}
+	if company.HeadcountPolicy == "" {
+		company.HeadcountPolicy = models.HEADCOUNT_POLICY_FLAG
+	}
+
+	// Store company to database, together with its event
+	savedCompany := models.Company{}
+	err = c.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		createdCompany, err := c.repo.CreateCompany(ctx, company)
+		if err != nil {
+			return err
+		}
+		if err := c.saveContactDetails(ctx, createdCompany.ID, createCompany); err != nil {
+			return err
+		}
 
-	// Store company to database
-	createdCompany, err := c.repo.CreateCompany(ctx, company)
+		// response
+		savedCompany, err = c.GetCompanyByID(ctx, createdCompany.ID)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, c.outbox, models.EVENT_COMPANY_CREATED, &savedCompany.ID, savedCompany)
+	})
 	if err != nil {
 		return models.CompanyResponse{}, err
 	}
-
-	// response
-	response := models.CompanyResponse{
-		Data: &models.Company{
-			ID:          createdCompany.ID,
-			CompanyName: createdCompany.CompanyName,
-		}}
-	return response, nil
+	return models.CompanyResponse{Data: &savedCompany}, nil
 }
 
-func (c *companyServiceImpl) UpdateCompany(ctx context.Context, id uint64, updateCompany models.CompanyRequest) (models.CompanyResponse, error) {
+func (c *companyServiceImpl) UpdateCompany(ctx context.Context, id uint64, updateCompany models.CompanyRequest, adminID uint64) (models.CompanyResponse, error) {
+	// currency, timezone and headcount policy drive payroll and hiring of company
+	if err := c.scope.Check(ctx, adminID, id); err != nil {
+		return models.CompanyResponse{}, err
+	}
 	existingCompany, err := c.repo.GetCompanyByID(ctx, id)
 	if err != nil {
 		return models.CompanyResponse{}, err
@@ -92,24 +144,70 @@ func (c *companyServiceImpl) UpdateCompany(ctx context.Context, id uint64, updat
 		}
 	}
 
-	// update req
-	company := models.CompanyRequest{
-		CompanyName: updateCompany.CompanyName,
+	if err := updateCompany.ValidateCompanyProfile(); err != nil {
+		return models.CompanyResponse{}, err
+	}
+
+	// update req, empty field is not updated
+	company := companyProfile(updateCompany)
+	if company.Currency != "" {
+		company.Currency = models.NormalizeCurrency(company.Currency)
 	}
 
-	// Store company to database
-	updatedCompany, err := c.repo.UpdateCompany(ctx, id, models.CompanyRequest(company))
+	// Store company to database, together with its event
+	savedCompany := models.Company{}
+	err = c.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := c.repo.UpdateCompany(ctx, id, company); err != nil {
+			return err
+		}
+		if err := c.saveContactDetails(ctx, id, updateCompany); err != nil {
+			return err
+		}
+
+		// response
+		savedCompany, err = c.GetCompanyByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, c.outbox, models.EVENT_COMPANY_UPDATED, &savedCompany.ID, savedCompany)
+	})
 	if err != nil {
 		return models.CompanyResponse{}, err
 	}
+	return models.CompanyResponse{Data: &savedCompany}, nil
+}
 
-	// response
-	response := models.CompanyResponse{
-		Data: &models.Company{
-			ID:          updatedCompany.ID,
-			CompanyName: updatedCompany.CompanyName,
-		}}
-	return response, nil
+// companyProfile copy the columns of companies table from request
+func companyProfile(request models.CompanyRequest) models.CompanyRequest {
+	return models.CompanyRequest{
+		CompanyName:          request.CompanyName,
+		LegalName:            request.LegalName,
+		TaxNumber:            models.NormalizeTaxNumber(request.TaxNumber),
+		RegistrationNumber:   request.RegistrationNumber,
+		Currency:             request.Currency,
+		Timezone:             request.Timezone,
+		Locale:               request.Locale,
+		FiscalYearStartMonth: request.FiscalYearStartMonth,
+		HeadcountPolicy:      request.HeadcountPolicy,
+	}
+}
+
+// saveContactDetails replace addresses and contacts that are sent in request
+func (c *companyServiceImpl) saveContactDetails(ctx context.Context, companyID uint64, request models.CompanyRequest) error {
+	if request.Addresses != nil {
+		if err := c.repo.SaveCompanyAddresses(ctx, companyID, *request.Addresses); err != nil {
+			return err
+		}
+	}
+	if request.Contacts != nil {
+		for i := range *request.Contacts {
+			(*request.Contacts)[i].Email = strings.ToLower(strings.TrimSpace((*request.Contacts)[i].Email))
+		}
+		if err := c.repo.SaveCompanyContacts(ctx, companyID, *request.Contacts); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c *companyServiceImpl) DeleteCompany(ctx context.Context, id uint64) (models.Company, error) {
@@ -121,6 +219,13 @@ func (c *companyServiceImpl) DeleteCompany(ctx context.Context, id uint64) (mode
 	if len(users) > 0 {
 		return models.Company{}, errors.New("company is still in use by user")
 	}
+	usage, err := c.repo.GetCompanyUsage(ctx, id)
+	if err != nil {
+		return models.Company{}, err
+	}
+	if usage != "" {
+		return models.Company{}, errors.New("company is still in use by " + usage)
+	}
 	company, err := c.repo.GetCompanyByID(ctx, id)
 	if err != nil {
 		return models.Company{}, err
@@ -130,7 +235,12 @@ func (c *companyServiceImpl) DeleteCompany(ctx context.Context, id uint64) (mode
 		return models.Company{}, err
 	}
 
-	err = c.repo.DeleteCompany(ctx, id)
+	err = c.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := c.repo.DeleteCompany(ctx, id); err != nil {
+			return err
+		}
+		return publishEvent(ctx, c.outbox, models.EVENT_COMPANY_DELETED, &company.ID, company)
+	})
 	if err != nil {
 		return models.Company{}, err
 	}
@@ -158,3 +268,156 @@ func (c *companyServiceImpl) RestoreCompany(ctx context.Context, id uint64) erro
 	}
 	return nil
 }
+
+func (c *companyServiceImpl) GetOrgChart(ctx context.Context, id uint64, userID uint64) ([]models.OrgChartNode, error) {
+	if err := c.scope.Check(ctx, userID, id); err != nil {
+		return []models.OrgChartNode{}, err
+	}
+	rows, err := c.repo.GetOrgChart(ctx, id)
+	if err != nil {
+		return []models.OrgChartNode{}, err
+	}
+
+	// rows are ordered by depth, so manager always comes before their reports
+	nodes := make(map[uint64]*models.OrgChartNode, len(rows))
+	roots := []*models.OrgChartNode{}
+	for _, row := range rows {
+		node := &models.OrgChartNode{
+			UserID:       row.UserID,
+			FirstName:    row.FirstName,
+			LastName:     row.LastName,
+			ManagerID:    row.ManagerID,
+			PositionID:   row.PositionID,
+			PositionName: row.PositionName,
+			Reports:      []*models.OrgChartNode{},
+		}
+		nodes[row.UserID] = node
+
+		if row.Depth == 0 || row.ManagerID == nil || nodes[*row.ManagerID] == nil {
+			roots = append(roots, node)
+			continue
+		}
+		manager := nodes[*row.ManagerID]
+		manager.Reports = append(manager.Reports, node)
+	}
+
+	chart := make([]models.OrgChartNode, len(roots))
+	for i, root := range roots {
+		chart[i] = *root
+	}
+	return chart, nil
+}
+
+func (c *companyServiceImpl) SetParentCompany(ctx context.Context, id uint64, parentRequest models.CompanyParentRequest, adminID uint64) (models.Company, error) {
+	// group decide the scope, it is checked under the hierarchy lock so the group
+	// cannot change between the check and the move
+	err := c.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := c.repo.LockCompanyHierarchy(ctx); err != nil {
+			return err
+		}
+		company, err := c.repo.GetCompanyByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if company.ID == 0 {
+			return errors.New("company not found")
+		}
+		if err := c.scope.Check(ctx, adminID, id); err != nil {
+			return err
+		}
+
+		if parentRequest.ParentID != nil {
+			parent, err := c.repo.GetCompanyByID(ctx, *parentRequest.ParentID)
+			if err != nil {
+				return err
+			}
+			if parent.ID == 0 {
+				return errors.New("parent company not found")
+			}
+			if err := c.scope.Check(ctx, adminID, parent.ID); err != nil {
+				return err
+			}
+		} else if company.ParentID != nil {
+			// only admin above the company can take it out of the group
+			if err := c.scope.Check(ctx, adminID, *company.ParentID); err != nil {
+				return err
+			}
+		}
+		return c.repo.SetParentCompany(ctx, id, parentRequest.ParentID)
+	})
+	if err != nil {
+		return models.Company{}, err
+	}
+	return c.GetCompanyByID(ctx, id)
+}
+
+func (c *companyServiceImpl) GetCompanySubtree(ctx context.Context, adminID uint64, id uint64) (models.CompanyTreeNode, error) {
+	if err := c.scope.Check(ctx, adminID, id); err != nil {
+		return models.CompanyTreeNode{}, err
+	}
+	rows, err := c.repo.GetCompanySubtree(ctx, id)
+	if err != nil {
+		return models.CompanyTreeNode{}, err
+	}
+	if len(rows) == 0 {
+		return models.CompanyTreeNode{}, errors.New("company not found")
+	}
+
+	// rows are ordered by depth, so parent always comes before its subsidiaries
+	nodes := make(map[uint64]*models.CompanyTreeNode, len(rows))
+	for _, row := range rows {
+		node := &models.CompanyTreeNode{
+			ID:           row.ID,
+			CompanyName:  row.CompanyName,
+			LegalName:    row.LegalName,
+			ParentID:     row.ParentID,
+			Subsidiaries: []*models.CompanyTreeNode{},
+		}
+		nodes[row.ID] = node
+		if row.Depth > 0 && row.ParentID != nil && nodes[*row.ParentID] != nil {
+			parent := nodes[*row.ParentID]
+			parent.Subsidiaries = append(parent.Subsidiaries, node)
+		}
+	}
+	return *nodes[rows[0].ID], nil
+}
+
+func (c *companyServiceImpl) GetCompanyRollup(ctx context.Context, adminID uint64, id uint64, period string) (models.CompanyRollup, error) {
+	start, _, err := models.ParseMonth(period)
+	if err != nil {
+		return models.CompanyRollup{}, err
+	}
+	if err := c.scope.Check(ctx, adminID, id); err != nil {
+		return models.CompanyRollup{}, err
+	}
+	rows, err := c.repo.GetCompanyRollup(ctx, id, models.NewDate(start))
+	if err != nil {
+		return models.CompanyRollup{}, err
+	}
+	if len(rows) == 0 {
+		return models.CompanyRollup{}, errors.New("company not found")
+	}
+
+	rollup := models.CompanyRollup{
+		CompanyID: id,
+		Period:    start.Format("2006-01"),
+		Totals:    []models.CompanyRollupTotal{},
+		Companies: rows,
+	}
+	totalByCurrency := map[string]int{}
+	for _, row := range rows {
+		rollup.Headcount += row.Headcount
+		i, ok := totalByCurrency[row.Currency]
+		if !ok {
+			i = len(rollup.Totals)
+			totalByCurrency[row.Currency] = i
+			rollup.Totals = append(rollup.Totals, models.CompanyRollupTotal{Currency: row.Currency})
+		}
+		total := &rollup.Totals[i]
+		total.Headcount += row.Headcount
+		total.GrossTotal = models.RoundMoney(total.GrossTotal + row.GrossTotal)
+		total.DeductionTotal = models.RoundMoney(total.DeductionTotal + row.DeductionTotal)
+		total.NetTotal = models.RoundMoney(total.NetTotal + row.NetTotal)
+	}
+	return rollup, nil
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type DepartmentService interface {
+	GetDepartment(ctx context.Context, filter models.DepartmentFilter, adminID uint64) ([]models.Department, error)
+	GetDepartmentByID(ctx context.Context, id uint64, adminID uint64) (models.Department, error)
+
+	CreateDepartment(ctx context.Context, createDepartment models.DepartmentRequest, adminID uint64) (models.DepartmentResponse, error)
+	UpdateDepartment(ctx context.Context, id uint64, updateDepartment models.DepartmentRequest, adminID uint64) (models.DepartmentResponse, error)
+
+	DeleteDepartment(ctx context.Context, id uint64, adminID uint64) (models.Department, error)
+
+	IsDepartmentSoftDeleted(ctx context.Context, companyID uint64, departmentName string, adminID uint64) (bool, error)
+	RestoreDepartment(ctx context.Context, id uint64, adminID uint64) error
+}
+
+type departmentServiceImpl struct {
+	repo  repository.DepartmentQuery
+	scope CompanyScope
+}
+
+func NewDepartmentService(repo repository.DepartmentQuery, scope CompanyScope) DepartmentService {
+	return &departmentServiceImpl{repo: repo, scope: scope}
+}
+
+func (d *departmentServiceImpl) GetDepartment(ctx context.Context, filter models.DepartmentFilter, adminID uint64) ([]models.Department, error) {
+	if err := d.scope.Check(ctx, adminID, filter.CompanyID); err != nil {
+		return []models.Department{}, err
+	}
+	departments, err := d.repo.GetDepartment(ctx, filter)
+	if err != nil {
+		return []models.Department{}, err
+	}
+	return departments, nil
+}
+
+func (d *departmentServiceImpl) GetDepartmentByID(ctx context.Context, id uint64, adminID uint64) (models.Department, error) {
+	return d.getDepartment(ctx, id, adminID)
+}
+
+// getDepartment return empty department when not found, error when its company
+// is outside admin access
+func (d *departmentServiceImpl) getDepartment(ctx context.Context, id uint64, adminID uint64) (models.Department, error) {
+	department, err := d.repo.GetDepartmentByID(ctx, id)
+	if err != nil || department.ID == 0 {
+		return models.Department{}, err
+	}
+	if err := d.scope.Check(ctx, adminID, department.CompanyID); err != nil {
+		return models.Department{}, err
+	}
+	return department, nil
+}
+
+func (d *departmentServiceImpl) CreateDepartment(ctx context.Context, createDepartment models.DepartmentRequest, adminID uint64) (models.DepartmentResponse, error) {
+	if err := d.scope.Check(ctx, adminID, createDepartment.CompanyID); err != nil {
+		return models.DepartmentResponse{}, err
+	}
+	// check departmentName in the same company
+	existingDepartment, err := d.repo.GetDepartmentByDepartmentName(ctx, createDepartment.CompanyID, createDepartment.DepartmentName)
+	if err != nil {
+		return models.DepartmentResponse{}, err
+	}
+	if existingDepartment.ID != 0 {
+		return models.DepartmentResponse{}, errors.New("department already exists")
+	}
+
+	if err := d.validateRelations(ctx, 0, createDepartment); err != nil {
+		return models.DepartmentResponse{}, err
+	}
+
+	// create req
+	department := models.DepartmentRequest{
+		CompanyID:      createDepartment.CompanyID,
+		ParentID:       createDepartment.ParentID,
+		HeadUserID:     createDepartment.HeadUserID,
+		DepartmentName: createDepartment.DepartmentName,
+		DepartmentCode: createDepartment.DepartmentCode,
+	}
+
+	// Store department to database
+	createdDepartment, err := d.repo.CreateDepartment(ctx, department)
+	if err != nil {
+		return models.DepartmentResponse{}, err
+	}
+
+	// response
+	response := models.DepartmentResponse{
+		Data: &models.Department{
+			ID:             createdDepartment.ID,
+			CompanyID:      createdDepartment.CompanyID,
+			ParentID:       createdDepartment.ParentID,
+			HeadUserID:     createdDepartment.HeadUserID,
+			DepartmentName: createdDepartment.DepartmentName,
+			DepartmentCode: createdDepartment.DepartmentCode,
+		}}
+	return response, nil
+}
+
+func (d *departmentServiceImpl) UpdateDepartment(ctx context.Context, id uint64, updateDepartment models.DepartmentRequest, adminID uint64) (models.DepartmentResponse, error) {
+	existingDepartment, err := d.getDepartment(ctx, id, adminID)
+	if err != nil {
+		return models.DepartmentResponse{}, err
+	}
+	if existingDepartment.ID == 0 {
+		return models.DepartmentResponse{}, errors.New("department not found")
+	}
+	// department can only be moved to company admin has access to
+	if err := d.scope.Check(ctx, adminID, updateDepartment.CompanyID); err != nil {
+		return models.DepartmentResponse{}, err
+	}
+	if existingDepartment.DepartmentName != updateDepartment.DepartmentName || existingDepartment.CompanyID != updateDepartment.CompanyID {
+		// Check if the new department name already exists in the company
+		newDepartment, err := d.repo.GetDepartmentByDepartmentName(ctx, updateDepartment.CompanyID, updateDepartment.DepartmentName)
+		if err != nil {
+			return models.DepartmentResponse{}, err
+		}
+
+		if newDepartment.ID != 0 && newDepartment.ID != existingDepartment.ID {
+			return models.DepartmentResponse{}, errors.New("department already exists")
+		}
+	}
+
+	if err := d.validateRelations(ctx, id, updateDepartment); err != nil {
+		return models.DepartmentResponse{}, err
+	}
+
+	// update req
+	department := models.DepartmentRequest{
+		CompanyID:      updateDepartment.CompanyID,
+		ParentID:       updateDepartment.ParentID,
+		HeadUserID:     updateDepartment.HeadUserID,
+		DepartmentName: updateDepartment.DepartmentName,
+		DepartmentCode: updateDepartment.DepartmentCode,
+	}
+
+	// Store department to database
+	updatedDepartment, err := d.repo.UpdateDepartment(ctx, id, department)
+	if err != nil {
+		return models.DepartmentResponse{}, err
+	}
+
+	// response
+	response := models.DepartmentResponse{
+		Data: &models.Department{
+			ID:             updatedDepartment.ID,
+			CompanyID:      updatedDepartment.CompanyID,
+			ParentID:       updatedDepartment.ParentID,
+			HeadUserID:     updatedDepartment.HeadUserID,
+			DepartmentName: updatedDepartment.DepartmentName,
+			DepartmentCode: updatedDepartment.DepartmentCode,
+		}}
+	return response, nil
+}
+
+// validateRelations check company, parent and head of department.
+// id is 0 when creating new department
+func (d *departmentServiceImpl) validateRelations(ctx context.Context, id uint64, department models.DepartmentRequest) error {
+	company, err := d.repo.GetCompanyByID(ctx, department.CompanyID)
+	if err != nil {
+		return err
+	}
+	if company.ID == 0 {
+		return errors.New("company not found")
+	}
+
+	if department.ParentID != nil {
+		// walk up the parent chain, department cannot be its own ancestor
+		parentID := *department.ParentID
+		for parentID != 0 {
+			if parentID == id {
+				return errors.New("department cannot be nested under itself")
+			}
+			parent, err := d.repo.GetDepartmentByID(ctx, parentID)
+			if err != nil {
+				return err
+			}
+			if parent.ID == 0 {
+				return errors.New("parent department not found")
+			}
+			if parent.CompanyID != department.CompanyID {
+				return errors.New("parent department must belong to the same company")
+			}
+			if parent.ParentID == nil {
+				break
+			}
+			parentID = *parent.ParentID
+		}
+	}
+
+	if department.HeadUserID != nil {
+		head, err := d.repo.GetUserByID(ctx, *department.HeadUserID)
+		if err != nil {
+			return err
+		}
+		if head.ID == 0 {
+			return errors.New("department head not found")
+		}
+		if head.CompanyID != department.CompanyID {
+			return errors.New("department head must belong to the same company")
+		}
+	}
+	return nil
+}
+
+func (d *departmentServiceImpl) DeleteDepartment(ctx context.Context, id uint64, adminID uint64) (models.Department, error) {
+	department, err := d.getDepartment(ctx, id, adminID)
+	if err != nil || department.ID == 0 {
+		return models.Department{}, err
+	}
+
+	// check if department is using in user
+	users, err := d.repo.GetUserByDepartmentID(ctx, id)
+	if err != nil {
+		return models.Department{}, err
+	}
+	if len(users) > 0 {
+		return models.Department{}, errors.New("department is still in use by user")
+	}
+
+	// check if department still has sub department
+	children, err := d.repo.GetDepartmentByParentID(ctx, id)
+	if err != nil {
+		return models.Department{}, err
+	}
+	if len(children) > 0 {
+		return models.Department{}, errors.New("department is still in use by sub department")
+	}
+
+	err = d.repo.DeleteDepartment(ctx, id)
+	if err != nil {
+		return models.Department{}, err
+	}
+	return department, err
+}
+
+func (d *departmentServiceImpl) IsDepartmentSoftDeleted(ctx context.Context, companyID uint64, departmentName string, adminID uint64) (bool, error) {
+	if err := d.scope.Check(ctx, adminID, companyID); err != nil {
+		return false, err
+	}
+	departments, err := d.repo.GetSoftDeletedDepartments(ctx, companyID)
+	if err != nil {
+		return false, err
+	}
+	for _, department := range departments {
+		if department.DepartmentName == departmentName {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *departmentServiceImpl) RestoreDepartment(ctx context.Context, id uint64, adminID uint64) error {
+	department, err := d.repo.GetDeletedDepartmentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if department.ID == 0 {
+		return errors.New("department not found")
+	}
+	if err := d.scope.Check(ctx, adminID, department.CompanyID); err != nil {
+		return err
+	}
+
+	// Restore soft deleted department
+	err = d.repo.RestoreDepartment(ctx, id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type EmployeeService interface {
+	GetEmployees(ctx context.Context, filter models.EmployeeFilter, adminID uint64) ([]models.Employee, error)
+	GetEmployeeByID(ctx context.Context, id uint64, adminID uint64) (models.Employee, error)
+
+	CreateEmployee(ctx context.Context, createEmployee models.EmployeeCreateRequest, adminID uint64) (models.EmployeeResponse, error)
+	UpdateEmployee(ctx context.Context, id uint64, updateEmployee models.EmployeeUpdateRequest, adminID uint64) (models.EmployeeResponse, error)
+
+	DeleteEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error)
+
+	// lifecycle transition
+	ConfirmEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error)
+	SuspendEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error)
+	ReactivateEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error)
+	TerminateEmployee(ctx context.Context, id uint64, terminate models.EmployeeTerminateRequest, adminID uint64) (models.Employee, error)
+}
+
+type employeeServiceImpl struct {
+	repo  repository.EmployeeQuery
+	scope CompanyScope
+}
+
+func NewEmployeeService(repo repository.EmployeeQuery, scope CompanyScope) EmployeeService {
+	return &employeeServiceImpl{repo: repo, scope: scope}
+}
+
+func (e *employeeServiceImpl) GetEmployees(ctx context.Context, filter models.EmployeeFilter, adminID uint64) ([]models.Employee, error) {
+	if err := e.scope.Check(ctx, adminID, filter.CompanyID); err != nil {
+		return []models.Employee{}, err
+	}
+	employees, err := e.repo.GetEmployees(ctx, filter)
+	if err != nil {
+		return []models.Employee{}, err
+	}
+	return employees, nil
+}
+
+func (e *employeeServiceImpl) GetEmployeeByID(ctx context.Context, id uint64, adminID uint64) (models.Employee, error) {
+	return e.getEmployee(ctx, id, adminID)
+}
+
+// getEmployee load employee and make sure admin can access its company,
+// empty employee is returned when not found
+func (e *employeeServiceImpl) getEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error) {
+	employee, err := e.repo.GetEmployeeByID(ctx, id)
+	if err != nil || employee.ID == 0 {
+		return models.Employee{}, err
+	}
+	if err := e.scope.Check(ctx, adminID, employee.CompanyID); err != nil {
+		return models.Employee{}, err
+	}
+	return employee, nil
+}
+
+func (e *employeeServiceImpl) CreateEmployee(ctx context.Context, createEmployee models.EmployeeCreateRequest, adminID uint64) (models.EmployeeResponse, error) {
+	if err := e.scope.Check(ctx, adminID, createEmployee.CompanyID); err != nil {
+		return models.EmployeeResponse{}, err
+	}
+	company, err := e.repo.GetCompanyByID(ctx, createEmployee.CompanyID)
+	if err != nil {
+		return models.EmployeeResponse{}, err
+	}
+	if company.ID == 0 {
+		return models.EmployeeResponse{}, errors.New("company not found")
+	}
+
+	// check employeeNumber in the same company
+	existingEmployee, err := e.repo.GetEmployeeByEmployeeNumber(ctx, createEmployee.CompanyID, createEmployee.EmployeeNumber)
+	if err != nil {
+		return models.EmployeeResponse{}, err
+	}
+	if existingEmployee.ID != 0 {
+		return models.EmployeeResponse{}, errors.New("employee number already exists")
+	}
+
+	if err := e.checkUser(ctx, 0, createEmployee.CompanyID, createEmployee.UserID); err != nil {
+		return models.EmployeeResponse{}, err
+	}
+
+	ptkpStatus := createEmployee.PtkpStatus
+	if ptkpStatus == "" {
+		ptkpStatus = models.DEFAULT_PTKP_STATUS
+	}
+
+	// create req, new employee is always active
+	employee := models.EmployeeCreateRequest{
+		CompanyID:      createEmployee.CompanyID,
+		UserID:         createEmployee.UserID,
+		EmployeeNumber: createEmployee.EmployeeNumber,
+		FirstName:      createEmployee.FirstName,
+		LastName:       createEmployee.LastName,
+		DateOfBirth:    createEmployee.DateOfBirth,
+		HireDate:       createEmployee.HireDate,
+		EmploymentType: createEmployee.EmploymentType,
+		PtkpStatus:     ptkpStatus,
+		TaxNumber:      createEmployee.TaxNumber,
+		Status:         models.EMPLOYEE_STATUS_ACTIVE,
+	}
+
+	// Store employee to database
+	createdEmployee, err := e.repo.CreateEmployee(ctx, employee)
+	if err != nil {
+		return models.EmployeeResponse{}, err
+	}
+
+	// response
+	response := models.EmployeeResponse{
+		Data: &models.Employee{
+			ID:             createdEmployee.ID,
+			CompanyID:      createdEmployee.CompanyID,
+			UserID:         createdEmployee.UserID,
+			EmployeeNumber: createdEmployee.EmployeeNumber,
+			FirstName:      createdEmployee.FirstName,
+			LastName:       createdEmployee.LastName,
+			DateOfBirth:    createdEmployee.DateOfBirth,
+			HireDate:       createdEmployee.HireDate,
+			EmploymentType: createdEmployee.EmploymentType,
+			PtkpStatus:     createdEmployee.PtkpStatus,
+			TaxNumber:      createdEmployee.TaxNumber,
+			Status:         createdEmployee.Status,
+		}}
+	return response, nil
+}
+
+func (e *employeeServiceImpl) UpdateEmployee(ctx context.Context, id uint64, updateEmployee models.EmployeeUpdateRequest, adminID uint64) (models.EmployeeResponse, error) {
+	existingEmployee, err := e.getEmployee(ctx, id, adminID)
+	if err != nil {
+		return models.EmployeeResponse{}, err
+	}
+	if existingEmployee.ID == 0 {
+		return models.EmployeeResponse{}, errors.New("employee not found")
+	}
+	if existingEmployee.Status == models.EMPLOYEE_STATUS_TERMINATED {
+		return models.EmployeeResponse{}, errors.New("terminated employee cannot be updated")
+	}
+
+	if updateEmployee.EmployeeNumber != "" && updateEmployee.EmployeeNumber != existingEmployee.EmployeeNumber {
+		newEmployee, err := e.repo.GetEmployeeByEmployeeNumber(ctx, existingEmployee.CompanyID, updateEmployee.EmployeeNumber)
+		if err != nil {
+			return models.EmployeeResponse{}, err
+		}
+		if newEmployee.ID != 0 && newEmployee.ID != id {
+			return models.EmployeeResponse{}, errors.New("employee number already exists")
+		}
+	}
+
+	if err := e.checkUser(ctx, id, existingEmployee.CompanyID, updateEmployee.UserID); err != nil {
+		return models.EmployeeResponse{}, err
+	}
+
+	// update req
+	employee := models.EmployeeUpdateRequest{
+		UserID:         updateEmployee.UserID,
+		EmployeeNumber: updateEmployee.EmployeeNumber,
+		FirstName:      updateEmployee.FirstName,
+		LastName:       updateEmployee.LastName,
+		DateOfBirth:    updateEmployee.DateOfBirth,
+		HireDate:       updateEmployee.HireDate,
+		EmploymentType: updateEmployee.EmploymentType,
+		PtkpStatus:     updateEmployee.PtkpStatus,
+		TaxNumber:      updateEmployee.TaxNumber,
+	}
+
+	// Store employee to database
+	updatedEmployee, err := e.repo.UpdateEmployee(ctx, id, employee)
+	if err != nil {
+		return models.EmployeeResponse{}, err
+	}
+
+	return models.EmployeeResponse{Data: &updatedEmployee}, nil
+}
+
+// checkUser make sure linked login account exists in the same company and
+// is not used by other employee. employeeID is 0 when creating employee
+func (e *employeeServiceImpl) checkUser(ctx context.Context, employeeID uint64, companyID uint64, userID *uint64) error {
+	if userID == nil {
+		return nil
+	}
+
+	user, err := e.repo.GetUserByID(ctx, *userID)
+	if err != nil {
+		return err
+	}
+	if user.ID == 0 {
+		return errors.New("user not found")
+	}
+	if user.CompanyID != companyID {
+		return errors.New("user must belong to the same company")
+	}
+
+	linkedEmployee, err := e.repo.GetEmployeeByUserID(ctx, *userID)
+	if err != nil {
+		return err
+	}
+	if linkedEmployee.ID != 0 && linkedEmployee.ID != employeeID {
+		return errors.New("user already linked to another employee")
+	}
+	return nil
+}
+
+func (e *employeeServiceImpl) DeleteEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error) {
+	employee, err := e.getEmployee(ctx, id, adminID)
+	if err != nil {
+		return models.Employee{}, err
+	}
+
+	if employee.ID == 0 {
+		return models.Employee{}, err
+	}
+
+	err = e.repo.DeleteEmployee(ctx, id)
+	if err != nil {
+		return models.Employee{}, err
+	}
+	return employee, err
+}
+
+// probation employee become permanent
+func (e *employeeServiceImpl) ConfirmEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error) {
+	employee, err := e.getEmployee(ctx, id, adminID)
+	if err != nil || employee.ID == 0 {
+		return models.Employee{}, err
+	}
+	if employee.Status != models.EMPLOYEE_STATUS_ACTIVE || employee.EmploymentType != models.EMPLOYMENT_TYPE_PROBATION {
+		return models.Employee{}, errors.New("invalid status transition: only active probation employee can be confirmed")
+	}
+
+	return e.repo.UpdateEmployeeLifecycle(ctx, id, map[string]any{
+		"employment_type": models.EMPLOYMENT_TYPE_PERMANENT,
+	})
+}
+
+func (e *employeeServiceImpl) SuspendEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error) {
+	return e.transition(ctx, id, adminID, models.EMPLOYEE_STATUS_ACTIVE, models.EMPLOYEE_STATUS_SUSPENDED)
+}
+
+func (e *employeeServiceImpl) ReactivateEmployee(ctx context.Context, id uint64, adminID uint64) (models.Employee, error) {
+	return e.transition(ctx, id, adminID, models.EMPLOYEE_STATUS_SUSPENDED, models.EMPLOYEE_STATUS_ACTIVE)
+}
+
+// transition change status when current status is from
+func (e *employeeServiceImpl) transition(ctx context.Context, id uint64, adminID uint64, from string, to string) (models.Employee, error) {
+	employee, err := e.getEmployee(ctx, id, adminID)
+	if err != nil || employee.ID == 0 {
+		return models.Employee{}, err
+	}
+	if employee.Status != from {
+		return models.Employee{}, fmt.Errorf("invalid status transition: employee is %s, expected %s", employee.Status, from)
+	}
+
+	return e.repo.UpdateEmployeeLifecycle(ctx, id, map[string]any{
+		"status": to,
+	})
+}
+
+func (e *employeeServiceImpl) TerminateEmployee(ctx context.Context, id uint64, terminate models.EmployeeTerminateRequest, adminID uint64) (models.Employee, error) {
+	employee, err := e.getEmployee(ctx, id, adminID)
+	if err != nil || employee.ID == 0 {
+		return models.Employee{}, err
+	}
+	if employee.Status == models.EMPLOYEE_STATUS_TERMINATED {
+		return models.Employee{}, errors.New("invalid status transition: employee is already terminated")
+	}
+	if terminate.TerminationDate.Before(employee.HireDate.Time) {
+		return models.Employee{}, errors.New("termination date must not be before hire date")
+	}
+
+	return e.repo.UpdateEmployeeLifecycle(ctx, id, map[string]any{
+		"status":             models.EMPLOYEE_STATUS_TERMINATED,
+		"termination_date":   terminate.TerminationDate,
+		"termination_reason": terminate.Reason,
+	})
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+// EventHandler handle one domain event, returning error make the event dispatched
+// again later. event may be delivered more than once, so handler must be idempotent
+type EventHandler func(ctx context.Context, event models.DomainEvent) error
+
+type EventBus interface {
+	// Subscribe register handler under unique name, pattern is exact event name,
+	// prefix like "user.*" or "*" for every event. called before RunDispatcher
+	Subscribe(name string, pattern string, handler EventHandler)
+
+	// RunDispatcher deliver outbox event to subscribers, subscriber that already
+	// handled the event is skipped when the event is retried
+	RunDispatcher(ctx context.Context)
+}
+
+type eventSubscriber struct {
+	name    string
+	pattern string
+	handler EventHandler
+}
+
+type eventBusImpl struct {
+	outbox      repository.OutboxQuery
+	subscribers []eventSubscriber
+}
+
+func NewEventBus(outbox repository.OutboxQuery) EventBus {
+	return &eventBusImpl{outbox: outbox}
+}
+
+func (e *eventBusImpl) Subscribe(name string, pattern string, handler EventHandler) {
+	e.subscribers = append(e.subscribers, eventSubscriber{name: name, pattern: pattern, handler: handler})
+}
+
+func (s eventSubscriber) matches(event string) bool {
+	if s.pattern == "*" {
+		return true
+	}
+	if strings.HasSuffix(s.pattern, ".*") {
+		return strings.HasPrefix(event, strings.TrimSuffix(s.pattern, "*"))
+	}
+	return s.pattern == event
+}
+
+func (e *eventBusImpl) RunDispatcher(ctx context.Context) {
+	// event is committed by other transaction, short poll keep subscriber close to real time
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		for e.dispatchNextEvent(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchNextEvent return false when there is no due event
+func (e *eventBusImpl) dispatchNextEvent(ctx context.Context) bool {
+	event, err := e.outbox.ClaimNextEvent(ctx)
+	if err != nil {
+		log.Println("cannot claim outbox event", err.Error())
+		return false
+	}
+	if event.ID == 0 {
+		return false
+	}
+
+	done := []string{}
+	if event.DoneSubscribers != "" {
+		done = strings.Split(event.DoneSubscribers, ",")
+	}
+	failures := []string{}
+	for _, subscriber := range e.subscribers {
+		if !subscriber.matches(event.Name) || slices.Contains(done, subscriber.name) {
+			continue
+		}
+		if err := handleEvent(ctx, subscriber, event); err != nil {
+			log.Println("event subscriber failed", subscriber.name, event.ID, err.Error())
+			failures = append(failures, subscriber.name+": "+err.Error())
+			continue
+		}
+		done = append(done, subscriber.name)
+	}
+
+	now := time.Now()
+	event.Attempts++
+	event.DoneSubscribers = strings.Join(done, ",")
+	event.LastError = strings.Join(failures, "; ")
+	if len(failures) == 0 {
+		event.Status = models.OUTBOX_STATUS_DISPATCHED
+		event.DispatchedAt = &now
+	} else {
+		event.NextAttemptAt = models.OutboxRetryAt(event.Attempts, now)
+		if event.NextAttemptAt.IsZero() {
+			event.Status = models.OUTBOX_STATUS_FAILED
+			event.NextAttemptAt = now
+		}
+	}
+	if err := e.outbox.FinishEvent(ctx, event); err != nil {
+		log.Println("cannot save outbox event", event.ID, err.Error())
+	}
+	return true
+}
+
+// handleEvent turn panic of subscriber into error so other subscriber still run
+func handleEvent(ctx context.Context, subscriber eventSubscriber, event models.DomainEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return subscriber.handler(ctx, event)
+}
+
+// publishEvent add domain event to outbox, ctx must be inside WithTransaction of
+// outbox so the event is only saved when the change is committed
+func publishEvent(ctx context.Context, outbox repository.OutboxQuery, name string, companyID *uint64, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return outbox.AddEvent(ctx, models.DomainEvent{
+		Name:          name,
+		CompanyID:     companyID,
+		Payload:       string(payload),
+		Status:        models.OUTBOX_STATUS_PENDING,
+		NextAttemptAt: now,
+		OccurredAt:    now,
+	})
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/export"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type ExportService interface {
+	// validate format and columns, fill default value
+	ValidateExportRequest(entity string, req models.ExportRequest) (models.ExportRequest, error)
+	// ScopeFilter limit filter to company admin can manage, company export is
+	// limited to the group of admin
+	ScopeFilter(ctx context.Context, filter any, adminID uint64) error
+
+	Export(ctx context.Context, entity string, filter any, req models.ExportRequest, w io.Writer) (int64, error)
+	IsLargeExport(ctx context.Context, entity string, filter any) (bool, error)
+
+	CreateExportJob(ctx context.Context, entity string, filter any, req models.ExportRequest, requestedBy uint64) (models.ExportJob, error)
+	GetExportJobByID(ctx context.Context, id uint64) (models.ExportJob, error)
+
+	// worker for background export, stop when ctx done
+	RunWorker(ctx context.Context)
+}
+
+// exporter hold columns and query for one entity,
+// filter is passed as json so the same code serve request and background job
+type exporter struct {
+	columns []string
+	count   func(ctx context.Context, filter []byte) (int64, error)
+	stream  func(ctx context.Context, filter []byte, columns []string, fn func(values []any) error) error
+}
+
+type exportServiceImpl struct {
+	repo      repository.ExportQuery
+	scope     CompanyScope
+	users     repository.UserQuery
+	exporters map[string]exporter
+	dir       string
+	notify    chan struct{}
+}
+
+func NewExportService(repo repository.ExportQuery, scope CompanyScope, userRepo repository.UserQuery, companyRepo repository.CompanyQuery, positionRepo repository.PositionQuery, dir string) ExportService {
+	exporters := map[string]exporter{
+		models.EXPORT_ENTITY_USERS: {
+			// password is never exported
+			columns: []string{"id", "first_name", "last_name", "email", "phone_number", "role", "status", "position_id", "company_id", "department_id", "manager_id", "created_at", "updated_at"},
+			count: func(ctx context.Context, filter []byte) (int64, error) {
+				f := models.UserFilter{}
+				if err := json.Unmarshal(filter, &f); err != nil {
+					return 0, err
+				}
+				return userRepo.CountUsers(ctx, f)
+			},
+			stream: func(ctx context.Context, filter []byte, columns []string, fn func(values []any) error) error {
+				f := models.UserFilter{}
+				if err := json.Unmarshal(filter, &f); err != nil {
+					return err
+				}
+				return userRepo.StreamUsers(ctx, f, columns, fn)
+			},
+		},
+		models.EXPORT_ENTITY_COMPANY: {
+			columns: []string{"id", "company_name", "legal_name", "tax_number", "currency", "timezone", "created_at", "updated_at"},
+			count: func(ctx context.Context, filter []byte) (int64, error) {
+				f := models.CompanyFilter{}
+				if err := json.Unmarshal(filter, &f); err != nil {
+					return 0, err
+				}
+				return companyRepo.CountCompany(ctx, f)
+			},
+			stream: func(ctx context.Context, filter []byte, columns []string, fn func(values []any) error) error {
+				f := models.CompanyFilter{}
+				if err := json.Unmarshal(filter, &f); err != nil {
+					return err
+				}
+				return companyRepo.StreamCompany(ctx, f, columns, fn)
+			},
+		},
+		models.EXPORT_ENTITY_POSITIONS: {
+			columns: []string{"id", "position_name", "position_code", "job_grade", "created_at", "updated_at"},
+			count: func(ctx context.Context, filter []byte) (int64, error) {
+				f := models.PositionFilter{}
+				if err := json.Unmarshal(filter, &f); err != nil {
+					return 0, err
+				}
+				return positionRepo.CountPosition(ctx, f)
+			},
+			stream: func(ctx context.Context, filter []byte, columns []string, fn func(values []any) error) error {
+				f := models.PositionFilter{}
+				if err := json.Unmarshal(filter, &f); err != nil {
+					return err
+				}
+				return positionRepo.StreamPosition(ctx, f, columns, fn)
+			},
+		},
+	}
+
+	return &exportServiceImpl{
+		repo:      repo,
+		scope:     scope,
+		users:     userRepo,
+		exporters: exporters,
+		dir:       dir,
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+func (e *exportServiceImpl) ValidateExportRequest(entity string, req models.ExportRequest) (models.ExportRequest, error) {
+	exp, ok := e.exporters[entity]
+	if !ok {
+		return models.ExportRequest{}, errors.New("invalid export entity")
+	}
+
+	if req.Format == "" {
+		req.Format = models.EXPORT_FORMAT_CSV
+	}
+	if req.Format != models.EXPORT_FORMAT_CSV && req.Format != models.EXPORT_FORMAT_XLSX && req.Format != models.EXPORT_FORMAT_NDJSON {
+		return models.ExportRequest{}, errors.New("invalid export format")
+	}
+
+	// empty columns means all columns
+	if req.Columns == "" {
+		req.Columns = strings.Join(exp.columns, ",")
+		return req, nil
+	}
+	columns := strings.Split(req.Columns, ",")
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		if !containsColumn(exp.columns, columns[i]) {
+			return models.ExportRequest{}, fmt.Errorf("invalid export column: %s", columns[i])
+		}
+	}
+	req.Columns = strings.Join(columns, ",")
+	return req, nil
+}
+
+func (e *exportServiceImpl) ScopeFilter(ctx context.Context, filter any, adminID uint64) error {
+	switch f := filter.(type) {
+	case *models.UserFilter:
+		return e.scope.Check(ctx, adminID, f.CompanyID)
+	case *models.PositionFilter:
+		// template position has no company
+		if f.Template {
+			return nil
+		}
+		return e.scope.Check(ctx, adminID, f.CompanyID)
+	case *models.CompanyFilter:
+		admin, err := e.users.GetUserByID(ctx, adminID)
+		if err != nil {
+			return err
+		}
+		if admin.ID == 0 {
+			return errors.New("user not found")
+		}
+		f.GroupID = admin.CompanyID
+		return nil
+	default:
+		return errors.New("invalid export entity")
+	}
+}
+
+func (e *exportServiceImpl) Export(ctx context.Context, entity string, filter any, req models.ExportRequest, w io.Writer) (int64, error) {
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return 0, err
+	}
+	return e.export(ctx, entity, filterJSON, req, w)
+}
+
+func (e *exportServiceImpl) export(ctx context.Context, entity string, filter []byte, req models.ExportRequest, w io.Writer) (int64, error) {
+	exp, ok := e.exporters[entity]
+	if !ok {
+		return 0, errors.New("invalid export entity")
+	}
+
+	writer, err := export.NewWriter(req.Format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	columns := strings.Split(req.Columns, ",")
+	if err := writer.WriteHeader(columns); err != nil {
+		return 0, err
+	}
+
+	var rowCount int64
+	err = exp.stream(ctx, filter, columns, func(values []any) error {
+		rowCount++
+		return writer.WriteRow(values)
+	})
+	if err != nil {
+		return rowCount, err
+	}
+	return rowCount, writer.Close()
+}
+
+func (e *exportServiceImpl) IsLargeExport(ctx context.Context, entity string, filter any) (bool, error) {
+	exp, ok := e.exporters[entity]
+	if !ok {
+		return false, errors.New("invalid export entity")
+	}
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return false, err
+	}
+	count, err := exp.count(ctx, filterJSON)
+	if err != nil {
+		return false, err
+	}
+	return count > models.EXPORT_ASYNC_THRESHOLD, nil
+}
+
+func (e *exportServiceImpl) CreateExportJob(ctx context.Context, entity string, filter any, req models.ExportRequest, requestedBy uint64) (models.ExportJob, error) {
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+
+	job := models.ExportJob{
+		Entity:      entity,
+		Format:      req.Format,
+		Columns:     req.Columns,
+		Filters:     string(filterJSON),
+		Status:      models.EXPORT_STATUS_PENDING,
+		RequestedBy: requestedBy,
+	}
+
+	createdJob, err := e.repo.CreateExportJob(ctx, job)
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+
+	// wake up worker, skip if already notified
+	select {
+	case e.notify <- struct{}{}:
+	default:
+	}
+	return createdJob, nil
+}
+
+func (e *exportServiceImpl) GetExportJobByID(ctx context.Context, id uint64) (models.ExportJob, error) {
+	job, err := e.repo.GetExportJobByID(ctx, id)
+	if err != nil {
+		return models.ExportJob{}, err
+	}
+	return job, nil
+}
+
+func (e *exportServiceImpl) RunWorker(ctx context.Context) {
+	if err := os.MkdirAll(e.dir, 0o750); err != nil {
+		log.Println("cannot create export directory", err.Error())
+		return
+	}
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		// process all pending job before waiting again
+		for e.runNextJob(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.notify:
+		}
+	}
+}
+
+// runNextJob return false when there is no pending job
+func (e *exportServiceImpl) runNextJob(ctx context.Context) bool {
+	job, err := e.repo.ClaimNextExportJob(ctx)
+	if err != nil {
+		log.Println("cannot claim export job", err.Error())
+		return false
+	}
+	if job.ID == 0 {
+		return false
+	}
+
+	filePath := filepath.Join(e.dir, fmt.Sprintf("export-%d.%s", job.ID, job.Format))
+	rowCount, err := e.writeJobFile(ctx, job, filePath)
+	if err != nil && ctx.Err() != nil {
+		// stopped by shutdown, job is picked up again on next start
+		os.Remove(filePath)
+		if err := e.repo.ReleaseExportJob(context.WithoutCancel(ctx), job.ID); err != nil {
+			log.Println("cannot release export job", job.ID, err.Error())
+		}
+		return false
+	}
+	if err != nil {
+		log.Println("export job failed", job.ID, err.Error())
+		os.Remove(filePath)
+		if err := e.repo.FinishExportJob(ctx, job.ID, models.EXPORT_STATUS_FAILED, "", rowCount, err.Error()); err != nil {
+			log.Println("cannot update export job", job.ID, err.Error())
+		}
+		return true
+	}
+
+	if err := e.repo.FinishExportJob(ctx, job.ID, models.EXPORT_STATUS_DONE, filePath, rowCount, ""); err != nil {
+		log.Println("cannot update export job", job.ID, err.Error())
+	}
+	return true
+}
+
+func (e *exportServiceImpl) writeJobFile(ctx context.Context, job models.ExportJob, filePath string) (int64, error) {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	req := models.ExportRequest{
+		Format:  job.Format,
+		Columns: job.Columns,
+	}
+	rowCount, err := e.export(ctx, job.Entity, []byte(job.Filters), req, f)
+	if err != nil {
+		return rowCount, err
+	}
+	return rowCount, f.Sync()
+}
+
+func containsColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
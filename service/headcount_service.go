@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type HeadcountService interface {
+	// plans of company in period with filled and open seats
+	GetHeadcountPlans(ctx context.Context, filter models.HeadcountFilter, adminID uint64) ([]models.HeadcountPlan, error)
+	// create plan or replace planned seats of the existing one
+	SaveHeadcountPlan(ctx context.Context, request models.HeadcountPlanRequest, adminID uint64) (models.HeadcountPlan, error)
+	DeleteHeadcountPlan(ctx context.Context, id uint64, adminID uint64) error
+	// plans which still have open seats
+	GetVacancies(ctx context.Context, filter models.HeadcountFilter, adminID uint64) ([]models.HeadcountPlan, error)
+}
+
+type headcountServiceImpl struct {
+	repo  repository.HeadcountQuery
+	scope CompanyScope
+}
+
+func NewHeadcountService(repo repository.HeadcountQuery, scope CompanyScope) HeadcountService {
+	return &headcountServiceImpl{repo: repo, scope: scope}
+}
+
+func (h *headcountServiceImpl) GetHeadcountPlans(ctx context.Context, filter models.HeadcountFilter, adminID uint64) ([]models.HeadcountPlan, error) {
+	if err := h.scope.Check(ctx, adminID, filter.CompanyID); err != nil {
+		return []models.HeadcountPlan{}, err
+	}
+	start, next, err := models.ParseMonth(filter.Period)
+	if err != nil {
+		return []models.HeadcountPlan{}, err
+	}
+	company, err := h.repo.GetCompanyByID(ctx, filter.CompanyID)
+	if err != nil {
+		return []models.HeadcountPlan{}, err
+	}
+	if company.ID == 0 {
+		return []models.HeadcountPlan{}, errors.New("company not found")
+	}
+
+	plans, err := h.repo.GetHeadcountPlans(ctx, filter.CompanyID, models.NewDate(start))
+	if err != nil {
+		return []models.HeadcountPlan{}, err
+	}
+	if len(plans) == 0 {
+		return plans, nil
+	}
+
+	// past period is counted on its last day, current and future period on today
+	asOf := models.NewDate(next.AddDate(0, 0, -1))
+	if today := company.Today(); asOf.After(today.Time) {
+		asOf = today
+	}
+	filled, err := h.repo.GetFilledSeats(ctx, filter.CompanyID, asOf)
+	if err != nil {
+		return []models.HeadcountPlan{}, err
+	}
+	for i := range plans {
+		plans[i].Filled = filledSeats(plans[i], filled)
+		plans[i].Open = max(plans[i].Planned-plans[i].Filled, 0)
+	}
+	return plans, nil
+}
+
+func (h *headcountServiceImpl) SaveHeadcountPlan(ctx context.Context, request models.HeadcountPlanRequest, adminID uint64) (models.HeadcountPlan, error) {
+	if err := request.ValidateHeadcountPlan(); err != nil {
+		return models.HeadcountPlan{}, err
+	}
+	if err := h.scope.Check(ctx, adminID, request.CompanyID); err != nil {
+		return models.HeadcountPlan{}, err
+	}
+	start, _, _ := models.ParseMonth(request.Period)
+
+	position, err := h.repo.GetPositionByID(ctx, request.PositionID)
+	if err != nil {
+		return models.HeadcountPlan{}, err
+	}
+	if position.ID == 0 {
+		return models.HeadcountPlan{}, errors.New("position not found")
+	}
+	// template position is shared, company position only inside its company
+	if position.CompanyID != nil && *position.CompanyID != request.CompanyID {
+		return models.HeadcountPlan{}, errors.New("position must belong to the same company")
+	}
+	if request.DepartmentID != nil {
+		department, err := h.repo.GetDepartmentByID(ctx, *request.DepartmentID)
+		if err != nil {
+			return models.HeadcountPlan{}, err
+		}
+		if department.ID == 0 {
+			return models.HeadcountPlan{}, errors.New("department not found")
+		}
+		if department.CompanyID != request.CompanyID {
+			return models.HeadcountPlan{}, errors.New("department must belong to the same company")
+		}
+	}
+
+	plan := models.HeadcountPlan{
+		CompanyID:    request.CompanyID,
+		DepartmentID: request.DepartmentID,
+		PositionID:   request.PositionID,
+		Period:       models.NewDate(start),
+		Planned:      request.Planned,
+	}
+	existing, err := h.repo.GetHeadcountPlan(ctx, plan)
+	if err != nil {
+		return models.HeadcountPlan{}, err
+	}
+	if existing.ID != 0 {
+		existing.Planned = request.Planned
+		return h.repo.UpdateHeadcountPlan(ctx, existing)
+	}
+
+	return h.repo.CreateHeadcountPlan(ctx, plan)
+}
+
+func (h *headcountServiceImpl) DeleteHeadcountPlan(ctx context.Context, id uint64, adminID uint64) error {
+	plan, err := h.repo.GetHeadcountPlanByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if plan.ID == 0 {
+		return errors.New("headcount plan not found")
+	}
+	if err := h.scope.Check(ctx, adminID, plan.CompanyID); err != nil {
+		return err
+	}
+	return h.repo.DeleteHeadcountPlan(ctx, id)
+}
+
+func (h *headcountServiceImpl) GetVacancies(ctx context.Context, filter models.HeadcountFilter, adminID uint64) ([]models.HeadcountPlan, error) {
+	plans, err := h.GetHeadcountPlans(ctx, filter, adminID)
+	if err != nil {
+		return []models.HeadcountPlan{}, err
+	}
+	vacancies := []models.HeadcountPlan{}
+	for _, plan := range plans {
+		if plan.Open > 0 {
+			vacancies = append(vacancies, plan)
+		}
+	}
+	return vacancies, nil
+}
+
+func filledSeats(plan models.HeadcountPlan, filled []models.HeadcountFilled) int {
+	total := 0
+	for _, seat := range filled {
+		if plan.Covers(seat.DepartmentID, seat.PositionID) {
+			total += seat.Filled
+		}
+	}
+	return total
+}
+
+// checkHeadcount check one more seat in department and position of company on date against
+// every plan covering it. seat without plan is not restricted.
+// over plan hiring return error on block policy and overPlan true on flag policy
+func checkHeadcount(ctx context.Context, repo repository.HeadcountQuery, companyID uint64, departmentID *uint64, positionID uint64, date models.Date) (bool, error) {
+	period := models.NewDate(time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC))
+	plans, err := repo.GetHeadcountPlans(ctx, companyID, period)
+	if err != nil {
+		return false, err
+	}
+	covering := []models.HeadcountPlan{}
+	for _, plan := range plans {
+		if plan.Covers(departmentID, positionID) {
+			covering = append(covering, plan)
+		}
+	}
+	if len(covering) == 0 {
+		return false, nil
+	}
+
+	filled, err := repo.GetFilledSeats(ctx, companyID, date)
+	if err != nil {
+		return false, err
+	}
+	overPlan := false
+	for _, plan := range covering {
+		if filledSeats(plan, filled)+1 > plan.Planned {
+			overPlan = true
+			break
+		}
+	}
+	if !overPlan {
+		return false, nil
+	}
+
+	company, err := repo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return false, err
+	}
+	if company.HeadcountPolicy == models.HEADCOUNT_POLICY_BLOCK {
+		return false, errors.New("hiring beyond headcount plan is not allowed")
+	}
+	return true, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/database/migration"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/lifecycle"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+// probe is called often, slow database count as not ready
+const healthCheckTimeout = 2 * time.Second
+
+type HealthService interface {
+	// Liveness only tell the process is serving, it never check dependency so
+	// database outage does not get the process restarted
+	Liveness() models.Health
+	// Readiness check database, migration and worker, it fail once shutdown start
+	Readiness(ctx context.Context) models.Health
+	Status(ctx context.Context) models.SystemStatus
+}
+
+type healthServiceImpl struct {
+	repo      repository.HealthQuery
+	workers   *lifecycle.Manager
+	version   string
+	startedAt time.Time
+}
+
+func NewHealthService(repo repository.HealthQuery, workers *lifecycle.Manager, version string) HealthService {
+	return &healthServiceImpl{
+		repo:      repo,
+		workers:   workers,
+		version:   version,
+		startedAt: time.Now(),
+	}
+}
+
+func (h *healthServiceImpl) Liveness() models.Health {
+	return models.Health{Status: models.HEALTH_STATUS_OK}
+}
+
+func (h *healthServiceImpl) Readiness(ctx context.Context) models.Health {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	checks := []models.HealthCheck{
+		h.checkShutdown(),
+		h.checkDatabase(ctx),
+	}
+	pending, err := h.pendingMigrations(ctx)
+	checks = append(checks, migrationCheck(pending, err))
+	workers := h.workerStatus()
+	checks = append(checks, workerCheck(workers))
+
+	health := models.Health{Status: models.HEALTH_STATUS_OK, Checks: checks, Workers: workers}
+	for _, check := range checks {
+		if check.Status != models.HEALTH_STATUS_OK {
+			health.Status = models.HEALTH_STATUS_FAIL
+		}
+	}
+	return health
+}
+
+func (h *healthServiceImpl) Status(ctx context.Context) models.SystemStatus {
+	status := models.SystemStatus{
+		Version:    h.version,
+		GoVersion:  runtime.Version(),
+		StartedAt:  h.startedAt,
+		Uptime:     time.Since(h.startedAt).Round(time.Second).String(),
+		Draining:   h.workers.Draining(),
+		Goroutines: runtime.NumGoroutine(),
+		Migrations: []string{},
+		Workers:    h.workerStatus(),
+		Health:     h.Readiness(ctx),
+	}
+	// revision and time are stamped by go build from git
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				status.Revision = setting.Value
+			case "vcs.time":
+				status.BuildTime = setting.Value
+			case "vcs.modified":
+				if setting.Value == "true" {
+					status.Revision += "-dirty"
+				}
+			}
+		}
+	}
+	if pool, err := h.repo.GetPoolStats(); err == nil {
+		status.Database = pool
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	if pending, err := h.pendingMigrations(ctx); err == nil {
+		status.Migrations = pending
+	}
+	return status
+}
+
+func (h *healthServiceImpl) checkShutdown() models.HealthCheck {
+	if h.workers.Draining() {
+		return models.HealthCheck{Name: "shutdown", Status: models.HEALTH_STATUS_FAIL, Detail: "shutting down"}
+	}
+	return models.HealthCheck{Name: "shutdown", Status: models.HEALTH_STATUS_OK}
+}
+
+func (h *healthServiceImpl) checkDatabase(ctx context.Context) models.HealthCheck {
+	if err := h.repo.Ping(ctx); err != nil {
+		return models.HealthCheck{Name: "database", Status: models.HEALTH_STATUS_FAIL, Detail: err.Error()}
+	}
+	return models.HealthCheck{Name: "database", Status: models.HEALTH_STATUS_OK}
+}
+
+// pendingMigrations return migration file of this build that is not applied yet
+func (h *healthServiceImpl) pendingMigrations(ctx context.Context) ([]string, error) {
+	applied, err := h.repo.GetAppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	done := map[string]bool{}
+	for _, version := range applied {
+		done[version] = true
+	}
+	pending := []string{}
+	for _, version := range migration.Versions() {
+		if !done[version] {
+			pending = append(pending, version)
+		}
+	}
+	return pending, nil
+}
+
+func migrationCheck(pending []string, err error) models.HealthCheck {
+	if err != nil {
+		return models.HealthCheck{Name: "migrations", Status: models.HEALTH_STATUS_FAIL, Detail: err.Error()}
+	}
+	if len(pending) > 0 {
+		return models.HealthCheck{Name: "migrations", Status: models.HEALTH_STATUS_FAIL, Detail: "pending " + strings.Join(pending, ", ")}
+	}
+	return models.HealthCheck{Name: "migrations", Status: models.HEALTH_STATUS_OK}
+}
+
+func (h *healthServiceImpl) workerStatus() []models.WorkerStatus {
+	workers := []models.WorkerStatus{}
+	for _, w := range h.workers.Workers() {
+		workers = append(workers, models.WorkerStatus(w))
+	}
+	return workers
+}
+
+func workerCheck(workers []models.WorkerStatus) models.HealthCheck {
+	stopped := []string{}
+	for _, w := range workers {
+		if !w.Running {
+			stopped = append(stopped, w.Name)
+		}
+	}
+	if len(stopped) > 0 {
+		return models.HealthCheck{Name: "workers", Status: models.HEALTH_STATUS_FAIL, Detail: fmt.Sprintf("stopped %s", strings.Join(stopped, ", "))}
+	}
+	return models.HealthCheck{Name: "workers", Status: models.HEALTH_STATUS_OK}
+}
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type LeaveService interface {
+	GetLeaveTypes(ctx context.Context, userID uint64, companyID uint64) ([]models.LeaveType, error)
+	// admin only, scoped to the company of the admin
+	CreateLeaveType(ctx context.Context, adminID uint64, leaveType models.LeaveType) (models.LeaveType, error)
+	UpdateLeaveType(ctx context.Context, adminID uint64, leaveType models.LeaveType) (models.LeaveType, error)
+	DeleteLeaveType(ctx context.Context, adminID uint64, id uint64) error
+
+	// balance of every leave type of the company of user in one year
+	GetLeaveBalances(ctx context.Context, userID uint64, year int) ([]models.LeaveBalance, error)
+
+	CreateLeaveRequest(ctx context.Context, userID uint64, leaveRequest models.LeaveRequestCreate) (models.LeaveRequest, error)
+	GetMyLeaveRequests(ctx context.Context, userID uint64) ([]models.LeaveRequest, error)
+	GetPendingLeaveRequests(ctx context.Context, approverID uint64) ([]models.LeaveRequest, error)
+	ApproveLeaveRequest(ctx context.Context, approverID uint64, id uint64, note string) (models.LeaveRequest, error)
+	RejectLeaveRequest(ctx context.Context, approverID uint64, id uint64, note string) (models.LeaveRequest, error)
+	CancelLeaveRequest(ctx context.Context, userID uint64, id uint64) (models.LeaveRequest, error)
+
+	GetLeaveCalendar(ctx context.Context, userID uint64, filter models.LeaveCalendarFilter) ([]models.LeaveCalendarEntry, error)
+}
+
+type leaveServiceImpl struct {
+	repo   repository.LeaveQuery
+	scope  CompanyScope
+	outbox repository.OutboxQuery
+}
+
+func NewLeaveService(repo repository.LeaveQuery, scope CompanyScope, outbox repository.OutboxQuery) LeaveService {
+	return &leaveServiceImpl{repo: repo, scope: scope, outbox: outbox}
+}
+
+// GetLeaveTypes return leave types of the company of user when company id is empty
+func (l *leaveServiceImpl) GetLeaveTypes(ctx context.Context, userID uint64, companyID uint64) ([]models.LeaveType, error) {
+	if companyID == 0 {
+		user, err := l.getUser(ctx, userID)
+		if err != nil {
+			return []models.LeaveType{}, err
+		}
+		companyID = user.CompanyID
+	}
+	leaveTypes, err := l.repo.GetLeaveTypes(ctx, companyID)
+	if err != nil {
+		return []models.LeaveType{}, err
+	}
+	return leaveTypes, nil
+}
+
+func (l *leaveServiceImpl) CreateLeaveType(ctx context.Context, adminID uint64, leaveType models.LeaveType) (models.LeaveType, error) {
+	if err := leaveType.ValidateLeaveType(); err != nil {
+		return models.LeaveType{}, err
+	}
+	if err := l.scope.Check(ctx, adminID, leaveType.CompanyID); err != nil {
+		return models.LeaveType{}, err
+	}
+
+	existing, err := l.repo.GetLeaveTypeByCode(ctx, leaveType.CompanyID, leaveType.Code)
+	if err != nil {
+		return models.LeaveType{}, err
+	}
+	if existing.ID != 0 {
+		return models.LeaveType{}, errors.New("leave type code already exists")
+	}
+	if leaveType.Accrual == "" {
+		leaveType.Accrual = models.LEAVE_ACCRUAL_YEARLY
+	}
+
+	createdLeaveType, err := l.repo.CreateLeaveType(ctx, leaveType)
+	if err != nil {
+		return models.LeaveType{}, err
+	}
+	return createdLeaveType, nil
+}
+
+// UpdateLeaveType change the rule, balance already created keep the old entitlement
+func (l *leaveServiceImpl) UpdateLeaveType(ctx context.Context, adminID uint64, leaveType models.LeaveType) (models.LeaveType, error) {
+	if err := leaveType.ValidateLeaveType(); err != nil {
+		return models.LeaveType{}, err
+	}
+	existing, err := l.getLeaveType(ctx, leaveType.ID)
+	if err != nil {
+		return models.LeaveType{}, err
+	}
+	if err := l.scope.Check(ctx, adminID, existing.CompanyID); err != nil {
+		return models.LeaveType{}, err
+	}
+
+	leaveType.CompanyID = existing.CompanyID
+	leaveType.Code = existing.Code
+	leaveType.CreatedAt = existing.CreatedAt
+	leaveType.UpdatedAt = time.Now()
+	if leaveType.Accrual == "" {
+		leaveType.Accrual = existing.Accrual
+	}
+
+	updatedLeaveType, err := l.repo.UpdateLeaveType(ctx, leaveType)
+	if err != nil {
+		return models.LeaveType{}, err
+	}
+	return updatedLeaveType, nil
+}
+
+func (l *leaveServiceImpl) DeleteLeaveType(ctx context.Context, adminID uint64, id uint64) error {
+	existing, err := l.getLeaveType(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := l.scope.Check(ctx, adminID, existing.CompanyID); err != nil {
+		return err
+	}
+	return l.repo.DeleteLeaveType(ctx, id)
+}
+
+func (l *leaveServiceImpl) GetLeaveBalances(ctx context.Context, userID uint64, year int) ([]models.LeaveBalance, error) {
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return []models.LeaveBalance{}, err
+	}
+	company, err := l.repo.GetCompanyByID(ctx, user.CompanyID)
+	if err != nil {
+		return []models.LeaveBalance{}, err
+	}
+	today := company.Today()
+	if year == 0 {
+		year = today.Year()
+	}
+
+	leaveTypes, err := l.repo.GetLeaveTypes(ctx, user.CompanyID)
+	if err != nil {
+		return []models.LeaveBalance{}, err
+	}
+	asOf := balanceDate(year, today)
+	balances := []models.LeaveBalance{}
+	for _, leaveType := range leaveTypes {
+		if !leaveType.RequiresBalance {
+			continue
+		}
+		balance, err := l.ensureBalance(ctx, userID, leaveType, year)
+		if err != nil {
+			return []models.LeaveBalance{}, err
+		}
+		balances = append(balances, withAvailable(balance, leaveType, asOf))
+	}
+	return balances, nil
+}
+
+func (l *leaveServiceImpl) CreateLeaveRequest(ctx context.Context, userID uint64, leaveRequest models.LeaveRequestCreate) (models.LeaveRequest, error) {
+	if err := leaveRequest.ValidateLeaveRequest(); err != nil {
+		return models.LeaveRequest{}, err
+	}
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	leaveType, err := l.getLeaveType(ctx, leaveRequest.LeaveTypeID)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	if leaveType.CompanyID != user.CompanyID {
+		return models.LeaveRequest{}, errors.New("leave type must belong to the company of user")
+	}
+
+	days := models.WorkingDays(leaveRequest.StartDate, leaveRequest.EndDate)
+	if days == 0 {
+		return models.LeaveRequest{}, errors.New("leave must contain at least one working day")
+	}
+
+	// whole request is charged to one yearly balance
+	year := leaveRequest.StartDate.Year()
+	if leaveRequest.EndDate.Year() != year {
+		return models.LeaveRequest{}, errors.New("leave cannot span two years, split the request")
+	}
+	balance, err := l.ensureBalance(ctx, userID, leaveType, year)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	var credit *float64
+	if leaveType.RequiresBalance {
+		// monthly accrual only count what is earned by the month the leave starts
+		total := accruedEntitlement(balance.Entitled, leaveType.Accrual, leaveRequest.StartDate) + balance.CarriedOver
+		credit = &total
+	}
+
+	request := models.LeaveRequest{
+		UserID:      userID,
+		CompanyID:   user.CompanyID,
+		LeaveTypeID: leaveType.ID,
+		StartDate:   leaveRequest.StartDate,
+		EndDate:     leaveRequest.EndDate,
+		Days:        days,
+		Reason:      leaveRequest.Reason,
+		Status:      models.LEAVE_STATUS_PENDING,
+	}
+	createdRequest := models.LeaveRequest{}
+	err = l.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		createdRequest, err = l.repo.CreateLeaveRequest(ctx, request, credit)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, l.outbox, models.EVENT_LEAVE_REQUESTED, &createdRequest.CompanyID, createdRequest)
+	})
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	return createdRequest, nil
+}
+
+func (l *leaveServiceImpl) GetMyLeaveRequests(ctx context.Context, userID uint64) ([]models.LeaveRequest, error) {
+	requests, err := l.repo.GetLeaveRequestsByUserID(ctx, userID)
+	if err != nil {
+		return []models.LeaveRequest{}, err
+	}
+	return requests, nil
+}
+
+// GetPendingLeaveRequests return request waiting for the approver,
+// admin see the whole company and manager see the direct reports
+func (l *leaveServiceImpl) GetPendingLeaveRequests(ctx context.Context, approverID uint64) ([]models.LeaveRequest, error) {
+	approver, err := l.getUser(ctx, approverID)
+	if err != nil {
+		return []models.LeaveRequest{}, err
+	}
+	requests, err := l.repo.GetPendingLeaveRequests(ctx, approver.CompanyID, approver.ID, approver.Role == models.USER_ROLE_ADMIN)
+	if err != nil {
+		return []models.LeaveRequest{}, err
+	}
+	return requests, nil
+}
+
+func (l *leaveServiceImpl) ApproveLeaveRequest(ctx context.Context, approverID uint64, id uint64, note string) (models.LeaveRequest, error) {
+	request, err := l.getRequestForApprover(ctx, approverID, id)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	request = reviewed(request, models.LEAVE_STATUS_APPROVED, approverID, note)
+
+	approvedRequest := models.LeaveRequest{}
+	err = l.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		approvedRequest, err = l.repo.UpdateLeaveRequestStatus(ctx, request, models.LEAVE_STATUS_PENDING, -request.Days, request.Days)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, l.outbox, models.EVENT_LEAVE_APPROVED, &approvedRequest.CompanyID, approvedRequest)
+	})
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	return approvedRequest, nil
+}
+
+func (l *leaveServiceImpl) RejectLeaveRequest(ctx context.Context, approverID uint64, id uint64, note string) (models.LeaveRequest, error) {
+	request, err := l.getRequestForApprover(ctx, approverID, id)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	request = reviewed(request, models.LEAVE_STATUS_REJECTED, approverID, note)
+
+	rejectedRequest := models.LeaveRequest{}
+	err = l.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		rejectedRequest, err = l.repo.UpdateLeaveRequestStatus(ctx, request, models.LEAVE_STATUS_PENDING, -request.Days, 0)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, l.outbox, models.EVENT_LEAVE_REJECTED, &rejectedRequest.CompanyID, rejectedRequest)
+	})
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	return rejectedRequest, nil
+}
+
+// CancelLeaveRequest is done by the requester, approved leave can only be
+// cancelled before it starts and the used days are given back
+func (l *leaveServiceImpl) CancelLeaveRequest(ctx context.Context, userID uint64, id uint64) (models.LeaveRequest, error) {
+	request, err := l.getRequest(ctx, id)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	if request.UserID != userID {
+		return models.LeaveRequest{}, errors.New("only requester can cancel leave request")
+	}
+
+	fromStatus := request.Status
+	var pendingDelta, usedDelta float64
+	switch fromStatus {
+	case models.LEAVE_STATUS_PENDING:
+		pendingDelta = -request.Days
+	case models.LEAVE_STATUS_APPROVED:
+		company, err := l.repo.GetCompanyByID(ctx, request.CompanyID)
+		if err != nil {
+			return models.LeaveRequest{}, err
+		}
+		if !request.StartDate.After(company.Today().Time) {
+			return models.LeaveRequest{}, errors.New("leave already started cannot be cancelled")
+		}
+		usedDelta = -request.Days
+	default:
+		return models.LeaveRequest{}, errors.New("leave request is already " + fromStatus)
+	}
+	request.Status = models.LEAVE_STATUS_CANCELLED
+
+	cancelledRequest := models.LeaveRequest{}
+	err = l.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		cancelledRequest, err = l.repo.UpdateLeaveRequestStatus(ctx, request, fromStatus, pendingDelta, usedDelta)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, l.outbox, models.EVENT_LEAVE_CANCELLED, &cancelledRequest.CompanyID, cancelledRequest)
+	})
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	return cancelledRequest, nil
+}
+
+// GetLeaveCalendar return who is out in the company of user between from and to
+func (l *leaveServiceImpl) GetLeaveCalendar(ctx context.Context, userID uint64, filter models.LeaveCalendarFilter) ([]models.LeaveCalendarEntry, error) {
+	from, err := models.ParseDate(filter.From)
+	if err != nil {
+		return []models.LeaveCalendarEntry{}, err
+	}
+	to, err := models.ParseDate(filter.To)
+	if err != nil {
+		return []models.LeaveCalendarEntry{}, err
+	}
+	if to.Before(from.Time) {
+		return []models.LeaveCalendarEntry{}, errors.New("to date cannot be before from date")
+	}
+	user, err := l.getUser(ctx, userID)
+	if err != nil {
+		return []models.LeaveCalendarEntry{}, err
+	}
+
+	statuses := []string{models.LEAVE_STATUS_APPROVED}
+	if filter.IncludePending {
+		statuses = append(statuses, models.LEAVE_STATUS_PENDING)
+	}
+	entries, err := l.repo.GetLeaveCalendar(ctx, user.CompanyID, from, to, filter.DepartmentID, statuses)
+	if err != nil {
+		return []models.LeaveCalendarEntry{}, err
+	}
+	return entries, nil
+}
+
+func (l *leaveServiceImpl) getUser(ctx context.Context, userID uint64) (models.User, error) {
+	user, err := l.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.ID == 0 {
+		return models.User{}, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (l *leaveServiceImpl) getLeaveType(ctx context.Context, id uint64) (models.LeaveType, error) {
+	leaveType, err := l.repo.GetLeaveTypeByID(ctx, id)
+	if err != nil {
+		return models.LeaveType{}, err
+	}
+	if leaveType.ID == 0 {
+		return models.LeaveType{}, errors.New("leave type not found")
+	}
+	return leaveType, nil
+}
+
+func (l *leaveServiceImpl) getRequest(ctx context.Context, id uint64) (models.LeaveRequest, error) {
+	request, err := l.repo.GetLeaveRequestByID(ctx, id)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	if request.ID == 0 {
+		return models.LeaveRequest{}, errors.New("leave request not found")
+	}
+	return request, nil
+}
+
+// getRequestForApprover allow the manager of requester, or admin of the same company
+func (l *leaveServiceImpl) getRequestForApprover(ctx context.Context, approverID uint64, id uint64) (models.LeaveRequest, error) {
+	request, err := l.getRequest(ctx, id)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	if request.Status != models.LEAVE_STATUS_PENDING {
+		return models.LeaveRequest{}, errors.New("leave request is already " + request.Status)
+	}
+	if request.UserID == approverID {
+		return models.LeaveRequest{}, errors.New("cannot review own leave request")
+	}
+
+	approver, err := l.getUser(ctx, approverID)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	requester, err := l.getUser(ctx, request.UserID)
+	if err != nil {
+		return models.LeaveRequest{}, err
+	}
+	isManager := requester.ManagerID != nil && *requester.ManagerID == approverID
+	isCompanyAdmin := false
+	if approver.Role == models.USER_ROLE_ADMIN {
+		inGroup, err := l.scope.InGroup(ctx, approver.CompanyID, request.CompanyID)
+		if err != nil {
+			return models.LeaveRequest{}, err
+		}
+		isCompanyAdmin = inGroup
+	}
+	if !isManager && !isCompanyAdmin {
+		return models.LeaveRequest{}, errors.New("only manager or company admin can review leave request")
+	}
+	return request, nil
+}
+
+// ensureBalance create the balance of the year on first use,
+// the remaining of last year is carried over up to the carry over max
+func (l *leaveServiceImpl) ensureBalance(ctx context.Context, userID uint64, leaveType models.LeaveType, year int) (models.LeaveBalance, error) {
+	balance, err := l.repo.GetLeaveBalance(ctx, userID, leaveType.ID, year)
+	if err != nil {
+		return models.LeaveBalance{}, err
+	}
+	if balance.ID != 0 {
+		return balance, nil
+	}
+
+	carriedOver := 0.0
+	if leaveType.CarryOverMax > 0 {
+		lastYear, err := l.repo.GetLeaveBalance(ctx, userID, leaveType.ID, year-1)
+		if err != nil {
+			return models.LeaveBalance{}, err
+		}
+		if lastYear.ID != 0 {
+			remaining := lastYear.Entitled + lastYear.CarriedOver - lastYear.Used - lastYear.Pending
+			carriedOver = math.Max(0, math.Min(remaining, leaveType.CarryOverMax))
+		}
+	}
+
+	err = l.repo.CreateLeaveBalance(ctx, models.LeaveBalance{
+		UserID:      userID,
+		LeaveTypeID: leaveType.ID,
+		Year:        year,
+		Entitled:    leaveType.YearlyEntitlement,
+		CarriedOver: carriedOver,
+	})
+	if err != nil {
+		return models.LeaveBalance{}, err
+	}
+	return l.repo.GetLeaveBalance(ctx, userID, leaveType.ID, year)
+}
+
+// balanceDate is today of company for the running year, and the last day for past year
+func balanceDate(year int, today models.Date) models.Date {
+	if year < today.Year() {
+		return models.NewDate(time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC))
+	}
+	return today
+}
+
+// accruedEntitlement is the entitlement earned by the month of asOf, rounded down to half day
+func accruedEntitlement(entitled float64, accrual string, asOf models.Date) float64 {
+	if accrual != models.LEAVE_ACCRUAL_MONTHLY {
+		return entitled
+	}
+	earned := entitled * float64(asOf.Month()) / 12
+	return math.Floor(earned*2) / 2
+}
+
+func withAvailable(balance models.LeaveBalance, leaveType models.LeaveType, asOf models.Date) models.LeaveBalance {
+	balance.Accrued = accruedEntitlement(balance.Entitled, leaveType.Accrual, asOf)
+	balance.Available = balance.Accrued + balance.CarriedOver - balance.Used - balance.Pending
+	return balance
+}
+
+func reviewed(request models.LeaveRequest, status string, reviewerID uint64, note string) models.LeaveRequest {
+	now := time.Now()
+	request.Status = status
+	request.ReviewedBy = &reviewerID
+	request.ReviewedAt = &now
+	request.ReviewNote = note
+	return request
+}
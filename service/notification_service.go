@@ -0,0 +1,363 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/notify"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+// stream of slow client drop notification instead of blocking the dispatcher
+const notificationStreamBuffer = 16
+
+type NotificationService interface {
+	GetNotifications(ctx context.Context, userID uint64, filter models.NotificationFilter) ([]models.Notification, error)
+	GetUnreadCount(ctx context.Context, userID uint64) (models.NotificationCount, error)
+	SetRead(ctx context.Context, userID uint64, id uint64, read bool) (models.Notification, error)
+	// MarkRead mark notification read in bulk, every unread notification when ids is empty
+	MarkRead(ctx context.Context, userID uint64, request models.NotificationReadRequest) (models.NotificationCount, error)
+
+	GetPreference(ctx context.Context, userID uint64) (models.NotificationPreference, error)
+	SavePreference(ctx context.Context, userID uint64, preference models.NotificationPreference) (models.NotificationPreference, error)
+
+	// template saved by company admin, type and locale without saved template use the default
+	GetTemplates(ctx context.Context, filter models.NotificationTemplateFilter, adminID uint64) ([]models.NotificationTemplate, error)
+	SaveTemplate(ctx context.Context, template models.NotificationTemplate, adminID uint64) (models.NotificationTemplate, error)
+	DeleteTemplate(ctx context.Context, id uint64, adminID uint64) error
+
+	// Notify render template in the locale of user company and send it to the channel chosen by user
+	Notify(ctx context.Context, userID uint64, notificationType string, eventID *uint64, data map[string]any) error
+	// HandleEvent turn domain event into notification, it is subscriber of event bus
+	HandleEvent(ctx context.Context, event models.DomainEvent) error
+
+	// Subscribe return channel receiving new in-app notification of user until cancel is called.
+	// stream only receive notification created by this process
+	Subscribe(userID uint64) (<-chan models.Notification, func())
+	// CloseStreams close every open stream so the client reconnect to another instance on shutdown
+	CloseStreams()
+}
+
+type notificationServiceImpl struct {
+	repo  repository.NotificationQuery
+	scope CompanyScope
+	email notify.Sender
+	chat  notify.Sender
+
+	mu      sync.Mutex
+	streams map[uint64]map[chan models.Notification]struct{}
+}
+
+// NewNotificationService take nil email or chat sender when the channel is not configured
+func NewNotificationService(repo repository.NotificationQuery, scope CompanyScope, email notify.Sender, chat notify.Sender) NotificationService {
+	return &notificationServiceImpl{
+		repo:    repo,
+		scope:   scope,
+		email:   email,
+		chat:    chat,
+		streams: map[uint64]map[chan models.Notification]struct{}{},
+	}
+}
+
+func (n *notificationServiceImpl) GetNotifications(ctx context.Context, userID uint64, filter models.NotificationFilter) ([]models.Notification, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = models.DEFAULT_NOTIFICATION_LIMIT
+	}
+	if filter.Limit > models.MAX_NOTIFICATION_LIMIT {
+		filter.Limit = models.MAX_NOTIFICATION_LIMIT
+	}
+	return n.repo.GetNotifications(ctx, userID, filter)
+}
+
+func (n *notificationServiceImpl) GetUnreadCount(ctx context.Context, userID uint64) (models.NotificationCount, error) {
+	unread, err := n.repo.CountUnread(ctx, userID)
+	if err != nil {
+		return models.NotificationCount{}, err
+	}
+	return models.NotificationCount{Unread: unread}, nil
+}
+
+func (n *notificationServiceImpl) SetRead(ctx context.Context, userID uint64, id uint64, read bool) (models.Notification, error) {
+	notification, err := n.repo.GetNotificationByID(ctx, id)
+	if err != nil {
+		return models.Notification{}, err
+	}
+	// notification of other user is hidden
+	if notification.ID == 0 || notification.UserID != userID {
+		return models.Notification{}, errors.New("notification not found")
+	}
+	if _, err := n.repo.SetRead(ctx, userID, []uint64{id}, read); err != nil {
+		return models.Notification{}, err
+	}
+	return n.repo.GetNotificationByID(ctx, id)
+}
+
+func (n *notificationServiceImpl) MarkRead(ctx context.Context, userID uint64, request models.NotificationReadRequest) (models.NotificationCount, error) {
+	if _, err := n.repo.SetRead(ctx, userID, request.IDs, true); err != nil {
+		return models.NotificationCount{}, err
+	}
+	return n.GetUnreadCount(ctx, userID)
+}
+
+func (n *notificationServiceImpl) GetPreference(ctx context.Context, userID uint64) (models.NotificationPreference, error) {
+	preference, err := n.repo.GetPreference(ctx, userID)
+	if err != nil {
+		return models.NotificationPreference{}, err
+	}
+	if preference.UserID == 0 {
+		return models.DefaultNotificationPreference(userID), nil
+	}
+	return preference, nil
+}
+
+func (n *notificationServiceImpl) SavePreference(ctx context.Context, userID uint64, preference models.NotificationPreference) (models.NotificationPreference, error) {
+	if err := preference.ValidatePreference(); err != nil {
+		return models.NotificationPreference{}, err
+	}
+	if preference.Email && n.email == nil {
+		return models.NotificationPreference{}, errors.New("email channel is not configured")
+	}
+	if preference.Chat && n.chat == nil {
+		return models.NotificationPreference{}, errors.New("chat channel is not configured")
+	}
+	preference.UserID = userID
+	return n.repo.SavePreference(ctx, preference)
+}
+
+func (n *notificationServiceImpl) GetTemplates(ctx context.Context, filter models.NotificationTemplateFilter, adminID uint64) ([]models.NotificationTemplate, error) {
+	if err := n.scope.Check(ctx, adminID, filter.CompanyID); err != nil {
+		return []models.NotificationTemplate{}, err
+	}
+	return n.repo.GetTemplates(ctx, filter.CompanyID)
+}
+
+func (n *notificationServiceImpl) SaveTemplate(ctx context.Context, template models.NotificationTemplate, adminID uint64) (models.NotificationTemplate, error) {
+	if err := template.ValidateTemplate(); err != nil {
+		return models.NotificationTemplate{}, err
+	}
+	if err := n.scope.Check(ctx, adminID, template.CompanyID); err != nil {
+		return models.NotificationTemplate{}, err
+	}
+	// broken template is refused now instead of failing every notification later
+	if _, err := renderNotification(template, map[string]any{}); err != nil {
+		return models.NotificationTemplate{}, errors.New("invalid template: " + err.Error())
+	}
+	template.ID = 0
+	return n.repo.SaveTemplate(ctx, template)
+}
+
+func (n *notificationServiceImpl) DeleteTemplate(ctx context.Context, id uint64, adminID uint64) error {
+	template, err := n.repo.GetTemplateByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if template.ID == 0 {
+		return errors.New("notification template not found")
+	}
+	if err := n.scope.Check(ctx, adminID, template.CompanyID); err != nil {
+		return err
+	}
+	return n.repo.DeleteTemplate(ctx, id)
+}
+
+func (n *notificationServiceImpl) Notify(ctx context.Context, userID uint64, notificationType string, eventID *uint64, data map[string]any) error {
+	user, err := n.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	// user removed before the event is handled
+	if user.ID == 0 {
+		return nil
+	}
+	message, err := n.render(ctx, user, notificationType, data)
+	if err != nil {
+		return err
+	}
+	preference, err := n.GetPreference(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if preference.InApp {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		notification, created, err := n.repo.CreateNotification(ctx, models.Notification{
+			UserID:  userID,
+			Type:    notificationType,
+			Title:   message.Title,
+			Body:    message.Body,
+			Data:    string(payload),
+			EventID: eventID,
+		})
+		if err != nil {
+			return err
+		}
+		// event delivered again, every channel is already sent
+		if !created {
+			return nil
+		}
+		n.push(notification)
+	}
+
+	// outside channel is best effort, retrying the event would repeat the in-app one
+	if preference.Email && n.email != nil && user.Email != "" {
+		if err := n.email.Send(ctx, user.Email, message); err != nil {
+			log.Println("cannot send notification email", userID, notificationType, err.Error())
+		}
+	}
+	if preference.Chat && n.chat != nil && preference.ChatAddress != "" {
+		if err := n.chat.Send(ctx, preference.ChatAddress, message); err != nil {
+			log.Println("cannot send notification chat", userID, notificationType, err.Error())
+		}
+	}
+	return nil
+}
+
+// render use template of user company in its locale, then the default template
+func (n *notificationServiceImpl) render(ctx context.Context, user models.User, notificationType string, data map[string]any) (notify.Message, error) {
+	locale := ""
+	if user.CompanyID != 0 {
+		company, err := n.repo.GetCompanyByID(ctx, user.CompanyID)
+		if err != nil {
+			return notify.Message{}, err
+		}
+		locale = company.Locale
+
+		locales := models.NotificationLocales(locale)
+		template, err := n.repo.FindTemplate(ctx, user.CompanyID, notificationType, locales)
+		if err != nil {
+			return notify.Message{}, err
+		}
+		if template.ID != 0 {
+			return renderNotification(template, data)
+		}
+	}
+
+	defaults, ok := models.DefaultNotificationTemplates[notificationType]
+	if !ok {
+		return notify.Message{}, errors.New("notification type is not supported: " + notificationType)
+	}
+	for _, candidate := range models.NotificationLocales(locale) {
+		if template, ok := defaults[candidate]; ok {
+			return renderNotification(template, data)
+		}
+	}
+	return notify.Message{}, errors.New("notification template not found: " + notificationType)
+}
+
+func renderNotification(notificationTemplate models.NotificationTemplate, data map[string]any) (notify.Message, error) {
+	render := func(text string) (string, error) {
+		tmpl, err := template.New("notification").Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, data); err != nil {
+			return "", err
+		}
+		// missing value is rendered as "<no value>" by map data
+		return strings.TrimSpace(strings.ReplaceAll(out.String(), "<no value>", "")), nil
+	}
+	title, err := render(notificationTemplate.Title)
+	if err != nil {
+		return notify.Message{}, err
+	}
+	body, err := render(notificationTemplate.Body)
+	if err != nil {
+		return notify.Message{}, err
+	}
+	return notify.Message{Title: title, Body: body}, nil
+}
+
+func (n *notificationServiceImpl) HandleEvent(ctx context.Context, event models.DomainEvent) error {
+	data := map[string]any{}
+	if err := json.Unmarshal([]byte(event.Payload), &data); err != nil {
+		return err
+	}
+	recipientID := uint64(0)
+	switch event.Name {
+	case models.EVENT_USER_CREATED, models.EVENT_USER_PASSWORD_CHANGED:
+		recipientID = payloadID(data, "id")
+	case models.EVENT_LEAVE_APPROVED, models.EVENT_LEAVE_REJECTED, models.EVENT_ASSIGNMENT_CREATED:
+		recipientID = payloadID(data, "user_id")
+	case models.EVENT_LEAVE_REQUESTED, models.EVENT_LEAVE_CANCELLED:
+		// manager approve the request, request of user without manager is handled by admin.
+		// cancelled request is sent to the same manager
+		requester, err := n.repo.GetUserByID(ctx, payloadID(data, "user_id"))
+		if err != nil {
+			return err
+		}
+		if requester.ManagerID == nil {
+			return nil
+		}
+		recipientID = *requester.ManagerID
+		data["requester_name"] = strings.TrimSpace(requester.FirstName + " " + requester.LastName)
+	default:
+		return nil
+	}
+	if recipientID == 0 {
+		return nil
+	}
+	return n.Notify(ctx, recipientID, event.Name, &event.ID, data)
+}
+
+// payloadID read id field of json payload, json number is decoded as float64
+func payloadID(data map[string]any, key string) uint64 {
+	id, ok := data[key].(float64)
+	if !ok {
+		return 0
+	}
+	return uint64(id)
+}
+
+func (n *notificationServiceImpl) Subscribe(userID uint64) (<-chan models.Notification, func()) {
+	stream := make(chan models.Notification, notificationStreamBuffer)
+	n.mu.Lock()
+	if n.streams[userID] == nil {
+		n.streams[userID] = map[chan models.Notification]struct{}{}
+	}
+	n.streams[userID][stream] = struct{}{}
+	n.mu.Unlock()
+
+	cancel := func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.streams[userID], stream)
+		if len(n.streams[userID]) == 0 {
+			delete(n.streams, userID)
+		}
+	}
+	return stream, cancel
+}
+
+func (n *notificationServiceImpl) CloseStreams() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, streams := range n.streams {
+		for stream := range streams {
+			close(stream)
+		}
+	}
+	n.streams = map[uint64]map[chan models.Notification]struct{}{}
+}
+
+// push send notification to every open stream of the user
+func (n *notificationServiceImpl) push(notification models.Notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for stream := range n.streams[notification.UserID] {
+		select {
+		case stream <- notification:
+		default:
+		}
+	}
+}
@@ -0,0 +1,827 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/statutory"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type PayrollService interface {
+	// admin only, scoped to the company of the admin
+	GetSalaryComponents(ctx context.Context, adminID uint64) ([]models.SalaryComponent, error)
+	CreateSalaryComponent(ctx context.Context, adminID uint64, component models.SalaryComponent) (models.SalaryComponent, error)
+	UpdateSalaryComponent(ctx context.Context, adminID uint64, component models.SalaryComponent) (models.SalaryComponent, error)
+	DeleteSalaryComponent(ctx context.Context, adminID uint64, id uint64) error
+
+	GetEmployeeSalary(ctx context.Context, adminID uint64, employeeID uint64) (models.EmployeeSalary, error)
+	SaveEmployeeSalary(ctx context.Context, adminID uint64, employeeID uint64, salary models.EmployeeSalaryRequest) (models.EmployeeSalary, error)
+
+	// RunPayroll calculate payslip of every paid employee of the period,
+	// running the same period again replace the draft result
+	RunPayroll(ctx context.Context, adminID uint64, runRequest models.PayrollRunRequest) (models.PayrollRun, error)
+	GetPayrollRuns(ctx context.Context, adminID uint64) ([]models.PayrollRun, error)
+	GetPayrollRunByID(ctx context.Context, adminID uint64, id uint64) (models.PayrollRun, error)
+	ApprovePayrollRun(ctx context.Context, adminID uint64, id uint64) (models.PayrollRun, error)
+
+	// payslip of approved run for the employee record of login user
+	GetMyPayslips(ctx context.Context, userID uint64) ([]models.Payslip, error)
+	// WritePayslipPDF is allowed for the owner of payslip or admin of the company
+	WritePayslipPDF(ctx context.Context, userID uint64, id uint64, w io.Writer) error
+
+	// GetCompaRatioReport compare base salary with salary band per position in the company of admin
+	GetCompaRatioReport(ctx context.Context, adminID uint64) ([]models.CompaRatioReport, error)
+
+	// GetTaxReconciliation compare yearly PPh 21 with withheld tax of every employee in the company of admin
+	GetTaxReconciliation(ctx context.Context, adminID uint64, year int) ([]models.TaxReconciliation, error)
+	GetForm1721A1(ctx context.Context, adminID uint64, employeeID uint64, year int) (models.Form1721A1, error)
+}
+
+type payrollServiceImpl struct {
+	repo          repository.PayrollQuery
+	scope         CompanyScope
+	statutoryRepo repository.StatutoryQuery
+	engine        *statutory.Engine
+}
+
+func NewPayrollService(repo repository.PayrollQuery, scope CompanyScope, statutoryRepo repository.StatutoryQuery, engine *statutory.Engine) PayrollService {
+	return &payrollServiceImpl{repo: repo, scope: scope, statutoryRepo: statutoryRepo, engine: engine}
+}
+
+func (p *payrollServiceImpl) GetSalaryComponents(ctx context.Context, adminID uint64) ([]models.SalaryComponent, error) {
+	admin, err := p.getUser(ctx, adminID)
+	if err != nil {
+		return []models.SalaryComponent{}, err
+	}
+	components, err := p.repo.GetSalaryComponents(ctx, admin.CompanyID)
+	if err != nil {
+		return []models.SalaryComponent{}, err
+	}
+	return components, nil
+}
+
+func (p *payrollServiceImpl) CreateSalaryComponent(ctx context.Context, adminID uint64, component models.SalaryComponent) (models.SalaryComponent, error) {
+	if err := component.ValidateSalaryComponent(); err != nil {
+		return models.SalaryComponent{}, err
+	}
+	if err := p.scope.Check(ctx, adminID, component.CompanyID); err != nil {
+		return models.SalaryComponent{}, err
+	}
+	existing, err := p.repo.GetSalaryComponentByCode(ctx, component.CompanyID, component.Code)
+	if err != nil {
+		return models.SalaryComponent{}, err
+	}
+	if existing.ID != 0 {
+		return models.SalaryComponent{}, errors.New("component code already exists")
+	}
+
+	createdComponent, err := p.repo.CreateSalaryComponent(ctx, component)
+	if err != nil {
+		return models.SalaryComponent{}, err
+	}
+	return createdComponent, nil
+}
+
+// UpdateSalaryComponent only change name and taxable, type and code are fixed
+func (p *payrollServiceImpl) UpdateSalaryComponent(ctx context.Context, adminID uint64, component models.SalaryComponent) (models.SalaryComponent, error) {
+	existing, err := p.getSalaryComponent(ctx, component.ID)
+	if err != nil {
+		return models.SalaryComponent{}, err
+	}
+	if err := p.scope.Check(ctx, adminID, existing.CompanyID); err != nil {
+		return models.SalaryComponent{}, err
+	}
+
+	existing.Name = component.Name
+	existing.Taxable = component.Taxable
+	existing.UpdatedAt = time.Now()
+	updatedComponent, err := p.repo.UpdateSalaryComponent(ctx, existing)
+	if err != nil {
+		return models.SalaryComponent{}, err
+	}
+	return updatedComponent, nil
+}
+
+func (p *payrollServiceImpl) DeleteSalaryComponent(ctx context.Context, adminID uint64, id uint64) error {
+	existing, err := p.getSalaryComponent(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := p.scope.Check(ctx, adminID, existing.CompanyID); err != nil {
+		return err
+	}
+	return p.repo.DeleteSalaryComponent(ctx, id)
+}
+
+func (p *payrollServiceImpl) GetEmployeeSalary(ctx context.Context, adminID uint64, employeeID uint64) (models.EmployeeSalary, error) {
+	employee, err := p.getEmployee(ctx, employeeID)
+	if err != nil {
+		return models.EmployeeSalary{}, err
+	}
+	if err := p.scope.Check(ctx, adminID, employee.CompanyID); err != nil {
+		return models.EmployeeSalary{}, err
+	}
+
+	salary, err := p.repo.GetEmployeeSalary(ctx, employeeID)
+	if err != nil {
+		return models.EmployeeSalary{}, err
+	}
+	if salary.ID == 0 {
+		return models.EmployeeSalary{}, errors.New("salary not found")
+	}
+	return salary, nil
+}
+
+func (p *payrollServiceImpl) SaveEmployeeSalary(ctx context.Context, adminID uint64, employeeID uint64, salaryRequest models.EmployeeSalaryRequest) (models.EmployeeSalary, error) {
+	if err := salaryRequest.ValidateEmployeeSalary(); err != nil {
+		return models.EmployeeSalary{}, err
+	}
+	employee, err := p.getEmployee(ctx, employeeID)
+	if err != nil {
+		return models.EmployeeSalary{}, err
+	}
+	if err := p.scope.Check(ctx, adminID, employee.CompanyID); err != nil {
+		return models.EmployeeSalary{}, err
+	}
+
+	for _, component := range salaryRequest.Components {
+		existing, err := p.getSalaryComponent(ctx, component.ComponentID)
+		if err != nil {
+			return models.EmployeeSalary{}, err
+		}
+		if existing.CompanyID != employee.CompanyID {
+			return models.EmployeeSalary{}, errors.New("component must belong to the company of employee")
+		}
+	}
+
+	// salary without currency is paid in currency of company
+	if salaryRequest.Currency == "" {
+		company, err := p.repo.GetCompanyByID(ctx, employee.CompanyID)
+		if err != nil {
+			return models.EmployeeSalary{}, err
+		}
+		salaryRequest.Currency = company.Currency
+	}
+	salary := models.EmployeeSalary{
+		EmployeeID: employeeID,
+		Currency:   models.NormalizeCurrency(salaryRequest.Currency),
+		BaseSalary: models.RoundMoney(salaryRequest.BaseSalary),
+		Components: salaryRequest.Components,
+	}
+	warning, err := p.checkSalaryBand(ctx, employee, &salary, strings.TrimSpace(salaryRequest.OverrideReason))
+	if err != nil {
+		return models.EmployeeSalary{}, err
+	}
+	savedSalary, err := p.repo.SaveEmployeeSalary(ctx, salary)
+	if err != nil {
+		return models.EmployeeSalary{}, err
+	}
+	savedSalary.BandWarning = warning
+	return savedSalary, nil
+}
+
+// checkSalaryBand compare base salary with the band of position of employee.
+// salary outside of band is saved with warning, or rejected when the band says so,
+// override reason accept the salary anyway and is kept with the salary.
+// employee without login account has no position, so there is no band to check
+func (p *payrollServiceImpl) checkSalaryBand(ctx context.Context, employee models.Employee, salary *models.EmployeeSalary, overrideReason string) (string, error) {
+	if employee.UserID == nil {
+		return "", nil
+	}
+	user, err := p.repo.GetUserByID(ctx, *employee.UserID)
+	if err != nil {
+		return "", err
+	}
+	if user.ID == 0 {
+		return "", nil
+	}
+	band, err := p.repo.GetSalaryBand(ctx, user.PositionID, salary.Currency)
+	if err != nil {
+		return "", err
+	}
+	if band.ID == 0 || band.Contains(salary.BaseSalary) {
+		return "", nil
+	}
+
+	warning := "base salary is outside of salary band " + salary.Currency + " " +
+		formatMoney(band.MinSalary) + " - " + formatMoney(band.MaxSalary)
+	if overrideReason != "" {
+		salary.BandOverrideReason = overrideReason
+		return warning + ", accepted by override", nil
+	}
+	if band.Enforcement == models.SALARY_BAND_ENFORCEMENT_REJECT {
+		return "", errors.New(warning + ", override reason is required")
+	}
+	return warning, nil
+}
+
+func (p *payrollServiceImpl) GetCompaRatioReport(ctx context.Context, adminID uint64) ([]models.CompaRatioReport, error) {
+	admin, err := p.getUser(ctx, adminID)
+	if err != nil {
+		return []models.CompaRatioReport{}, err
+	}
+	employees, err := p.repo.GetCompaRatioEmployees(ctx, admin.CompanyID)
+	if err != nil {
+		return []models.CompaRatioReport{}, err
+	}
+
+	positionIDs := []uint64{}
+	for i, employee := range employees {
+		if i == 0 || employees[i-1].PositionID != employee.PositionID {
+			positionIDs = append(positionIDs, employee.PositionID)
+		}
+	}
+	positions, err := p.repo.GetPositionsByIDs(ctx, positionIDs)
+	if err != nil {
+		return []models.CompaRatioReport{}, err
+	}
+	positionByID := map[uint64]models.Position{}
+	for _, position := range positions {
+		positionByID[position.ID] = position
+	}
+	bands, err := p.repo.GetSalaryBandsByPositionIDs(ctx, positionIDs)
+	if err != nil {
+		return []models.CompaRatioReport{}, err
+	}
+	type bandKey struct {
+		positionID uint64
+		currency   string
+	}
+	bandByKey := map[bandKey]models.SalaryBand{}
+	for _, band := range bands {
+		bandByKey[bandKey{band.PositionID, band.Currency}] = band
+	}
+
+	// employees are ordered by position and currency, so each pair is one group
+	reports := []models.CompaRatioReport{}
+	for start := 0; start < len(employees); {
+		end := start
+		for end < len(employees) && employees[end].PositionID == employees[start].PositionID &&
+			employees[end].Currency == employees[start].Currency {
+			end++
+		}
+		position := positionByID[employees[start].PositionID]
+		report := models.CompaRatioReport{
+			PositionID:   position.ID,
+			PositionName: position.PositionName,
+			PositionCode: position.PositionCode,
+			JobGrade:     position.JobGrade,
+			Currency:     employees[start].Currency,
+			Headcount:    end - start,
+			Employees:    employees[start:end],
+		}
+		band, hasBand := bandByKey[bandKey{position.ID, report.Currency}]
+		if hasBand {
+			report.Band = &band
+		}
+
+		totalSalary, totalRatio := 0.0, 0.0
+		for i := range report.Employees {
+			employee := &report.Employees[i]
+			totalSalary += employee.BaseSalary
+			if !hasBand {
+				continue
+			}
+			employee.CompaRatio = band.CompaRatio(employee.BaseSalary)
+			totalRatio += employee.CompaRatio
+			if i == 0 || employee.CompaRatio < report.MinCompaRatio {
+				report.MinCompaRatio = employee.CompaRatio
+			}
+			if employee.CompaRatio > report.MaxCompaRatio {
+				report.MaxCompaRatio = employee.CompaRatio
+			}
+			if employee.BaseSalary < band.MinSalary {
+				report.BelowBand++
+			}
+			if employee.BaseSalary > band.MaxSalary {
+				report.AboveBand++
+			}
+		}
+		report.AverageSalary = models.RoundMoney(totalSalary / float64(report.Headcount))
+		if hasBand {
+			report.AverageCompaRatio = models.RoundRatio(totalRatio / float64(report.Headcount))
+		}
+		reports = append(reports, report)
+		start = end
+	}
+	return reports, nil
+}
+
+func (p *payrollServiceImpl) RunPayroll(ctx context.Context, adminID uint64, runRequest models.PayrollRunRequest) (models.PayrollRun, error) {
+	start, next, err := models.ParseMonth(runRequest.Period)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	if err := statutory.CheckPeriod(start); err != nil {
+		return models.PayrollRun{}, err
+	}
+	if err := p.scope.Check(ctx, adminID, runRequest.CompanyID); err != nil {
+		return models.PayrollRun{}, err
+	}
+	periodStart := models.NewDate(start)
+	periodEnd := models.NewDate(next.AddDate(0, 0, -1))
+	company, err := p.repo.GetCompanyByID(ctx, runRequest.CompanyID)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	if company.ID == 0 {
+		return models.PayrollRun{}, errors.New("company not found")
+	}
+
+	employees, err := p.repo.GetPayrollEmployees(ctx, runRequest.CompanyID, periodStart, periodEnd)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	employeeIDs := make([]uint64, len(employees))
+	for i, employee := range employees {
+		employeeIDs[i] = employee.ID
+	}
+	salaries, err := p.repo.GetEmployeeSalaries(ctx, employeeIDs)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	components, err := p.repo.GetSalaryComponents(ctx, runRequest.CompanyID)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	componentByID := map[uint64]models.SalaryComponent{}
+	for _, component := range components {
+		componentByID[component.ID] = component
+	}
+
+	rates, err := p.statutoryRepo.GetRateSet(ctx, periodStart)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	yearToDates, err := p.statutoryRepo.GetYearToDates(ctx, employeeIDs, periodStart)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+
+	run := models.PayrollRun{
+		CompanyID:  runRequest.CompanyID,
+		Period:     periodStart,
+		IncludeTHR: runRequest.IncludeTHR,
+		CreatedBy:  &adminID,
+	}
+	payslips := []models.Payslip{}
+	for _, employee := range employees {
+		// employee without salary structure is not paid by payroll
+		salary, ok := salaries[employee.ID]
+		if !ok {
+			continue
+		}
+		// run total and statutory rate are in currency of company, salary in other currency is not converted
+		if models.NormalizeCurrency(salary.Currency) != models.NormalizeCurrency(company.Currency) {
+			return models.PayrollRun{}, errors.New("employee " + employee.EmployeeNumber + ": salary currency must be " + company.Currency + ", the currency of company, not " + salary.Currency)
+		}
+		payslip := calculatePayslip(employee, salary, componentByID)
+		if runRequest.IncludeTHR {
+			addTHR(&payslip, employee, periodEnd)
+		}
+		if err := p.applyStatutory(&payslip, employee, run.Period, periodEnd, rates, yearToDates[employee.ID]); err != nil {
+			return models.PayrollRun{}, errors.New("employee " + employee.EmployeeNumber + ": " + err.Error())
+		}
+		payslips = append(payslips, payslip)
+
+		run.EmployeeCount++
+		run.GrossTotal += payslip.Gross
+		run.DeductionTotal += payslip.DeductionTotal
+		run.NetTotal += payslip.Net
+	}
+	run.GrossTotal = models.RoundMoney(run.GrossTotal)
+	run.DeductionTotal = models.RoundMoney(run.DeductionTotal)
+	run.NetTotal = models.RoundMoney(run.NetTotal)
+
+	savedRun, err := p.repo.SavePayrollRun(ctx, run, payslips)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	return savedRun, nil
+}
+
+func (p *payrollServiceImpl) GetPayrollRuns(ctx context.Context, adminID uint64) ([]models.PayrollRun, error) {
+	admin, err := p.getUser(ctx, adminID)
+	if err != nil {
+		return []models.PayrollRun{}, err
+	}
+	runs, err := p.repo.GetPayrollRuns(ctx, admin.CompanyID)
+	if err != nil {
+		return []models.PayrollRun{}, err
+	}
+	return runs, nil
+}
+
+func (p *payrollServiceImpl) GetPayrollRunByID(ctx context.Context, adminID uint64, id uint64) (models.PayrollRun, error) {
+	run, err := p.getPayrollRun(ctx, adminID, id)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	payslips, err := p.repo.GetPayslipsByRunID(ctx, run.ID)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	run.Payslips = payslips
+	return run, nil
+}
+
+// ApprovePayrollRun lock the run, it can not be run again after this
+func (p *payrollServiceImpl) ApprovePayrollRun(ctx context.Context, adminID uint64, id uint64) (models.PayrollRun, error) {
+	run, err := p.getPayrollRun(ctx, adminID, id)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	if run.Status != models.PAYROLL_STATUS_DRAFT {
+		return models.PayrollRun{}, errors.New("payroll run is already approved")
+	}
+	if run.EmployeeCount == 0 {
+		return models.PayrollRun{}, errors.New("payroll run has no payslip")
+	}
+
+	now := time.Now()
+	run.Status = models.PAYROLL_STATUS_APPROVED
+	run.ApprovedBy = &adminID
+	run.ApprovedAt = &now
+	approvedRun, err := p.repo.ApprovePayrollRun(ctx, run)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	return approvedRun, nil
+}
+
+func (p *payrollServiceImpl) GetMyPayslips(ctx context.Context, userID uint64) ([]models.Payslip, error) {
+	employee, err := p.repo.GetEmployeeByUserID(ctx, userID)
+	if err != nil {
+		return []models.Payslip{}, err
+	}
+	if employee.ID == 0 {
+		return []models.Payslip{}, errors.New("employee not found")
+	}
+	payslips, err := p.repo.GetPayslipsByEmployeeID(ctx, employee.ID)
+	if err != nil {
+		return []models.Payslip{}, err
+	}
+	return payslips, nil
+}
+
+func (p *payrollServiceImpl) WritePayslipPDF(ctx context.Context, userID uint64, id uint64, w io.Writer) error {
+	payslip, err := p.repo.GetPayslipByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if payslip.ID == 0 {
+		return errors.New("payslip not found")
+	}
+	run, err := p.repo.GetPayrollRunByID(ctx, payslip.PayrollRunID)
+	if err != nil {
+		return err
+	}
+	employee, err := p.repo.GetEmployeeByID(ctx, payslip.EmployeeID)
+	if err != nil {
+		return err
+	}
+	user, err := p.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	isOwner := employee.UserID != nil && *employee.UserID == userID && run.Status == models.PAYROLL_STATUS_APPROVED
+	if !isOwner {
+		if user.Role != models.USER_ROLE_ADMIN {
+			return errors.New("payslip is outside of your access")
+		}
+		if err := p.scope.Check(ctx, userID, run.CompanyID); err != nil {
+			return errors.New("payslip is outside of your access")
+		}
+	}
+
+	company, err := p.repo.GetCompanyByID(ctx, run.CompanyID)
+	if err != nil {
+		return err
+	}
+	return renderPayslipPDF(w, company, run, payslip)
+}
+
+func (p *payrollServiceImpl) getUser(ctx context.Context, userID uint64) (models.User, error) {
+	user, err := p.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.ID == 0 {
+		return models.User{}, errors.New("user not found")
+	}
+	return user, nil
+}
+
+func (p *payrollServiceImpl) getEmployee(ctx context.Context, id uint64) (models.Employee, error) {
+	employee, err := p.repo.GetEmployeeByID(ctx, id)
+	if err != nil {
+		return models.Employee{}, err
+	}
+	if employee.ID == 0 {
+		return models.Employee{}, errors.New("employee not found")
+	}
+	return employee, nil
+}
+
+func (p *payrollServiceImpl) getSalaryComponent(ctx context.Context, id uint64) (models.SalaryComponent, error) {
+	component, err := p.repo.GetSalaryComponentByID(ctx, id)
+	if err != nil {
+		return models.SalaryComponent{}, err
+	}
+	if component.ID == 0 {
+		return models.SalaryComponent{}, errors.New("salary component not found")
+	}
+	return component, nil
+}
+
+func (p *payrollServiceImpl) getPayrollRun(ctx context.Context, adminID uint64, id uint64) (models.PayrollRun, error) {
+	run, err := p.repo.GetPayrollRunByID(ctx, id)
+	if err != nil {
+		return models.PayrollRun{}, err
+	}
+	if run.ID == 0 {
+		return models.PayrollRun{}, errors.New("payroll run not found")
+	}
+	if err := p.scope.Check(ctx, adminID, run.CompanyID); err != nil {
+		return models.PayrollRun{}, err
+	}
+	return run, nil
+}
+
+// calculatePayslip snapshot salary of employee: gross is base plus allowance,
+// net is gross minus deduction
+func calculatePayslip(employee models.Employee, salary models.EmployeeSalary, componentByID map[uint64]models.SalaryComponent) models.Payslip {
+	payslip := models.Payslip{
+		EmployeeID:     employee.ID,
+		EmployeeNumber: employee.EmployeeNumber,
+		EmployeeName:   strings.TrimSpace(employee.FirstName + " " + employee.LastName),
+		BaseSalary:     salary.BaseSalary,
+		Lines:          models.PayslipLines{},
+	}
+	for _, salaryComponent := range salary.Components {
+		component, ok := componentByID[salaryComponent.ComponentID]
+		if !ok {
+			continue
+		}
+		payslip.Lines = append(payslip.Lines, models.PayslipLine{
+			Code:    component.Code,
+			Name:    component.Name,
+			Type:    component.Type,
+			Taxable: component.Taxable,
+			Amount:  salaryComponent.Amount,
+		})
+		if component.Type == models.SALARY_COMPONENT_ALLOWANCE {
+			payslip.AllowanceTotal += salaryComponent.Amount
+		} else {
+			payslip.DeductionTotal += salaryComponent.Amount
+		}
+	}
+	payslip.AllowanceTotal = models.RoundMoney(payslip.AllowanceTotal)
+	payslip.DeductionTotal = models.RoundMoney(payslip.DeductionTotal)
+	payslip.Gross = models.RoundMoney(payslip.BaseSalary + payslip.AllowanceTotal)
+	payslip.Net = models.RoundMoney(payslip.Gross - payslip.DeductionTotal)
+	return payslip
+}
+
+// addTHR add tunjangan hari raya: one month wage after 12 months of service,
+// prorated by month of service after one month, nothing before that
+func addTHR(payslip *models.Payslip, employee models.Employee, periodEnd models.Date) {
+	months := monthsBetween(employee.HireDate, periodEnd)
+	if months < 1 {
+		return
+	}
+	if months > 12 {
+		months = 12
+	}
+	amount := models.RoundMoney((payslip.BaseSalary + payslip.AllowanceTotal) * float64(months) / 12)
+	payslip.Lines = append(payslip.Lines, models.PayslipLine{
+		Code:    models.PAYSLIP_LINE_THR,
+		Name:    "THR",
+		Type:    models.SALARY_COMPONENT_ALLOWANCE,
+		Taxable: true,
+		Amount:  amount,
+	})
+	payslip.AllowanceTotal = models.RoundMoney(payslip.AllowanceTotal + amount)
+	payslip.Gross = models.RoundMoney(payslip.BaseSalary + payslip.AllowanceTotal)
+	payslip.Net = models.RoundMoney(payslip.Gross - payslip.DeductionTotal)
+}
+
+// applyStatutory add BPJS and PPh 21 from the statutory engine to payslip.
+// december or the month of termination is the final period, tax is reconciled for the year
+func (p *payrollServiceImpl) applyStatutory(payslip *models.Payslip, employee models.Employee, periodStart models.Date, periodEnd models.Date, rates statutory.RateSet, yearToDate statutory.YearToDate) error {
+	contributionWage := payslip.BaseSalary
+	taxableGross := payslip.BaseSalary
+	for _, line := range payslip.Lines {
+		if line.Type != models.SALARY_COMPONENT_ALLOWANCE {
+			continue
+		}
+		if line.Code != models.PAYSLIP_LINE_THR {
+			contributionWage += line.Amount
+		}
+		if line.Taxable {
+			taxableGross += line.Amount
+		}
+	}
+
+	terminated := employee.TerminationDate != nil && !employee.TerminationDate.After(periodEnd.Time)
+	finalPeriod := periodStart.Month() == time.December || terminated
+
+	result, err := p.engine.Calculate(statutory.Input{
+		Period:           periodStart.Time,
+		PTKPStatus:       employee.PtkpStatus,
+		ContributionWage: contributionWage,
+		TaxableGross:     taxableGross,
+		FinalPeriod:      finalPeriod,
+		YearToDate:       yearToDate,
+	}, rates)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range result.Lines {
+		lineType := models.SALARY_COMPONENT_DEDUCTION
+		if line.Kind == statutory.KIND_EMPLOYER {
+			lineType = models.PAYSLIP_LINE_EMPLOYER
+		} else {
+			payslip.DeductionTotal += line.Amount
+		}
+		payslip.Lines = append(payslip.Lines, models.PayslipLine{
+			Code:   line.Code,
+			Name:   line.Name,
+			Type:   lineType,
+			Amount: line.Amount,
+		})
+	}
+	payslip.DeductionTotal = models.RoundMoney(payslip.DeductionTotal)
+	payslip.Net = models.RoundMoney(payslip.Gross - payslip.DeductionTotal)
+	payslip.TaxableIncome = models.RoundMoney(result.TaxableIncome)
+	payslip.PensionContribution = models.RoundMoney(result.PensionContribution)
+	payslip.Tax = models.RoundMoney(result.Tax)
+	payslip.FinalPeriod = finalPeriod
+	return nil
+}
+
+// monthsBetween count full months from start until end
+func monthsBetween(start models.Date, end models.Date) int {
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+	if end.Day() < start.Day() {
+		months--
+	}
+	return months
+}
+
+func (p *payrollServiceImpl) GetTaxReconciliation(ctx context.Context, adminID uint64, year int) ([]models.TaxReconciliation, error) {
+	admin, err := p.getUser(ctx, adminID)
+	if err != nil {
+		return []models.TaxReconciliation{}, err
+	}
+	payslips, err := p.statutoryRepo.GetApprovedPayslipsOfYear(ctx, admin.CompanyID, 0, year)
+	if err != nil {
+		return []models.TaxReconciliation{}, err
+	}
+	rates, err := p.statutoryRepo.GetRateSet(ctx, yearEnd(year))
+	if err != nil {
+		return []models.TaxReconciliation{}, err
+	}
+
+	// payslips are ordered by employee, so each employee is one group
+	reconciliations := []models.TaxReconciliation{}
+	for start := 0; start < len(payslips); {
+		end := start
+		for end < len(payslips) && payslips[end].EmployeeID == payslips[start].EmployeeID {
+			end++
+		}
+		employee, err := p.getEmployee(ctx, payslips[start].EmployeeID)
+		if err != nil {
+			return []models.TaxReconciliation{}, err
+		}
+		form, err := build1721A1(employee, payslips[start:end], rates, year)
+		if err != nil {
+			return []models.TaxReconciliation{}, errors.New("employee " + employee.EmployeeNumber + ": " + err.Error())
+		}
+		reconciliations = append(reconciliations, models.TaxReconciliation{
+			EmployeeID:          employee.ID,
+			EmployeeNumber:      employee.EmployeeNumber,
+			EmployeeName:        form.EmployeeName,
+			PtkpStatus:          employee.PtkpStatus,
+			Year:                year,
+			Months:              end - start,
+			Gross:               form.Gross,
+			JobExpense:          form.JobExpense,
+			PensionContribution: form.PensionContribution,
+			Net:                 form.Net,
+			PTKP:                form.PTKP,
+			TaxableIncome:       form.TaxableIncome,
+			AnnualTax:           form.AnnualTax,
+			TaxWithheld:         form.TaxWithheld,
+			Difference:          models.RoundMoney(form.AnnualTax - form.TaxWithheld),
+		})
+		start = end
+	}
+	return reconciliations, nil
+}
+
+func (p *payrollServiceImpl) GetForm1721A1(ctx context.Context, adminID uint64, employeeID uint64, year int) (models.Form1721A1, error) {
+	employee, err := p.getEmployee(ctx, employeeID)
+	if err != nil {
+		return models.Form1721A1{}, err
+	}
+	if err := p.scope.Check(ctx, adminID, employee.CompanyID); err != nil {
+		return models.Form1721A1{}, err
+	}
+	payslips, err := p.statutoryRepo.GetApprovedPayslipsOfYear(ctx, employee.CompanyID, employeeID, year)
+	if err != nil {
+		return models.Form1721A1{}, err
+	}
+	if len(payslips) == 0 {
+		return models.Form1721A1{}, errors.New("payslip of the year not found")
+	}
+	rates, err := p.statutoryRepo.GetRateSet(ctx, yearEnd(year))
+	if err != nil {
+		return models.Form1721A1{}, err
+	}
+	company, err := p.repo.GetCompanyByID(ctx, employee.CompanyID)
+	if err != nil {
+		return models.Form1721A1{}, err
+	}
+
+	form, err := build1721A1(employee, payslips, rates, year)
+	if err != nil {
+		return models.Form1721A1{}, err
+	}
+	form.EmployerName = company.CompanyName
+	if company.LegalName != "" {
+		form.EmployerName = company.LegalName
+	}
+	form.EmployerTaxNumber = company.TaxNumber
+	return form, nil
+}
+
+// build1721A1 sum approved payslip of one employee in one year into form 1721-A1.
+// insurance premium is benefit paid by employer that is added to taxable income
+func build1721A1(employee models.Employee, payslips []models.Payslip, rates statutory.RateSet, year int) (models.Form1721A1, error) {
+	form := models.Form1721A1{
+		Year:              year,
+		PeriodFrom:        int(payslips[0].Period.Month()),
+		PeriodTo:          int(payslips[len(payslips)-1].Period.Month()),
+		EmployeeNumber:    employee.EmployeeNumber,
+		EmployeeName:      strings.TrimSpace(employee.FirstName + " " + employee.LastName),
+		EmployeeTaxNumber: employee.TaxNumber,
+		PtkpStatus:        employee.PtkpStatus,
+	}
+	for _, payslip := range payslips {
+		form.Salary += payslip.BaseSalary
+		salaryIncome := payslip.BaseSalary
+		for _, line := range payslip.Lines {
+			if line.Type != models.SALARY_COMPONENT_ALLOWANCE || !line.Taxable {
+				continue
+			}
+			if line.Code == models.PAYSLIP_LINE_THR {
+				form.BonusAndTHR += line.Amount
+			} else {
+				form.OtherAllowance += line.Amount
+			}
+			salaryIncome += line.Amount
+		}
+		form.InsurancePremium += payslip.TaxableIncome - salaryIncome
+		form.Gross += payslip.TaxableIncome
+		form.PensionContribution += payslip.PensionContribution
+		form.TaxWithheld += payslip.Tax
+	}
+
+	annual, err := statutory.AnnualTax(rates, statutory.AnnualInput{
+		PTKPStatus:          employee.PtkpStatus,
+		Gross:               form.Gross,
+		PensionContribution: form.PensionContribution,
+		Months:              len(payslips),
+	})
+	if err != nil {
+		return models.Form1721A1{}, err
+	}
+	form.Salary = models.RoundMoney(form.Salary)
+	form.OtherAllowance = models.RoundMoney(form.OtherAllowance)
+	form.InsurancePremium = models.RoundMoney(form.InsurancePremium)
+	form.BonusAndTHR = models.RoundMoney(form.BonusAndTHR)
+	form.Gross = models.RoundMoney(form.Gross)
+	form.PensionContribution = models.RoundMoney(form.PensionContribution)
+	form.TaxWithheld = models.RoundMoney(form.TaxWithheld)
+	form.JobExpense = annual.JobExpense
+	form.TotalDeduction = models.RoundMoney(annual.JobExpense + annual.PensionContribution)
+	form.Net = models.RoundMoney(annual.Net)
+	form.AnnualNet = form.Net
+	form.PTKP = annual.PTKP
+	form.TaxableIncome = annual.TaxableIncome
+	form.AnnualTax = annual.Tax
+	form.TaxDue = annual.Tax
+	return form, nil
+}
+
+func yearEnd(year int) models.Date {
+	return models.NewDate(time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC))
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/pdf"
+)
+
+const (
+	payslipMarginLeft  = 50.0
+	payslipMarginRight = pdf.PageWidth - 50.0
+	payslipLineHeight  = 16.0
+	payslipBottom      = 60.0
+)
+
+// renderPayslipPDF write one payslip as A4 PDF, long component list continue on the next page
+func renderPayslipPDF(w io.Writer, company models.Company, run models.PayrollRun, payslip models.Payslip) error {
+	doc := pdf.New()
+	y := pdf.PageHeight - 60
+
+	companyName := company.CompanyName
+	if company.LegalName != "" {
+		companyName = company.LegalName
+	}
+	doc.Text(payslipMarginLeft, y, 16, true, companyName)
+	if company.TaxNumber != "" {
+		doc.TextRight(payslipMarginRight, y, 10, false, "NPWP "+company.TaxNumber)
+	}
+	y -= 22
+	doc.Text(payslipMarginLeft, y, 12, false, "Payslip "+run.Period.Format("January 2006"))
+	doc.TextRight(payslipMarginRight, y, 10, false, "Currency "+models.NormalizeCurrency(company.Currency))
+	y -= 28
+
+	doc.Text(payslipMarginLeft, y, 10, false, "Employee number")
+	doc.Text(payslipMarginLeft+120, y, 10, false, payslip.EmployeeNumber)
+	y -= payslipLineHeight
+	doc.Text(payslipMarginLeft, y, 10, false, "Employee name")
+	doc.Text(payslipMarginLeft+120, y, 10, false, payslip.EmployeeName)
+	y -= payslipLineHeight + 8
+
+	row := func(label string, amount float64, bold bool) {
+		if y < payslipBottom {
+			doc.AddPage()
+			y = pdf.PageHeight - 60
+		}
+		doc.Text(payslipMarginLeft, y, 10, bold, label)
+		doc.TextRight(payslipMarginRight, y, 10, bold, formatMoney(amount))
+		y -= payslipLineHeight
+	}
+	section := func(title string) {
+		y -= 6
+		doc.Line(payslipMarginLeft, y+payslipLineHeight-4, payslipMarginRight, y+payslipLineHeight-4)
+		doc.Text(payslipMarginLeft, y, 11, true, title)
+		y -= payslipLineHeight
+	}
+
+	section("Earnings")
+	row("Base salary", payslip.BaseSalary, false)
+	for _, line := range payslip.Lines {
+		if line.Type == models.SALARY_COMPONENT_ALLOWANCE {
+			row(line.Name, line.Amount, false)
+		}
+	}
+	row("Gross", payslip.Gross, true)
+
+	section("Deductions")
+	for _, line := range payslip.Lines {
+		if line.Type == models.SALARY_COMPONENT_DEDUCTION {
+			row(line.Name, line.Amount, false)
+		}
+	}
+	row("Total deduction", payslip.DeductionTotal, true)
+
+	section("Net pay")
+	row("Net", payslip.Net, true)
+
+	// paid by employer on top of salary, not deducted from net pay
+	employerTotal, hasEmployer := 0.0, false
+	for _, line := range payslip.Lines {
+		if line.Type == models.PAYSLIP_LINE_EMPLOYER {
+			if !hasEmployer {
+				section("Employer contributions")
+				hasEmployer = true
+			}
+			row(line.Name, line.Amount, false)
+			employerTotal += line.Amount
+		}
+	}
+	if hasEmployer {
+		row("Total employer contribution", employerTotal, true)
+	}
+
+	return doc.Write(w)
+}
+
+// formatMoney format amount as 1,234,567.89
+func formatMoney(amount float64) string {
+	s := strconv.FormatFloat(models.RoundMoney(amount), 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction := s[:len(s)-3], s[len(s)-3:]
+
+	b := strings.Builder{}
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + fraction
+}
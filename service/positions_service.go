@@ -3,13 +3,14 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/geedotrar/erp-api/models"
 	"github.com/geedotrar/erp-api/repository"
 )
 
 type PositionService interface {
-	GetPosition(ctx context.Context) ([]models.Position, error)
+	GetPosition(ctx context.Context, filter models.PositionFilter) ([]models.Position, error)
 	GetPositionByID(ctx context.Context, id uint64) (models.Position, error)
 
 	CreatePosition(ctx context.Context, createPosition models.PositionCreateRequest) (models.PositionResponse, error)
@@ -17,19 +18,32 @@ type PositionService interface {
 
 	DeletePosition(ctx context.Context, id uint64) (models.Position, error)
 
-	IsPositionSoftDeleted(ctx context.Context, positionName string, positionCode string) (bool, error)
+	IsPositionSoftDeleted(ctx context.Context, companyID *uint64, positionName string, positionCode string) (bool, error)
+
+	// AdoptPositionTemplate copy global template position into the catalog of company
+	AdoptPositionTemplate(ctx context.Context, templateID uint64, adoptRequest models.PositionAdoptRequest, adminID uint64) (models.Position, error)
+
+	// code rule of company, the default rule when company has not set one
+	GetPositionCodeRule(ctx context.Context, companyID uint64) (models.PositionCodeRule, error)
+	SavePositionCodeRule(ctx context.Context, rule models.PositionCodeRule, adminID uint64) (models.PositionCodeRule, error)
+
+	GetSalaryBands(ctx context.Context, positionID uint64) ([]models.SalaryBand, error)
+	SaveSalaryBand(ctx context.Context, positionID uint64, bandRequest models.SalaryBandRequest, adminID uint64) (models.SalaryBand, error)
+	DeleteSalaryBand(ctx context.Context, positionID uint64, currency string, adminID uint64) error
 }
 
 type positionServiceImpl struct {
-	repo repository.PositionQuery
+	repo   repository.PositionQuery
+	scope  CompanyScope
+	outbox repository.OutboxQuery
 }
 
-func NewPositionService(repo repository.PositionQuery) PositionService {
-	return &positionServiceImpl{repo: repo}
+func NewPositionService(repo repository.PositionQuery, scope CompanyScope, outbox repository.OutboxQuery) PositionService {
+	return &positionServiceImpl{repo: repo, scope: scope, outbox: outbox}
 }
 
-func (p *positionServiceImpl) GetPosition(ctx context.Context) ([]models.Position, error) {
-	position, err := p.repo.GetPosition(ctx)
+func (p *positionServiceImpl) GetPosition(ctx context.Context, filter models.PositionFilter) ([]models.Position, error) {
+	position, err := p.repo.GetPosition(ctx, filter)
 	if err != nil {
 		return []models.Position{}, err
 	}
@@ -45,67 +59,101 @@ func (p *positionServiceImpl) GetPositionByID(ctx context.Context, id uint64) (m
 }
 
 func (p *positionServiceImpl) CreatePosition(ctx context.Context, createPosition models.PositionCreateRequest) (models.PositionResponse, error) {
-	// check positionName
-	existingPosition, err := p.repo.GetPositionByPositionName(ctx, createPosition.PositionName)
-	if err != nil {
+	if createPosition.CompanyID != nil {
+		company, err := p.repo.GetCompanyByID(ctx, *createPosition.CompanyID)
+		if err != nil {
+			return models.PositionResponse{}, err
+		}
+		if company.ID == 0 {
+			return models.PositionResponse{}, errors.New("company not found")
+		}
+	}
+
+	// check code format and positionName, positionCode inside the company
+	positionName := strings.TrimSpace(createPosition.PositionName)
+	positionCode := strings.TrimSpace(createPosition.PositionCode)
+	if err := p.checkPositionCode(ctx, createPosition.CompanyID, positionCode); err != nil {
 		return models.PositionResponse{}, err
 	}
-	if existingPosition.ID != 0 {
-		return models.PositionResponse{}, errors.New("position already exists")
+	if err := p.checkUniquePosition(ctx, createPosition.CompanyID, 0, positionName, positionCode); err != nil {
+		return models.PositionResponse{}, err
 	}
 
 	// create req
 	position := models.PositionCreateRequest{
-		PositionName: createPosition.PositionName,
-		PositionCode: createPosition.PositionCode,
+		CompanyID:    createPosition.CompanyID,
+		PositionName: positionName,
+		PositionCode: positionCode,
+		JobGrade:     createPosition.JobGrade,
 	}
 
-	// Store position to database
-	createdPosition, err := p.repo.CreatePosition(ctx, position)
-	if err != nil {
-		return models.PositionResponse{}, err
-	}
+	// Store position to database, together with its event
+	response := models.PositionResponse{}
+	err := p.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		createdPosition, err := p.repo.CreatePosition(ctx, position)
+		if err != nil {
+			return err
+		}
 
-	// response
-	response := models.PositionResponse{
-		Data: &models.Position{
+		// response
+		response.Data = &models.Position{
 			ID:           createdPosition.ID,
+			CompanyID:    createdPosition.CompanyID,
 			PositionName: createdPosition.PositionName,
 			PositionCode: createdPosition.PositionCode,
-		}}
+			JobGrade:     createdPosition.JobGrade,
+		}
+		return publishEvent(ctx, p.outbox, models.EVENT_POSITION_CREATED, createdPosition.CompanyID, response.Data)
+	})
+	if err != nil {
+		return models.PositionResponse{}, err
+	}
 	return response, nil
 }
 
+// UpdatePosition keep company of position, empty name or code is not changed
 func (p *positionServiceImpl) UpdatePosition(ctx context.Context, id uint64, updatePosition models.PositionUpdateRequest) (models.PositionResponse, error) {
-	// check email
-	existingPosition, err := p.repo.GetPositionByPositionName(ctx, updatePosition.PositionName)
+	existingPosition, err := p.getPosition(ctx, id)
 	if err != nil {
 		return models.PositionResponse{}, err
 	}
-	if existingPosition.ID != 0 && existingPosition.ID != id {
-		return models.PositionResponse{}, errors.New("position already exists")
+
+	positionName := strings.TrimSpace(updatePosition.PositionName)
+	positionCode := strings.TrimSpace(updatePosition.PositionCode)
+	if positionCode != "" && positionCode != existingPosition.PositionCode {
+		if err := p.checkPositionCode(ctx, existingPosition.CompanyID, positionCode); err != nil {
+			return models.PositionResponse{}, err
+		}
+	}
+	if err := p.checkUniquePosition(ctx, existingPosition.CompanyID, id, positionName, positionCode); err != nil {
+		return models.PositionResponse{}, err
 	}
 
 	// update req
 	position := models.PositionUpdateRequest{
-		PositionName: updatePosition.PositionName,
-		PositionCode: updatePosition.PositionCode,
+		PositionName: positionName,
+		PositionCode: positionCode,
+		JobGrade:     updatePosition.JobGrade,
 	}
 
-	// Store position to database
-	updatedPosition, err := p.repo.UpdatePosition(ctx, id, models.PositionUpdateRequest(position))
+	// Store position to database, together with its event
+	updatedPosition := models.Position{}
+	err = p.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := p.repo.UpdatePosition(ctx, id, position); err != nil {
+			return err
+		}
+
+		// response
+		updatedPosition, err = p.repo.GetPositionByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, p.outbox, models.EVENT_POSITION_UPDATED, updatedPosition.CompanyID, updatedPosition)
+	})
 	if err != nil {
 		return models.PositionResponse{}, err
 	}
-
-	// response
-	response := models.PositionResponse{
-		Data: &models.Position{
-			ID:           updatedPosition.ID,
-			PositionName: updatedPosition.PositionName,
-			PositionCode: updatedPosition.PositionCode,
-		}}
-	return response, nil
+	return models.PositionResponse{Data: &updatedPosition}, nil
 }
 
 func (p *positionServiceImpl) DeletePosition(ctx context.Context, id uint64) (models.Position, error) {
@@ -127,15 +175,20 @@ func (p *positionServiceImpl) DeletePosition(ctx context.Context, id uint64) (mo
 		return models.Position{}, err
 	}
 
-	err = p.repo.DeletePosition(ctx, id)
+	err = p.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := p.repo.DeletePosition(ctx, id); err != nil {
+			return err
+		}
+		return publishEvent(ctx, p.outbox, models.EVENT_POSITION_DELETED, position.CompanyID, position)
+	})
 	if err != nil {
 		return models.Position{}, err
 	}
 	return position, err
 }
 
-func (p *positionServiceImpl) IsPositionSoftDeleted(ctx context.Context, positonName string, positionCode string) (bool, error) {
-	positions, err := p.repo.GetSoftDeletedPosition(ctx)
+func (p *positionServiceImpl) IsPositionSoftDeleted(ctx context.Context, companyID *uint64, positonName string, positionCode string) (bool, error) {
+	positions, err := p.repo.GetSoftDeletedPosition(ctx, companyID)
 	if err != nil {
 		return false, err
 	}
@@ -146,3 +199,194 @@ func (p *positionServiceImpl) IsPositionSoftDeleted(ctx context.Context, positon
 	}
 	return false, nil
 }
+
+func (p *positionServiceImpl) GetSalaryBands(ctx context.Context, positionID uint64) ([]models.SalaryBand, error) {
+	if _, err := p.getPosition(ctx, positionID); err != nil {
+		return []models.SalaryBand{}, err
+	}
+	bands, err := p.repo.GetSalaryBands(ctx, positionID)
+	if err != nil {
+		return []models.SalaryBand{}, err
+	}
+	return bands, nil
+}
+
+func (p *positionServiceImpl) SaveSalaryBand(ctx context.Context, positionID uint64, bandRequest models.SalaryBandRequest, adminID uint64) (models.SalaryBand, error) {
+	if err := bandRequest.ValidateSalaryBand(); err != nil {
+		return models.SalaryBand{}, err
+	}
+	position, err := p.getPosition(ctx, positionID)
+	if err != nil {
+		return models.SalaryBand{}, err
+	}
+	if err := p.checkPositionScope(ctx, adminID, position); err != nil {
+		return models.SalaryBand{}, err
+	}
+
+	band := models.SalaryBand{
+		PositionID:  positionID,
+		Currency:    models.NormalizeCurrency(bandRequest.Currency),
+		MinSalary:   models.RoundMoney(bandRequest.MinSalary),
+		MidSalary:   models.RoundMoney(bandRequest.MidSalary),
+		MaxSalary:   models.RoundMoney(bandRequest.MaxSalary),
+		Enforcement: bandRequest.Enforcement,
+	}
+	if band.Enforcement == "" {
+		band.Enforcement = models.SALARY_BAND_ENFORCEMENT_WARN
+	}
+	savedBand, err := p.repo.SaveSalaryBand(ctx, band)
+	if err != nil {
+		return models.SalaryBand{}, err
+	}
+	return savedBand, nil
+}
+
+func (p *positionServiceImpl) DeleteSalaryBand(ctx context.Context, positionID uint64, currency string, adminID uint64) error {
+	position, err := p.getPosition(ctx, positionID)
+	if err != nil {
+		return err
+	}
+	if err := p.checkPositionScope(ctx, adminID, position); err != nil {
+		return err
+	}
+	deleted, err := p.repo.DeleteSalaryBand(ctx, positionID, models.NormalizeCurrency(currency))
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return errors.New("salary band not found")
+	}
+	return nil
+}
+
+func (p *positionServiceImpl) AdoptPositionTemplate(ctx context.Context, templateID uint64, adoptRequest models.PositionAdoptRequest, adminID uint64) (models.Position, error) {
+	template, err := p.getPosition(ctx, templateID)
+	if err != nil {
+		return models.Position{}, err
+	}
+	if template.CompanyID != nil {
+		return models.Position{}, errors.New("only template position can be adopted")
+	}
+	company, err := p.repo.GetCompanyByID(ctx, adoptRequest.CompanyID)
+	if err != nil {
+		return models.Position{}, err
+	}
+	if company.ID == 0 {
+		return models.Position{}, errors.New("company not found")
+	}
+	if err := p.scope.Check(ctx, adminID, company.ID); err != nil {
+		return models.Position{}, err
+	}
+
+	if err := p.checkPositionCode(ctx, &company.ID, template.PositionCode); err != nil {
+		return models.Position{}, err
+	}
+	if err := p.checkUniquePosition(ctx, &company.ID, 0, template.PositionName, template.PositionCode); err != nil {
+		return models.Position{}, err
+	}
+
+	position := models.Position{}
+	err = p.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		position, err = p.repo.AdoptPositionTemplate(ctx, template, company.ID)
+		if err != nil {
+			return err
+		}
+		return publishEvent(ctx, p.outbox, models.EVENT_POSITION_CREATED, position.CompanyID, position)
+	})
+	if err != nil {
+		return models.Position{}, err
+	}
+	return position, nil
+}
+
+func (p *positionServiceImpl) GetPositionCodeRule(ctx context.Context, companyID uint64) (models.PositionCodeRule, error) {
+	rule, err := p.repo.GetPositionCodeRule(ctx, companyID)
+	if err != nil {
+		return models.PositionCodeRule{}, err
+	}
+	if rule.CompanyID == 0 {
+		rule = models.PositionCodeRule{
+			CompanyID:   companyID,
+			Pattern:     models.DEFAULT_POSITION_CODE_PATTERN,
+			Description: "2 to 20 upper case letters, digits, dash or underscore starting with a letter",
+		}
+	}
+	return rule, nil
+}
+
+func (p *positionServiceImpl) SavePositionCodeRule(ctx context.Context, rule models.PositionCodeRule, adminID uint64) (models.PositionCodeRule, error) {
+	if err := rule.ValidateCodeRule(); err != nil {
+		return models.PositionCodeRule{}, err
+	}
+	company, err := p.repo.GetCompanyByID(ctx, rule.CompanyID)
+	if err != nil {
+		return models.PositionCodeRule{}, err
+	}
+	if company.ID == 0 {
+		return models.PositionCodeRule{}, errors.New("company not found")
+	}
+	if err := p.scope.Check(ctx, adminID, company.ID); err != nil {
+		return models.PositionCodeRule{}, err
+	}
+	savedRule, err := p.repo.SavePositionCodeRule(ctx, rule)
+	if err != nil {
+		return models.PositionCodeRule{}, err
+	}
+	return savedRule, nil
+}
+
+// checkPositionCode validate code with rule of company, template use the default rule
+func (p *positionServiceImpl) checkPositionCode(ctx context.Context, companyID *uint64, positionCode string) error {
+	rule := models.PositionCodeRule{Pattern: models.DEFAULT_POSITION_CODE_PATTERN}
+	if companyID != nil {
+		companyRule, err := p.GetPositionCodeRule(ctx, *companyID)
+		if err != nil {
+			return err
+		}
+		rule = companyRule
+	}
+	return rule.CheckCode(positionCode)
+}
+
+// checkUniquePosition check name and code inside the catalog of company, ignoring position id itself
+func (p *positionServiceImpl) checkUniquePosition(ctx context.Context, companyID *uint64, id uint64, positionName string, positionCode string) error {
+	if positionName != "" {
+		existingPosition, err := p.repo.GetPositionByName(ctx, companyID, positionName)
+		if err != nil {
+			return err
+		}
+		if existingPosition.ID != 0 && existingPosition.ID != id {
+			return errors.New("position already exists")
+		}
+	}
+	if positionCode != "" {
+		existingPosition, err := p.repo.GetPositionByCode(ctx, companyID, positionCode)
+		if err != nil {
+			return err
+		}
+		if existingPosition.ID != 0 && existingPosition.ID != id {
+			return errors.New("position code already exists")
+		}
+	}
+	return nil
+}
+
+func (p *positionServiceImpl) getPosition(ctx context.Context, id uint64) (models.Position, error) {
+	position, err := p.repo.GetPositionByID(ctx, id)
+	if err != nil {
+		return models.Position{}, err
+	}
+	if position.ID == 0 {
+		return models.Position{}, errors.New("position not found")
+	}
+	return position, nil
+}
+
+// checkPositionScope make sure admin can manage position of company,
+// template position is shared by every company and only need admin role
+func (p *positionServiceImpl) checkPositionScope(ctx context.Context, adminID uint64, position models.Position) error {
+	if position.CompanyID == nil {
+		return nil
+	}
+	return p.scope.Check(ctx, adminID, *position.CompanyID)
+}
@@ -0,0 +1,572 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+type RecruitmentService interface {
+	// pipeline of company, default stages are created when company has none
+	GetStages(ctx context.Context, companyID uint64, adminID uint64) ([]models.RecruitmentStage, error)
+	SaveStages(ctx context.Context, request models.RecruitmentStagesRequest, adminID uint64) ([]models.RecruitmentStage, error)
+
+	GetRequisitions(ctx context.Context, filter models.RequisitionFilter, adminID uint64) ([]models.Requisition, error)
+	GetRequisitionByID(ctx context.Context, id uint64, adminID uint64) (models.Requisition, error)
+	CreateRequisition(ctx context.Context, request models.RequisitionRequest, adminID uint64) (models.Requisition, error)
+	UpdateRequisition(ctx context.Context, id uint64, request models.RequisitionUpdateRequest, adminID uint64) (models.Requisition, error)
+
+	GetCandidates(ctx context.Context, requisitionID uint64, adminID uint64) ([]models.Candidate, error)
+	GetCandidateByID(ctx context.Context, id uint64, adminID uint64) (models.Candidate, error)
+	CreateCandidate(ctx context.Context, request models.CandidateRequest, adminID uint64) (models.Candidate, error)
+	MoveCandidate(ctx context.Context, id uint64, request models.CandidateStageRequest, adminID uint64) (models.Candidate, error)
+	RejectCandidate(ctx context.Context, id uint64, request models.CandidateRejectRequest, adminID uint64) (models.Candidate, error)
+	UploadCandidateCV(ctx context.Context, id uint64, fileName string, file io.Reader, adminID uint64) (models.Candidate, error)
+	// path of stored cv file and the original file name
+	GetCandidateCV(ctx context.Context, id uint64, adminID uint64) (string, string, error)
+	// create user through CreateUser and employee for candidate on the final stage
+	AcceptCandidate(ctx context.Context, id uint64, request models.CandidateAcceptRequest, adminID uint64) (models.Candidate, error)
+
+	GetInterviews(ctx context.Context, candidateID uint64, adminID uint64) ([]models.Interview, error)
+	ScheduleInterview(ctx context.Context, candidateID uint64, request models.InterviewRequest, adminID uint64) (models.Interview, error)
+	GetMyInterviews(ctx context.Context, userID uint64) ([]models.Interview, error)
+	// feedback is given by the interviewer, or admin of the candidate company
+	SaveInterviewFeedback(ctx context.Context, id uint64, request models.InterviewFeedbackRequest, userID uint64, isAdmin bool) (models.Interview, error)
+
+	// purge personal data of candidate closed longer than retention, stop when ctx done
+	RunWorker(ctx context.Context)
+}
+
+type recruitmentServiceImpl struct {
+	repo      repository.RecruitmentQuery
+	scope     CompanyScope
+	headcount repository.HeadcountQuery
+	users     UserService
+	employees EmployeeService
+	outbox    repository.OutboxQuery
+	dir       string
+	retention time.Duration
+}
+
+// NewRecruitmentService use user and employee service so hired candidate go through
+// the same validation as user and employee created by admin
+func NewRecruitmentService(repo repository.RecruitmentQuery, scope CompanyScope, headcount repository.HeadcountQuery, users UserService, employees EmployeeService, outbox repository.OutboxQuery, dir string, retentionDays int) RecruitmentService {
+	return &recruitmentServiceImpl{
+		repo:      repo,
+		scope:     scope,
+		headcount: headcount,
+		users:     users,
+		employees: employees,
+		outbox:    outbox,
+		dir:       dir,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+func (r *recruitmentServiceImpl) GetStages(ctx context.Context, companyID uint64, adminID uint64) ([]models.RecruitmentStage, error) {
+	if err := r.scope.Check(ctx, adminID, companyID); err != nil {
+		return []models.RecruitmentStage{}, err
+	}
+	return r.getStages(ctx, companyID)
+}
+
+func (r *recruitmentServiceImpl) getStages(ctx context.Context, companyID uint64) ([]models.RecruitmentStage, error) {
+	stages, err := r.repo.GetStages(ctx, companyID)
+	if err != nil {
+		return []models.RecruitmentStage{}, err
+	}
+	if len(stages) > 0 {
+		return stages, nil
+	}
+	for _, name := range models.DefaultRecruitmentStages {
+		stages = append(stages, models.RecruitmentStage{Name: name})
+	}
+	return r.repo.SaveStages(ctx, companyID, stages)
+}
+
+func (r *recruitmentServiceImpl) SaveStages(ctx context.Context, request models.RecruitmentStagesRequest, adminID uint64) ([]models.RecruitmentStage, error) {
+	if err := request.ValidateStages(); err != nil {
+		return []models.RecruitmentStage{}, err
+	}
+	if err := r.scope.Check(ctx, adminID, request.CompanyID); err != nil {
+		return []models.RecruitmentStage{}, err
+	}
+	stages := []models.RecruitmentStage{}
+	for _, stage := range request.Stages {
+		stages = append(stages, models.RecruitmentStage{ID: stage.ID, Name: strings.TrimSpace(stage.Name)})
+	}
+	return r.repo.SaveStages(ctx, request.CompanyID, stages)
+}
+
+func (r *recruitmentServiceImpl) GetRequisitions(ctx context.Context, filter models.RequisitionFilter, adminID uint64) ([]models.Requisition, error) {
+	if err := r.scope.Check(ctx, adminID, filter.CompanyID); err != nil {
+		return []models.Requisition{}, err
+	}
+	return r.repo.GetRequisitions(ctx, filter)
+}
+
+func (r *recruitmentServiceImpl) GetRequisitionByID(ctx context.Context, id uint64, adminID uint64) (models.Requisition, error) {
+	requisition, err := r.repo.GetRequisitionByID(ctx, id)
+	if err != nil {
+		return models.Requisition{}, err
+	}
+	if requisition.ID == 0 {
+		return models.Requisition{}, errors.New("requisition not found")
+	}
+	if err := r.scope.Check(ctx, adminID, requisition.CompanyID); err != nil {
+		return models.Requisition{}, err
+	}
+	return requisition, nil
+}
+
+func (r *recruitmentServiceImpl) CreateRequisition(ctx context.Context, request models.RequisitionRequest, adminID uint64) (models.Requisition, error) {
+	if err := request.ValidateRequisition(); err != nil {
+		return models.Requisition{}, err
+	}
+	if err := r.scope.Check(ctx, adminID, request.CompanyID); err != nil {
+		return models.Requisition{}, err
+	}
+
+	position, err := r.repo.GetPositionByID(ctx, request.PositionID)
+	if err != nil {
+		return models.Requisition{}, err
+	}
+	if position.ID == 0 {
+		return models.Requisition{}, errors.New("position not found")
+	}
+	// template position is shared, company position only inside its company
+	if position.CompanyID != nil && *position.CompanyID != request.CompanyID {
+		return models.Requisition{}, errors.New("position must belong to the same company")
+	}
+	if request.DepartmentID != nil {
+		department, err := r.repo.GetDepartmentByID(ctx, *request.DepartmentID)
+		if err != nil {
+			return models.Requisition{}, err
+		}
+		if department.ID == 0 {
+			return models.Requisition{}, errors.New("department not found")
+		}
+		if department.CompanyID != request.CompanyID {
+			return models.Requisition{}, errors.New("department must belong to the same company")
+		}
+	}
+
+	openings := request.Openings
+	if openings == 0 {
+		openings = 1
+	}
+	requisition := models.Requisition{
+		CompanyID:      request.CompanyID,
+		DepartmentID:   request.DepartmentID,
+		PositionID:     request.PositionID,
+		Title:          request.Title,
+		Description:    request.Description,
+		Openings:       openings,
+		EmploymentType: request.EmploymentType,
+		Status:         models.REQUISITION_STATUS_OPEN,
+		CreatedBy:      &adminID,
+	}
+	return r.repo.CreateRequisition(ctx, requisition)
+}
+
+func (r *recruitmentServiceImpl) UpdateRequisition(ctx context.Context, id uint64, request models.RequisitionUpdateRequest, adminID uint64) (models.Requisition, error) {
+	if err := request.ValidateUpdate(); err != nil {
+		return models.Requisition{}, err
+	}
+	requisition, err := r.GetRequisitionByID(ctx, id, adminID)
+	if err != nil {
+		return models.Requisition{}, err
+	}
+	if request.Openings != 0 && request.Openings < requisition.Hired {
+		return models.Requisition{}, errors.New("openings cannot be less than hired candidates")
+	}
+	return r.repo.UpdateRequisition(ctx, id, request)
+}
+
+func (r *recruitmentServiceImpl) GetCandidates(ctx context.Context, requisitionID uint64, adminID uint64) ([]models.Candidate, error) {
+	if _, err := r.GetRequisitionByID(ctx, requisitionID, adminID); err != nil {
+		return []models.Candidate{}, err
+	}
+	return r.repo.GetCandidates(ctx, requisitionID)
+}
+
+func (r *recruitmentServiceImpl) GetCandidateByID(ctx context.Context, id uint64, adminID uint64) (models.Candidate, error) {
+	candidate, err := r.repo.GetCandidateByID(ctx, id)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	if candidate.ID == 0 {
+		return models.Candidate{}, errors.New("candidate not found")
+	}
+	if err := r.scope.Check(ctx, adminID, candidate.CompanyID); err != nil {
+		return models.Candidate{}, err
+	}
+	return candidate, nil
+}
+
+// getActiveCandidate load candidate still in pipeline, purged or closed candidate can not be changed
+func (r *recruitmentServiceImpl) getActiveCandidate(ctx context.Context, id uint64, adminID uint64) (models.Candidate, error) {
+	candidate, err := r.GetCandidateByID(ctx, id, adminID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	if candidate.IsClosed() {
+		return models.Candidate{}, errors.New("candidate is already " + candidate.Status)
+	}
+	return candidate, nil
+}
+
+func (r *recruitmentServiceImpl) CreateCandidate(ctx context.Context, request models.CandidateRequest, adminID uint64) (models.Candidate, error) {
+	if err := request.ValidateCandidate(); err != nil {
+		return models.Candidate{}, err
+	}
+	requisition, err := r.GetRequisitionByID(ctx, request.RequisitionID, adminID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	if requisition.Status != models.REQUISITION_STATUS_OPEN {
+		return models.Candidate{}, errors.New("requisition is not open")
+	}
+
+	existing, err := r.repo.GetCandidateByEmail(ctx, request.RequisitionID, request.Email)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	if existing.ID != 0 {
+		return models.Candidate{}, errors.New("candidate already applied to this requisition")
+	}
+
+	// new candidate start on the first stage
+	stages, err := r.getStages(ctx, requisition.CompanyID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+
+	candidate := models.Candidate{
+		RequisitionID: requisition.ID,
+		CompanyID:     requisition.CompanyID,
+		StageID:       stages[0].ID,
+		FirstName:     request.FirstName,
+		LastName:      request.LastName,
+		Email:         strings.TrimSpace(request.Email),
+		PhoneNumber:   request.PhoneNumber,
+		Source:        request.Source,
+		Status:        models.CANDIDATE_STATUS_ACTIVE,
+	}
+	return r.repo.CreateCandidate(ctx, candidate)
+}
+
+func (r *recruitmentServiceImpl) MoveCandidate(ctx context.Context, id uint64, request models.CandidateStageRequest, adminID uint64) (models.Candidate, error) {
+	candidate, err := r.getActiveCandidate(ctx, id, adminID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	stage, err := r.repo.GetStageByID(ctx, request.StageID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	if stage.ID == 0 || stage.CompanyID != candidate.CompanyID {
+		return models.Candidate{}, errors.New("stage not found")
+	}
+	return r.repo.UpdateCandidateStage(ctx, id, stage.ID)
+}
+
+func (r *recruitmentServiceImpl) RejectCandidate(ctx context.Context, id uint64, request models.CandidateRejectRequest, adminID uint64) (models.Candidate, error) {
+	if _, err := r.getActiveCandidate(ctx, id, adminID); err != nil {
+		return models.Candidate{}, err
+	}
+	status := models.CANDIDATE_STATUS_REJECTED
+	if request.Withdrawn {
+		status = models.CANDIDATE_STATUS_WITHDRAWN
+	}
+	return r.repo.CloseCandidate(ctx, id, status, request.Reason)
+}
+
+func (r *recruitmentServiceImpl) UploadCandidateCV(ctx context.Context, id uint64, fileName string, file io.Reader, adminID uint64) (models.Candidate, error) {
+	ext, err := models.CandidateCVExtension(fileName)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	candidate, err := r.getActiveCandidate(ctx, id, adminID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+
+	if err := os.MkdirAll(r.dir, 0o750); err != nil {
+		return models.Candidate{}, err
+	}
+	// new name on every upload so the old file is only removed after the row is updated
+	cvPath := filepath.Join(r.dir, fmt.Sprintf("candidate-%d-%d%s", candidate.ID, time.Now().UnixNano(), ext))
+	if err := writeFile(cvPath, file); err != nil {
+		return models.Candidate{}, err
+	}
+
+	updatedCandidate, err := r.repo.UpdateCandidateCV(ctx, id, cvPath, filepath.Base(fileName))
+	if err != nil {
+		os.Remove(cvPath)
+		return models.Candidate{}, err
+	}
+	if candidate.CVPath != "" {
+		os.Remove(candidate.CVPath)
+	}
+	return updatedCandidate, nil
+}
+
+func writeFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+func (r *recruitmentServiceImpl) GetCandidateCV(ctx context.Context, id uint64, adminID uint64) (string, string, error) {
+	candidate, err := r.GetCandidateByID(ctx, id, adminID)
+	if err != nil {
+		return "", "", err
+	}
+	if candidate.CVPath == "" {
+		return "", "", errors.New("cv not found")
+	}
+	return candidate.CVPath, candidate.CVFileName, nil
+}
+
+func (r *recruitmentServiceImpl) AcceptCandidate(ctx context.Context, id uint64, request models.CandidateAcceptRequest, adminID uint64) (models.Candidate, error) {
+	candidate, err := r.getActiveCandidate(ctx, id, adminID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	stages, err := r.getStages(ctx, candidate.CompanyID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	if stages[len(stages)-1].ID != candidate.StageID {
+		return models.Candidate{}, errors.New("candidate must reach the final stage before accepted")
+	}
+
+	requisition, err := r.repo.GetRequisitionByID(ctx, candidate.RequisitionID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	if requisition.Status != models.REQUISITION_STATUS_OPEN {
+		return models.Candidate{}, errors.New("requisition is not open")
+	}
+	company, err := r.repo.GetCompanyByID(ctx, requisition.CompanyID)
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	if company.ID == 0 {
+		return models.Candidate{}, errors.New("company not found")
+	}
+
+	role := request.Role
+	if role == "" {
+		role = models.USER_ROLE_USER
+	}
+	if role != models.USER_ROLE_USER && role != models.USER_ROLE_ADMIN {
+		return models.Candidate{}, errors.New("role must be user or admin")
+	}
+	hireDate := request.HireDate
+	if hireDate.IsZero() {
+		hireDate = company.Today()
+	}
+	// blocked seat is rejected before anything is created
+	if _, err := checkHeadcount(ctx, r.headcount, requisition.CompanyID, requisition.DepartmentID, requisition.PositionID, hireDate); err != nil {
+		return models.Candidate{}, err
+	}
+	employee := models.EmployeeCreateRequest{
+		CompanyID:      requisition.CompanyID,
+		EmployeeNumber: request.EmployeeNumber,
+		FirstName:      candidate.FirstName,
+		LastName:       candidate.LastName,
+		DateOfBirth:    request.DateOfBirth,
+		HireDate:       hireDate,
+		EmploymentType: requisition.EmploymentType,
+		PtkpStatus:     request.PtkpStatus,
+		TaxNumber:      request.TaxNumber,
+	}
+	if err := employee.ValidateCreate(); err != nil {
+		return models.Candidate{}, err
+	}
+	// employee, user and hired candidate are saved together, failed step leave nothing behind
+	hired := models.Candidate{}
+	err = r.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		employeeResponse, err := r.employees.CreateEmployee(ctx, employee, adminID)
+		if err != nil {
+			return err
+		}
+		employeeID := employeeResponse.Data.ID
+
+		userResponse, err := r.users.CreateUser(ctx, models.UserCreateRequest{
+			FirstName:    candidate.FirstName,
+			LastName:     candidate.LastName,
+			Email:        candidate.Email,
+			Password:     request.Password,
+			Role:         role,
+			PhoneNumber:  candidate.PhoneNumber,
+			PositionName: requisition.PositionName,
+			Company:      company.CompanyName,
+			PositionID:   &requisition.PositionID,
+			CompanyID:    requisition.CompanyID,
+			DepartmentID: requisition.DepartmentID,
+			ManagerID:    request.ManagerID,
+		}, adminID)
+		if err != nil {
+			return err
+		}
+		userID := userResponse.Data.ID
+
+		if _, err := r.employees.UpdateEmployee(ctx, employeeID, models.EmployeeUpdateRequest{UserID: &userID}, adminID); err != nil {
+			return err
+		}
+
+		hired, err = r.repo.HireCandidate(ctx, candidate.ID, userID, employeeID)
+		return err
+	})
+	if err != nil {
+		return models.Candidate{}, err
+	}
+	return hired, nil
+}
+
+func (r *recruitmentServiceImpl) GetInterviews(ctx context.Context, candidateID uint64, adminID uint64) ([]models.Interview, error) {
+	if _, err := r.GetCandidateByID(ctx, candidateID, adminID); err != nil {
+		return []models.Interview{}, err
+	}
+	return r.repo.GetInterviews(ctx, candidateID)
+}
+
+func (r *recruitmentServiceImpl) ScheduleInterview(ctx context.Context, candidateID uint64, request models.InterviewRequest, adminID uint64) (models.Interview, error) {
+	if err := request.ValidateInterview(); err != nil {
+		return models.Interview{}, err
+	}
+	candidate, err := r.getActiveCandidate(ctx, candidateID, adminID)
+	if err != nil {
+		return models.Interview{}, err
+	}
+
+	// interviewer is staff of the candidate company group
+	interviewer, err := r.repo.GetUserByID(ctx, request.InterviewerID)
+	if err != nil {
+		return models.Interview{}, err
+	}
+	if interviewer.ID == 0 {
+		return models.Interview{}, errors.New("interviewer not found")
+	}
+	inGroup, err := r.scope.InGroup(ctx, interviewer.CompanyID, candidate.CompanyID)
+	if err != nil {
+		return models.Interview{}, err
+	}
+	if !inGroup {
+		return models.Interview{}, errors.New("interviewer must belong to the same company")
+	}
+
+	duration := request.DurationMinutes
+	if duration == 0 {
+		duration = 60
+	}
+	interview := models.Interview{
+		CandidateID:     candidate.ID,
+		InterviewerID:   interviewer.ID,
+		ScheduledAt:     request.ScheduledAt,
+		DurationMinutes: duration,
+		Location:        request.Location,
+		Status:          models.INTERVIEW_STATUS_SCHEDULED,
+		CreatedBy:       &adminID,
+	}
+	return r.repo.CreateInterview(ctx, interview)
+}
+
+func (r *recruitmentServiceImpl) GetMyInterviews(ctx context.Context, userID uint64) ([]models.Interview, error) {
+	return r.repo.GetInterviewsByInterviewer(ctx, userID)
+}
+
+func (r *recruitmentServiceImpl) SaveInterviewFeedback(ctx context.Context, id uint64, request models.InterviewFeedbackRequest, userID uint64, isAdmin bool) (models.Interview, error) {
+	if err := request.ValidateFeedback(); err != nil {
+		return models.Interview{}, err
+	}
+	interview, err := r.repo.GetInterviewByID(ctx, id)
+	if err != nil {
+		return models.Interview{}, err
+	}
+	if interview.ID == 0 {
+		return models.Interview{}, errors.New("interview not found")
+	}
+	if interview.InterviewerID != userID {
+		if !isAdmin {
+			return models.Interview{}, errors.New("interview is outside of your access")
+		}
+		if _, err := r.GetCandidateByID(ctx, interview.CandidateID, userID); err != nil {
+			return models.Interview{}, err
+		}
+	}
+	if interview.Status == models.INTERVIEW_STATUS_CANCELLED {
+		return models.Interview{}, errors.New("interview is already cancelled")
+	}
+	return r.repo.SaveInterviewFeedback(ctx, id, request.Score, request.Feedback)
+}
+
+func (r *recruitmentServiceImpl) RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		purged, err := r.purgeCandidates(ctx)
+		if err != nil {
+			log.Println("cannot purge candidates", err.Error())
+		}
+		if purged > 0 {
+			log.Println("purged candidates", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeCandidates remove cv file and personal data in batch until nothing is due
+func (r *recruitmentServiceImpl) purgeCandidates(ctx context.Context) (int, error) {
+	const batchSize = 100
+	purged := 0
+	closedBefore := time.Now().Add(-r.retention)
+	for {
+		candidates, err := r.repo.GetCandidatesToPurge(ctx, closedBefore, batchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, candidate := range candidates {
+			if candidate.CVPath != "" {
+				if err := os.Remove(candidate.CVPath); err != nil && !os.IsNotExist(err) {
+					return purged, err
+				}
+			}
+			if err := r.repo.PurgeCandidate(ctx, candidate.ID); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(candidates) < batchSize {
+			return purged, nil
+		}
+	}
+}
@@ -6,20 +6,26 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/geedotrar/erp-api/config"
 	"github.com/geedotrar/erp-api/helper"
 	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/pkg/metrics"
 	"github.com/geedotrar/erp-api/repository"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
-	GetUsers(ctx context.Context) ([]models.User, error)
+	GetUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error)
 	GetUserByID(ctx context.Context, id uint64) (models.User, error)
 
-	CreateUser(ctx context.Context, createUser models.UserCreateRequest) (models.UserResponse, error)
-	UpdateUser(ctx context.Context, id uint64, updateUser models.UserEditRequest) (models.UserResponse, error)
+	CreateUser(ctx context.Context, createUser models.UserCreateRequest, adminID uint64) (models.UserResponse, error)
+	UpdateUser(ctx context.Context, id uint64, updateUser models.UserEditRequest, adminID uint64) (models.UserResponse, error)
 
-	DeleteUser(ctx context.Context, id uint64) (models.User, error)
+	// user with offboarding checklist is disabled after the checklist is completed,
+	// the open offboarding checklist is returned until then
+	DeleteUser(ctx context.Context, id uint64, adminID uint64) (models.User, []models.Checklist, error)
+
+	GetReports(ctx context.Context, id uint64, directOnly bool) ([]models.UserReport, error)
 
 	SignUp(ctx context.Context, userSignUp models.UserSignUp) (models.UserView, error)
 	GenerateUserAccessToken(ctx context.Context, user models.User) (token string, err error)
@@ -27,15 +33,20 @@ type UserService interface {
 }
 
 type userServiceImpl struct {
-	repo repository.UserQuery
+	repo          repository.UserQuery
+	scope         CompanyScope
+	checklistRepo repository.ChecklistQuery
+	headcountRepo repository.HeadcountQuery
+	outbox        repository.OutboxQuery
+	jwtSecret     config.Secret
 }
 
-func NewUserService(repo repository.UserQuery) UserService {
-	return &userServiceImpl{repo: repo}
+func NewUserService(repo repository.UserQuery, scope CompanyScope, checklistRepo repository.ChecklistQuery, headcountRepo repository.HeadcountQuery, outbox repository.OutboxQuery, jwtSecret config.Secret) UserService {
+	return &userServiceImpl{repo: repo, scope: scope, checklistRepo: checklistRepo, headcountRepo: headcountRepo, outbox: outbox, jwtSecret: jwtSecret}
 }
 
-func (u *userServiceImpl) GetUsers(ctx context.Context) ([]models.User, error) {
-	users, err := u.repo.GetUsers(ctx)
+func (u *userServiceImpl) GetUsers(ctx context.Context, filter models.UserFilter) ([]models.User, error) {
+	users, err := u.repo.GetUsers(ctx, filter)
 	if err != nil {
 		return []models.User{}, err
 	}
@@ -50,7 +61,14 @@ func (u *userServiceImpl) GetUserByID(ctx context.Context, id uint64) (models.Us
 	return user, nil
 }
 
-func (u *userServiceImpl) CreateUser(ctx context.Context, createUser models.UserCreateRequest) (models.UserResponse, error) {
+func (u *userServiceImpl) CreateUser(ctx context.Context, createUser models.UserCreateRequest, adminID uint64) (models.UserResponse, error) {
+	if err := u.scope.Check(ctx, adminID, createUser.CompanyID); err != nil {
+		return models.UserResponse{}, err
+	}
+	if err := u.checkRole(ctx, adminID, models.USER_ROLE_USER, createUser.Role); err != nil {
+		return models.UserResponse{}, err
+	}
+
 	// check email
 	existingUser, err := u.repo.GetUserByEmail(ctx, createUser.Email)
 	if err != nil {
@@ -60,6 +78,17 @@ func (u *userServiceImpl) CreateUser(ctx context.Context, createUser models.User
 		return models.UserResponse{}, errors.New("email already exists")
 	}
 
+	if err := u.checkDepartment(ctx, createUser.CompanyID, createUser.DepartmentID); err != nil {
+		return models.UserResponse{}, err
+	}
+	if err := u.checkManager(ctx, 0, createUser.CompanyID, createUser.ManagerID); err != nil {
+		return models.UserResponse{}, err
+	}
+	overPlan, err := u.checkSeat(ctx, createUser)
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+
 	// create req
 	user := models.UserCreateRequest{
 		FirstName:    createUser.FirstName,
@@ -69,6 +98,11 @@ func (u *userServiceImpl) CreateUser(ctx context.Context, createUser models.User
 		PhoneNumber:  createUser.PhoneNumber,
 		PositionName: createUser.PositionName,
 		Company:      createUser.Company,
+		PositionID:   createUser.PositionID,
+		CompanyID:    createUser.CompanyID,
+		DepartmentID: createUser.DepartmentID,
+		ManagerID:    createUser.ManagerID,
+		OverPlan:     overPlan,
 	}
 
 	// Hash password
@@ -78,15 +112,16 @@ func (u *userServiceImpl) CreateUser(ctx context.Context, createUser models.User
 	}
 	user.Password = pass
 
-	// Store user to database
-	createdUser, err := u.repo.CreateUser(ctx, user)
-	if err != nil {
-		return models.UserResponse{}, err
-	}
+	// Store user to database, together with its event
+	response := models.UserResponse{}
+	err = u.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		createdUser, err := u.repo.CreateUser(ctx, user)
+		if err != nil {
+			return err
+		}
 
-	// response
-	response := models.UserResponse{
-		Data: &models.User{
+		// response
+		response.Data = &models.User{
 			ID:           createdUser.ID,
 			FirstName:    createdUser.FirstName,
 			LastName:     createdUser.LastName,
@@ -95,11 +130,43 @@ func (u *userServiceImpl) CreateUser(ctx context.Context, createUser models.User
 			PhoneNumber:  createdUser.PhoneNumber,
 			PositionName: createdUser.PositionName,
 			Company:      createdUser.Company,
-		}}
+			CompanyID:    createdUser.CompanyID,
+			DepartmentID: createdUser.DepartmentID,
+			ManagerID:    createdUser.ManagerID,
+			OverPlan:     createdUser.OverPlan,
+		}
+		if createdUser.PositionID != nil {
+			response.Data.PositionID = *createdUser.PositionID
+		}
+		// user is not created without its onboarding checklist
+		if _, err := u.startChecklists(ctx, *response.Data, models.CHECKLIST_TYPE_ONBOARDING); err != nil {
+			return err
+		}
+		return publishEvent(ctx, u.outbox, models.EVENT_USER_CREATED, &createdUser.CompanyID, response.Data)
+	})
+	if err != nil {
+		return models.UserResponse{}, err
+	}
 	return response, nil
 }
 
-func (u *userServiceImpl) UpdateUser(ctx context.Context, id uint64, updateUser models.UserEditRequest) (models.UserResponse, error) {
+func (u *userServiceImpl) UpdateUser(ctx context.Context, id uint64, updateUser models.UserEditRequest, adminID uint64) (models.UserResponse, error) {
+	currentUser, err := u.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+	if currentUser.ID == 0 {
+		return models.UserResponse{}, errors.New("user not found")
+	}
+	if err := u.scope.Check(ctx, adminID, currentUser.CompanyID); err != nil {
+		return models.UserResponse{}, err
+	}
+	if updateUser.Role != "" {
+		if err := u.checkRole(ctx, adminID, currentUser.Role, updateUser.Role); err != nil {
+			return models.UserResponse{}, err
+		}
+	}
+
 	// check email
 	existingUser, err := u.repo.GetUserByEmail(ctx, updateUser.Email)
 	if err != nil {
@@ -109,6 +176,20 @@ func (u *userServiceImpl) UpdateUser(ctx context.Context, id uint64, updateUser
 		return models.UserResponse{}, errors.New("email already exists")
 	}
 
+	// company is changed with transfer so the assignment history is kept
+	if updateUser.CompanyID != 0 && updateUser.CompanyID != currentUser.CompanyID {
+		return models.UserResponse{}, errors.New("company cannot be changed directly, use transfer")
+	}
+	updateUser.CompanyID = 0
+	// position is changed with promote so the assignment history is kept
+	if updateUser.PositionID != nil && *updateUser.PositionID != currentUser.PositionID {
+		return models.UserResponse{}, errors.New("position cannot be changed directly, use promote")
+	}
+
+	if err := u.checkDepartment(ctx, currentUser.CompanyID, updateUser.DepartmentID); err != nil {
+		return models.UserResponse{}, err
+	}
+
 	// update req
 	user := models.UserCreateRequest{
 		FirstName:    updateUser.FirstName,
@@ -118,6 +199,9 @@ func (u *userServiceImpl) UpdateUser(ctx context.Context, id uint64, updateUser
 		PhoneNumber:  updateUser.PhoneNumber,
 		PositionName: updateUser.PositionName,
 		Company:      updateUser.Company,
+		CompanyID:    updateUser.CompanyID,
+		DepartmentID: updateUser.DepartmentID,
+		ManagerID:    updateUser.ManagerID,
 	}
 
 	// Hash password
@@ -127,16 +211,26 @@ func (u *userServiceImpl) UpdateUser(ctx context.Context, id uint64, updateUser
 	}
 	user.Password = pass
 
-	// Store user to database
-
-	updatedUser, err := u.repo.UpdateUser(ctx, id, models.UserEditRequest(user))
-	if err != nil {
-		return models.UserResponse{}, err
-	}
-
-	// response
-	response := models.UserResponse{
-		Data: &models.User{
+	// Store user to database, together with its event
+	response := models.UserResponse{}
+	err = u.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		// cycle check and update see the same reporting lines
+		if updateUser.ManagerID != nil {
+			if err := u.repo.LockReportingLines(ctx); err != nil {
+				return err
+			}
+		}
+		if err := u.checkManager(ctx, id, currentUser.CompanyID, updateUser.ManagerID); err != nil {
+			return err
+		}
+
+		updatedUser, err := u.repo.UpdateUser(ctx, id, models.UserEditRequest(user))
+		if err != nil {
+			return err
+		}
+
+		// response
+		response.Data = &models.User{
 			ID:           updatedUser.ID,
 			FirstName:    updatedUser.FirstName,
 			LastName:     updatedUser.LastName,
@@ -145,25 +239,168 @@ func (u *userServiceImpl) UpdateUser(ctx context.Context, id uint64, updateUser
 			PhoneNumber:  updatedUser.PhoneNumber,
 			PositionName: updatedUser.PositionName,
 			Company:      updatedUser.Company,
-		}}
+			CompanyID:    updatedUser.CompanyID,
+			DepartmentID: updatedUser.DepartmentID,
+			ManagerID:    updatedUser.ManagerID,
+		}
+		if err := publishEvent(ctx, u.outbox, models.EVENT_USER_UPDATED, &currentUser.CompanyID, response.Data); err != nil {
+			return err
+		}
+		// password is always sent on update, only a different one is a change
+		if bcrypt.CompareHashAndPassword([]byte(currentUser.Password), []byte(updateUser.Password)) != nil {
+			return publishEvent(ctx, u.outbox, models.EVENT_USER_PASSWORD_CHANGED, &currentUser.CompanyID, response.Data)
+		}
+		return nil
+	})
+	if err != nil {
+		return models.UserResponse{}, err
+	}
 	return response, nil
 }
 
-func (u *userServiceImpl) DeleteUser(ctx context.Context, id uint64) (models.User, error) {
+// checkRole make sure role is granted only by admin, role claim of token is
+// read from users so self granted admin would pass every admin route
+func (u *userServiceImpl) checkRole(ctx context.Context, adminID uint64, currentRole string, role string) error {
+	if role == currentRole {
+		return nil
+	}
+	if role != models.USER_ROLE_USER && role != models.USER_ROLE_ADMIN {
+		return errors.New("role must be user or admin")
+	}
+	admin, err := u.repo.GetUserByID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if admin.Role != models.USER_ROLE_ADMIN {
+		return errors.New("role can only be changed by admin")
+	}
+	return nil
+}
+
+// checkDepartment make sure department of user exists in the company of user,
+// department is optional
+func (u *userServiceImpl) checkDepartment(ctx context.Context, companyID uint64, departmentID *uint64) error {
+	if departmentID == nil {
+		return nil
+	}
+	department, err := u.repo.GetDepartmentByID(ctx, *departmentID)
+	if err != nil {
+		return err
+	}
+	if department.ID == 0 {
+		return errors.New("department not found")
+	}
+	if department.CompanyID != companyID {
+		return errors.New("department must belong to the same company")
+	}
+	return nil
+}
+
+// startChecklists start checklist of user on today of its company
+func (u *userServiceImpl) startChecklists(ctx context.Context, user models.User, checklistType string) ([]models.Checklist, error) {
+	company, err := u.repo.GetCompanyByID(ctx, user.CompanyID)
+	if err != nil {
+		return []models.Checklist{}, err
+	}
+	return startChecklists(ctx, u.checklistRepo, user.ID, user.CompanyID, user.PositionID, checklistType, company.Today())
+}
+
+// checkSeat check the new user against headcount plan of its position and report
+// whether the user is over plan, user without position take no seat
+func (u *userServiceImpl) checkSeat(ctx context.Context, createUser models.UserCreateRequest) (bool, error) {
+	if createUser.PositionID == nil || *createUser.PositionID == 0 {
+		return false, nil
+	}
+	company, err := u.repo.GetCompanyByID(ctx, createUser.CompanyID)
+	if err != nil {
+		return false, err
+	}
+	return checkHeadcount(ctx, u.headcountRepo, createUser.CompanyID, createUser.DepartmentID, *createUser.PositionID, company.Today())
+}
+
+// checkManager make sure manager exists in the same company and
+// is not one of the user's own reports. userID is 0 when creating user
+func (u *userServiceImpl) checkManager(ctx context.Context, userID uint64, companyID uint64, managerID *uint64) error {
+	if managerID == nil {
+		return nil
+	}
+	if *managerID == userID {
+		return errors.New("manager assignment would create a reporting cycle")
+	}
+
+	manager, err := u.repo.GetUserByID(ctx, *managerID)
+	if err != nil {
+		return err
+	}
+	if manager.ID == 0 {
+		return errors.New("manager not found")
+	}
+	if manager.CompanyID != companyID {
+		return errors.New("manager must belong to the same company")
+	}
+
+	// new user has no reports yet
+	if userID == 0 {
+		return nil
+	}
+	// manager must not be a report of user at any depth
+	isReport, err := u.repo.IsReportOf(ctx, *managerID, userID)
+	if err != nil {
+		return err
+	}
+	if isReport {
+		return errors.New("manager assignment would create a reporting cycle")
+	}
+	return nil
+}
+
+func (u *userServiceImpl) GetReports(ctx context.Context, id uint64, directOnly bool) ([]models.UserReport, error) {
+	reports, err := u.repo.GetReports(ctx, id, directOnly)
+	if err != nil {
+		return []models.UserReport{}, err
+	}
+	return reports, nil
+}
+
+func (u *userServiceImpl) DeleteUser(ctx context.Context, id uint64, adminID uint64) (models.User, []models.Checklist, error) {
 	user, err := u.repo.GetUserByID(ctx, id)
 	if err != nil {
-		return models.User{}, err
+		return models.User{}, []models.Checklist{}, err
 	}
 
 	if user.ID == 0 {
-		return models.User{}, err
+		return models.User{}, []models.Checklist{}, err
+	}
+	if err := u.scope.Check(ctx, adminID, user.CompanyID); err != nil {
+		return models.User{}, []models.Checklist{}, err
 	}
 
-	err = u.repo.DeleteUser(ctx, id)
+	// repeated delete while offboarding is running return the same checklist
+	open, err := u.checklistRepo.GetOpenChecklists(ctx, id, models.CHECKLIST_TYPE_OFFBOARDING)
 	if err != nil {
-		return models.User{}, err
+		return models.User{}, []models.Checklist{}, err
+	}
+	if len(open) > 0 {
+		return user, open, nil
+	}
+	started, err := u.startChecklists(ctx, user, models.CHECKLIST_TYPE_OFFBOARDING)
+	if err != nil {
+		return models.User{}, []models.Checklist{}, err
+	}
+	if len(started) > 0 {
+		return user, started, nil
+	}
+
+	err = u.outbox.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.DeleteUser(ctx, id); err != nil {
+			return err
+		}
+		return publishEvent(ctx, u.outbox, models.EVENT_USER_DELETED, &user.CompanyID, user)
+	})
+	if err != nil {
+		return models.User{}, []models.Checklist{}, err
 	}
-	return user, err
+	return user, []models.Checklist{}, err
 }
 
 func (u *userServiceImpl) SignUp(ctx context.Context, userSignUp models.UserSignUp) (models.UserView, error) {
@@ -191,6 +428,7 @@ func (u *userServiceImpl) SignUp(ctx context.Context, userSignUp models.UserSign
 	if err != nil {
 		return models.UserView{}, err
 	}
+	metrics.Signups.Inc()
 	printUser := models.UserView{
 		ID:    createdUser.ID,
 		Email: createdUser.Email,
@@ -216,9 +454,10 @@ func (u *userServiceImpl) GenerateUserAccessToken(ctx context.Context, user mode
 	userClaim := models.AccessClaim{
 		StandardClaim: claim,
 		UserID:        user.ID,
+		Role:          user.Role,
 	}
 
-	token, err = helper.GenerateToken(userClaim)
+	token, err = helper.GenerateToken(u.jwtSecret.Value(), userClaim)
 	return
 }
 
@@ -226,21 +465,25 @@ func (u *userServiceImpl) CheckCredentials(ctx context.Context, email string, pa
 	// Retrieve user by email
 	user, err := u.repo.GetUserByEmail(ctx, email)
 	if err != nil {
+		metrics.LoginFailures.WithLabelValues(metrics.LOGIN_FAILURE_ERROR).Inc()
 		return models.User{}, err
 	}
 
 	// Check if user exists
 	if user.ID == 0 {
+		metrics.LoginFailures.WithLabelValues(metrics.LOGIN_FAILURE_UNKNOWN_USER).Inc()
 		return models.User{}, errors.New("user not found")
 	}
 
 	// Compare hashed password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
+		metrics.LoginFailures.WithLabelValues(metrics.LOGIN_FAILURE_WRONG_PASSWORD).Inc()
 		return models.User{}, err
 	}
 
 	// Credentials are correct, return user
+	metrics.Logins.Inc()
 	return user, nil
 }
 
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geedotrar/erp-api/helper"
+	"github.com/geedotrar/erp-api/models"
+	"github.com/geedotrar/erp-api/repository"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+
+	defaultWebhookDeliveryLimit = 50
+	maxWebhookDeliveryLimit     = 200
+)
+
+type WebhookService interface {
+	GetWebhooks(ctx context.Context, filter models.WebhookFilter, adminID uint64) ([]models.Webhook, error)
+	GetWebhookByID(ctx context.Context, id uint64, adminID uint64) (models.Webhook, error)
+	// secret of created webhook is only returned here and by rotate
+	CreateWebhook(ctx context.Context, request models.WebhookRequest, adminID uint64) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, id uint64, request models.WebhookRequest, adminID uint64) (models.Webhook, error)
+	RotateWebhookSecret(ctx context.Context, id uint64, adminID uint64) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uint64, adminID uint64) error
+
+	GetDeliveries(ctx context.Context, webhookID uint64, filter models.WebhookDeliveryFilter, adminID uint64) ([]models.WebhookDelivery, error)
+	GetDeliveryByID(ctx context.Context, webhookID uint64, id uint64, adminID uint64) (models.WebhookDelivery, error)
+	// Redeliver queue the same payload again as new delivery
+	Redeliver(ctx context.Context, webhookID uint64, id uint64, adminID uint64) (models.WebhookDelivery, error)
+
+	// HandleEvent queue domain event for every subscribed webhook, it is subscriber of event bus
+	HandleEvent(ctx context.Context, event models.DomainEvent) error
+
+	RunWorker(ctx context.Context)
+}
+
+type webhookServiceImpl struct {
+	repo   repository.WebhookQuery
+	scope  CompanyScope
+	client *http.Client
+	notify chan struct{}
+}
+
+func NewWebhookService(repo repository.WebhookQuery, scope CompanyScope) WebhookService {
+	return &webhookServiceImpl{
+		repo:   repo,
+		scope:  scope,
+		client: &http.Client{Timeout: models.WEBHOOK_TIMEOUT},
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (w *webhookServiceImpl) GetWebhooks(ctx context.Context, filter models.WebhookFilter, adminID uint64) ([]models.Webhook, error) {
+	if err := w.scope.Check(ctx, adminID, filter.CompanyID); err != nil {
+		return []models.Webhook{}, err
+	}
+	webhooks, err := w.repo.GetWebhooks(ctx, filter)
+	if err != nil {
+		return []models.Webhook{}, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (w *webhookServiceImpl) GetWebhookByID(ctx context.Context, id uint64, adminID uint64) (models.Webhook, error) {
+	webhook, err := w.getWebhook(ctx, id, adminID)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// getWebhook return webhook with its secret
+func (w *webhookServiceImpl) getWebhook(ctx context.Context, id uint64, adminID uint64) (models.Webhook, error) {
+	webhook, err := w.repo.GetWebhookByID(ctx, id)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	if webhook.ID == 0 {
+		return models.Webhook{}, errors.New("webhook not found")
+	}
+	if err := w.scope.Check(ctx, adminID, webhook.CompanyID); err != nil {
+		return models.Webhook{}, err
+	}
+	return webhook, nil
+}
+
+func (w *webhookServiceImpl) CreateWebhook(ctx context.Context, request models.WebhookRequest, adminID uint64) (models.Webhook, error) {
+	if err := request.ValidateWebhook(); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := w.scope.Check(ctx, adminID, request.CompanyID); err != nil {
+		return models.Webhook{}, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	webhook := models.Webhook{
+		CompanyID: request.CompanyID,
+		URL:       strings.TrimSpace(request.URL),
+		Secret:    secret,
+		Events:    strings.Join(request.Events, ","),
+		Active:    request.Active == nil || *request.Active,
+		CreatedBy: adminID,
+	}
+	createdWebhook, err := w.repo.CreateWebhook(ctx, webhook)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	createdWebhook.EventList = request.Events
+	return createdWebhook, nil
+}
+
+func (w *webhookServiceImpl) UpdateWebhook(ctx context.Context, id uint64, request models.WebhookRequest, adminID uint64) (models.Webhook, error) {
+	existing, err := w.getWebhook(ctx, id, adminID)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	if request.CompanyID == 0 {
+		request.CompanyID = existing.CompanyID
+	}
+	if request.CompanyID != existing.CompanyID {
+		return models.Webhook{}, errors.New("company of webhook cannot be changed")
+	}
+	if err := request.ValidateWebhook(); err != nil {
+		return models.Webhook{}, err
+	}
+
+	webhook := models.Webhook{
+		ID:     id,
+		URL:    strings.TrimSpace(request.URL),
+		Events: strings.Join(request.Events, ","),
+		Active: existing.Active,
+	}
+	if request.Active != nil {
+		webhook.Active = *request.Active
+	}
+	updatedWebhook, err := w.repo.UpdateWebhook(ctx, webhook)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	updatedWebhook.Secret = ""
+	return updatedWebhook, nil
+}
+
+// RotateWebhookSecret replace secret, queued delivery is signed with the new secret
+func (w *webhookServiceImpl) RotateWebhookSecret(ctx context.Context, id uint64, adminID uint64) (models.Webhook, error) {
+	webhook, err := w.getWebhook(ctx, id, adminID)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	if err := w.repo.UpdateWebhookSecret(ctx, id, secret); err != nil {
+		return models.Webhook{}, err
+	}
+	webhook.Secret = secret
+	return webhook, nil
+}
+
+func (w *webhookServiceImpl) DeleteWebhook(ctx context.Context, id uint64, adminID uint64) error {
+	if _, err := w.getWebhook(ctx, id, adminID); err != nil {
+		return err
+	}
+	return w.repo.DeleteWebhook(ctx, id)
+}
+
+func (w *webhookServiceImpl) GetDeliveries(ctx context.Context, webhookID uint64, filter models.WebhookDeliveryFilter, adminID uint64) ([]models.WebhookDelivery, error) {
+	if _, err := w.getWebhook(ctx, webhookID, adminID); err != nil {
+		return []models.WebhookDelivery{}, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultWebhookDeliveryLimit
+	}
+	if filter.Limit > maxWebhookDeliveryLimit {
+		filter.Limit = maxWebhookDeliveryLimit
+	}
+	return w.repo.GetDeliveries(ctx, webhookID, filter)
+}
+
+func (w *webhookServiceImpl) GetDeliveryByID(ctx context.Context, webhookID uint64, id uint64, adminID uint64) (models.WebhookDelivery, error) {
+	if _, err := w.getWebhook(ctx, webhookID, adminID); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	delivery, err := w.repo.GetDeliveryByID(ctx, id)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if delivery.ID == 0 || delivery.WebhookID != webhookID {
+		return models.WebhookDelivery{}, errors.New("webhook delivery not found")
+	}
+	return delivery, nil
+}
+
+func (w *webhookServiceImpl) Redeliver(ctx context.Context, webhookID uint64, id uint64, adminID uint64) (models.WebhookDelivery, error) {
+	original, err := w.GetDeliveryByID(ctx, webhookID, id, adminID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if original.Status == models.WEBHOOK_DELIVERY_STATUS_PENDING {
+		return models.WebhookDelivery{}, errors.New("webhook delivery is still pending")
+	}
+
+	// payload keep the event id, receiver can ignore event it already processed
+	deliveries := []models.WebhookDelivery{{
+		WebhookID:     webhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.WEBHOOK_DELIVERY_STATUS_PENDING,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  &original.ID,
+	}}
+	if err := w.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	w.wakeWorker()
+	return deliveries[0], nil
+}
+
+func (w *webhookServiceImpl) HandleEvent(ctx context.Context, event models.DomainEvent) error {
+	if !models.IsWebhookEvent(event.Name) {
+		return nil
+	}
+	webhooks, err := w.repo.GetSubscribedWebhooks(ctx, event.CompanyID, event.Name)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:         event.ID,
+		Event:      event.Name,
+		CompanyID:  event.CompanyID,
+		OccurredAt: event.OccurredAt.UTC(),
+		Data:       json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := []models.WebhookDelivery{}
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			Event:         event.Name,
+			Payload:       string(payload),
+			Status:        models.WEBHOOK_DELIVERY_STATUS_PENDING,
+			NextAttemptAt: now,
+		})
+	}
+	if err := w.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	w.wakeWorker()
+	return nil
+}
+
+// wakeWorker skip if worker is already notified
+func (w *webhookServiceImpl) wakeWorker() {
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *webhookServiceImpl) RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		// send all due delivery before waiting again
+		for w.sendNextDelivery(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.notify:
+		}
+	}
+}
+
+// sendNextDelivery return false when there is no due delivery
+func (w *webhookServiceImpl) sendNextDelivery(ctx context.Context) bool {
+	delivery, err := w.repo.ClaimNextDelivery(ctx)
+	if err != nil {
+		log.Println("cannot claim webhook delivery", err.Error())
+		return false
+	}
+	if delivery.ID == 0 {
+		return false
+	}
+
+	webhook, err := w.repo.GetWebhookByID(ctx, delivery.WebhookID)
+	if err != nil {
+		log.Println("cannot get webhook of delivery", delivery.ID, err.Error())
+		return true
+	}
+
+	now := time.Now()
+	switch {
+	case webhook.ID == 0:
+		delivery.Status = models.WEBHOOK_DELIVERY_STATUS_FAILED
+		delivery.ErrorReason = "webhook deleted"
+	case !webhook.Active:
+		delivery.Status = models.WEBHOOK_DELIVERY_STATUS_FAILED
+		delivery.ErrorReason = "webhook is inactive"
+	default:
+		delivery.Attempts++
+		delivery.ResponseStatus, delivery.ResponseBody, err = w.send(ctx, webhook, delivery)
+		if err == nil {
+			delivery.Status = models.WEBHOOK_DELIVERY_STATUS_SUCCESS
+			delivery.ErrorReason = ""
+			delivery.DeliveredAt = &now
+			break
+		}
+		delivery.ErrorReason = err.Error()
+		delivery.NextAttemptAt = models.WebhookRetryAt(delivery.Attempts, now)
+		if delivery.NextAttemptAt.IsZero() {
+			delivery.Status = models.WEBHOOK_DELIVERY_STATUS_FAILED
+			delivery.NextAttemptAt = now
+		}
+	}
+
+	if err := w.repo.FinishDelivery(ctx, delivery); err != nil {
+		log.Println("cannot save webhook delivery", delivery.ID, err.Error())
+	}
+	return true
+}
+
+// send post payload signed with HMAC-SHA256 of "<timestamp>.<body>",
+// receiver reject old timestamp to prevent replay
+func (w *webhookServiceImpl) send(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	signature := helper.SignHMAC(webhook.Secret, append([]byte(timestamp+"."), body...))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "erp-api-webhook")
+	req.Header.Set(models.WEBHOOK_EVENT_HEADER, delivery.Event)
+	req.Header.Set(models.WEBHOOK_DELIVERY_HEADER, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(models.WEBHOOK_TIMESTAMP_HEADER, timestamp)
+	req.Header.Set(models.WEBHOOK_SIGNATURE_HEADER, "sha256="+signature)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, models.WEBHOOK_RESPONSE_BODY_LIMIT))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(responseBody), fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(responseBody), nil
+}
+
+func generateWebhookSecret() (string, error) {
+	token, err := helper.GenerateRandomToken(24)
+	if err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + token, nil
+}